@@ -6,10 +6,12 @@ import (
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog"
 
 	"github.com/venue-master/platform/lib/config"
 	"github.com/venue-master/platform/lib/logutil"
+	"github.com/venue-master/platform/lib/svcauth"
 )
 
 // Server bundles the gin engine with shared config + logger.
@@ -17,6 +19,8 @@ type Server struct {
 	Engine *gin.Engine
 	Config *config.Config
 	Logger zerolog.Logger
+	// ServiceAuth signs this service's calls to other services and verifies calls to it.
+	ServiceAuth *svcauth.Keys
 }
 
 // New constructs a Server with common middleware.
@@ -33,22 +37,30 @@ func New(serviceName string) (*Server, error) {
 	engine := gin.New()
 	logger := logutil.New(serviceName, cfg.AppEnv)
 
+	serviceAuth, err := svcauth.Load(serviceName, cfg.InternalAuth)
+	if err != nil {
+		return nil, err
+	}
+	if !serviceAuth.Enabled() {
+		logger.Warn().Msg("INTERNAL_AUTH_KEYS_DIR not set; service-to-service calls are not authenticated")
+	}
+
 	// CORS middleware - allow all origins, no credentials
 	corsConfig := cors.Config{
 		AllowAllOrigins:  true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Authorization", "Content-Type", "X-User-ID", "X-User-Roles"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Authorization", "Content-Type", "X-User-ID", "X-User-Roles", "X-User-Permissions", logutil.HeaderRequestID},
+		ExposeHeaders:    []string{"Content-Length", logutil.HeaderRequestID},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}
 
-	engine.Use(gin.Recovery(), requestLogger(logger), cors.New(corsConfig))
+	engine.Use(gin.Recovery(), requestID(), requestLogger(logger), cors.New(corsConfig))
 	engine.GET("/healthz", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, gin.H{"status": "ok", "service": serviceName})
 	})
 
-	return &Server{Engine: engine, Config: cfg, Logger: logger}, nil
+	return &Server{Engine: engine, Config: cfg, Logger: logger, ServiceAuth: serviceAuth}, nil
 }
 
 // Run boots the HTTP server.
@@ -65,7 +77,22 @@ func requestLogger(logger zerolog.Logger) gin.HandlerFunc {
 			Str("method", ctx.Request.Method).
 			Str("path", ctx.Request.URL.Path).
 			Int("status", ctx.Writer.Status()).
+			Str("requestId", ctx.GetHeader(logutil.HeaderRequestID)).
 			Dur("duration", time.Since(start)).
 			Msg("http")
 	}
 }
+
+// requestID keeps the X-Request-ID a caller sent, or assigns one, and echoes it in the
+// response. It is stored on the request so handlers and outgoing calls can pass it on.
+func requestID() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id := ctx.GetHeader(logutil.HeaderRequestID)
+		if id == "" || len(id) > 128 {
+			id = uuid.NewString()
+			ctx.Request.Header.Set(logutil.HeaderRequestID, id)
+		}
+		ctx.Header(logutil.HeaderRequestID, id)
+		ctx.Next()
+	}
+}
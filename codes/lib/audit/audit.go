@@ -0,0 +1,203 @@
+// Package audit records privileged and security-relevant actions: who did what to which
+// target, from where, and what changed. Services send events to the audit-service, which
+// appends them to a hash chain; every event's hash covers its content and the previous
+// event's hash, so editing, deleting or reordering a stored event breaks the chain.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outcomes of an audited action.
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Change is one field's value before and after an action. Before is nil for fields that were
+// added, After for fields that were removed.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Event is one audited action. Sequence, RecordedAt, PrevHash and Hash are assigned by the
+// audit-service when the event is appended.
+type Event struct {
+	ID         uuid.UUID `json:"id"`
+	Sequence   int64     `json:"sequence"`
+	OccurredAt time.Time `json:"occurredAt"`
+	RecordedAt time.Time `json:"recordedAt"`
+	// Service is the service that performed the action.
+	Service string `json:"service"`
+	// ActorID is the user (or partner client) who acted; empty for the system and for sign-in
+	// attempts that never resolved to an account.
+	ActorID    string   `json:"actorId"`
+	ActorRoles []string `json:"actorRoles"`
+	// Action names what happened as <resource>.<verb>, e.g. booking.cancel or auth.login.
+	Action     string `json:"action"`
+	TargetType string `json:"targetType"`
+	TargetID   string `json:"targetId"`
+	Outcome    string `json:"outcome"`
+	// Changes maps changed fields to their old and new values.
+	Changes map[string]Change `json:"changes,omitempty"`
+	// Details holds anything else worth keeping, e.g. the reason a sign-in failed.
+	Details   map[string]any `json:"details,omitempty"`
+	IP        string         `json:"ip"`
+	UserAgent string         `json:"userAgent"`
+	RequestID string         `json:"requestId"`
+	PrevHash  string         `json:"prevHash"`
+	Hash      string         `json:"hash"`
+}
+
+// Seal links the event to the end of the chain, whose last hash is prev ("" for the first
+// event), and computes its hash.
+func (e *Event) Seal(prev string) error {
+	e.PrevHash = prev
+	hash, err := e.digest()
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+	return nil
+}
+
+// Verify reports whether the event still matches its hash.
+func (e *Event) Verify() bool {
+	hash, err := e.digest()
+	return err == nil && hash == e.Hash
+}
+
+// digest hashes a canonical form of the event: times in UTC at microsecond precision (what
+// PostgreSQL keeps), map keys sorted by encoding/json, empty collections as null.
+func (e *Event) digest() (string, error) {
+	canonical := func(t time.Time) string {
+		return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+	}
+	body := struct {
+		ID         string            `json:"id"`
+		Sequence   int64             `json:"sequence"`
+		OccurredAt string            `json:"occurredAt"`
+		RecordedAt string            `json:"recordedAt"`
+		Service    string            `json:"service"`
+		ActorID    string            `json:"actorId"`
+		ActorRoles []string          `json:"actorRoles"`
+		Action     string            `json:"action"`
+		TargetType string            `json:"targetType"`
+		TargetID   string            `json:"targetId"`
+		Outcome    string            `json:"outcome"`
+		Changes    map[string]Change `json:"changes"`
+		Details    map[string]any    `json:"details"`
+		IP         string            `json:"ip"`
+		UserAgent  string            `json:"userAgent"`
+		RequestID  string            `json:"requestId"`
+		PrevHash   string            `json:"prevHash"`
+	}{
+		ID:         e.ID.String(),
+		Sequence:   e.Sequence,
+		OccurredAt: canonical(e.OccurredAt),
+		RecordedAt: canonical(e.RecordedAt),
+		Service:    e.Service,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Outcome:    e.Outcome,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		PrevHash:   e.PrevHash,
+	}
+	if len(e.ActorRoles) > 0 {
+		body.ActorRoles = e.ActorRoles
+	}
+	if len(e.Changes) > 0 {
+		body.Changes = e.Changes
+	}
+	if len(e.Details) > 0 {
+		body.Details = e.Details
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ChainError describes where a chain stopped verifying.
+type ChainError struct {
+	Sequence int64
+	Reason   string
+}
+
+func (e *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at sequence %d: %s", e.Sequence, e.Reason)
+}
+
+// VerifyChain checks consecutive events in sequence order. prev is the hash of the event just
+// before events[0] ("" when events[0] is the first event). It returns a *ChainError for the
+// first event that was altered, is missing or is out of place.
+func VerifyChain(events []Event, prev string) error {
+	for i, event := range events {
+		if i > 0 && event.Sequence != events[i-1].Sequence+1 {
+			return &ChainError{Sequence: events[i-1].Sequence + 1, Reason: "event missing"}
+		}
+		if event.PrevHash != prev {
+			return &ChainError{Sequence: event.Sequence, Reason: "previous hash does not match"}
+		}
+		if !event.Verify() {
+			return &ChainError{Sequence: event.Sequence, Reason: "event altered"}
+		}
+		prev = event.Hash
+	}
+	return nil
+}
+
+// Diff compares the JSON forms of before and after, which should be values of the same shape
+// (structs or maps), and returns the top-level fields that differ. Either may be nil, for
+// creations and deletions.
+func Diff(before, after any) (map[string]Change, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]Change{}
+	for key, value := range old {
+		if next, ok := updated[key]; !ok || !reflect.DeepEqual(value, next) {
+			changes[key] = Change{Before: value, After: updated[key]}
+		}
+	}
+	for key, value := range updated {
+		if _, ok := old[key]; !ok {
+			changes[key] = Change{After: value}
+		}
+	}
+	return changes, nil
+}
+
+func fields(value any) (map[string]any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("audit: diff needs an object: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,164 @@
+package audit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/logutil"
+)
+
+func chain(t *testing.T, n int) []audit.Event {
+	t.Helper()
+	events := make([]audit.Event, 0, n)
+	prev := ""
+	for i := 0; i < n; i++ {
+		event := audit.Event{
+			ID:         uuid.New(),
+			Sequence:   int64(i + 1),
+			OccurredAt: time.Date(2026, 10, 1, 12, 0, i, 123456789, time.UTC),
+			RecordedAt: time.Date(2026, 10, 1, 12, 0, i, 500000000, time.UTC),
+			Service:    "booking-service",
+			ActorID:    "admin-1",
+			ActorRoles: []string{"ADMIN"},
+			Action:     "booking.cancel",
+			TargetType: "booking",
+			TargetID:   uuid.NewString(),
+			Outcome:    audit.OutcomeSuccess,
+			Changes:    map[string]audit.Change{"status": {Before: "CONFIRMED", After: "CANCELLED"}},
+			Details:    map[string]any{"refundCents": 4500.0},
+			IP:         "203.0.113.9",
+		}
+		if err := event.Seal(prev); err != nil {
+			t.Fatal(err)
+		}
+		prev = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestVerifyChain(t *testing.T) {
+	events := chain(t, 4)
+	if err := audit.VerifyChain(events, ""); err != nil {
+		t.Fatalf("intact chain: %v", err)
+	}
+	if err := audit.VerifyChain(events[2:], events[1].Hash); err != nil {
+		t.Fatalf("chain from the middle: %v", err)
+	}
+
+	tampered := append([]audit.Event(nil), events...)
+	tampered[1].ActorID = "someone-else"
+	assertBrokenAt(t, audit.VerifyChain(tampered, ""), 2, "edited event")
+
+	removed := append(append([]audit.Event(nil), events[:1]...), events[2:]...)
+	assertBrokenAt(t, audit.VerifyChain(removed, ""), 2, "deleted event")
+
+	resealed := append([]audit.Event(nil), events...)
+	resealed[1].Action = "booking.create"
+	if err := resealed[1].Seal(resealed[1].PrevHash); err != nil {
+		t.Fatal(err)
+	}
+	assertBrokenAt(t, audit.VerifyChain(resealed, ""), 3, "resealed event")
+}
+
+func assertBrokenAt(t *testing.T, err error, sequence int64, what string) {
+	t.Helper()
+	var broken *audit.ChainError
+	if !errors.As(err, &broken) {
+		t.Fatalf("%s: err = %v, want a ChainError", what, err)
+	}
+	if broken.Sequence != sequence {
+		t.Errorf("%s: broken at %d, want %d", what, broken.Sequence, sequence)
+	}
+}
+
+func TestSealSurvivesStorage(t *testing.T) {
+	event := chain(t, 1)[0]
+	// Storage keeps microseconds and hands back empty collections rather than nil.
+	raw, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored audit.Event
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		t.Fatal(err)
+	}
+	stored.OccurredAt = stored.OccurredAt.Truncate(time.Microsecond).In(time.FixedZone("EDT", -4*3600))
+	if !stored.Verify() {
+		t.Fatal("event no longer verifies after a storage round trip")
+	}
+
+	bare := audit.Event{ID: uuid.New(), Sequence: 1, Action: "auth.login", Outcome: audit.OutcomeFailure}
+	if err := bare.Seal(""); err != nil {
+		t.Fatal(err)
+	}
+	bare.ActorRoles, bare.Details = []string{}, map[string]any{}
+	if !bare.Verify() {
+		t.Fatal("empty collections should hash like nil ones")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	type venue struct {
+		Name  string `json:"name"`
+		City  string `json:"city"`
+		Phone string `json:"phone,omitempty"`
+	}
+	changes, err := audit.Diff(venue{Name: "Court A", City: "Toronto"}, venue{Name: "Court A", City: "Ottawa", Phone: "+16135550100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("changes = %v, want city and phone", changes)
+	}
+	if c := changes["city"]; c.Before != "Toronto" || c.After != "Ottawa" {
+		t.Errorf("city = %+v", c)
+	}
+	if c := changes["phone"]; c.Before != nil || c.After != "+16135550100" {
+		t.Errorf("phone = %+v", c)
+	}
+
+	removed, err := audit.Diff(venue{Name: "Court A"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := removed["name"]; c.Before != "Court A" || c.After != nil {
+		t.Errorf("deletion: name = %+v", c)
+	}
+
+	if _, err := audit.Diff([]string{"a"}, nil); err == nil {
+		t.Error("Diff of a non-object should fail")
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodDelete, "/v1/venues/v1", nil)
+	ctx.Request.RemoteAddr = "198.51.100.7:5000"
+	ctx.Request.Header.Set(authz.HeaderUserID, "admin-1")
+	ctx.Request.Header.Set(authz.HeaderRoles, "ADMIN,VENUE_ADMIN")
+	ctx.Request.Header.Set(logutil.HeaderRequestID, "req-42")
+	ctx.Request.Header.Set("User-Agent", "console/1.0")
+
+	event := audit.FromRequest(ctx, "venue.archive", "venue", "v1")
+	if event.ActorID != "admin-1" || len(event.ActorRoles) != 2 {
+		t.Errorf("actor = %q %v", event.ActorID, event.ActorRoles)
+	}
+	if event.IP != "198.51.100.7" || event.RequestID != "req-42" || event.UserAgent != "console/1.0" {
+		t.Errorf("request context = %q %q %q", event.IP, event.RequestID, event.UserAgent)
+	}
+	if event.Outcome != audit.OutcomeSuccess {
+		t.Errorf("outcome = %q, want success", event.Outcome)
+	}
+}
@@ -0,0 +1,139 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/logutil"
+)
+
+// retryDelays are the waits before each resend of an event the audit-service did not accept.
+var retryDelays = []time.Duration{2 * time.Second, 10 * time.Second, 30 * time.Second}
+
+// Client sends events to the audit-service. A nil *Client records nothing.
+type Client struct {
+	service    string
+	baseURL    string
+	httpClient *http.Client
+	logger     zerolog.Logger
+}
+
+// New returns a Client recording events on behalf of service. transport signs requests for the
+// audit-service (see svcauth); nil uses the default transport.
+func New(service, baseURL string, transport http.RoundTripper, logger zerolog.Logger) *Client {
+	return &Client{
+		service:    service,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: transport},
+		logger:     logger,
+	}
+}
+
+// FromRequest starts an event for action on the target, filled in from the request: the
+// caller from the request context or the gateway identity headers, the client IP, the user
+// agent and the request id.
+func FromRequest(ctx *gin.Context, action, targetType, targetID string) Event {
+	event := Event{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Outcome:    OutcomeSuccess,
+		IP:         ctx.ClientIP(),
+		UserAgent:  ctx.Request.UserAgent(),
+		RequestID:  ctx.GetHeader(logutil.HeaderRequestID),
+	}
+	p, ok := authz.FromContext(ctx.Request.Context())
+	if !ok {
+		p, ok = authz.FromHeaders(ctx.Request.Header)
+	}
+	if ok {
+		event.ActorID = p.UserID
+		event.ActorRoles = p.Roles
+	}
+	return event
+}
+
+// RecordChange records action on the target as made by the request in ctx. before and after
+// are the target as the API renders it around the change (either nil for creations and
+// deletions); the fields that differ become the event's changes.
+func (c *Client) RecordChange(ctx *gin.Context, action, targetType, targetID string, before, after any, details map[string]any) {
+	if c == nil {
+		return
+	}
+	event := FromRequest(ctx, action, targetType, targetID)
+	changes, err := Diff(before, after)
+	if err != nil {
+		c.logger.Error().Err(err).Str("action", action).Msg("failed to diff audited change")
+	}
+	event.Changes = changes
+	event.Details = details
+	c.Record(event)
+}
+
+// Record sends event in the background, so the audited request never waits on the
+// audit-service or fails because of it. Rejected sends are retried a few times; an event that
+// still cannot be delivered is logged in full with category=audit.
+func (c *Client) Record(event Event) {
+	if c == nil {
+		return
+	}
+	if event.ID == uuid.Nil {
+		// Assigned here so retries of the same event are not appended twice.
+		event.ID = uuid.New()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if event.Outcome == "" {
+		event.Outcome = OutcomeSuccess
+	}
+	event.Service = c.service
+
+	go func() {
+		err := c.Send(context.Background(), event)
+		for _, delay := range retryDelays {
+			if err == nil {
+				return
+			}
+			time.Sleep(delay)
+			err = c.Send(context.Background(), event)
+		}
+		if err != nil {
+			c.logger.Error().Err(err).
+				Str("category", "audit").
+				Interface("event", event).
+				Msg("failed to record audit event")
+		}
+	}()
+}
+
+// Send delivers one event to the audit-service.
+func (c *Client) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/audit/events", c.baseURL), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("audit service responded with %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers the gateway sets on requests to downstream services.
+const (
+	HeaderUserID      = "X-User-ID"
+	HeaderRoles       = "X-User-Roles"
+	HeaderPermissions = "X-User-Permissions"
+	// HeaderVenuePermissions carries venue-scoped grants as "<venueId> perm perm;<venueId> perm".
+	HeaderVenuePermissions = "X-User-Venue-Permissions"
+)
+
+var (
+	// ErrUnauthenticated is returned by Check when the context carries no principal.
+	ErrUnauthenticated = errors.New("unauthorized")
+	// ErrForbidden is returned by Check when the principal lacks the permission.
+	ErrForbidden = errors.New("forbidden")
+)
+
+// Principal is the authenticated caller. Permissions apply everywhere; VenuePermissions only
+// at the venue they are keyed by.
+type Principal struct {
+	UserID           string
+	Roles            []string
+	Permissions      []string
+	VenuePermissions map[string][]string
+}
+
+// Can reports whether the principal holds any of permissions globally.
+func (p Principal) Can(permissions ...string) bool {
+	return containsAny(p.Permissions, permissions)
+}
+
+// CanAt reports whether the principal holds any of permissions globally or at venueID.
+func (p Principal) CanAt(venueID string, permissions ...string) bool {
+	return p.Can(permissions...) || (venueID != "" && containsAny(p.VenuePermissions[venueID], permissions))
+}
+
+// CanSomewhere reports whether the principal holds any of permissions globally or at any venue.
+func (p Principal) CanSomewhere(permissions ...string) bool {
+	return p.Can(permissions...) || len(p.VenuesWith(permissions...)) > 0
+}
+
+// VenuesWith returns the venues where the principal holds any of permissions through a
+// venue-scoped role, sorted.
+func (p Principal) VenuesWith(permissions ...string) []string {
+	var venues []string
+	for venueID, have := range p.VenuePermissions {
+		if containsAny(have, permissions) {
+			venues = append(venues, venueID)
+		}
+	}
+	sort.Strings(venues)
+	return venues
+}
+
+func containsAny(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores the caller on ctx.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the caller stored by WithPrincipal.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// FromHeaders reads the caller from the gateway-set headers.
+func FromHeaders(h http.Header) (Principal, bool) {
+	userID := strings.TrimSpace(h.Get(HeaderUserID))
+	if userID == "" {
+		return Principal{}, false
+	}
+	return Principal{
+		UserID:           userID,
+		Roles:            ParseList(h.Get(HeaderRoles), true),
+		Permissions:      ParseList(h.Get(HeaderPermissions), false),
+		VenuePermissions: ParseVenuePermissions(h.Get(HeaderVenuePermissions)),
+	}, true
+}
+
+// SetHeaders writes the caller onto an outgoing request to a downstream service.
+func SetHeaders(h http.Header, p Principal) {
+	if p.UserID != "" {
+		h.Set(HeaderUserID, p.UserID)
+	}
+	if len(p.Roles) > 0 {
+		h.Set(HeaderRoles, strings.Join(p.Roles, ","))
+	}
+	if len(p.Permissions) > 0 {
+		h.Set(HeaderPermissions, strings.Join(p.Permissions, ","))
+	}
+	if len(p.VenuePermissions) > 0 {
+		h.Set(HeaderVenuePermissions, FormatVenuePermissions(p.VenuePermissions))
+	}
+}
+
+// FormatVenuePermissions encodes venue-scoped grants for HeaderVenuePermissions.
+func FormatVenuePermissions(venues map[string][]string) string {
+	ids := make([]string, 0, len(venues))
+	for id := range venues {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if len(venues[id]) > 0 {
+			parts = append(parts, id+" "+strings.Join(venues[id], " "))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// ParseVenuePermissions decodes a HeaderVenuePermissions value.
+func ParseVenuePermissions(value string) map[string][]string {
+	var venues map[string][]string
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		if venues == nil {
+			venues = make(map[string][]string)
+		}
+		venues[fields[0]] = append(venues[fields[0]], fields[1:]...)
+	}
+	return venues
+}
+
+// ParseList splits a comma-separated header value, optionally upper-casing entries (roles).
+func ParseList(value string, upper bool) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if upper {
+			part = strings.ToUpper(part)
+		}
+		out = append(out, part)
+	}
+	return out
+}
+
+// Check returns nil when the caller on ctx holds any of permissions globally.
+func Check(ctx context.Context, permissions ...string) error {
+	return check(ctx, false, permissions)
+}
+
+// CheckScoped is Check that also accepts venue-scoped grants. GraphQL resolvers use it for
+// operations on a venue; the owning service checks the target venue.
+func CheckScoped(ctx context.Context, permissions ...string) error {
+	return check(ctx, true, permissions)
+}
+
+func check(ctx context.Context, scoped bool, permissions []string) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !p.Can(permissions...) && !(scoped && p.CanSomewhere(permissions...)) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// RequirePermission is gin middleware admitting callers holding any of permissions globally.
+// The caller comes from the request context if an earlier middleware stored one, otherwise
+// from the gateway headers.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return require(false, permissions)
+}
+
+// RequireScopedPermission is RequirePermission that also admits venue-scoped grants. Handlers
+// behind it must check the target venue with Principal.CanAt.
+func RequireScopedPermission(permissions ...string) gin.HandlerFunc {
+	return require(true, permissions)
+}
+
+func require(scoped bool, permissions []string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		p, ok := FromContext(ctx.Request.Context())
+		if !ok {
+			p, ok = FromHeaders(ctx.Request.Header)
+		}
+		if !ok {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		if !p.Can(permissions...) && !(scoped && p.CanSomewhere(permissions...)) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden", "required": permissions})
+			return
+		}
+		ctx.Next()
+	}
+}
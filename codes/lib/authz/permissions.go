@@ -0,0 +1,46 @@
+// Package authz is the permission model shared by every service. Permissions are granted to
+// roles in user-service, embedded in access tokens at login/refresh, and forwarded by the
+// gateway in the X-User-Permissions header. Names read resource:action[:scope].
+package authz
+
+// Venue and facility catalogue.
+const (
+	VenueRead            = "venue:read"
+	VenueReadArchived    = "venue:read:archived"
+	VenueCreate          = "venue:create"
+	VenueUpdate          = "venue:update"
+	VenueDelete          = "venue:delete"
+	FacilityRead         = "facility:read"
+	FacilityReadArchived = "facility:read:archived"
+	FacilityCreate       = "facility:create"
+	FacilityUpdate       = "facility:update"
+	FacilityDelete       = "facility:delete"
+	FacilitySchedule     = "facility:schedule:edit"
+)
+
+// Bookings. The :own variants cover the caller's bookings, :any everyone's.
+const (
+	BookingReadOwn   = "booking:read:own"
+	BookingReadAny   = "booking:read:any"
+	BookingCreate    = "booking:create"
+	BookingCreateAny = "booking:create:any"
+	BookingCancelOwn = "booking:cancel:own"
+	BookingCancelAny = "booking:cancel:any"
+)
+
+// Users and the permission model itself.
+const (
+	UserRead        = "user:read"
+	UserDisable     = "user:disable"
+	UserRolesAssign = "user:roles:assign"
+	UserUnlock      = "user:unlock"
+	// UserImport covers bulk member imports; exporting the directory needs UserRead.
+	UserImport = "user:import"
+	RBACManage = "rbac:manage"
+	// OAuthClientsManage covers registering partner apps and rotating their secrets.
+	OAuthClientsManage = "oauth:clients:manage"
+	// PrivacyManage covers filing data requests for members and reading the compliance log.
+	PrivacyManage = "privacy:manage"
+	// AuditRead covers searching, exporting and verifying the security audit trail.
+	AuditRead = "audit:read"
+)
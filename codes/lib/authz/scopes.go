@@ -0,0 +1,76 @@
+package authz
+
+import "sort"
+
+// OAuth scopes partner apps can request. A partner token carries only the permissions its
+// scopes allow (intersected with the user's own for delegated tokens), and the gateway only
+// routes it to endpoints its scopes cover.
+const (
+	ScopeProfile       = "profile"
+	ScopeVenuesRead    = "venues:read"
+	ScopeBookingsRead  = "bookings:read"
+	ScopeBookingsWrite = "bookings:write"
+)
+
+// scopePermissions maps each scope to the permissions it allows.
+var scopePermissions = map[string][]string{
+	ScopeProfile:       {},
+	ScopeVenuesRead:    {VenueRead, FacilityRead},
+	ScopeBookingsRead:  {BookingReadOwn, BookingReadAny},
+	ScopeBookingsWrite: {BookingCreate, BookingCancelOwn},
+}
+
+// Scopes returns every defined scope, sorted.
+func Scopes() []string {
+	out := make([]string, 0, len(scopePermissions))
+	for scope := range scopePermissions {
+		out = append(out, scope)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ValidScope reports whether scope is defined.
+func ValidScope(scope string) bool {
+	_, ok := scopePermissions[scope]
+	return ok
+}
+
+// ScopePermissions returns the permissions scopes allow, sorted.
+func ScopePermissions(scopes []string) []string {
+	set := make(map[string]bool)
+	for _, scope := range scopes {
+		for _, perm := range scopePermissions[scope] {
+			set[perm] = true
+		}
+	}
+	out := make([]string, 0, len(set))
+	for perm := range set {
+		out = append(out, perm)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// LimitToScopes keeps the permissions scopes allow.
+func LimitToScopes(permissions, scopes []string) []string {
+	allowed := ScopePermissions(scopes)
+	out := []string{}
+	for _, perm := range permissions {
+		if containsAny(allowed, []string{perm}) {
+			out = append(out, perm)
+		}
+	}
+	return out
+}
+
+// LimitVenuesToScopes applies LimitToScopes to each venue, dropping venues left empty.
+func LimitVenuesToScopes(venues map[string][]string, scopes []string) map[string][]string {
+	out := make(map[string][]string)
+	for venueID, perms := range venues {
+		if limited := LimitToScopes(perms, scopes); len(limited) > 0 {
+			out[venueID] = limited
+		}
+	}
+	return out
+}
@@ -25,6 +25,9 @@ type Config struct {
 	Stripe   StripeConfig
 	SendGrid SendGridConfig
 	FCM      FCMConfig
+
+	// InternalAuth authenticates service-to-service calls.
+	InternalAuth InternalAuthConfig
 }
 
 // DatabaseConfig captures connection attributes for PostgreSQL.
@@ -46,18 +49,56 @@ type RedisConfig struct {
 
 // JWTConfig groups token behavior.
 type JWTConfig struct {
-	Secret        string
+	// Algorithm is HS256 (shared Secret), RS256 or EdDSA.
+	Algorithm string
+	Secret    string
+	// PrivateKeyFile is the PEM signing key; only the auth-service should set it.
+	PrivateKeyFile string
+	// PublicKeyFiles are extra PEM public keys that still verify, e.g. the key before a rotation.
+	PublicKeyFiles []string
+	// JWKSURL is where verify-only services fetch the auth-service public keys.
+	JWKSURL       string
 	Issuer        string
 	Audience      string
 	AccessExpiry  time.Duration
 	RefreshExpiry time.Duration
 }
 
-// AWSConfig configures S3-compatible storage.
+// InternalAuthConfig locates the keys services sign and verify internal calls with.
+type InternalAuthConfig struct {
+	// KeysDir holds <service>.pub.pem public keys, and this service's <service>.pem private key
+	// unless PrivateKeyFile names it. Empty disables service authentication, which production
+	// refuses.
+	KeysDir string
+	// PrivateKeyFile keeps the private key apart from the shared public keys, so a service only
+	// ever sees its own.
+	PrivateKeyFile string
+}
+
+// defaultJWTSecrets are the built-in and .env.example secrets, refused in production.
+var defaultJWTSecrets = map[string]bool{
+	"super-secret":              true,
+	"change-this-in-production": true,
+}
+
+// defaultStorageSigningKeys are the built-in and .env.example filesystem storage keys, refused
+// in production.
+var defaultStorageSigningKeys = map[string]bool{
+	"local-storage-signing-key": true,
+	"change-this-in-production": true,
+}
+
+// AWSConfig configures S3-compatible storage and the local alternatives.
 type AWSConfig struct {
 	Region   string
 	Bucket   string
 	Endpoint string
+
+	// StorageProvider selects the backend: s3 (default), filesystem or memory.
+	StorageProvider string
+	LocalRoot       string
+	LocalBaseURL    string
+	SigningKey      string
 }
 
 // StripeConfig stores payment credentials.
@@ -102,17 +143,41 @@ func Load(serviceName string) (*Config, error) {
 	}
 
 	cfg.JWT = JWTConfig{
-		Secret:        getEnv("JWT_SECRET", "super-secret"),
-		Issuer:        getEnv("JWT_ISSUER", "venue-master"),
-		Audience:      getEnv("JWT_AUDIENCE", "venue-master-clients"),
-		AccessExpiry:  time.Duration(getEnvAsInt("JWT_ACCESS_EXP_MINUTES", 15)) * time.Minute,
-		RefreshExpiry: time.Duration(getEnvAsInt("JWT_REFRESH_EXP_MINUTES", 7*24*60)) * time.Minute,
+		Algorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+		Secret:         getEnv("JWT_SECRET", "super-secret"),
+		PrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		PublicKeyFiles: getEnvAsList("JWT_PUBLIC_KEY_FILES"),
+		JWKSURL:        getEnv("JWT_JWKS_URL", ""),
+		Issuer:         getEnv("JWT_ISSUER", "venue-master"),
+		Audience:       getEnv("JWT_AUDIENCE", "venue-master-clients"),
+		AccessExpiry:   time.Duration(getEnvAsInt("JWT_ACCESS_EXP_MINUTES", 15)) * time.Minute,
+		RefreshExpiry:  time.Duration(getEnvAsInt("JWT_REFRESH_EXP_MINUTES", 7*24*60)) * time.Minute,
+	}
+
+	if cfg.AppEnv == "production" && cfg.JWT.Algorithm == "HS256" && defaultJWTSecrets[cfg.JWT.Secret] {
+		return nil, fmt.Errorf("refusing to start: JWT_SECRET is a default value and APP_ENV=production")
+	}
+
+	cfg.InternalAuth = InternalAuthConfig{
+		KeysDir:        getEnv("INTERNAL_AUTH_KEYS_DIR", ""),
+		PrivateKeyFile: getEnv("INTERNAL_AUTH_PRIVATE_KEY_FILE", ""),
+	}
+	if cfg.AppEnv == "production" && cfg.InternalAuth.KeysDir == "" {
+		return nil, fmt.Errorf("refusing to start: INTERNAL_AUTH_KEYS_DIR is not set and APP_ENV=production")
 	}
 
 	cfg.AWS = AWSConfig{
 		Region:   getEnv("AWS_REGION", "us-east-1"),
 		Bucket:   getEnv("AWS_S3_BUCKET", "venue-master"),
 		Endpoint: getEnv("AWS_ENDPOINT", ""),
+
+		StorageProvider: getEnv("STORAGE_PROVIDER", "s3"),
+		LocalRoot:       getEnv("STORAGE_LOCAL_ROOT", "./data/storage"),
+		LocalBaseURL:    getEnv("STORAGE_PUBLIC_URL", "http://localhost:8080/storage"),
+		SigningKey:      getEnv("STORAGE_SIGNING_KEY", "local-storage-signing-key"),
+	}
+	if cfg.AppEnv == "production" && cfg.AWS.StorageProvider == "filesystem" && defaultStorageSigningKeys[cfg.AWS.SigningKey] {
+		return nil, fmt.Errorf("refusing to start: STORAGE_SIGNING_KEY is a default value and APP_ENV=production")
 	}
 
 	cfg.Stripe = StripeConfig{APIKey: getEnv("STRIPE_API_KEY", "")}
@@ -143,6 +208,16 @@ func getEnvAsInt(key string, fallback int) int {
 	return fallback
 }
 
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
 func defaultDBName(serviceName string) string {
 	name := strings.ReplaceAll(serviceName, "-", "_")
 	name = strings.ReplaceAll(name, " ", "_")
@@ -0,0 +1,37 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+func TestLoadRefusesDefaultSecretsInProduction(t *testing.T) {
+	cases := []struct {
+		name      string
+		env       map[string]string
+		wantError bool
+	}{
+		{"development with the built-in secret", map[string]string{"APP_ENV": "development"}, false},
+		{"production with the built-in secret", map[string]string{"APP_ENV": "production"}, true},
+		{"production with the example secret", map[string]string{"APP_ENV": "production", "JWT_SECRET": "change-this-in-production"}, true},
+		{"production with a real secret", map[string]string{"APP_ENV": "production", "JWT_SECRET": "s3cr3t-from-the-vault"}, false},
+		{"production signing with a private key", map[string]string{"APP_ENV": "production", "JWT_ALGORITHM": "EdDSA"}, false},
+		{"production with the default storage key", map[string]string{"APP_ENV": "production", "JWT_SECRET": "s3cr3t-from-the-vault", "STORAGE_PROVIDER": "filesystem"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, key := range []string{"APP_ENV", "JWT_SECRET", "JWT_ALGORITHM", "STORAGE_PROVIDER", "STORAGE_SIGNING_KEY"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("INTERNAL_AUTH_KEYS_DIR", "/run/keys")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			_, err := config.Load("user-service")
+			if (err != nil) != tc.wantError {
+				t.Errorf("Load err = %v, want error %v", err, tc.wantError)
+			}
+		})
+	}
+}
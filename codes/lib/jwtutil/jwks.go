@@ -0,0 +1,182 @@
+package jwtutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// jwksCacheTTL is how long a fetched key set is trusted before it is fetched again.
+	jwksCacheTTL = 10 * time.Minute
+	// jwksMinRefetch limits refetches triggered by unknown kids, so forged tokens cannot turn
+	// every request into a call to the auth-service.
+	jwksMinRefetch   = 30 * time.Second
+	jwksFetchTimeout = 5 * time.Second
+)
+
+// keySource resolves the verification key named by a token's kid header.
+type keySource interface {
+	key(kid string) (verificationKey, error)
+}
+
+// staticKeys is the key set of the auth-service itself: the active key plus retired keys
+// that still verify tokens issued before a rotation.
+type staticKeys struct {
+	ordered []verificationKey
+	byID    map[string]verificationKey
+}
+
+func newStaticKeys(keys ...verificationKey) *staticKeys {
+	s := &staticKeys{byID: make(map[string]verificationKey, len(keys))}
+	for _, k := range keys {
+		if _, dup := s.byID[k.id]; dup {
+			continue
+		}
+		s.ordered = append(s.ordered, k)
+		s.byID[k.id] = k
+	}
+	return s
+}
+
+func (s *staticKeys) key(kid string) (verificationKey, error) {
+	if k, ok := s.byID[kid]; ok {
+		return k, nil
+	}
+	return verificationKey{}, ErrUnknownKey
+}
+
+// remoteKeys fetches a JWKS document over HTTP and caches it. A kid missing from the cache
+// triggers an early refetch so freshly rotated keys are picked up immediately. Fetches are
+// throttled to one per jwksMinRefetch and run outside the lock; lookups meanwhile get the cached
+// set, or wait for the fetch when the kid is not cached.
+type remoteKeys struct {
+	url    string
+	client *http.Client
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]verificationKey
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+	// fetching is closed when the running fetch finishes; nil while none runs.
+	fetching chan struct{}
+}
+
+func newRemoteKeys(url string) *remoteKeys {
+	return &remoteKeys{
+		url:    url,
+		client: &http.Client{Timeout: jwksFetchTimeout},
+		now:    time.Now,
+	}
+}
+
+func (r *remoteKeys) key(kid string) (verificationKey, error) {
+	r.mu.Lock()
+	now := r.now()
+	k, ok := r.keys[kid]
+	if ok && now.Sub(r.fetchedAt) < jwksCacheTTL {
+		r.mu.Unlock()
+		return k, nil
+	}
+	if done := r.fetching; done == nil && now.Sub(r.lastAttempt) >= jwksMinRefetch {
+		done = make(chan struct{})
+		r.fetching, r.lastAttempt = done, now
+		r.mu.Unlock()
+		keys, err := r.fetch()
+		r.mu.Lock()
+		if err == nil {
+			// The map is replaced, never modified, so lookups need no copy.
+			r.keys, r.fetchedAt = keys, r.now()
+		}
+		r.lastErr, r.fetching = err, nil
+		close(done)
+	} else if done != nil && !ok {
+		r.mu.Unlock()
+		<-done
+		r.mu.Lock()
+	}
+	defer r.mu.Unlock()
+
+	// A failed fetch keeps serving the last good key set while the auth-service is unreachable.
+	if k, ok := r.keys[kid]; ok {
+		return k, nil
+	}
+	if r.keys == nil && r.lastErr != nil {
+		return verificationKey{}, r.lastErr
+	}
+	return verificationKey{}, ErrUnknownKey
+}
+
+// fetch downloads and parses the key set without touching the cache.
+func (r *remoteKeys) fetch() (map[string]verificationKey, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var doc JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+	keys := make(map[string]verificationKey, len(doc.Keys))
+	for _, jwk := range doc.Keys {
+		k, err := fromJWK(jwk)
+		if err != nil {
+			// Skip keys we cannot use rather than rejecting the whole set.
+			continue
+		}
+		keys[k.id] = k
+	}
+	return keys, nil
+}
+
+// KeySet verifies tokens signed by a third party that publishes a JWKS, such as an OpenID
+// provider. It caches and refetches keys like the gateway's view of the auth-service keys.
+type KeySet struct {
+	keys *remoteKeys
+}
+
+// NewKeySet returns a key set backed by the JWKS document at url.
+func NewKeySet(url string) *KeySet {
+	return &KeySet{keys: newRemoteKeys(url)}
+}
+
+// Keyfunc resolves the key named by the token's kid header, for use with jwt.Parse.
+func (s *KeySet) Keyfunc(t *jwt.Token) (interface{}, error) {
+	return lookupKey(s.keys, t)
+}
+
+// lookupKey resolves the key named by the token's kid header and checks it signs the token's alg.
+func lookupKey(src keySource, t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid header")
+	}
+	key, err := src.key(kid)
+	if err != nil {
+		return nil, err
+	}
+	if key.alg != t.Method.Alg() {
+		return nil, fmt.Errorf("key %s does not sign %s tokens", kid, t.Method.Alg())
+	}
+	return key.public, nil
+}
@@ -0,0 +1,237 @@
+package jwtutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+// newKeyFile writes a fresh Ed25519 private key and returns its path and public key.
+func newKeyFile(t *testing.T) (string, ed25519.PublicKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "private.pem")
+	writePEM(t, path, "PRIVATE KEY", der)
+	return path, public
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func jwtConfig(privateKeyFile string, publicKeyFiles ...string) config.JWTConfig {
+	return config.JWTConfig{
+		Algorithm:      AlgEdDSA,
+		PrivateKeyFile: privateKeyFile,
+		PublicKeyFiles: publicKeyFiles,
+		Issuer:         "venue-master",
+		Audience:       "venue-master-clients",
+		AccessExpiry:   time.Minute,
+	}
+}
+
+func newManager(t *testing.T, cfg config.JWTConfig) *Manager {
+	t.Helper()
+	m, err := NewManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func token(t *testing.T, m *Manager) string {
+	t.Helper()
+	signed, err := m.Generate("42", []string{"MEMBER"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestValidateSelectsKeyByKid(t *testing.T) {
+	activeFile, _ := newKeyFile(t)
+	retiredFile, retiredPublic := newKeyFile(t)
+	strangerFile, _ := newKeyFile(t)
+	retiredPublicFile := filepath.Join(t.TempDir(), "retired.pub.pem")
+	der, err := x509.MarshalPKIXPublicKey(retiredPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writePEM(t, retiredPublicFile, "PUBLIC KEY", der)
+
+	verifier := newManager(t, jwtConfig(activeFile, retiredPublicFile))
+	cases := []struct {
+		name    string
+		signer  *Manager
+		wantErr error
+	}{
+		{"active key", verifier, nil},
+		{"retired key", newManager(t, jwtConfig(retiredFile)), nil},
+		{"unknown key", newManager(t, jwtConfig(strangerFile)), ErrUnknownKey},
+	}
+	for _, tc := range cases {
+		claims, err := verifier.Validate(token(t, tc.signer))
+		switch {
+		case tc.wantErr == nil && (err != nil || claims.UserID != "42"):
+			t.Errorf("%s: Validate = %+v, %v; want user 42", tc.name, claims, err)
+		case tc.wantErr != nil && !errors.Is(err, tc.wantErr):
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+// jwksServer serves the public halves of the current signing managers and counts fetches.
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	doc     JWKS
+	fetches atomic.Int32
+	// release, when set, holds every fetch until it is closed.
+	release chan struct{}
+	down    atomic.Bool
+}
+
+func newJWKSServer(t *testing.T, signers ...*Manager) *jwksServer {
+	s := &jwksServer{}
+	s.serve(signers...)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if s.release != nil {
+			<-s.release
+		}
+		if s.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		_ = json.NewEncoder(w).Encode(s.doc)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) serve(signers ...*Manager) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.doc = JWKS{Keys: []JWK{}}
+	for _, m := range signers {
+		s.doc.Keys = append(s.doc.Keys, m.JWKS().Keys...)
+	}
+}
+
+// remoteVerifier returns a verify-only manager for server whose clock the test controls.
+func remoteVerifier(t *testing.T, server *jwksServer) (*Manager, *time.Time) {
+	t.Helper()
+	m := newManager(t, config.JWTConfig{Algorithm: AlgEdDSA, JWKSURL: server.URL, Issuer: "venue-master", Audience: "venue-master-clients"})
+	now := time.Now()
+	m.keys.(*remoteKeys).now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestRemoteKeysPickUpRotation(t *testing.T) {
+	oldFile, _ := newKeyFile(t)
+	newFile, _ := newKeyFile(t)
+	oldSigner, newSigner := newManager(t, jwtConfig(oldFile)), newManager(t, jwtConfig(newFile))
+	server := newJWKSServer(t, oldSigner)
+	verifier, now := remoteVerifier(t, server)
+
+	if _, err := verifier.Validate(token(t, oldSigner)); err != nil {
+		t.Fatalf("token of the served key: %v", err)
+	}
+	server.serve(oldSigner, newSigner)
+	*now = now.Add(jwksMinRefetch)
+	if _, err := verifier.Validate(token(t, newSigner)); err != nil {
+		t.Fatalf("token of a rotated-in key: %v", err)
+	}
+	if _, err := verifier.Validate(token(t, oldSigner)); err != nil {
+		t.Fatalf("token of the retired key after rotation: %v", err)
+	}
+	if got := server.fetches.Load(); got != 2 {
+		t.Errorf("fetches = %d, want 2 (initial and the unknown kid)", got)
+	}
+}
+
+func TestRemoteKeysThrottleFetches(t *testing.T) {
+	servedFile, _ := newKeyFile(t)
+	strangerFile, _ := newKeyFile(t)
+	served, stranger := newManager(t, jwtConfig(servedFile)), newManager(t, jwtConfig(strangerFile))
+
+	cases := []struct {
+		name string
+		// down makes the auth-service unreachable from the start.
+		down    bool
+		signer  *Manager
+		wantErr bool
+	}{
+		{"unknown kids", false, stranger, true},
+		{"auth-service down at startup", true, served, true},
+		{"known kid", false, served, false},
+	}
+	for _, tc := range cases {
+		server := newJWKSServer(t, served)
+		server.down.Store(tc.down)
+		verifier, now := remoteVerifier(t, server)
+		for i := 0; i < 5; i++ {
+			_, err := verifier.Validate(token(t, tc.signer))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("%s: Validate err = %v, want error %v", tc.name, err, tc.wantErr)
+			}
+			*now = now.Add(time.Second)
+		}
+		if got := server.fetches.Load(); got != 1 {
+			t.Errorf("%s: fetches within jwksMinRefetch = %d, want 1", tc.name, got)
+		}
+	}
+}
+
+func TestRemoteKeysFetchOnceForConcurrentLookups(t *testing.T) {
+	keyFile, _ := newKeyFile(t)
+	signer := newManager(t, jwtConfig(keyFile))
+	server := newJWKSServer(t, signer)
+	server.release = make(chan struct{})
+	verifier, _ := remoteVerifier(t, server)
+
+	signed := token(t, signer)
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			_, err := verifier.Validate(signed)
+			errs <- err
+		}()
+	}
+	// Let every lookup queue up behind the first fetch before it completes.
+	time.Sleep(50 * time.Millisecond)
+	close(server.release)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Validate: %v", err)
+		}
+	}
+	if got := server.fetches.Load(); got != 1 {
+		t.Errorf("fetches = %d, want 1", got)
+	}
+}
@@ -2,9 +2,12 @@ package jwtutil
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 
 	"github.com/venue-master/platform/lib/config"
 )
@@ -14,53 +17,178 @@ type Claims struct {
 	UserID      string   `json:"sub"`
 	Roles       []string `json:"roles"`
 	Permissions []string `json:"permissions"`
+	// VenuePermissions holds permissions granted by venue-scoped roles, keyed by venue id. They
+	// apply only to that venue, on top of Permissions.
+	VenuePermissions map[string][]string `json:"venuePermissions,omitempty"`
+	// SessionID ties the token to the auth-service session that issued it.
+	SessionID string `json:"sid,omitempty"`
+	// AMR lists the authentication methods used to sign in (RFC 8176), e.g. ["pwd","otp","mfa"].
+	AMR []string `json:"amr,omitempty"`
+	// ClientID and Scope are set on tokens issued to partner apps through OAuth (RFC 9068).
+	// Scope is space-separated; such tokens only reach the routes their scopes cover.
+	ClientID string `json:"client_id,omitempty"`
+	Scope    string `json:"scope,omitempty"`
+	// IssuedAtMillis repeats iat in milliseconds on first-party tokens, so revocation watermarks
+	// can tell apart tokens issued in the same second.
+	IssuedAtMillis int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// Manager issues and validates JWT/refresh tokens.
-type Manager struct {
-	cfg config.JWTConfig
+// AMR values set by the auth-service.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+	// AMRFederated marks a sign-in through an external OpenID provider. It is not registered in
+	// RFC 8176; the provider's own amr is not carried over.
+	AMRFederated = "fed"
+	// AMRSMS marks a passwordless sign-in with a code texted to a verified number.
+	AMRSMS = "sms"
+	// AMREmailLink marks a passwordless sign-in through an emailed link. Like "fed" it is not
+	// registered in RFC 8176.
+	AMREmailLink = "email"
+)
+
+// HasAMR reports whether method was used to authenticate.
+func (c *Claims) HasAMR(method string) bool {
+	for _, m := range c.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
 }
 
-// NewManager constructs a Manager from shared config.
-func NewManager(cfg config.JWTConfig) *Manager {
-	return &Manager{cfg: cfg}
+// ErrNoSigningKey is returned by Issue on a Manager that can only verify tokens.
+var ErrNoSigningKey = errors.New("jwt manager has no signing key")
+
+// Manager issues and validates access tokens. Refresh tokens are opaque and owned by the
+// auth-service; the manager only knows their lifetime.
+//
+// With HS256 every service shares cfg.Secret. With RS256 or EdDSA only the auth-service holds
+// the private key (cfg.PrivateKeyFile); other services verify against its JWKS (cfg.JWKSURL).
+type Manager struct {
+	cfg    config.JWTConfig
+	signer *signingKey
+	keys   keySource
+	local  *staticKeys
 }
 
-// Generate issues signed access + refresh tokens.
-func (m *Manager) Generate(userID string, roles, permissions []string) (accessToken string, refreshToken string, err error) {
-	now := time.Now()
+// NewManager constructs a Manager from shared config, loading key material as needed.
+func NewManager(cfg config.JWTConfig) (*Manager, error) {
+	m := &Manager{cfg: cfg}
+	switch cfg.Algorithm {
+	case "", AlgHS256:
+		m.cfg.Algorithm = AlgHS256
+		if cfg.Secret == "" {
+			return nil, errors.New("JWT_SECRET is required for HS256")
+		}
+		return m, nil
+	case AlgRS256, AlgEdDSA:
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", cfg.Algorithm)
+	}
 
-	claims := Claims{
-		UserID:      userID,
-		Roles:       roles,
-		Permissions: permissions,
-		RegisteredClaims: jwt.RegisteredClaims{
-			Issuer:    m.cfg.Issuer,
-			Audience:  []string{m.cfg.Audience},
-			Subject:   userID,
-			ExpiresAt: jwt.NewNumericDate(now.Add(m.cfg.AccessExpiry)),
-			IssuedAt:  jwt.NewNumericDate(now),
-		},
+	var verification []verificationKey
+	if cfg.PrivateKeyFile != "" {
+		signer, public, err := loadSigningKey(cfg.PrivateKeyFile, cfg.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		m.signer = signer
+		verification = append(verification, public)
+	}
+	for _, path := range cfg.PublicKeyFiles {
+		key, err := loadPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		verification = append(verification, key)
 	}
 
-	refreshClaims := claims
-	refreshClaims.RegisteredClaims.ExpiresAt = jwt.NewNumericDate(now.Add(m.cfg.RefreshExpiry))
+	switch {
+	case len(verification) > 0:
+		m.local = newStaticKeys(verification...)
+		m.keys = m.local
+	case cfg.JWKSURL != "":
+		m.keys = newRemoteKeys(cfg.JWKSURL)
+	default:
+		return nil, fmt.Errorf("%s needs JWT_PRIVATE_KEY_FILE, JWT_PUBLIC_KEY_FILES or JWT_JWKS_URL", cfg.Algorithm)
+	}
+	return m, nil
+}
 
-	accessToken, err = m.sign(claims)
-	if err != nil {
-		return "", "", err
+// AccessToken is a signed access token plus its jti and expiry, so callers can revoke it later.
+type AccessToken struct {
+	Token     string
+	ID        string
+	ExpiresAt time.Time
+}
+
+// Generate issues a signed access token that is not tied to a session (tests and tooling).
+func (m *Manager) Generate(userID string, roles, permissions []string) (string, error) {
+	token, err := m.Issue(userID, "", roles, permissions, nil, nil)
+	return token.Token, err
+}
+
+// Issue signs an access token carrying the session id as the sid claim, venue-scoped
+// permissions and the sign-in methods as the amr claim.
+func (m *Manager) Issue(userID, sessionID string, roles, permissions []string, venuePermissions map[string][]string, amr []string) (AccessToken, error) {
+	return m.issue(Claims{
+		UserID:           userID,
+		Roles:            roles,
+		Permissions:      permissions,
+		VenuePermissions: venuePermissions,
+		SessionID:        sessionID,
+		AMR:              amr,
+	})
+}
+
+// IssueForClient signs an access token for a partner app. subject is the user who granted
+// access, or the client itself for client-credentials tokens; permissions should already be
+// limited to scopes.
+func (m *Manager) IssueForClient(clientID, subject string, scopes, roles, permissions []string, venuePermissions map[string][]string, amr []string) (AccessToken, error) {
+	return m.issue(Claims{
+		UserID:           subject,
+		Roles:            roles,
+		Permissions:      permissions,
+		VenuePermissions: venuePermissions,
+		AMR:              amr,
+		ClientID:         clientID,
+		Scope:            strings.Join(scopes, " "),
+	})
+}
+
+// Scopes returns the OAuth scopes of a partner token.
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func (m *Manager) issue(claims Claims) (AccessToken, error) {
+	now := time.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		Issuer:    m.cfg.Issuer,
+		Audience:  []string{m.cfg.Audience},
+		Subject:   claims.UserID,
+		ExpiresAt: jwt.NewNumericDate(now.Add(m.cfg.AccessExpiry)),
+		IssuedAt:  jwt.NewNumericDate(now),
+	}
+	if claims.ClientID == "" {
+		claims.IssuedAtMillis = now.UnixMilli()
 	}
 
-	refreshToken, err = m.sign(refreshClaims)
-	return accessToken, refreshToken, err
+	signed, err := m.sign(claims)
+	if err != nil {
+		return AccessToken{}, err
+	}
+	return AccessToken{Token: signed, ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}, nil
 }
 
 // Validate parses a token string and returns claims if valid.
 func (m *Manager) Validate(token string) (*Claims, error) {
-	parsed, err := jwt.ParseWithClaims(token, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(m.cfg.Secret), nil
-	}, jwt.WithAudience(m.cfg.Audience), jwt.WithIssuer(m.cfg.Issuer))
+	parsed, err := jwt.ParseWithClaims(token, &Claims{}, m.keyFunc,
+		jwt.WithValidMethods(m.validMethods()), jwt.WithAudience(m.cfg.Audience), jwt.WithIssuer(m.cfg.Issuer))
 	if err != nil {
 		return nil, err
 	}
@@ -73,18 +201,50 @@ func (m *Manager) Validate(token string) (*Claims, error) {
 	return claims, nil
 }
 
-// Refresh consumes a refresh token and returns new access+refresh tokens.
-func (m *Manager) Refresh(refreshToken string) (string, string, error) {
-	claims, err := m.Validate(refreshToken)
-	if err != nil {
-		return "", "", err
+// JWKS returns the public keys tokens of this Manager may be signed with. It is empty for
+// HS256 and for verify-only managers.
+func (m *Manager) JWKS() JWKS {
+	doc := JWKS{Keys: []JWK{}}
+	if m.local == nil {
+		return doc
+	}
+	for _, key := range m.local.ordered {
+		jwk, err := toJWK(key.public)
+		if err != nil {
+			continue
+		}
+		doc.Keys = append(doc.Keys, jwk)
 	}
-	return m.Generate(claims.UserID, claims.Roles, claims.Permissions)
+	return doc
 }
 
 func (m *Manager) sign(claims Claims) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(m.cfg.Secret))
+	if m.cfg.Algorithm == AlgHS256 {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(m.cfg.Secret))
+	}
+	if m.signer == nil {
+		return "", ErrNoSigningKey
+	}
+	token := jwt.NewWithClaims(m.signer.method, claims)
+	token.Header["kid"] = m.signer.id
+	return token.SignedString(m.signer.private)
+}
+
+func (m *Manager) keyFunc(t *jwt.Token) (interface{}, error) {
+	if m.cfg.Algorithm == AlgHS256 {
+		return []byte(m.cfg.Secret), nil
+	}
+	return lookupKey(m.keys, t)
+}
+
+// validMethods pins the accepted alg header. Both asymmetric algorithms are accepted during
+// a rotation from one to the other; the key lookup still ties each kid to a single algorithm.
+func (m *Manager) validMethods() []string {
+	if m.cfg.Algorithm == AlgHS256 {
+		return []string{AlgHS256}
+	}
+	return []string{AlgRS256, AlgEdDSA}
 }
 
 // AccessTTL returns the configured access token TTL.
@@ -92,7 +252,7 @@ func (m *Manager) AccessTTL() time.Duration {
 	return m.cfg.AccessExpiry
 }
 
-// RefreshTTL returns the configured refresh token TTL.
+// RefreshTTL returns the configured refresh token (session) TTL.
 func (m *Manager) RefreshTTL() time.Duration {
 	return m.cfg.RefreshExpiry
 }
@@ -0,0 +1,218 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported signing algorithms.
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// ErrUnknownKey is returned when a token names a kid no verification key matches.
+var ErrUnknownKey = errors.New("unknown signing key")
+
+// verificationKey is a public key plus the kid and algorithm tokens signed with it carry.
+type verificationKey struct {
+	id     string
+	alg    string
+	public crypto.PublicKey
+}
+
+// signingKey is the private half of the active key.
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// loadSigningKey reads a PKCS#8 (or PKCS#1 RSA) PEM private key and checks it matches alg.
+func loadSigningKey(path, alg string) (*signingKey, verificationKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, verificationKey{}, err
+	}
+	var parsed any
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, verificationKey{}, fmt.Errorf("parse private key %s: %w", path, err)
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, verificationKey{}, fmt.Errorf("private key %s cannot sign", path)
+	}
+	public, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return nil, verificationKey{}, fmt.Errorf("private key %s: %w", path, err)
+	}
+	if public.alg != alg {
+		return nil, verificationKey{}, fmt.Errorf("private key %s is a %s key but JWT_ALGORITHM is %s", path, public.alg, alg)
+	}
+	return &signingKey{id: public.id, method: jwt.GetSigningMethod(alg), private: signer}, public, nil
+}
+
+// loadPublicKey reads a PKIX PEM public key, e.g. a retired signing key that must keep
+// verifying until the tokens it signed have expired.
+func loadPublicKey(path string) (verificationKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return verificationKey{}, fmt.Errorf("parse public key %s: %w", path, err)
+	}
+	key, err := newVerificationKey(parsed)
+	if err != nil {
+		return verificationKey{}, fmt.Errorf("public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+	return block, nil
+}
+
+// newVerificationKey derives the algorithm from the key type and the kid from its RFC 7638
+// thumbprint, so kids stay stable without extra configuration.
+func newVerificationKey(public crypto.PublicKey) (verificationKey, error) {
+	jwk, err := toJWK(public)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	return verificationKey{id: jwk.Kid, alg: jwk.Alg, public: public}, nil
+}
+
+// JWK is a single JSON Web Key as served from the JWKS endpoint.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// OKP (Ed25519)
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func toJWK(public crypto.PublicKey) (JWK, error) {
+	var jwk JWK
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk = JWK{
+			Kty: "RSA",
+			Alg: AlgRS256,
+			N:   b64(key.N.Bytes()),
+			E:   b64(big.NewInt(int64(key.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		jwk = JWK{Kty: "OKP", Alg: AlgEdDSA, Crv: "Ed25519", X: b64(key)}
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", public)
+	}
+	jwk.Use = "sig"
+	jwk.Kid = thumbprint(jwk)
+	return jwk, nil
+}
+
+func fromJWK(jwk JWK) (verificationKey, error) {
+	if jwk.Use != "" && jwk.Use != "sig" {
+		return verificationKey{}, fmt.Errorf("key %s is not a signing key", jwk.Kid)
+	}
+	var public crypto.PublicKey
+	switch {
+	case jwk.Kty == "RSA":
+		n, err := unb64(jwk.N)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		e, err := unb64(jwk.E)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		public = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	case jwk.Kty == "OKP" && jwk.Crv == "Ed25519":
+		x, err := unb64(jwk.X)
+		if err != nil {
+			return verificationKey{}, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return verificationKey{}, fmt.Errorf("key %s: invalid Ed25519 key length", jwk.Kid)
+		}
+		public = ed25519.PublicKey(x)
+	default:
+		return verificationKey{}, fmt.Errorf("key %s: unsupported key type %s", jwk.Kid, jwk.Kty)
+	}
+	key, err := newVerificationKey(public)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	if jwk.Kid != "" {
+		key.id = jwk.Kid
+	}
+	return key, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint over the required members in lexical order.
+func thumbprint(jwk JWK) string {
+	var members any
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	raw, _ := json.Marshal(members)
+	sum := sha256.Sum256(raw)
+	return b64(sum[:])
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func unb64(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/rs/zerolog"
 )
 
+// HeaderRequestID carries the id that ties one client request together across services, their
+// logs and the audit trail.
+const HeaderRequestID = "X-Request-ID"
+
 // New builds a zerolog logger configured for the current environment.
 func New(service string, env string) zerolog.Logger {
 	zerolog.TimeFieldFormat = time.RFC3339Nano
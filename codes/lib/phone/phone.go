@@ -0,0 +1,65 @@
+// Package phone normalises phone numbers to E.164, the form they are stored and compared in.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalid is returned for input that cannot be read as a phone number.
+var ErrInvalid = errors.New("invalid phone number")
+
+// Normalize returns raw in E.164 form, "+" followed by the country calling code and number.
+// Numbers without an international prefix ("+" or "00") are read as national numbers of
+// defaultCountry, a calling code such as "1"; their trunk prefix (a leading "1" in North
+// America, "0" elsewhere) is dropped. Spaces, dots, dashes and parentheses are ignored.
+func Normalize(raw, defaultCountry string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+")
+	if international {
+		raw = raw[1:]
+	}
+	var digits strings.Builder
+	for _, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == ' ' || r == '.' || r == '-' || r == '(' || r == ')':
+		default:
+			return "", ErrInvalid
+		}
+	}
+	number := digits.String()
+	if !international && strings.HasPrefix(number, "00") {
+		international = true
+		number = number[2:]
+	}
+	if !international {
+		if defaultCountry == "" {
+			return "", ErrInvalid
+		}
+		switch {
+		case defaultCountry == "1" && len(number) == 11 && number[0] == '1':
+			number = number[1:]
+		case defaultCountry != "1" && strings.HasPrefix(number, "0"):
+			number = number[1:]
+		}
+		number = defaultCountry + number
+	}
+	if !valid(number) {
+		return "", ErrInvalid
+	}
+	return "+" + number, nil
+}
+
+// valid checks the length limits of E.164 (at most 15 digits) and, for the North American
+// numbering plan, the fixed ten-digit national number.
+func valid(number string) bool {
+	if len(number) < 8 || len(number) > 15 || number[0] == '0' {
+		return false
+	}
+	if number[0] == '1' && len(number) != 11 {
+		return false
+	}
+	return true
+}
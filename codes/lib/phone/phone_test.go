@@ -0,0 +1,49 @@
+package phone
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		raw, country, want string
+	}{
+		{"+1 (416) 555-0199", "1", "+14165550199"},
+		{"416.555.0199", "1", "+14165550199"},
+		{"1-416-555-0199", "1", "+14165550199"},
+		{"0044 20 7946 0958", "1", "+442079460958"},
+		{"+44 20 7946 0958", "", "+442079460958"},
+		{"020 7946 0958", "44", "+442079460958"},
+		{"+852 9123 4567", "1", "+85291234567"},
+	}
+	for _, tc := range cases {
+		got, err := Normalize(tc.raw, tc.country)
+		if err != nil {
+			t.Errorf("Normalize(%q, %q) failed: %v", tc.raw, tc.country, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Normalize(%q, %q) = %q, want %q", tc.raw, tc.country, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeRejects(t *testing.T) {
+	cases := []struct {
+		raw, country string
+	}{
+		{"", "1"},
+		{"555-0199", "1"},
+		{"416 555 0199 ext 2", "1"},
+		{"+1 416 555 01999", "1"},
+		{"+0 123 456 789", "1"},
+		{"+1234567890123456", "1"},
+		{"4165550199", ""},
+	}
+	for _, tc := range cases {
+		if got, err := Normalize(tc.raw, tc.country); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Normalize(%q, %q) = %q, %v; want ErrInvalid", tc.raw, tc.country, got, err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package revocation
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/venue-master/platform/lib/jwtutil"
+)
+
+// Cache remembers Checker answers for a short time so hot paths do not hit Redis on every
+// request. A revocation therefore takes up to ttl to reach a caching process.
+type Cache struct {
+	next       Checker
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+// NewCache wraps next with a cache holding at most maxEntries answers for ttl each.
+func NewCache(next Checker, ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		next:       next,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+// IsRevoked answers from the cache when possible. Errors are never cached.
+func (c *Cache) IsRevoked(ctx context.Context, claims *jwtutil.Claims) (bool, error) {
+	key := cacheKey(claims)
+	now := c.now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.revoked, nil
+	}
+
+	revoked, err := c.next.IsRevoked(ctx, claims)
+	if err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) >= c.maxEntries {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			c.entries = make(map[string]cacheEntry)
+		}
+	}
+	c.entries[key] = cacheEntry{revoked: revoked, expiresAt: now.Add(c.ttl)}
+	return revoked, nil
+}
+
+func cacheKey(claims *jwtutil.Claims) string {
+	if claims.ID != "" {
+		return claims.ID
+	}
+	issued := claims.IssuedAtMillis
+	if issued == 0 && claims.IssuedAt != nil {
+		issued = claims.IssuedAt.Time.UnixMilli()
+	}
+	return claims.UserID + "@" + strconv.FormatInt(issued, 10)
+}
@@ -0,0 +1,124 @@
+// Package revocation tracks access tokens that must stop working before they expire.
+//
+// Two records live in Redis: a denylist entry per revoked token id (jti), and a per-user
+// watermark holding the time before which every token of that user is void. Both expire after
+// the access token lifetime, when the tokens they guard would have expired anyway.
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/jwtutil"
+)
+
+var (
+	// ErrRevoked is returned by Verify when a valid token has been revoked.
+	ErrRevoked = errors.New("token revoked")
+	// ErrUnavailable is returned by Verify when the revocation records could not be read.
+	ErrUnavailable = errors.New("token revocation check unavailable")
+)
+
+// Checker reports whether validated claims have been revoked.
+type Checker interface {
+	IsRevoked(ctx context.Context, claims *jwtutil.Claims) (bool, error)
+}
+
+// Store reads and writes revocation records in Redis.
+type Store struct {
+	client   *redis.Client
+	tokenTTL time.Duration
+	now      func() time.Time
+}
+
+// New creates a Store. The Redis connection is established lazily; tokenTTL must be at least
+// the access token lifetime.
+func New(cfg config.RedisConfig, tokenTTL time.Duration) *Store {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	return &Store{client: client, tokenTTL: tokenTTL, now: time.Now}
+}
+
+// RevokeToken denylists a single token until it expires.
+func (s *Store) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if tokenID == "" {
+		return nil
+	}
+	ttl := expiresAt.Sub(s.now())
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, tokenKey(tokenID), "1", ttl).Err()
+}
+
+// RevokeUser voids every token issued to the user up to now.
+func (s *Store) RevokeUser(ctx context.Context, userID string) error {
+	return s.client.Set(ctx, userKey(userID), s.now().UnixMilli(), s.tokenTTL).Err()
+}
+
+// IsRevoked reports whether the token is denylisted or was issued before the user's watermark.
+func (s *Store) IsRevoked(ctx context.Context, claims *jwtutil.Claims) (bool, error) {
+	keys := []string{userKey(claims.UserID)}
+	if claims.ID != "" {
+		keys = append(keys, tokenKey(claims.ID))
+	}
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	if len(values) > 1 && values[1] != nil {
+		return true, nil
+	}
+	if values[0] == nil {
+		return false, nil
+	}
+	raw, _ := values[0].(string)
+	watermark, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return false, fmt.Errorf("invalid revocation watermark for user %s: %w", claims.UserID, err)
+	}
+	if claims.IssuedAtMillis != 0 {
+		return claims.IssuedAtMillis < watermark, nil
+	}
+	if claims.IssuedAt == nil {
+		return true, nil
+	}
+	// Without iat_ms only whole seconds are known, so a token from the watermark's second is void.
+	return claims.IssuedAt.Unix() <= watermark/1000, nil
+}
+
+// Verify validates a token and checks it against the revocation records.
+func Verify(ctx context.Context, manager *jwtutil.Manager, checker Checker, token string) (*jwtutil.Claims, error) {
+	claims, err := manager.Validate(token)
+	if err != nil {
+		return nil, err
+	}
+	if checker == nil {
+		return claims, nil
+	}
+	revoked, err := checker.IsRevoked(ctx, claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if revoked {
+		return nil, ErrRevoked
+	}
+	return claims, nil
+}
+
+func tokenKey(tokenID string) string {
+	return "revoked:token:" + tokenID
+}
+
+func userKey(userID string) string {
+	return "revoked:user:" + userID
+}
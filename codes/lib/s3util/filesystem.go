@@ -0,0 +1,346 @@
+package s3util
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FilesystemProvider stores objects under a local directory. Presigned URLs point at
+// baseURL and carry an HMAC signature; Handler serves them so browsers can upload and
+// download without S3 or Localstack.
+type FilesystemProvider struct {
+	root       string
+	baseURL    *url.URL
+	signingKey []byte
+	now        func() time.Time
+}
+
+type fileMeta struct {
+	ContentType string `json:"contentType"`
+}
+
+// NewFilesystem creates a provider rooted at root. baseURL is where Handler is reachable
+// (for example http://localhost:8080/storage) and signingKey signs the presigned URLs.
+func NewFilesystem(root, baseURL string, signingKey []byte) (*FilesystemProvider, error) {
+	if root == "" {
+		return nil, errors.New("filesystem storage root is required")
+	}
+	if len(signingKey) == 0 {
+		return nil, errors.New("filesystem storage signing key is required")
+	}
+	u, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage base url: %w", err)
+	}
+	if u.Path == "" {
+		return nil, errors.New("storage base url needs a path such as /storage")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range []string{"objects", "meta", "tmp"} {
+		if err := os.MkdirAll(filepath.Join(abs, dir), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	return &FilesystemProvider{root: abs, baseURL: u, signingKey: signingKey, now: time.Now}, nil
+}
+
+// Upload streams body to disk, replacing any existing object atomically.
+func (p *FilesystemProvider) Upload(_ context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(p.root, "tmp"), "upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p.objectPath(key)), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), p.objectPath(key)); err != nil {
+		return "", err
+	}
+	if err := p.writeMeta(key, fileMeta{ContentType: contentType}); err != nil {
+		return "", err
+	}
+	return p.objectURL(key, nil), nil
+}
+
+// Get reads an object into memory.
+func (p *FilesystemProvider) Get(_ context.Context, key string) ([]byte, error) {
+	if _, err := p.stat(key); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Head returns object metadata.
+func (p *FilesystemProvider) Head(_ context.Context, key string) (*ObjectInfo, error) {
+	info, err := p.stat(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Key: key, Size: info.Size(), ContentType: p.readMeta(key).ContentType, LastModified: info.ModTime()}, nil
+}
+
+// List returns objects whose key starts with prefix, sorted by key.
+func (p *FilesystemProvider) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	objects := filepath.Join(p.root, "objects")
+	var items []ObjectInfo
+	err := filepath.WalkDir(objects, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(objects, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		items = append(items, ObjectInfo{Key: key, Size: info.Size(), ContentType: p.readMeta(key).ContentType, LastModified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items, nil
+}
+
+// Copy duplicates srcKey to dstKey, keeping its content type.
+func (p *FilesystemProvider) Copy(ctx context.Context, srcKey, dstKey string) error {
+	if err := validateKey(dstKey); err != nil {
+		return err
+	}
+	if _, err := p.stat(srcKey); err != nil {
+		return err
+	}
+	src, err := os.Open(p.objectPath(srcKey))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	_, err = p.Upload(ctx, dstKey, src, p.readMeta(srcKey).ContentType)
+	return err
+}
+
+// Delete removes an object; deleting a missing object is not an error.
+func (p *FilesystemProvider) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	for _, path := range []string{p.objectPath(key), p.metaPath(key)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PresignGet returns a signed download URL served by Handler.
+func (p *FilesystemProvider) PresignGet(_ context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	exp := p.now().Add(expires).Unix()
+	return p.objectURL(key, url.Values{
+		"expires": {strconv.FormatInt(exp, 10)},
+		"sig":     {p.sign(http.MethodGet, key, "", 0, exp)},
+	}), nil
+}
+
+// PresignPut returns a signed upload URL served by Handler. The upload must send the same
+// Content-Type and at most maxBytes.
+func (p *FilesystemProvider) PresignPut(_ context.Context, key, contentType string, maxBytes int64, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	if maxBytes <= 0 {
+		return "", errors.New("signed uploads need a positive size limit")
+	}
+	exp := p.now().Add(expires).Unix()
+	return p.objectURL(key, url.Values{
+		"expires":     {strconv.FormatInt(exp, 10)},
+		"contentType": {contentType},
+		"maxBytes":    {strconv.FormatInt(maxBytes, 10)},
+		"sig":         {p.sign(http.MethodPut, key, contentType, maxBytes, exp)},
+	}), nil
+}
+
+// BasePath is the URL path Handler expects to be mounted at.
+func (p *FilesystemProvider) BasePath() string {
+	return p.baseURL.Path
+}
+
+// Handler serves presigned GET/HEAD and PUT requests under BasePath.
+func (p *FilesystemProvider) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := strings.CutPrefix(r.URL.Path, p.baseURL.Path+"/")
+		if !ok || validateKey(key) != nil {
+			http.NotFound(w, r)
+			return
+		}
+		query := r.URL.Query()
+		exp, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+		if err != nil || p.now().Unix() > exp {
+			http.Error(w, "url expired", http.StatusForbidden)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			if !p.validSignature(query.Get("sig"), http.MethodGet, key, "", 0, exp) {
+				http.Error(w, "invalid signature", http.StatusForbidden)
+				return
+			}
+			p.serveObject(w, r, key)
+		case http.MethodPut:
+			contentType := query.Get("contentType")
+			maxBytes, err := strconv.ParseInt(query.Get("maxBytes"), 10, 64)
+			if err != nil || !p.validSignature(query.Get("sig"), http.MethodPut, key, contentType, maxBytes, exp) {
+				http.Error(w, "invalid signature", http.StatusForbidden)
+				return
+			}
+			if r.Header.Get("Content-Type") != contentType {
+				http.Error(w, "content type does not match signed upload", http.StatusBadRequest)
+				return
+			}
+			if r.ContentLength > maxBytes {
+				http.Error(w, "upload exceeds signed size limit", http.StatusRequestEntityTooLarge)
+				return
+			}
+			// Upload writes to a temporary file first, so a body cut off here leaves nothing behind.
+			body := http.MaxBytesReader(w, r.Body, maxBytes)
+			if _, err := p.Upload(r.Context(), key, body, contentType); err != nil {
+				var tooLarge *http.MaxBytesError
+				if errors.As(err, &tooLarge) {
+					http.Error(w, "upload exceeds signed size limit", http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (p *FilesystemProvider) serveObject(w http.ResponseWriter, r *http.Request, key string) {
+	info, err := p.stat(key)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := os.Open(p.objectPath(key))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	if ct := p.readMeta(key).ContentType; ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	http.ServeContent(w, r, "", info.ModTime(), f)
+}
+
+func (p *FilesystemProvider) sign(method, key, contentType string, maxBytes, expires int64) string {
+	mac := hmac.New(sha256.New, p.signingKey)
+	mac.Write([]byte(strings.Join([]string{method, key, contentType, strconv.FormatInt(maxBytes, 10), strconv.FormatInt(expires, 10)}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *FilesystemProvider) validSignature(sig, method, key, contentType string, maxBytes, expires int64) bool {
+	return hmac.Equal([]byte(sig), []byte(p.sign(method, key, contentType, maxBytes, expires)))
+}
+
+func (p *FilesystemProvider) stat(key string) (fs.FileInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p.objectPath(key))
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return nil, ErrNotFound
+	}
+	return info, err
+}
+
+func (p *FilesystemProvider) objectPath(key string) string {
+	return filepath.Join(p.root, "objects", filepath.FromSlash(key))
+}
+
+func (p *FilesystemProvider) metaPath(key string) string {
+	return filepath.Join(p.root, "meta", filepath.FromSlash(key)+".json")
+}
+
+func (p *FilesystemProvider) writeMeta(key string, meta fileMeta) error {
+	if err := os.MkdirAll(filepath.Dir(p.metaPath(key)), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p.metaPath(key), data, 0o644)
+}
+
+func (p *FilesystemProvider) readMeta(key string) fileMeta {
+	meta := fileMeta{ContentType: "application/octet-stream"}
+	if data, err := os.ReadFile(p.metaPath(key)); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	return meta
+}
+
+func (p *FilesystemProvider) objectURL(key string, query url.Values) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	u := *p.baseURL
+	u.RawPath = ""
+	u.Path = ""
+	out := u.String() + p.baseURL.EscapedPath() + "/" + strings.Join(segments, "/")
+	if len(query) > 0 {
+		out += "?" + query.Encode()
+	}
+	return out
+}
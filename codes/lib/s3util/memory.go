@@ -0,0 +1,141 @@
+package s3util
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryProvider keeps objects in process memory. It is meant for tests and throwaway
+// local runs; presigned URLs use the memory:// scheme and cannot be fetched.
+type MemoryProvider struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data        []byte
+	contentType string
+	modified    time.Time
+}
+
+// NewMemory creates an empty in-memory provider.
+func NewMemory() *MemoryProvider {
+	return &MemoryProvider{objects: make(map[string]memoryObject)}
+}
+
+// Upload stores the full contents of body under key.
+func (p *MemoryProvider) Upload(_ context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	p.mu.Lock()
+	p.objects[key] = memoryObject{data: data, contentType: contentType, modified: time.Now()}
+	p.mu.Unlock()
+	return memoryURL(key, nil), nil
+}
+
+// Get returns a copy of the stored bytes.
+func (p *MemoryProvider) Get(_ context.Context, key string) ([]byte, error) {
+	obj, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), obj.data...), nil
+}
+
+// Head returns object metadata.
+func (p *MemoryProvider) Head(_ context.Context, key string) (*ObjectInfo, error) {
+	obj, err := p.lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	return &ObjectInfo{Key: key, Size: int64(len(obj.data)), ContentType: obj.contentType, LastModified: obj.modified}, nil
+}
+
+// List returns objects whose key starts with prefix, sorted by key.
+func (p *MemoryProvider) List(_ context.Context, prefix string) ([]ObjectInfo, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var items []ObjectInfo
+	for key, obj := range p.objects {
+		if strings.HasPrefix(key, prefix) {
+			items = append(items, ObjectInfo{Key: key, Size: int64(len(obj.data)), ContentType: obj.contentType, LastModified: obj.modified})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Key < items[j].Key })
+	return items, nil
+}
+
+// Copy duplicates srcKey to dstKey.
+func (p *MemoryProvider) Copy(_ context.Context, srcKey, dstKey string) error {
+	if err := validateKey(dstKey); err != nil {
+		return err
+	}
+	obj, err := p.lookup(srcKey)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.objects[dstKey] = memoryObject{data: append([]byte(nil), obj.data...), contentType: obj.contentType, modified: time.Now()}
+	p.mu.Unlock()
+	return nil
+}
+
+// Delete removes key; deleting a missing object is not an error.
+func (p *MemoryProvider) Delete(_ context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	delete(p.objects, key)
+	p.mu.Unlock()
+	return nil
+}
+
+// PresignGet returns a memory:// URL carrying the expiry.
+func (p *MemoryProvider) PresignGet(_ context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return memoryURL(key, url.Values{"expires": {fmt.Sprint(time.Now().Add(expires).Unix())}}), nil
+}
+
+// PresignPut returns a memory:// URL carrying the expiry, content type and size limit.
+func (p *MemoryProvider) PresignPut(_ context.Context, key, contentType string, maxBytes int64, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	return memoryURL(key, url.Values{
+		"expires":     {fmt.Sprint(time.Now().Add(expires).Unix())},
+		"contentType": {contentType},
+		"maxBytes":    {fmt.Sprint(maxBytes)},
+	}), nil
+}
+
+func (p *MemoryProvider) lookup(key string) (memoryObject, error) {
+	if err := validateKey(key); err != nil {
+		return memoryObject{}, err
+	}
+	p.mu.RLock()
+	obj, ok := p.objects[key]
+	p.mu.RUnlock()
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
+
+func memoryURL(key string, query url.Values) string {
+	u := url.URL{Scheme: "memory", Path: "/" + key, RawQuery: query.Encode()}
+	return u.String()
+}
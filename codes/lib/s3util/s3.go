@@ -1,13 +1,16 @@
 package s3util
 
 import (
-	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
@@ -15,13 +18,6 @@ import (
 	"github.com/venue-master/platform/lib/config"
 )
 
-// StorageProvider defines the behavior shared across services for object storage.
-type StorageProvider interface {
-	Upload(ctx context.Context, key string, body []byte, contentType string) (string, error)
-	Delete(ctx context.Context, key string) error
-	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
-}
-
 // S3Provider implements StorageProvider using AWS S3 (or compatible endpoints like Localstack).
 type S3Provider struct {
 	client   *s3.Client
@@ -51,12 +47,33 @@ func New(ctx context.Context, cfg config.AWSConfig) (*S3Provider, error) {
 	}, nil
 }
 
-// Upload pushes bytes to S3 and returns the absolute object URL.
-func (p *S3Provider) Upload(ctx context.Context, key string, body []byte, contentType string) (string, error) {
+// Upload streams body to S3 and returns the absolute object URL. Non-seekable readers
+// are spooled to a temp file first because PutObject needs a known content length.
+func (p *S3Provider) Upload(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	seeker, ok := body.(io.ReadSeeker)
+	if !ok {
+		tmp, err := os.CreateTemp("", "s3util-upload-*")
+		if err != nil {
+			return "", err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if _, err := io.Copy(tmp, body); err != nil {
+			return "", err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return "", err
+		}
+		seeker = tmp
+	}
+
 	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket:      &p.bucket,
 		Key:         &key,
-		Body:        bytes.NewReader(body),
+		Body:        seeker,
 		ContentType: awsString(contentType),
 		ACL:         types.ObjectCannedACLPrivate,
 	})
@@ -67,14 +84,85 @@ func (p *S3Provider) Upload(ctx context.Context, key string, body []byte, conten
 	return p.objectURL(key), nil
 }
 
+// Get downloads an object into memory.
+func (p *S3Provider) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	out, err := p.client.GetObject(ctx, &s3.GetObjectInput{Bucket: &p.bucket, Key: &key})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	defer out.Body.Close()
+	return io.ReadAll(out.Body)
+}
+
+// Head fetches object metadata without downloading the body.
+func (p *S3Provider) Head(ctx context.Context, key string) (*ObjectInfo, error) {
+	if err := validateKey(key); err != nil {
+		return nil, err
+	}
+	out, err := p.client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: &p.bucket, Key: &key})
+	if err != nil {
+		return nil, mapS3Error(err)
+	}
+	info := &ObjectInfo{Key: key, ContentType: aws.ToString(out.ContentType), Size: aws.ToInt64(out.ContentLength)}
+	if out.LastModified != nil {
+		info.LastModified = *out.LastModified
+	}
+	return info, nil
+}
+
+// List pages through every object under prefix. Content types are not part of the
+// listing API, so ContentType is left empty.
+func (p *S3Provider) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	paginator := s3.NewListObjectsV2Paginator(p.client, &s3.ListObjectsV2Input{Bucket: &p.bucket, Prefix: awsString(prefix)})
+	var items []ObjectInfo
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			info := ObjectInfo{Key: aws.ToString(obj.Key), Size: aws.ToInt64(obj.Size)}
+			if obj.LastModified != nil {
+				info.LastModified = *obj.LastModified
+			}
+			items = append(items, info)
+		}
+	}
+	return items, nil
+}
+
+// Copy duplicates an object server-side.
+func (p *S3Provider) Copy(ctx context.Context, srcKey, dstKey string) error {
+	if err := validateKey(dstKey); err != nil {
+		return err
+	}
+	source := p.bucket + "/" + url.PathEscape(srcKey)
+	_, err := p.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &p.bucket,
+		Key:        &dstKey,
+		CopySource: &source,
+		ACL:        types.ObjectCannedACLPrivate,
+	})
+	return mapS3Error(err)
+}
+
 // Delete removes an object from S3.
 func (p *S3Provider) Delete(ctx context.Context, key string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
 	_, err := p.client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: &p.bucket, Key: &key})
 	return err
 }
 
 // PresignGet generates a signed GET URL valid for the provided duration.
 func (p *S3Provider) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
 	out, err := p.presign.PresignGetObject(ctx, &s3.GetObjectInput{Bucket: &p.bucket, Key: &key}, s3.WithPresignExpires(expires))
 	if err != nil {
 		return "", err
@@ -82,6 +170,23 @@ func (p *S3Provider) PresignGet(ctx context.Context, key string, expires time.Du
 	return out.URL, nil
 }
 
+// PresignPut generates a signed PUT URL so clients can upload directly to the bucket. A
+// presigned PUT cannot cap the body size, so maxBytes is not enforced here.
+func (p *S3Provider) PresignPut(ctx context.Context, key, contentType string, _ int64, expires time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", err
+	}
+	out, err := p.presign.PresignPutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &p.bucket,
+		Key:         &key,
+		ContentType: awsString(contentType),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", err
+	}
+	return out.URL, nil
+}
+
 func (p *S3Provider) objectURL(key string) string {
 	if p.endpoint == "" {
 		return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", p.bucket, url.PathEscape(key))
@@ -89,6 +194,16 @@ func (p *S3Provider) objectURL(key string) string {
 	return fmt.Sprintf("%s/%s/%s", strings.TrimRight(p.endpoint, "/"), p.bucket, url.PathEscape(key))
 }
 
+// mapS3Error converts missing-object errors into ErrNotFound.
+func mapS3Error(err error) error {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
 func awsString(v string) *string {
 	if v == "" {
 		return nil
@@ -0,0 +1,254 @@
+package s3util_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/s3util"
+	"github.com/venue-master/platform/lib/s3util/storagetest"
+)
+
+func TestMemoryProviderConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) s3util.StorageProvider {
+		return s3util.NewMemory()
+	})
+}
+
+func TestFilesystemProviderConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) s3util.StorageProvider {
+		return newFilesystem(t, "http://localhost:8080/storage")
+	})
+}
+
+// TestS3ProviderConformance runs against Localstack/MinIO when STORAGE_TEST_S3_ENDPOINT is set.
+func TestS3ProviderConformance(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT not set")
+	}
+	storagetest.Run(t, func(t *testing.T) s3util.StorageProvider {
+		p, err := s3util.New(context.Background(), config.AWSConfig{
+			Region:   "us-east-1",
+			Bucket:   os.Getenv("STORAGE_TEST_S3_BUCKET"),
+			Endpoint: endpoint,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		prefix := "conformance/" + strings.ReplaceAll(t.Name(), "/", "_") + "/"
+		return &prefixed{StorageProvider: p, prefix: prefix}
+	})
+}
+
+func TestNewFromConfig(t *testing.T) {
+	ctx := context.Background()
+	if p, err := s3util.NewFromConfig(ctx, config.AWSConfig{StorageProvider: s3util.ProviderMemory}); err != nil {
+		t.Fatalf("memory: %v", err)
+	} else if _, ok := p.(*s3util.MemoryProvider); !ok {
+		t.Fatalf("memory: got %T", p)
+	}
+	p, err := s3util.NewFromConfig(ctx, config.AWSConfig{
+		StorageProvider: s3util.ProviderFilesystem,
+		LocalRoot:       t.TempDir(),
+		LocalBaseURL:    "http://localhost:8080/storage",
+		SigningKey:      "secret",
+	})
+	if err != nil {
+		t.Fatalf("filesystem: %v", err)
+	}
+	if _, ok := p.(*s3util.FilesystemProvider); !ok {
+		t.Fatalf("filesystem: got %T", p)
+	}
+	if _, err := s3util.NewFromConfig(ctx, config.AWSConfig{StorageProvider: "ftp"}); err == nil {
+		t.Fatal("unknown provider should fail")
+	}
+}
+
+func TestFilesystemSignedURLs(t *testing.T) {
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := newFilesystem(t, srv.URL+"/storage")
+	mux.Handle(p.BasePath()+"/", p.Handler())
+	ctx := context.Background()
+
+	putURL, err := p.PresignPut(ctx, "venues/1/media/photo one.png", "image/png", 16, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp := do(t, http.MethodPut, putURL, "image/jpeg", "png-bytes"); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("PUT with wrong content type = %d, want 400", resp.StatusCode)
+	}
+	if resp := do(t, http.MethodPut, putURL, "image/png", "png-bytes"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("PUT = %d, want 200", resp.StatusCode)
+	}
+	if info, err := p.Head(ctx, "venues/1/media/photo one.png"); err != nil || info.ContentType != "image/png" {
+		t.Fatalf("Head() after signed PUT = %+v, %v", info, err)
+	}
+
+	getURL, err := p.PresignGet(ctx, "venues/1/media/photo one.png", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := do(t, http.MethodGet, getURL, "", "")
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "png-bytes" || resp.Header.Get("Content-Type") != "image/png" {
+		t.Fatalf("GET = %d %q %q", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+
+	// A GET signature cannot be replayed as an upload.
+	if resp := do(t, http.MethodPut, getURL, "image/png", "evil"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("PUT with GET signature = %d, want 403", resp.StatusCode)
+	}
+
+	tampered := strings.Replace(getURL, "photo%20one.png", "other.png", 1)
+	if resp := do(t, http.MethodGet, tampered, "", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("GET with tampered key = %d, want 403", resp.StatusCode)
+	}
+
+	u, _ := url.Parse(getURL)
+	q := u.Query()
+	q.Set("expires", "1")
+	u.RawQuery = q.Encode()
+	if resp := do(t, http.MethodGet, u.String(), "", ""); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("GET with altered expiry = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestFilesystemSignedURLExpires(t *testing.T) {
+	p := newFilesystem(t, "http://localhost/storage")
+	if _, err := p.Upload(context.Background(), "a.txt", strings.NewReader("a"), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+	expired, err := p.PresignGet(context.Background(), "a.txt", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, expired, nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expired GET = %d, want 403", rec.Code)
+	}
+}
+
+func TestFilesystemSignedUploadSizeLimit(t *testing.T) {
+	p := newFilesystem(t, "http://localhost/storage")
+	putURL, err := p.PresignPut(context.Background(), "a.txt", "text/plain", 4, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(target, body string, length int64) int {
+		req := httptest.NewRequest(http.MethodPut, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/plain")
+		req.ContentLength = length
+		rec := httptest.NewRecorder()
+		p.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := put(putURL, "12345", 5); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("PUT over declared limit = %d, want 413", code)
+	}
+	// Without a Content-Length the body is cut off at the limit instead.
+	if code := put(putURL, "12345", -1); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked PUT over limit = %d, want 413", code)
+	}
+	if _, err := p.Head(context.Background(), "a.txt"); !errors.Is(err, s3util.ErrNotFound) {
+		t.Fatalf("oversized upload was stored: %v", err)
+	}
+	if code := put(strings.Replace(putURL, "maxBytes=4", "maxBytes=4000", 1), "12345", 5); code != http.StatusForbidden {
+		t.Fatalf("PUT with raised limit = %d, want 403", code)
+	}
+	if code := put(putURL, "1234", 4); code != http.StatusOK {
+		t.Fatalf("PUT within limit = %d, want 200", code)
+	}
+}
+
+func newFilesystem(t *testing.T, baseURL string) *s3util.FilesystemProvider {
+	t.Helper()
+	p, err := s3util.NewFilesystem(t.TempDir(), baseURL, []byte("test-signing-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func do(t *testing.T, method, target, contentType, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// prefixed isolates each conformance subtest inside a shared bucket.
+type prefixed struct {
+	s3util.StorageProvider
+	prefix string
+}
+
+func (p *prefixed) Upload(ctx context.Context, key string, body io.Reader, contentType string) (string, error) {
+	return p.StorageProvider.Upload(ctx, p.key(key), body, contentType)
+}
+
+func (p *prefixed) Get(ctx context.Context, key string) ([]byte, error) {
+	return p.StorageProvider.Get(ctx, p.key(key))
+}
+
+func (p *prefixed) Head(ctx context.Context, key string) (*s3util.ObjectInfo, error) {
+	info, err := p.StorageProvider.Head(ctx, p.key(key))
+	if info != nil {
+		info.Key = strings.TrimPrefix(info.Key, p.prefix)
+	}
+	return info, err
+}
+
+func (p *prefixed) List(ctx context.Context, prefix string) ([]s3util.ObjectInfo, error) {
+	items, err := p.StorageProvider.List(ctx, p.prefix+prefix)
+	for i := range items {
+		items[i].Key = strings.TrimPrefix(items[i].Key, p.prefix)
+	}
+	return items, err
+}
+
+func (p *prefixed) Copy(ctx context.Context, srcKey, dstKey string) error {
+	return p.StorageProvider.Copy(ctx, p.key(srcKey), p.key(dstKey))
+}
+
+func (p *prefixed) Delete(ctx context.Context, key string) error {
+	return p.StorageProvider.Delete(ctx, p.key(key))
+}
+
+func (p *prefixed) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	return p.StorageProvider.PresignGet(ctx, p.key(key), expires)
+}
+
+func (p *prefixed) PresignPut(ctx context.Context, key, contentType string, maxBytes int64, expires time.Duration) (string, error) {
+	return p.StorageProvider.PresignPut(ctx, p.key(key), contentType, maxBytes, expires)
+}
+
+// key keeps invalid keys invalid so the InvalidKeys case still exercises the provider.
+func (p *prefixed) key(key string) string {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") || strings.Contains(key, "//") {
+		return key
+	}
+	return p.prefix + key
+}
@@ -0,0 +1,69 @@
+package s3util
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+// Storage provider names accepted in config.AWSConfig.StorageProvider.
+const (
+	ProviderS3         = "s3"
+	ProviderFilesystem = "filesystem"
+	ProviderMemory     = "memory"
+)
+
+var (
+	// ErrNotFound is returned when an object does not exist.
+	ErrNotFound = errors.New("object not found")
+	// ErrInvalidKey is returned for empty, absolute or dot-dot object keys.
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// StorageProvider defines the behavior shared across services for object storage.
+type StorageProvider interface {
+	Upload(ctx context.Context, key string, body io.Reader, contentType string) (string, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	Head(ctx context.Context, key string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Copy(ctx context.Context, srcKey, dstKey string) error
+	Delete(ctx context.Context, key string) error
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// PresignPut signs an upload of at most maxBytes. Only the filesystem provider can enforce
+	// the limit, so callers still check the stored size before trusting an upload.
+	PresignPut(ctx context.Context, key, contentType string, maxBytes int64, expires time.Duration) (string, error)
+}
+
+// ObjectInfo describes a stored object without its contents.
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// NewFromConfig builds the StorageProvider selected by cfg.StorageProvider (S3 when unset).
+func NewFromConfig(ctx context.Context, cfg config.AWSConfig) (StorageProvider, error) {
+	switch cfg.StorageProvider {
+	case "", ProviderS3:
+		return New(ctx, cfg)
+	case ProviderFilesystem:
+		return NewFilesystem(cfg.LocalRoot, cfg.LocalBaseURL, []byte(cfg.SigningKey))
+	case ProviderMemory:
+		return NewMemory(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage provider %q", cfg.StorageProvider)
+	}
+}
+
+func validateKey(key string) error {
+	if !fs.ValidPath(key) || key == "." {
+		return ErrInvalidKey
+	}
+	return nil
+}
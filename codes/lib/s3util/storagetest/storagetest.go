@@ -0,0 +1,191 @@
+// Package storagetest holds the conformance suite every s3util.StorageProvider must pass.
+package storagetest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/venue-master/platform/lib/s3util"
+)
+
+// Run exercises provider behaviour shared by all backends. newProvider must return an
+// empty provider on every call.
+func Run(t *testing.T, newProvider func(t *testing.T) s3util.StorageProvider) {
+	t.Run("UploadGetHead", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		if _, err := p.Upload(ctx, "docs/readme.txt", strings.NewReader("hello"), "text/plain"); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		data, err := p.Get(ctx, "docs/readme.txt")
+		if err != nil || string(data) != "hello" {
+			t.Fatalf("Get() = %q, %v; want %q", data, err, "hello")
+		}
+		info, err := p.Head(ctx, "docs/readme.txt")
+		if err != nil {
+			t.Fatalf("Head() error = %v", err)
+		}
+		if info.Key != "docs/readme.txt" || info.Size != 5 || info.ContentType != "text/plain" {
+			t.Fatalf("Head() = %+v", info)
+		}
+		if info.LastModified.IsZero() || time.Since(info.LastModified) > time.Hour {
+			t.Fatalf("Head() LastModified = %v", info.LastModified)
+		}
+	})
+
+	t.Run("UploadStreamsNonSeekableReader", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		payload := bytes.Repeat([]byte("0123456789"), 100_000)
+		pr, pw := io.Pipe()
+		go func() {
+			for chunk := payload; len(chunk) > 0; chunk = chunk[min(len(chunk), 4096):] {
+				if _, err := pw.Write(chunk[:min(len(chunk), 4096)]); err != nil {
+					return
+				}
+			}
+			pw.Close()
+		}()
+		if _, err := p.Upload(ctx, "big.bin", pr, "application/octet-stream"); err != nil {
+			t.Fatalf("Upload() error = %v", err)
+		}
+		data, err := p.Get(ctx, "big.bin")
+		if err != nil || !bytes.Equal(data, payload) {
+			t.Fatalf("Get() returned %d bytes, err %v; want %d bytes", len(data), err, len(payload))
+		}
+	})
+
+	t.Run("UploadOverwrites", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		mustUpload(t, p, "a.txt", "first", "text/plain")
+		mustUpload(t, p, "a.txt", "second", "text/markdown")
+		data, _ := p.Get(ctx, "a.txt")
+		info, _ := p.Head(ctx, "a.txt")
+		if string(data) != "second" || info == nil || info.ContentType != "text/markdown" {
+			t.Fatalf("overwrite not applied: %q %+v", data, info)
+		}
+	})
+
+	t.Run("MissingObjects", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		if _, err := p.Get(ctx, "nope.txt"); !errors.Is(err, s3util.ErrNotFound) {
+			t.Fatalf("Get() error = %v, want ErrNotFound", err)
+		}
+		if _, err := p.Head(ctx, "nope.txt"); !errors.Is(err, s3util.ErrNotFound) {
+			t.Fatalf("Head() error = %v, want ErrNotFound", err)
+		}
+		if err := p.Copy(ctx, "nope.txt", "copy.txt"); !errors.Is(err, s3util.ErrNotFound) {
+			t.Fatalf("Copy() error = %v, want ErrNotFound", err)
+		}
+		if err := p.Delete(ctx, "nope.txt"); err != nil {
+			t.Fatalf("Delete() of missing object error = %v", err)
+		}
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		for _, key := range []string{"", "/abs.txt", "../escape.txt", "a/../../b.txt", "a//b.txt"} {
+			if _, err := p.Upload(ctx, key, strings.NewReader("x"), "text/plain"); !errors.Is(err, s3util.ErrInvalidKey) {
+				t.Errorf("Upload(%q) error = %v, want ErrInvalidKey", key, err)
+			}
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		mustUpload(t, p, "venues/1/b.jpg", "bb", "image/jpeg")
+		mustUpload(t, p, "venues/1/a.jpg", "a", "image/jpeg")
+		mustUpload(t, p, "venues/10/c.jpg", "c", "image/jpeg")
+		mustUpload(t, p, "facilities/1/d.jpg", "d", "image/jpeg")
+
+		items, err := p.List(ctx, "venues/1/")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if got := keys(items); strings.Join(got, ",") != "venues/1/a.jpg,venues/1/b.jpg" {
+			t.Fatalf("List(venues/1/) = %v", got)
+		}
+		if items[1].Size != 2 {
+			t.Fatalf("List() size = %d, want 2", items[1].Size)
+		}
+
+		all, err := p.List(ctx, "")
+		if err != nil || len(all) != 4 {
+			t.Fatalf("List(\"\") = %v, %v; want 4 objects", keys(all), err)
+		}
+		none, err := p.List(ctx, "bookings/")
+		if err != nil || len(none) != 0 {
+			t.Fatalf("List(bookings/) = %v, %v; want none", keys(none), err)
+		}
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		mustUpload(t, p, "src/photo.png", "png-bytes", "image/png")
+		if err := p.Copy(ctx, "src/photo.png", "dst/photo.png"); err != nil {
+			t.Fatalf("Copy() error = %v", err)
+		}
+		data, err := p.Get(ctx, "dst/photo.png")
+		if err != nil || string(data) != "png-bytes" {
+			t.Fatalf("Get(copy) = %q, %v", data, err)
+		}
+		if info, err := p.Head(ctx, "dst/photo.png"); err != nil || info.ContentType != "image/png" {
+			t.Fatalf("Head(copy) = %+v, %v", info, err)
+		}
+		if _, err := p.Get(ctx, "src/photo.png"); err != nil {
+			t.Fatalf("source removed by Copy: %v", err)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		mustUpload(t, p, "tmp/x.txt", "x", "text/plain")
+		if err := p.Delete(ctx, "tmp/x.txt"); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := p.Head(ctx, "tmp/x.txt"); !errors.Is(err, s3util.ErrNotFound) {
+			t.Fatalf("Head() after delete error = %v, want ErrNotFound", err)
+		}
+		if items, _ := p.List(ctx, "tmp/"); len(items) != 0 {
+			t.Fatalf("List() after delete = %v", keys(items))
+		}
+	})
+
+	t.Run("Presign", func(t *testing.T) {
+		p, ctx := newProvider(t), context.Background()
+		mustUpload(t, p, "signed/a.txt", "a", "text/plain")
+		getA, err := p.PresignGet(ctx, "signed/a.txt", time.Minute)
+		if err != nil || getA == "" {
+			t.Fatalf("PresignGet() = %q, %v", getA, err)
+		}
+		getB, _ := p.PresignGet(ctx, "signed/b.txt", time.Minute)
+		if getA == getB {
+			t.Fatalf("PresignGet() returned the same URL for different keys")
+		}
+		put, err := p.PresignPut(ctx, "signed/c.txt", "text/plain", 1<<10, time.Minute)
+		if err != nil || put == "" || put == getA {
+			t.Fatalf("PresignPut() = %q, %v", put, err)
+		}
+		if _, err := p.PresignGet(ctx, "../a.txt", time.Minute); !errors.Is(err, s3util.ErrInvalidKey) {
+			t.Fatalf("PresignGet(invalid) error = %v, want ErrInvalidKey", err)
+		}
+	})
+}
+
+func mustUpload(t *testing.T, p s3util.StorageProvider, key, body, contentType string) {
+	t.Helper()
+	if _, err := p.Upload(context.Background(), key, strings.NewReader(body), contentType); err != nil {
+		t.Fatalf("Upload(%q) error = %v", key, err)
+	}
+}
+
+func keys(items []s3util.ObjectInfo) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.Key)
+	}
+	return out
+}
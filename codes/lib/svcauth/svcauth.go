@@ -0,0 +1,360 @@
+// Package svcauth authenticates calls between services. Every calling service signs each
+// outgoing request with its own Ed25519 key: a short-lived, single-use token naming the caller
+// and binding the method, path, query, body and user identity headers (X-User-*), so a token
+// cannot be replayed against another endpoint, with other parameters or body, with a different
+// user, or a second time. Services that trust identity headers verify the token before anything
+// reads them.
+package svcauth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/config"
+)
+
+// Header carries the service token.
+const Header = "X-Service-Token"
+
+const (
+	// tokenTTL keeps tokens single-request: they are minted per call and never stored.
+	tokenTTL = time.Minute
+	// leeway absorbs clock skew between containers.
+	leeway = 5 * time.Second
+)
+
+// identityHeaders are bound into every token; a verified request's identity cannot be altered.
+var identityHeaders = []string{authz.HeaderUserID, authz.HeaderRoles, authz.HeaderPermissions, authz.HeaderVenuePermissions}
+
+var (
+	// ErrMissingToken is returned by Verify for requests without a service token.
+	ErrMissingToken = errors.New("service token missing")
+	// ErrInvalidToken is returned by Verify for tokens that are malformed, expired, signed by an
+	// unknown service or issued for another request.
+	ErrInvalidToken = errors.New("service token invalid")
+)
+
+type claims struct {
+	Method   string `json:"htm"`
+	Path     string `json:"htu"`
+	Query    string `json:"htq"`
+	Identity string `json:"idh"`
+	Body     string `json:"bdh"`
+	jwt.RegisteredClaims
+}
+
+// Keys signs this service's outgoing calls and verifies incoming ones. Keys without a private
+// key sign nothing; Keys without trusted keys are disabled and verify nothing.
+type Keys struct {
+	service string
+	private ed25519.PrivateKey
+	trusted map[string]ed25519.PublicKey
+	seen    *seenSet
+}
+
+// New returns Keys for service. trusted maps service names to the public keys they sign with.
+func New(service string, private ed25519.PrivateKey, trusted map[string]ed25519.PublicKey) *Keys {
+	return &Keys{service: service, private: private, trusted: trusted, seen: newSeenSet()}
+}
+
+// Load reads the keys from cfg.KeysDir: the service's own private key (PKCS#8) from
+// cfg.PrivateKeyFile, or <service>.pem in KeysDir when that is unset, and every <name>.pub.pem
+// public key as trusted caller <name>. A missing private key only disables signing. An empty
+// KeysDir disables service authentication.
+func Load(service string, cfg config.InternalAuthConfig) (*Keys, error) {
+	keys := &Keys{service: service, seen: newSeenSet()}
+	if cfg.KeysDir == "" {
+		return keys, nil
+	}
+	privatePath := cfg.PrivateKeyFile
+	if privatePath == "" {
+		privatePath = filepath.Join(cfg.KeysDir, service+".pem")
+	}
+	private, err := readPrivateKey(privatePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	keys.private = private
+
+	paths, err := filepath.Glob(filepath.Join(cfg.KeysDir, "*.pub.pem"))
+	if err != nil {
+		return nil, err
+	}
+	keys.trusted = make(map[string]ed25519.PublicKey, len(paths))
+	for _, path := range paths {
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, err
+		}
+		keys.trusted[strings.TrimSuffix(filepath.Base(path), ".pub.pem")] = public
+	}
+	if len(keys.trusted) == 0 {
+		return nil, fmt.Errorf("svcauth: no *.pub.pem keys in %s", cfg.KeysDir)
+	}
+	return keys, nil
+}
+
+// Enabled reports whether incoming calls are verified.
+func (k *Keys) Enabled() bool {
+	return len(k.trusted) > 0
+}
+
+// Sign attaches a service token for req, which must already carry its identity headers. The
+// body is read to be hashed and replaced with a buffered copy.
+func (k *Keys) Sign(req *http.Request) error {
+	if k.private == nil {
+		return nil
+	}
+	body, err := bufferBody(req)
+	if err != nil {
+		return err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims{
+		Method:   req.Method,
+		Path:     req.URL.EscapedPath(),
+		Query:    req.URL.RawQuery,
+		Identity: identityHash(req.Header),
+		Body:     body,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        base64.RawURLEncoding.EncodeToString(id),
+			Issuer:    k.service,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tokenTTL)),
+		},
+	})
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(Header, signed)
+	return nil
+}
+
+// Transport wraps base (http.DefaultTransport when nil) so every request is signed.
+func (k *Keys) Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if k.private == nil {
+		return base
+	}
+	return &signingTransport{keys: k, base: base}
+}
+
+type signingTransport struct {
+	keys *Keys
+	base http.RoundTripper
+}
+
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTrippers must not modify the caller's request.
+	req = req.Clone(req.Context())
+	if err := t.keys.Sign(req); err != nil {
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
+
+// Verify checks req's service token and returns the calling service. The body is read to be
+// hashed and replaced with a buffered copy. Each token is accepted once; the tokens already used
+// are remembered in memory until they expire.
+func (k *Keys) Verify(req *http.Request) (string, error) {
+	raw := req.Header.Get(Header)
+	if raw == "" {
+		return "", ErrMissingToken
+	}
+	var c claims
+	_, err := jwt.ParseWithClaims(raw, &c, func(t *jwt.Token) (interface{}, error) {
+		public, ok := k.trusted[c.Issuer]
+		if !ok {
+			return nil, fmt.Errorf("untrusted caller %q", c.Issuer)
+		}
+		return public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}), jwt.WithLeeway(leeway), jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if c.Method != req.Method || c.Path != req.URL.EscapedPath() || c.Query != req.URL.RawQuery || c.Identity != identityHash(req.Header) {
+		return "", fmt.Errorf("%w: issued for another request", ErrInvalidToken)
+	}
+	body, err := bufferBody(req)
+	if err != nil {
+		return "", err
+	}
+	if c.Body != body {
+		return "", fmt.Errorf("%w: issued for another body", ErrInvalidToken)
+	}
+	if c.ID == "" || !k.seen.add(c.ID, c.ExpiresAt.Add(leeway)) {
+		return "", fmt.Errorf("%w: already used", ErrInvalidToken)
+	}
+	return c.Issuer, nil
+}
+
+type callerKey struct{}
+
+// Middleware rejects requests without a valid service token. It must run before anything that
+// reads identity headers. Disabled Keys let every request through.
+func (k *Keys) Middleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !k.Enabled() {
+			ctx.Next()
+			return
+		}
+		caller, err := k.Verify(ctx.Request)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "service authentication required"})
+			return
+		}
+		ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), callerKey{}, caller))
+		ctx.Next()
+	}
+}
+
+// RequireCaller limits a route to the named services. It must follow Middleware.
+func (k *Keys) RequireCaller(services ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !k.Enabled() {
+			ctx.Next()
+			return
+		}
+		caller, _ := CallerFromContext(ctx.Request.Context())
+		for _, service := range services {
+			if caller == service {
+				ctx.Next()
+				return
+			}
+		}
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "caller not allowed", "caller": caller})
+	}
+}
+
+// CallerFromContext returns the service Middleware verified the request came from.
+func CallerFromContext(ctx context.Context) (string, bool) {
+	caller, ok := ctx.Value(callerKey{}).(string)
+	return caller, ok
+}
+
+// identityHash digests the identity headers in a fixed order.
+func identityHash(h http.Header) string {
+	sum := sha256.New()
+	for _, name := range identityHeaders {
+		fmt.Fprintf(sum, "%s:%s\n", name, strings.Join(h.Values(name), ","))
+	}
+	return base64.RawURLEncoding.EncodeToString(sum.Sum(nil))
+}
+
+// bufferBody reads req's body, puts an unread copy back and returns the body's digest.
+func bufferBody(req *http.Request) (string, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return "", err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+	sum := sha256.Sum256(body)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// seenSet remembers token ids until they expire.
+type seenSet struct {
+	mu        sync.Mutex
+	ids       map[string]time.Time
+	lastSweep time.Time
+}
+
+func newSeenSet() *seenSet {
+	return &seenSet{ids: make(map[string]time.Time)}
+}
+
+// add records id until expires and reports whether it was new.
+func (s *seenSet) add(id string, expires time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > tokenTTL {
+		for seen, until := range s.ids {
+			if now.After(until) {
+				delete(s.ids, seen)
+			}
+		}
+		s.lastSweep = now
+	}
+	if _, ok := s.ids[id]; ok {
+		return false
+	}
+	s.ids[id] = expires
+	return true
+}
+
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("svcauth: %s: %w", path, err)
+	}
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("svcauth: %s is not an Ed25519 key", path)
+	}
+	return private, nil
+}
+
+func readPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("svcauth: %s: %w", path, err)
+	}
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("svcauth: %s is not an Ed25519 key", path)
+	}
+	return public, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("svcauth: %s is not PEM", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,212 @@
+package svcauth_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/svcauth"
+)
+
+func newKey(t *testing.T) (ed25519.PublicKey, ed25519.PrivateKey) {
+	t.Helper()
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public, private
+}
+
+func signedRequest(t *testing.T, keys *svcauth.Keys, method, path, userID string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(method, "http://user-service"+path, nil)
+	if userID != "" {
+		req.Header.Set(authz.HeaderUserID, userID)
+		req.Header.Set(authz.HeaderRoles, "MEMBER")
+	}
+	if err := keys.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestVerify(t *testing.T) {
+	gwPublic, gwPrivate := newKey(t)
+	_, strangerPrivate := newKey(t)
+	trusted := map[string]ed25519.PublicKey{"api-gateway": gwPublic}
+	gateway := svcauth.New("api-gateway", gwPrivate, trusted)
+	verifier := svcauth.New("user-service", nil, trusted)
+
+	req := signedRequest(t, gateway, http.MethodGet, "/v1/users/42", "42")
+	caller, err := verifier.Verify(req)
+	if err != nil || caller != "api-gateway" {
+		t.Fatalf("Verify = %q, %v; want api-gateway", caller, err)
+	}
+	if _, err := verifier.Verify(req); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("token used twice: err = %v, want ErrInvalidToken", err)
+	}
+
+	post := httptest.NewRequest(http.MethodPut, "http://user-service/v1/users/42/roles", strings.NewReader(`{"roles":["MEMBER"]}`))
+	if err := gateway.Sign(post); err != nil {
+		t.Fatal(err)
+	}
+	swapped := httptest.NewRequest(http.MethodPut, "http://user-service/v1/users/42/roles", strings.NewReader(`{"roles":["SUPER_ADMIN"]}`))
+	swapped.Header = post.Header.Clone()
+	if _, err := verifier.Verify(swapped); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("token replayed with another body: err = %v, want ErrInvalidToken", err)
+	}
+	if _, err := verifier.Verify(post); err != nil {
+		t.Fatalf("signed body rejected: %v", err)
+	}
+	if body, _ := io.ReadAll(post.Body); string(body) != `{"roles":["MEMBER"]}` {
+		t.Errorf("body after Verify = %q, want it left readable", body)
+	}
+
+	tampered := signedRequest(t, gateway, http.MethodGet, "/v1/users/42", "42")
+	tampered.Header.Set(authz.HeaderRoles, "SUPER_ADMIN")
+	if _, err := verifier.Verify(tampered); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("altered identity headers: err = %v, want ErrInvalidToken", err)
+	}
+
+	replayed := signedRequest(t, gateway, http.MethodGet, "/v1/users/42", "42")
+	other := httptest.NewRequest(http.MethodDelete, "http://user-service/v1/users/42/mfa", nil)
+	other.Header = replayed.Header.Clone()
+	if _, err := verifier.Verify(other); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("token replayed on another route: err = %v, want ErrInvalidToken", err)
+	}
+
+	requery := signedRequest(t, gateway, http.MethodGet, "/v1/users?role=MEMBER", "42")
+	requery.URL.RawQuery = "role=SUPER_ADMIN"
+	if _, err := verifier.Verify(requery); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("token replayed with another query: err = %v, want ErrInvalidToken", err)
+	}
+
+	impostor := svcauth.New("api-gateway", strangerPrivate, nil)
+	if _, err := verifier.Verify(signedRequest(t, impostor, http.MethodGet, "/v1/users/42", "42")); !errors.Is(err, svcauth.ErrInvalidToken) {
+		t.Errorf("untrusted key: err = %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := verifier.Verify(httptest.NewRequest(http.MethodGet, "/v1/users/42", nil)); !errors.Is(err, svcauth.ErrMissingToken) {
+		t.Errorf("unsigned request: err = %v, want ErrMissingToken", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	authPublic, authPrivate := newKey(t)
+	gwPublic, gwPrivate := newKey(t)
+	trusted := map[string]ed25519.PublicKey{"auth-service": authPublic, "api-gateway": gwPublic}
+	keys := svcauth.New("user-service", nil, trusted)
+
+	router := gin.New()
+	router.Use(keys.Middleware())
+	router.POST("/v1/users/authenticate", keys.RequireCaller("auth-service"), func(ctx *gin.Context) {
+		ctx.Status(http.StatusOK)
+	})
+
+	cases := []struct {
+		name   string
+		req    *http.Request
+		status int
+	}{
+		{"auth-service", signedRequest(t, svcauth.New("auth-service", authPrivate, nil), http.MethodPost, "/v1/users/authenticate", ""), http.StatusOK},
+		{"other service", signedRequest(t, svcauth.New("api-gateway", gwPrivate, nil), http.MethodPost, "/v1/users/authenticate", ""), http.StatusForbidden},
+		{"unsigned", httptest.NewRequest(http.MethodPost, "/v1/users/authenticate", nil), http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, tc.req)
+		if rec.Code != tc.status {
+			t.Errorf("%s: status = %d, want %d", tc.name, rec.Code, tc.status)
+		}
+	}
+}
+
+func TestTransportSignsRequests(t *testing.T) {
+	public, private := newKey(t)
+	verifier := svcauth.New("booking-service", nil, map[string]ed25519.PublicKey{"api-gateway": public})
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, verifyErr = verifier.Verify(r)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: svcauth.New("api-gateway", private, nil).Transport(nil)}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/bookings", nil)
+	req.Header.Set(authz.HeaderUserID, "42")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if verifyErr != nil {
+		t.Fatalf("server rejected signed request: %v", verifyErr)
+	}
+	if req.Header.Get(svcauth.Header) != "" {
+		t.Error("transport modified the caller's request")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	public, private := newKey(t)
+	writePEM(t, filepath.Join(dir, "auth-service.pem"), "PRIVATE KEY", must(x509.MarshalPKCS8PrivateKey(private)))
+	writePEM(t, filepath.Join(dir, "auth-service.pub.pem"), "PUBLIC KEY", must(x509.MarshalPKIXPublicKey(public)))
+
+	signer, err := svcauth.Load("auth-service", config.InternalAuthConfig{KeysDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := svcauth.Load("user-service", config.InternalAuthConfig{KeysDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller, err := verifier.Verify(signedRequest(t, signer, http.MethodPost, "/v1/users/register", "")); err != nil || caller != "auth-service" {
+		t.Fatalf("Verify = %q, %v; want auth-service", caller, err)
+	}
+
+	// A private key kept outside KeysDir, as docker compose mounts it.
+	keyDir := t.TempDir()
+	if err := os.Rename(filepath.Join(dir, "auth-service.pem"), filepath.Join(keyDir, "private.pem")); err != nil {
+		t.Fatal(err)
+	}
+	signer, err = svcauth.Load("auth-service", config.InternalAuthConfig{KeysDir: dir, PrivateKeyFile: filepath.Join(keyDir, "private.pem")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caller, err := verifier.Verify(signedRequest(t, signer, http.MethodGet, "/v1/users?q=a", "")); err != nil || caller != "auth-service" {
+		t.Fatalf("Verify with PrivateKeyFile = %q, %v; want auth-service", caller, err)
+	}
+
+	disabled, err := svcauth.Load("user-service", config.InternalAuthConfig{})
+	if err != nil || disabled.Enabled() {
+		t.Fatalf("empty KeysDir: Enabled = %v, err = %v; want disabled", disabled.Enabled(), err)
+	}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func must(der []byte, err error) []byte {
+	if err != nil {
+		panic(err)
+	}
+	return der
+}
@@ -13,6 +13,15 @@ import (
 
 	"github.com/venue-master/platform/internal/server"
 	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/lib/svcauth"
+)
+
+const (
+	// Revocation answers are cached briefly so most requests skip Redis; a logout or role
+	// change reaches the gateway within this window.
+	revocationCacheTTL     = 5 * time.Second
+	revocationCacheEntries = 50_000
 )
 
 func main() {
@@ -21,18 +30,22 @@ func main() {
 		log.Fatalf("failed to init server: %v", err)
 	}
 
-	jwtManager := jwtutil.NewManager(srv.Config.JWT)
-	clients := selectServiceClients()
+	jwtManager, err := jwtutil.NewManager(srv.Config.JWT)
+	if err != nil {
+		log.Fatalf("failed to init jwt manager: %v", err)
+	}
+	clients := selectServiceClients(srv.ServiceAuth)
+	revoked := revocation.NewCache(revocation.New(srv.Config.Redis, jwtManager.AccessTTL()), revocationCacheTTL, revocationCacheEntries)
 
 	// Register GraphQL handler
-	graphqlHandler, err := graphqlhandler.New(clients, jwtManager, srv.Logger)
+	graphqlHandler, err := graphqlhandler.New(clients, jwtManager, revoked, srv.Logger)
 	if err != nil {
 		log.Fatalf("failed to init graphql handler: %v", err)
 	}
 	graphqlHandler.Register(srv.Engine)
 
 	// Register REST proxy handlers
-	restHandler := rest.New(clients, jwtManager, srv.Logger)
+	restHandler := rest.New(clients, jwtManager, revoked, srv.Logger, srv.ServiceAuth.Transport(nil))
 	restHandler.Register(srv.Engine)
 
 	if err := srv.Run(); err != nil {
@@ -40,14 +53,15 @@ func main() {
 	}
 }
 
-func selectServiceClients() *services.ServiceClients {
+func selectServiceClients(serviceAuth *svcauth.Keys) *services.ServiceClients {
 	userURL := os.Getenv("USER_SERVICE_URL")
 	bookingURL := os.Getenv("BOOKING_SERVICE_URL")
 	if strings.EqualFold(os.Getenv("USE_MOCK_SERVICES"), "true") || userURL == "" || bookingURL == "" {
 		return services.NewMockClients()
 	}
 	httpClient := &http.Client{
-		Timeout: 5 * time.Second,
+		Timeout:   5 * time.Second,
+		Transport: serviceAuth.Transport(nil),
 	}
 	return services.NewHTTPClients(httpClient, userURL, bookingURL)
 }
@@ -2,6 +2,7 @@ package graphqlhandler
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,8 +10,11 @@ import (
 	"github.com/graphql-go/graphql"
 	"github.com/rs/zerolog"
 
+	"github.com/venue-master/platform/lib/authz"
 	"github.com/venue-master/platform/lib/errutil"
 	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/logutil"
+	"github.com/venue-master/platform/lib/revocation"
 	"github.com/venue-master/platform/services/api-gateway/internal/services"
 )
 
@@ -20,6 +24,7 @@ type claimsKey struct{}
 type Handler struct {
 	schema  graphql.Schema
 	jwt     *jwtutil.Manager
+	revoked revocation.Checker
 	logger  zerolog.Logger
 	clients *services.ServiceClients
 }
@@ -32,13 +37,13 @@ type GraphQLRequest struct {
 }
 
 // New builds a Handler with schema + dependencies.
-func New(clients *services.ServiceClients, jwt *jwtutil.Manager, logger zerolog.Logger) (*Handler, error) {
+func New(clients *services.ServiceClients, jwt *jwtutil.Manager, revoked revocation.Checker, logger zerolog.Logger) (*Handler, error) {
 	schema, err := buildSchema(clients)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Handler{schema: schema, jwt: jwt, logger: logger, clients: clients}, nil
+	return &Handler{schema: schema, jwt: jwt, revoked: revoked, logger: logger, clients: clients}, nil
 }
 
 // Register attaches the GraphQL endpoints to the gin engine.
@@ -62,12 +67,30 @@ func (h *Handler) handleGraphQL(ctx *gin.Context) {
 		return
 	}
 
-	claims := h.extractClaims(ctx.Request)
+	claims, ok := h.authenticate(ctx)
+	if !ok {
+		return
+	}
+	if claims != nil && claims.ClientID != "" {
+		// Partner tokens are limited to the REST routes their scopes cover.
+		errutil.Write(ctx, http.StatusForbidden, "insufficient_scope", "Partner app tokens cannot use the GraphQL API", nil)
+		return
+	}
 	requestCtx := context.WithValue(ctx.Request.Context(), claimsKey{}, claims)
 	if claims != nil {
 		requestCtx = services.WithAuth(requestCtx, services.AuthMetadata{
-			UserID: claims.UserID,
-			Roles:  claims.Roles,
+			UserID:           claims.UserID,
+			Roles:            claims.Roles,
+			Permissions:      claims.Permissions,
+			VenuePermissions: claims.VenuePermissions,
+			ClientIP:         ctx.ClientIP(),
+			RequestID:        ctx.GetHeader(logutil.HeaderRequestID),
+		})
+		requestCtx = authz.WithPrincipal(requestCtx, authz.Principal{
+			UserID:           claims.UserID,
+			Roles:            claims.Roles,
+			Permissions:      claims.Permissions,
+			VenuePermissions: claims.VenuePermissions,
 		})
 	}
 
@@ -92,18 +115,31 @@ func ClaimsFromContext(ctx context.Context) *jwtutil.Claims {
 	return claims
 }
 
-func (h *Handler) extractClaims(r *http.Request) *jwtutil.Claims {
-	defaultClaims := &jwtutil.Claims{UserID: "user-1", Roles: []string{"MEMBER"}}
-
-	authz := r.Header.Get("Authorization")
-	if !strings.HasPrefix(strings.ToLower(authz), "bearer ") {
-		return defaultClaims
+// authenticate returns the caller's claims. Requests without an Authorization header run as the
+// default member; a token that is malformed, invalid or revoked is answered with 401, and 503
+// when revocation cannot be checked, like the REST routes.
+func (h *Handler) authenticate(ctx *gin.Context) (*jwtutil.Claims, bool) {
+	header := ctx.GetHeader("Authorization")
+	if header == "" {
+		return &jwtutil.Claims{UserID: "user-1", Roles: []string{"MEMBER"}}, true
 	}
-	token := strings.TrimSpace(authz[7:])
-	claims, err := h.jwt.Validate(token)
-	if err != nil {
-		h.logger.Warn().Err(err).Msg("jwt validation failed, falling back to default claims")
-		return defaultClaims
+	if !strings.HasPrefix(strings.ToLower(header), "bearer ") {
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_token", "Invalid authorization header format", nil)
+		return nil, false
 	}
-	return claims
+	claims, err := revocation.Verify(ctx.Request.Context(), h.jwt, h.revoked, strings.TrimSpace(header[7:]))
+	switch {
+	case errors.Is(err, revocation.ErrUnavailable):
+		// Fail closed: a revoked token must not slip through while Redis is down.
+		h.logger.Error().Err(err).Msg("token revocation check failed")
+		errutil.Write(ctx, http.StatusServiceUnavailable, "auth_unavailable", "Unable to verify token", nil)
+		return nil, false
+	case errors.Is(err, revocation.ErrRevoked):
+		errutil.Write(ctx, http.StatusUnauthorized, "token_revoked", "Token revoked", nil)
+		return nil, false
+	case err != nil:
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_token", "Invalid token", nil)
+		return nil, false
+	}
+	return claims, true
 }
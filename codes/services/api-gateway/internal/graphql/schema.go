@@ -1,6 +1,7 @@
 package graphqlhandler
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -8,18 +9,30 @@ import (
 
 	"github.com/graphql-go/graphql"
 
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/jwtutil"
 	"github.com/venue-master/platform/services/api-gateway/internal/services"
 )
 
 type schemaBuilder struct {
 	clients       *services.ServiceClients
 	user          *graphql.Object
+	household     *graphql.Object
+	member        *graphql.Object
 	facility      *graphql.Object
+	venue         *graphql.Object
+	media         *graphql.Object
+	venueMap      *graphql.Object
+	mapFacility   *graphql.Object
+	mapPoint      *graphql.Object
 	booking       *graphql.Object
 	override      *graphql.Object
 	slot          *graphql.Object
 	schedule      *graphql.Object
 	overrideInput *graphql.InputObject
+	facilityInput *graphql.InputObject
+	venueMapInput *graphql.InputObject
+	profileInput  *graphql.InputObject
 }
 
 func buildSchema(clients *services.ServiceClients) (graphql.Schema, error) {
@@ -38,16 +51,59 @@ func (b *schemaBuilder) queryType() *graphql.Object {
 				Type:    b.userType(),
 				Resolve: b.resolveMe,
 			},
+			"users": {
+				Type: graphql.NewList(b.userType()),
+				Args: graphql.FieldConfigArgument{
+					"query":  &graphql.ArgumentConfig{Type: graphql.String},
+					"role":   &graphql.ArgumentConfig{Type: graphql.String},
+					"status": &graphql.ArgumentConfig{Type: graphql.String},
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: b.resolveUsers,
+			},
+			"venues": {
+				Type: graphql.NewList(b.venueType()),
+				Args: graphql.FieldConfigArgument{
+					"includeArchived": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"limit":           &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset":          &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: b.resolveVenues,
+			},
+			"venue": {
+				Type: b.venueType(),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: b.resolveVenue,
+			},
+			"venueMap": {
+				Type: b.venueMapType(),
+				Args: graphql.FieldConfigArgument{
+					"venueId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"at":      &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: b.resolveVenueMap,
+			},
 			"facilities": {
 				Type: graphql.NewList(b.facilityType()),
 				Args: graphql.FieldConfigArgument{
-					"venueId":   &graphql.ArgumentConfig{Type: graphql.ID},
-					"available": &graphql.ArgumentConfig{Type: graphql.Boolean},
-					"limit":     &graphql.ArgumentConfig{Type: graphql.Int},
-					"offset":    &graphql.ArgumentConfig{Type: graphql.Int},
+					"venueId":         &graphql.ArgumentConfig{Type: graphql.ID},
+					"available":       &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"includeArchived": &graphql.ArgumentConfig{Type: graphql.Boolean},
+					"limit":           &graphql.ArgumentConfig{Type: graphql.Int},
+					"offset":          &graphql.ArgumentConfig{Type: graphql.Int},
 				},
 				Resolve: b.resolveFacilities,
 			},
+			"facility": {
+				Type: b.facilityType(),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: b.resolveFacility,
+			},
 			"bookings": {
 				Type: graphql.NewList(b.bookingType()),
 				Args: graphql.FieldConfigArgument{
@@ -97,6 +153,28 @@ func (b *schemaBuilder) mutationType() *graphql.Object {
 				},
 				Resolve: b.resolveCancelBooking,
 			},
+			"createFacility": {
+				Type: b.facilityType(),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(b.facilityInputType())},
+				},
+				Resolve: b.resolveCreateFacility,
+			},
+			"updateFacility": {
+				Type: b.facilityType(),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(b.facilityInputType())},
+				},
+				Resolve: b.resolveUpdateFacility,
+			},
+			"deleteFacility": {
+				Type: graphql.Boolean,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: b.resolveDeleteFacility,
+			},
 			"updateFacilityAvailability": {
 				Type: b.facilityType(),
 				Args: graphql.FieldConfigArgument{
@@ -120,6 +198,43 @@ func (b *schemaBuilder) mutationType() *graphql.Object {
 				},
 				Resolve: b.resolveRemoveFacilityOverride,
 			},
+			"saveVenueMap": {
+				Type: b.venueMapType(),
+				Args: graphql.FieldConfigArgument{
+					"venueId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input":   &graphql.ArgumentConfig{Type: graphql.NewNonNull(b.venueMapInputType())},
+				},
+				Resolve: b.resolveSaveVenueMap,
+			},
+			"updateProfile": {
+				Type: b.userType(),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(b.profileInputType())},
+				},
+				Resolve: b.resolveUpdateProfile,
+			},
+			"updateUserRoles": {
+				Type: b.userType(),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"roles": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String)))},
+				},
+				Resolve: b.resolveUpdateUserRoles,
+			},
+			"disableUser": {
+				Type: b.userType(),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: b.resolveSetUserDisabled(true),
+			},
+			"enableUser": {
+				Type: b.userType(),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: b.resolveSetUserDisabled(false),
+			},
 		},
 	})
 }
@@ -132,6 +247,94 @@ func (b *schemaBuilder) resolveMe(p graphql.ResolveParams) (any, error) {
 	return b.clients.Users.Me(p.Context, claims.UserID)
 }
 
+func (b *schemaBuilder) resolveUsers(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.UserRead); err != nil {
+		return nil, err
+	}
+	limit, offset, err := paginationArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	return b.clients.Users.ListUsers(p.Context, services.UserQuery{
+		Search: stringValue(p.Args["query"]),
+		Role:   stringValue(p.Args["role"]),
+		Status: stringValue(p.Args["status"]),
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+func (b *schemaBuilder) resolveUpdateProfile(p graphql.ResolveParams) (any, error) {
+	if ClaimsFromContext(p.Context) == nil {
+		return nil, errors.New("unauthorized")
+	}
+	input, err := parseProfileInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	return b.clients.Users.UpdateProfile(p.Context, input)
+}
+
+func (b *schemaBuilder) resolveUpdateUserRoles(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.UserRolesAssign); err != nil {
+		return nil, err
+	}
+	id, _ := p.Args["id"].(string)
+	rawRoles, _ := p.Args["roles"].([]any)
+	roles := make([]string, 0, len(rawRoles))
+	for _, role := range rawRoles {
+		roles = append(roles, stringValue(role))
+	}
+	return b.clients.Users.SetRoles(p.Context, id, roles)
+}
+
+func (b *schemaBuilder) resolveSetUserDisabled(disabled bool) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		if err := ensurePermission(p, authz.UserDisable); err != nil {
+			return nil, err
+		}
+		id, _ := p.Args["id"].(string)
+		return b.clients.Users.SetDisabled(p.Context, id, disabled)
+	}
+}
+
+func (b *schemaBuilder) resolveVenues(p graphql.ResolveParams) (any, error) {
+	limit, offset, err := paginationArgs(p)
+	if err != nil {
+		return nil, err
+	}
+	includeArchived, _ := p.Args["includeArchived"].(bool)
+	return b.clients.Bookings.ListVenues(p.Context, services.VenueQuery{
+		IncludeArchived: includeArchived,
+		Limit:           limit,
+		Offset:          offset,
+	})
+}
+
+func (b *schemaBuilder) resolveVenue(p graphql.ResolveParams) (any, error) {
+	id, _ := p.Args["id"].(string)
+	if id == "" {
+		return nil, errors.New("venue id is required")
+	}
+	return b.clients.Bookings.GetVenue(p.Context, id)
+}
+
+func (b *schemaBuilder) resolveVenueMap(p graphql.ResolveParams) (any, error) {
+	venueID, _ := p.Args["venueId"].(string)
+	if venueID == "" {
+		return nil, errors.New("venue id is required")
+	}
+	var at *time.Time
+	if raw, ok := p.Args["at"]; ok && raw != nil {
+		parsed, err := parseTimeArg(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid at: %w", err)
+		}
+		at = &parsed
+	}
+	return b.clients.Bookings.GetVenueMap(p.Context, venueID, at)
+}
+
 func (b *schemaBuilder) resolveFacilities(p graphql.ResolveParams) (any, error) {
 	venueID, _ := p.Args["venueId"].(string)
 	limit, offset, err := paginationArgs(p)
@@ -146,15 +349,25 @@ func (b *schemaBuilder) resolveFacilities(p graphql.ResolveParams) (any, error)
 		}
 		availablePtr = &parsed
 	}
+	includeArchived, _ := p.Args["includeArchived"].(bool)
 	query := services.FacilityQuery{
-		VenueID:   venueID,
-		Available: availablePtr,
-		Limit:     limit,
-		Offset:    offset,
+		VenueID:         venueID,
+		Available:       availablePtr,
+		IncludeArchived: includeArchived,
+		Limit:           limit,
+		Offset:          offset,
 	}
 	return b.clients.Bookings.ListFacilities(p.Context, query)
 }
 
+func (b *schemaBuilder) resolveFacility(p graphql.ResolveParams) (any, error) {
+	id, _ := p.Args["id"].(string)
+	if id == "" {
+		return nil, errors.New("facility id is required")
+	}
+	return b.clients.Bookings.GetFacility(p.Context, id)
+}
+
 func (b *schemaBuilder) resolveBookings(p graphql.ResolveParams) (any, error) {
 	userID, _ := p.Args["userId"].(string)
 	if userID == "" {
@@ -211,7 +424,7 @@ func (b *schemaBuilder) resolveCancelBooking(p graphql.ResolveParams) (any, erro
 }
 
 func (b *schemaBuilder) resolveUpdateFacilityAvailability(p graphql.ResolveParams) (any, error) {
-	if err := ensureRoles(p, adminRoles...); err != nil {
+	if err := ensurePermission(p, authz.FacilityUpdate); err != nil {
 		return nil, err
 	}
 	id, _ := p.Args["id"].(string)
@@ -222,6 +435,49 @@ func (b *schemaBuilder) resolveUpdateFacilityAvailability(p graphql.ResolveParam
 	return b.clients.Bookings.UpdateFacilityAvailability(p.Context, id, available)
 }
 
+func (b *schemaBuilder) resolveCreateFacility(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.FacilityCreate); err != nil {
+		return nil, err
+	}
+	input, err := parseFacilityInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	if input.VenueID == "" {
+		return nil, errors.New("venueId is required")
+	}
+	return b.clients.Bookings.CreateFacility(p.Context, input)
+}
+
+func (b *schemaBuilder) resolveUpdateFacility(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.FacilityUpdate); err != nil {
+		return nil, err
+	}
+	id, _ := p.Args["id"].(string)
+	if id == "" {
+		return nil, errors.New("facility id is required")
+	}
+	input, err := parseFacilityInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	return b.clients.Bookings.UpdateFacility(p.Context, id, input)
+}
+
+func (b *schemaBuilder) resolveDeleteFacility(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.FacilityDelete); err != nil {
+		return nil, err
+	}
+	id, _ := p.Args["id"].(string)
+	if id == "" {
+		return nil, errors.New("facility id is required")
+	}
+	if err := b.clients.Bookings.DeleteFacility(p.Context, id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
 func (b *schemaBuilder) resolveFacilitySchedule(p graphql.ResolveParams) (any, error) {
 	facilityID, _ := p.Args["facilityId"].(string)
 	fromStr, _ := p.Args["from"].(string)
@@ -248,7 +504,7 @@ func (b *schemaBuilder) resolveFacilitySchedule(p graphql.ResolveParams) (any, e
 }
 
 func (b *schemaBuilder) resolveCreateFacilityOverride(p graphql.ResolveParams) (any, error) {
-	if err := ensureRoles(p, adminRoles...); err != nil {
+	if err := ensurePermission(p, authz.FacilitySchedule); err != nil {
 		return nil, err
 	}
 	inputRaw, _ := p.Args["input"].(map[string]any)
@@ -264,7 +520,7 @@ func (b *schemaBuilder) resolveCreateFacilityOverride(p graphql.ResolveParams) (
 }
 
 func (b *schemaBuilder) resolveRemoveFacilityOverride(p graphql.ResolveParams) (any, error) {
-	if err := ensureRoles(p, adminRoles...); err != nil {
+	if err := ensurePermission(p, authz.FacilitySchedule); err != nil {
 		return nil, err
 	}
 	facilityID, _ := p.Args["facilityId"].(string)
@@ -278,6 +534,21 @@ func (b *schemaBuilder) resolveRemoveFacilityOverride(p graphql.ResolveParams) (
 	return true, nil
 }
 
+func (b *schemaBuilder) resolveSaveVenueMap(p graphql.ResolveParams) (any, error) {
+	if err := ensurePermission(p, authz.VenueUpdate); err != nil {
+		return nil, err
+	}
+	venueID, _ := p.Args["venueId"].(string)
+	if venueID == "" {
+		return nil, errors.New("venue id is required")
+	}
+	input, err := parseVenueMapInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	return b.clients.Bookings.SaveVenueMap(p.Context, venueID, input)
+}
+
 func (b *schemaBuilder) userType() *graphql.Object {
 	if b.user != nil {
 		return b.user
@@ -290,11 +561,85 @@ func (b *schemaBuilder) userType() *graphql.Object {
 			"lastName":  {Type: graphql.String},
 			"email":     {Type: graphql.String},
 			"roles":     {Type: graphql.NewList(graphql.String)},
+			"phone":     {Type: graphql.String},
+			"avatarUrl": {Type: graphql.String},
+			"preferences": {
+				Type:        graphql.String,
+				Description: "Preferences as a JSON object.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user, ok := p.Source.(*services.User)
+					if !ok || user.Preferences == nil {
+						return "{}", nil
+					}
+					raw, err := json.Marshal(user.Preferences)
+					return string(raw), err
+				},
+			},
+			"emailVerified": {Type: graphql.Boolean},
+			"disabled":      {Type: graphql.Boolean},
+			"dependent":     {Type: graphql.Boolean},
+			"createdAt": {
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user, ok := p.Source.(*services.User)
+					if !ok || user.CreatedAt.IsZero() {
+						return nil, nil
+					}
+					return user.CreatedAt.Format(time.RFC3339), nil
+				},
+			},
+			"household": {
+				Type:        b.householdType(),
+				Description: "The household the user belongs to, if any.",
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					user, ok := p.Source.(*services.User)
+					if !ok {
+						return nil, nil
+					}
+					return b.clients.Users.Household(p.Context, user.ID)
+				},
+			},
 		},
 	})
 	return b.user
 }
 
+func (b *schemaBuilder) householdType() *graphql.Object {
+	if b.household != nil {
+		return b.household
+	}
+	b.household = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Household",
+		Description: "Accounts that share a payment method and a membership, and may book for each other.",
+		Fields: graphql.Fields{
+			"id":              {Type: graphql.NewNonNull(graphql.ID)},
+			"name":            {Type: graphql.String},
+			"primaryUserId":   {Type: graphql.NewNonNull(graphql.ID)},
+			"paymentMethodId": {Type: graphql.String},
+			"membershipType":  {Type: graphql.String},
+			"members":         {Type: graphql.NewList(b.householdMemberType())},
+		},
+	})
+	return b.household
+}
+
+func (b *schemaBuilder) householdMemberType() *graphql.Object {
+	if b.member != nil {
+		return b.member
+	}
+	b.member = graphql.NewObject(graphql.ObjectConfig{
+		Name: "HouseholdMember",
+		Fields: graphql.Fields{
+			"userId":    {Type: graphql.NewNonNull(graphql.ID)},
+			"firstName": {Type: graphql.String},
+			"lastName":  {Type: graphql.String},
+			"email":     {Type: graphql.String},
+			"role":      {Type: graphql.String},
+		},
+	})
+	return b.member
+}
+
 func (b *schemaBuilder) facilityType() *graphql.Object {
 	if b.facility != nil {
 		return b.facility
@@ -329,11 +674,167 @@ func (b *schemaBuilder) facilityType() *graphql.Object {
 			"weekdayRateCents": {Type: graphql.Int},
 			"weekendRateCents": {Type: graphql.Int},
 			"currency":         {Type: graphql.String},
+			"archivedAt": {
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if facility, ok := p.Source.(*services.Facility); ok && facility.ArchivedAt != nil {
+						return facility.ArchivedAt.Format(time.RFC3339), nil
+					}
+					return nil, nil
+				},
+			},
+			"photos":    {Type: graphql.NewList(b.mediaType())},
+			"floorPlan": {Type: b.mediaType()},
 		},
 	})
 	return b.facility
 }
 
+func (b *schemaBuilder) venueType() *graphql.Object {
+	if b.venue != nil {
+		return b.venue
+	}
+	b.venue = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Venue",
+		Fields: graphql.Fields{
+			"id":          {Type: graphql.NewNonNull(graphql.ID)},
+			"name":        {Type: graphql.String},
+			"description": {Type: graphql.String},
+			"address":     {Type: graphql.String},
+			"city":        {Type: graphql.String},
+			"state":       {Type: graphql.String},
+			"zipCode":     {Type: graphql.String},
+			"country":     {Type: graphql.String},
+			"phone":       {Type: graphql.String},
+			"email":       {Type: graphql.String},
+			"website":     {Type: graphql.String},
+			"timezone":    {Type: graphql.String},
+			"archivedAt": {
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if venue, ok := p.Source.(*services.Venue); ok && venue.ArchivedAt != nil {
+						return venue.ArchivedAt.Format(time.RFC3339), nil
+					}
+					return nil, nil
+				},
+			},
+			"photos":    {Type: graphql.NewList(b.mediaType())},
+			"floorPlan": {Type: b.mediaType()},
+			"facilities": {
+				Type: graphql.NewList(b.facilityType()),
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					venue, ok := p.Source.(*services.Venue)
+					if !ok {
+						return nil, nil
+					}
+					return b.clients.Bookings.ListFacilities(p.Context, services.FacilityQuery{VenueID: venue.ID})
+				},
+			},
+		},
+	})
+	return b.venue
+}
+
+func (b *schemaBuilder) mediaType() *graphql.Object {
+	if b.media != nil {
+		return b.media
+	}
+	b.media = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Media",
+		Fields: graphql.Fields{
+			"id":           {Type: graphql.NewNonNull(graphql.ID)},
+			"kind":         {Type: graphql.String},
+			"url":          {Type: graphql.String},
+			"thumbnailUrl": {Type: graphql.String},
+			"contentType":  {Type: graphql.String},
+			"width":        {Type: graphql.Int},
+			"height":       {Type: graphql.Int},
+			"position":     {Type: graphql.Int},
+		},
+	})
+	return b.media
+}
+
+func (b *schemaBuilder) venueMapType() *graphql.Object {
+	if b.venueMap != nil {
+		return b.venueMap
+	}
+	b.venueMap = graphql.NewObject(graphql.ObjectConfig{
+		Name: "VenueMap",
+		Fields: graphql.Fields{
+			"venueId":       {Type: graphql.NewNonNull(graphql.ID)},
+			"width":         {Type: graphql.Float},
+			"height":        {Type: graphql.Float},
+			"unit":          {Type: graphql.String},
+			"backgroundUrl": {Type: graphql.String},
+			"at": {
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					if venueMap, ok := p.Source.(*services.VenueMap); ok {
+						return formatOptionalTimestamp(venueMap.At), nil
+					}
+					return nil, nil
+				},
+			},
+			"facilities": {Type: graphql.NewList(b.mapFacilityType())},
+		},
+	})
+	return b.venueMap
+}
+
+func (b *schemaBuilder) mapFacilityType() *graphql.Object {
+	if b.mapFacility != nil {
+		return b.mapFacility
+	}
+	timestamp := func(extractor func(services.MapFacility) *time.Time) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (any, error) {
+			if facility, ok := p.Source.(services.MapFacility); ok {
+				return formatOptionalTimestamp(extractor(facility)), nil
+			}
+			return nil, nil
+		}
+	}
+	b.mapFacility = graphql.NewObject(graphql.ObjectConfig{
+		Name: "MapFacility",
+		Fields: graphql.Fields{
+			"facilityId": {Type: graphql.NewNonNull(graphql.ID)},
+			"name":       {Type: graphql.String},
+			"label":      {Type: graphql.String},
+			"polygon":    {Type: graphql.NewList(b.mapPointType())},
+			"rotation":   {Type: graphql.Float},
+			"status":     {Type: graphql.String},
+			"colour":     {Type: graphql.String},
+			"availableUntil": {
+				Type:    graphql.String,
+				Resolve: timestamp(func(f services.MapFacility) *time.Time { return f.AvailableUntil }),
+			},
+			"busyUntil": {
+				Type:    graphql.String,
+				Resolve: timestamp(func(f services.MapFacility) *time.Time { return f.BusyUntil }),
+			},
+			"nextBookingAt": {
+				Type:    graphql.String,
+				Resolve: timestamp(func(f services.MapFacility) *time.Time { return f.NextBookingAt }),
+			},
+		},
+	})
+	return b.mapFacility
+}
+
+func (b *schemaBuilder) mapPointType() *graphql.Object {
+	if b.mapPoint != nil {
+		return b.mapPoint
+	}
+	b.mapPoint = graphql.NewObject(graphql.ObjectConfig{
+		Name: "MapPoint",
+		Fields: graphql.Fields{
+			"x": {Type: graphql.Float},
+			"y": {Type: graphql.Float},
+		},
+	})
+	return b.mapPoint
+}
+
 func (b *schemaBuilder) bookingType() *graphql.Object {
 	if b.booking != nil {
 		return b.booking
@@ -356,6 +857,8 @@ func (b *schemaBuilder) bookingType() *graphql.Object {
 			"amountCents":   {Type: graphql.Int},
 			"currency":      {Type: graphql.String},
 			"paymentIntent": {Type: graphql.String},
+			"bookedBy":      {Type: graphql.ID},
+			"cancelledBy":   {Type: graphql.ID},
 			"facility": {
 				Type: b.facilityType(),
 				Resolve: func(p graphql.ResolveParams) (any, error) {
@@ -505,6 +1008,79 @@ func (b *schemaBuilder) facilityOverrideInput() *graphql.InputObject {
 	return b.overrideInput
 }
 
+func (b *schemaBuilder) facilityInputType() *graphql.InputObject {
+	if b.facilityInput != nil {
+		return b.facilityInput
+	}
+	b.facilityInput = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "FacilityInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"venueId":          {Type: graphql.ID},
+			"name":             {Type: graphql.NewNonNull(graphql.String)},
+			"description":      {Type: graphql.String},
+			"surface":          {Type: graphql.String},
+			"openAt":           {Type: graphql.NewNonNull(graphql.String)},
+			"closeAt":          {Type: graphql.NewNonNull(graphql.String)},
+			"weekdayRateCents": {Type: graphql.Int},
+			"weekendRateCents": {Type: graphql.Int},
+			"currency":         {Type: graphql.String},
+		},
+	})
+	return b.facilityInput
+}
+
+func (b *schemaBuilder) venueMapInputType() *graphql.InputObject {
+	if b.venueMapInput != nil {
+		return b.venueMapInput
+	}
+	point := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "MapPointInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"x": {Type: graphql.NewNonNull(graphql.Float)},
+			"y": {Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+	facility := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "MapFacilityInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"facilityId": {Type: graphql.NewNonNull(graphql.ID)},
+			"polygon":    {Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(point)))},
+			"rotation":   {Type: graphql.Float},
+			"label":      {Type: graphql.String},
+		},
+	})
+	b.venueMapInput = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "VenueMapInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"width":             {Type: graphql.NewNonNull(graphql.Float)},
+			"height":            {Type: graphql.NewNonNull(graphql.Float)},
+			"unit":              {Type: graphql.String},
+			"backgroundMediaId": {Type: graphql.ID},
+			"facilities":        {Type: graphql.NewList(graphql.NewNonNull(facility))},
+		},
+	})
+	return b.venueMapInput
+}
+
+func (b *schemaBuilder) profileInputType() *graphql.InputObject {
+	if b.profileInput != nil {
+		return b.profileInput
+	}
+	b.profileInput = graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "ProfileInput",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"firstName": {Type: graphql.String},
+			"lastName":  {Type: graphql.String},
+			"phone":     {Type: graphql.String},
+			"preferences": {
+				Type:        graphql.String,
+				Description: "JSON object merged into the stored preferences; null values remove keys.",
+			},
+		},
+	})
+	return b.profileInput
+}
+
 func formatTimeField(extractor func(*services.Booking) time.Time) graphql.FieldResolveFn {
 	return func(p graphql.ResolveParams) (any, error) {
 		booking, ok := p.Source.(*services.Booking)
@@ -565,6 +1141,19 @@ func intFromArg(value interface{}) (int, error) {
 	}
 }
 
+func floatFromArg(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	default:
+		return 0, fmt.Errorf("invalid number type")
+	}
+}
+
 func boolFromArg(value interface{}) (bool, error) {
 	switch v := value.(type) {
 	case bool:
@@ -582,16 +1171,18 @@ func boolFromArg(value interface{}) (bool, error) {
 		return false, fmt.Errorf("invalid boolean type")
 	}
 }
-func ensureRoles(p graphql.ResolveParams, allowed ...string) error {
-	claims := ClaimsFromContext(p.Context)
-	if claims == nil {
-		return errors.New("unauthorized")
-	}
-	if len(allowed) == 0 {
-		return nil
+
+// ensurePermission gates privileged resolvers: the caller needs permission (venue-scoped grants
+// pass; the booking service checks the target venue), and admins also need a token issued
+// after a second factor (amr contains "mfa").
+func ensurePermission(p graphql.ResolveParams, permission string) error {
+	if err := authz.CheckScoped(p.Context, permission); err != nil {
+		return err
 	}
-	if !hasAnyRole(claims.Roles, allowed...) {
-		return errors.New("forbidden")
+	claims := ClaimsFromContext(p.Context)
+	isAdmin := hasAnyRole(claims.Roles, adminRoles...) || len(claims.VenuePermissions) > 0
+	if isAdmin && !claims.HasAMR(jwtutil.AMRMFA) {
+		return errors.New("mfa required")
 	}
 	return nil
 }
@@ -653,6 +1244,13 @@ func formatOptionalTime(value *time.Time) any {
 	return value.Format(timeOnlyFormat)
 }
 
+func formatOptionalTimestamp(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+	return value.Format(time.RFC3339)
+}
+
 func parseOverrideInput(raw map[string]any) (services.FacilityOverrideInput, error) {
 	var input services.FacilityOverrideInput
 	if raw == nil {
@@ -718,6 +1316,120 @@ func parseOverrideInput(raw map[string]any) (services.FacilityOverrideInput, err
 	return input, nil
 }
 
+func parseFacilityInput(value any) (services.FacilityInput, error) {
+	var input services.FacilityInput
+	raw, _ := value.(map[string]any)
+	if raw == nil {
+		return input, errors.New("input is required")
+	}
+	openAt := stringValue(raw["openAt"])
+	closeAt := stringValue(raw["closeAt"])
+	if _, err := time.Parse(timeOnlyFormat, openAt); err != nil {
+		return input, fmt.Errorf("invalid openAt: %w", err)
+	}
+	if _, err := time.Parse(timeOnlyFormat, closeAt); err != nil {
+		return input, fmt.Errorf("invalid closeAt: %w", err)
+	}
+	input = services.FacilityInput{
+		VenueID:     stringValue(raw["venueId"]),
+		Name:        stringValue(raw["name"]),
+		Description: stringValue(raw["description"]),
+		Surface:     stringValue(raw["surface"]),
+		OpenAt:      openAt,
+		CloseAt:     closeAt,
+		Currency:    stringValue(raw["currency"]),
+	}
+	if rate, ok := raw["weekdayRateCents"]; ok && rate != nil {
+		parsed, err := intFromArg(rate)
+		if err != nil {
+			return input, fmt.Errorf("invalid weekdayRateCents")
+		}
+		input.WeekdayRate = parsed
+	}
+	if rate, ok := raw["weekendRateCents"]; ok && rate != nil {
+		parsed, err := intFromArg(rate)
+		if err != nil {
+			return input, fmt.Errorf("invalid weekendRateCents")
+		}
+		input.WeekendRate = parsed
+	}
+	return input, nil
+}
+
+func parseVenueMapInput(value any) (services.VenueMapInput, error) {
+	var input services.VenueMapInput
+	raw, _ := value.(map[string]any)
+	if raw == nil {
+		return input, errors.New("input is required")
+	}
+	width, err := floatFromArg(raw["width"])
+	if err != nil {
+		return input, fmt.Errorf("invalid width")
+	}
+	height, err := floatFromArg(raw["height"])
+	if err != nil {
+		return input, fmt.Errorf("invalid height")
+	}
+	input = services.VenueMapInput{
+		Width:             width,
+		Height:            height,
+		Unit:              stringValue(raw["unit"]),
+		BackgroundMediaID: stringValue(raw["backgroundMediaId"]),
+	}
+	facilities, _ := raw["facilities"].([]interface{})
+	for _, item := range facilities {
+		entry, _ := item.(map[string]any)
+		if entry == nil {
+			return input, errors.New("invalid facility shape")
+		}
+		shape := services.MapFacilityInput{
+			FacilityID: stringValue(entry["facilityId"]),
+			Label:      stringValue(entry["label"]),
+		}
+		if rotation, ok := entry["rotation"]; ok && rotation != nil {
+			if shape.Rotation, err = floatFromArg(rotation); err != nil {
+				return input, fmt.Errorf("invalid rotation")
+			}
+		}
+		points, _ := entry["polygon"].([]interface{})
+		for _, rawPoint := range points {
+			point, _ := rawPoint.(map[string]any)
+			x, errX := floatFromArg(point["x"])
+			y, errY := floatFromArg(point["y"])
+			if errX != nil || errY != nil {
+				return input, fmt.Errorf("invalid polygon point for facility %s", shape.FacilityID)
+			}
+			shape.Polygon = append(shape.Polygon, services.MapPoint{X: x, Y: y})
+		}
+		input.Facilities = append(input.Facilities, shape)
+	}
+	return input, nil
+}
+
+func parseProfileInput(value any) (services.ProfileInput, error) {
+	raw, ok := value.(map[string]any)
+	if !ok {
+		return services.ProfileInput{}, errors.New("input is required")
+	}
+	var input services.ProfileInput
+	optional := func(key string) *string {
+		if v, ok := raw[key]; ok && v != nil {
+			str := stringValue(v)
+			return &str
+		}
+		return nil
+	}
+	input.FirstName = optional("firstName")
+	input.LastName = optional("lastName")
+	input.Phone = optional("phone")
+	if prefs := optional("preferences"); prefs != nil {
+		if err := json.Unmarshal([]byte(*prefs), &input.Preferences); err != nil || input.Preferences == nil {
+			return services.ProfileInput{}, errors.New("preferences must be a JSON object")
+		}
+	}
+	return input, nil
+}
+
 func parseWeekdays(value any) ([]int, error) {
 	if value == nil {
 		return nil, nil
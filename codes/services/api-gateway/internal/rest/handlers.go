@@ -1,27 +1,37 @@
 package rest
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"net/http/httputil"
+	"net/url"
 	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog"
 
+	"github.com/venue-master/platform/lib/authz"
 	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/logutil"
+	"github.com/venue-master/platform/lib/revocation"
 	"github.com/venue-master/platform/services/api-gateway/internal/services"
 )
 
 type Handler struct {
 	clients     *services.ServiceClients
 	jwtManager  *jwtutil.Manager
+	revoked     revocation.Checker
 	logger      zerolog.Logger
 	bookingURL  string
 	userURL     string
+	auditURL    string
+	// httpClient signs proxied requests so downstream services trust the identity headers.
+	httpClient  *http.Client
 }
 
-func New(clients *services.ServiceClients, jwtManager *jwtutil.Manager, logger zerolog.Logger) *Handler {
+func New(clients *services.ServiceClients, jwtManager *jwtutil.Manager, revoked revocation.Checker, logger zerolog.Logger, transport http.RoundTripper) *Handler {
 	bookingURL := os.Getenv("BOOKING_SERVICE_URL")
 	if bookingURL == "" {
 		bookingURL = "http://booking-service:8080"
@@ -30,13 +40,20 @@ func New(clients *services.ServiceClients, jwtManager *jwtutil.Manager, logger z
 	if userURL == "" {
 		userURL = "http://user-service:8080"
 	}
+	auditURL := os.Getenv("AUDIT_SERVICE_URL")
+	if auditURL == "" {
+		auditURL = "http://audit-service:8080"
+	}
 
 	return &Handler{
 		clients:     clients,
 		jwtManager:  jwtManager,
+		revoked:     revoked,
 		logger:      logger,
 		bookingURL:  strings.TrimRight(bookingURL, "/"),
 		userURL:     strings.TrimRight(userURL, "/"),
+		auditURL:    strings.TrimRight(auditURL, "/"),
+		httpClient:  &http.Client{Transport: transport},
 	}
 }
 
@@ -45,6 +62,9 @@ func (h *Handler) Register(engine *gin.Engine) {
 	// Auth middleware for REST endpoints
 	authMiddleware := h.authMiddleware()
 
+	// Local object storage (STORAGE_PROVIDER=filesystem) - URLs carry their own signature, so no JWT
+	engine.Any("/storage/*key", h.proxyStorage())
+
 	// Venues endpoints - proxy to booking service
 	venues := engine.Group("/v1/venues", authMiddleware)
 	{
@@ -53,6 +73,17 @@ func (h *Handler) Register(engine *gin.Engine) {
 		venues.POST("", h.createVenue)
 		venues.PUT("/:id", h.updateVenue)
 		venues.DELETE("/:id", h.deleteVenue)
+		venues.POST("/:id/restore", h.restoreVenue)
+		venues.GET("/:id/media", h.proxyMedia)
+		venues.POST("/:id/media", h.proxyMedia)
+		venues.POST("/:id/media/:mediaId/complete", h.proxyMedia)
+		venues.PUT("/:id/media/order", h.proxyMedia)
+		venues.DELETE("/:id/media/:mediaId", h.proxyMedia)
+		venues.GET("/:id/map", h.proxyVenueMap)
+		venues.PUT("/:id/map", h.proxyVenueMap)
+		venues.GET("/:id/map/availability", h.proxyVenueMap)
+		venues.PUT("/:id/map/facilities/:facilityId", h.proxyVenueMap)
+		venues.DELETE("/:id/map/facilities/:facilityId", h.proxyVenueMap)
 	}
 
 	// Facilities endpoints - proxy to booking service
@@ -63,7 +94,13 @@ func (h *Handler) Register(engine *gin.Engine) {
 		facilities.POST("", h.createFacility)
 		facilities.PUT("/:id", h.updateFacility)
 		facilities.DELETE("/:id", h.deleteFacility)
+		facilities.POST("/:id/restore", h.restoreFacility)
 		facilities.GET("/:id/schedule", h.getFacilitySchedule)
+		facilities.GET("/:id/media", h.proxyMedia)
+		facilities.POST("/:id/media", h.proxyMedia)
+		facilities.POST("/:id/media/:mediaId/complete", h.proxyMedia)
+		facilities.PUT("/:id/media/order", h.proxyMedia)
+		facilities.DELETE("/:id/media/:mediaId", h.proxyMedia)
 	}
 
 	// Bookings endpoints - proxy to booking service
@@ -81,8 +118,80 @@ func (h *Handler) Register(engine *gin.Engine) {
 	// Users endpoints - proxy to user service
 	users := engine.Group("/v1/users", authMiddleware)
 	{
-		users.GET("", h.listUsers)
+		users.GET("", h.proxyUsers)
+		users.GET("/me", h.proxyUsers)
+		users.PATCH("/me", h.proxyUsers)
+		users.POST("/me/avatar", h.proxyUsers)
+		users.POST("/me/avatar/complete", h.proxyUsers)
+		users.DELETE("/me/avatar", h.proxyUsers)
 		users.GET("/:id", h.getUser)
+		users.PUT("/:id/roles", h.updateUserRoles)
+		users.PUT("/:id/venue-roles", h.updateUserRoles)
+		users.POST("/:id/disable", h.proxyUsers)
+		users.POST("/:id/enable", h.proxyUsers)
+		users.GET("/exports", h.proxyUsers)
+		users.GET("/me/exports", h.proxyUsers)
+		users.POST("/me/exports", h.proxyUsers)
+		users.POST("/:id/exports", h.proxyUsers)
+		users.GET("/erasures", h.proxyUsers)
+		users.GET("/erasures/:id", h.proxyUsers)
+		users.GET("/me/privacy/consents", h.proxyUsers)
+		users.PUT("/me/privacy/consents", h.proxyUsers)
+		users.GET("/me/privacy/consents/history", h.proxyUsers)
+		users.GET("/me/privacy/consents/outstanding", h.proxyUsers)
+		users.GET("/:id/privacy/consents", h.proxyUsers)
+		users.PUT("/:id/privacy/consents", h.proxyUsers)
+		users.GET("/:id/privacy/consents/history", h.proxyUsers)
+		users.GET("/me/household", h.proxyUsers)
+		users.POST("/me/household", h.proxyUsers)
+		users.PATCH("/me/household", h.proxyUsers)
+		users.POST("/me/household/members", h.proxyUsers)
+		users.DELETE("/me/household/members/:userId", h.proxyUsers)
+		users.POST("/me/household/dependents", h.proxyUsers)
+		users.GET("/me/household/invitations", h.proxyUsers)
+		users.POST("/me/household/invitations/:householdId/accept", h.proxyUsers)
+		users.DELETE("/me/household/invitations/:householdId", h.proxyUsers)
+		users.GET("/:id/household", h.proxyUsers)
+		users.POST("/imports", h.proxyUsers)
+		users.GET("/imports", h.proxyUsers)
+		users.GET("/imports/:id", h.proxyUsers)
+		users.GET("/imports/:id/errors", h.proxyUsers)
+		users.GET("/export", h.proxyUsers)
+	}
+
+	// Policy documents - proxy to user service; reading them needs no sign-in so consent banners
+	// can show them before registration, publishing checks privacy:manage
+	engine.GET("/v1/policies", h.proxyUsers)
+	engine.GET("/v1/policies/:kind", h.proxyUsers)
+	engine.GET("/v1/policies/:kind/versions", h.proxyUsers)
+	engine.POST("/v1/policies/:kind", authMiddleware, h.proxyUsers)
+
+	// Role and permission administration - proxy to user service, which checks rbac:manage
+	roles := engine.Group("/v1/roles", authMiddleware)
+	{
+		roles.GET("", h.proxyRBAC)
+		roles.PUT("/:name", h.proxyRBAC)
+		roles.DELETE("/:name", h.proxyRBAC)
+	}
+	engine.GET("/v1/permissions", authMiddleware, h.proxyRBAC)
+
+	// OAuth client registration - proxy to user service, which checks oauth:clients:manage
+	oauthClients := engine.Group("/v1/oauth/clients", authMiddleware)
+	{
+		oauthClients.GET("", h.proxyRBAC)
+		oauthClients.POST("", h.proxyRBAC)
+		oauthClients.PUT("/:id", h.proxyRBAC)
+		oauthClients.DELETE("/:id", h.proxyRBAC)
+		oauthClients.POST("/:id/secret", h.proxyRBAC)
+	}
+
+	// Security audit trail - proxy to audit service, which checks audit:read
+	auditLog := engine.Group("/v1/audit", authMiddleware)
+	{
+		auditLog.GET("/events", h.proxyAudit)
+		auditLog.GET("/events/export", h.proxyAudit)
+		auditLog.GET("/events/:id", h.proxyAudit)
+		auditLog.GET("/verify", h.proxyAudit)
 	}
 }
 
@@ -103,17 +212,48 @@ func (h *Handler) authMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		claims, err := h.jwtManager.Validate(token)
+		claims, err := revocation.Verify(ctx.Request.Context(), h.jwtManager, h.revoked, token)
+		if errors.Is(err, revocation.ErrRevoked) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+			ctx.Abort()
+			return
+		}
+		if errors.Is(err, revocation.ErrUnavailable) {
+			// Fail closed: a revoked token must not slip through while Redis is down.
+			h.logger.Error().Err(err).Msg("token revocation check failed")
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token"})
+			ctx.Abort()
+			return
+		}
 		if err != nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			ctx.Abort()
 			return
 		}
 
+		if adminMutationWithoutMFA(ctx.Request.Method, claims) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "mfa required"})
+			ctx.Abort()
+			return
+		}
+
+		if claims.ClientID != "" && !scopeAllows(ctx, claims) {
+			writeInsufficientScope(ctx)
+			return
+		}
+
 		// Store auth metadata in context for downstream requests
 		authCtx := services.WithAuth(ctx.Request.Context(), services.AuthMetadata{
-			UserID: claims.UserID,
-			Roles:  claims.Roles,
+			UserID:           claims.UserID,
+			Roles:            claims.Roles,
+			Permissions:      claims.Permissions,
+			VenuePermissions: claims.VenuePermissions,
+		})
+		authCtx = authz.WithPrincipal(authCtx, authz.Principal{
+			UserID:           claims.UserID,
+			Roles:            claims.Roles,
+			Permissions:      claims.Permissions,
+			VenuePermissions: claims.VenuePermissions,
 		})
 		ctx.Request = ctx.Request.WithContext(authCtx)
 
@@ -121,6 +261,73 @@ func (h *Handler) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// adminMutationWithoutMFA reports whether an admin, global or venue-scoped, is trying to change
+// data with a token that was issued without a second factor.
+func adminMutationWithoutMFA(method string, claims *jwtutil.Claims) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if claims.HasAMR(jwtutil.AMRMFA) {
+		return false
+	}
+	if len(claims.VenuePermissions) > 0 {
+		return true
+	}
+	for _, role := range claims.Roles {
+		if strings.EqualFold(role, "ADMIN") || strings.EqualFold(role, "VENUE_ADMIN") {
+			return true
+		}
+	}
+	return false
+}
+
+// userStoragePrefixes are the first key segments of objects user-service stores (avatars and
+// data exports); everything else (venue and facility media) belongs to booking-service.
+var userStoragePrefixes = map[string]bool{"users": true, "exports": true}
+
+// proxyStorage streams signed storage requests untouched to the service that owns the key.
+func (h *Handler) proxyStorage() gin.HandlerFunc {
+	booking, err := h.storageProxy(h.bookingURL)
+	if err != nil {
+		h.logger.Error().Err(err).Str("url", h.bookingURL).Msg("invalid booking service url")
+	}
+	users, err := h.storageProxy(h.userURL)
+	if err != nil {
+		h.logger.Error().Err(err).Str("url", h.userURL).Msg("invalid user service url")
+	}
+	return func(ctx *gin.Context) {
+		prefix, _, _ := strings.Cut(strings.TrimPrefix(ctx.Param("key"), "/"), "/")
+		proxy := booking
+		if userStoragePrefixes[prefix] {
+			proxy = users
+		}
+		if proxy == nil {
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": "storage unavailable"})
+			return
+		}
+		proxy.ServeHTTP(ctx.Writer, ctx.Request)
+	}
+}
+
+func (h *Handler) storageProxy(rawURL string) (*httputil.ReverseProxy, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		// The gateway already applied CORS; duplicated headers make browsers reject the response.
+		for key := range resp.Header {
+			if strings.HasPrefix(key, "Access-Control-") {
+				resp.Header.Del(key)
+			}
+		}
+		return nil
+	}
+	return proxy, nil
+}
+
 // proxyRequest forwards the request to the booking service
 func (h *Handler) proxyRequest(ctx *gin.Context, targetURL, method, path string, body io.Reader) {
 	fullURL := targetURL + path
@@ -132,8 +339,18 @@ func (h *Handler) proxyRequest(ctx *gin.Context, targetURL, method, path string,
 		return
 	}
 
-	// Copy headers
-	req.Header.Set("Content-Type", "application/json")
+	// Copy headers. Uploads such as member imports keep their multipart content type.
+	contentType := ctx.GetHeader("Content-Type")
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	// Pass on who is calling, e.g. for consent records and audit events.
+	req.Header.Set("X-Forwarded-For", ctx.ClientIP())
+	req.Header.Set(logutil.HeaderRequestID, ctx.GetHeader(logutil.HeaderRequestID))
+	if ua := ctx.Request.UserAgent(); ua != "" {
+		req.Header.Set("User-Agent", ua)
+	}
 
 	// Inject auth headers from context
 	if meta, ok := services.AuthFromContext(ctx.Request.Context()); ok {
@@ -143,11 +360,16 @@ func (h *Handler) proxyRequest(ctx *gin.Context, targetURL, method, path string,
 		if len(meta.Roles) > 0 {
 			req.Header.Set("X-User-Roles", strings.Join(meta.Roles, ","))
 		}
+		if len(meta.Permissions) > 0 {
+			req.Header.Set(authz.HeaderPermissions, strings.Join(meta.Permissions, ","))
+		}
+		if len(meta.VenuePermissions) > 0 {
+			req.Header.Set(authz.HeaderVenuePermissions, authz.FormatVenuePermissions(meta.VenuePermissions))
+		}
 	}
 
 	// Forward request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := h.httpClient.Do(req)
 	if err != nil {
 		h.logger.Error().Err(err).Str("url", fullURL).Msg("failed to forward request")
 		ctx.JSON(http.StatusBadGateway, gin.H{"error": "failed to forward request"})
@@ -198,6 +420,11 @@ func (h *Handler) deleteFacility(ctx *gin.Context) {
 	h.proxyRequest(ctx, h.bookingURL, http.MethodDelete, path, nil)
 }
 
+func (h *Handler) restoreFacility(ctx *gin.Context) {
+	path := "/v1/facilities/" + ctx.Param("id") + "/restore"
+	h.proxyRequest(ctx, h.bookingURL, http.MethodPost, path, nil)
+}
+
 func (h *Handler) getFacilitySchedule(ctx *gin.Context) {
 	path := "/v1/facilities/" + ctx.Param("id") + "/schedule?" + ctx.Request.URL.RawQuery
 	h.proxyRequest(ctx, h.bookingURL, http.MethodGet, path, nil)
@@ -261,15 +488,39 @@ func (h *Handler) updateVenue(ctx *gin.Context) {
 }
 
 func (h *Handler) deleteVenue(ctx *gin.Context) {
-	path := "/v1/venues/" + ctx.Param("id")
+	path := "/v1/venues/" + ctx.Param("id") + "?" + ctx.Request.URL.RawQuery
 	h.proxyRequest(ctx, h.bookingURL, http.MethodDelete, path, nil)
 }
 
+func (h *Handler) restoreVenue(ctx *gin.Context) {
+	path := "/v1/venues/" + ctx.Param("id") + "/restore"
+	h.proxyRequest(ctx, h.bookingURL, http.MethodPost, path, nil)
+}
+
+// Media handlers share one proxy since the gateway paths mirror the booking service.
+func (h *Handler) proxyMedia(ctx *gin.Context) {
+	h.proxyRequest(ctx, h.bookingURL, ctx.Request.Method, ctx.Request.URL.Path, ctx.Request.Body)
+}
+
+// Venue map handlers mirror the booking service paths; availability takes an optional ?at=.
+func (h *Handler) proxyVenueMap(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	if ctx.Request.URL.RawQuery != "" {
+		path += "?" + ctx.Request.URL.RawQuery
+	}
+	h.proxyRequest(ctx, h.bookingURL, ctx.Request.Method, path, ctx.Request.Body)
+}
+
 // User handlers
-func (h *Handler) listUsers(ctx *gin.Context) {
-	// For simplicity, return empty array
-	// You can implement full user listing if needed
-	ctx.JSON(http.StatusOK, []gin.H{})
+// proxyUsers forwards profile (/me), directory, bulk import, privacy (exports, erasures, consents)
+// and policy requests to the user service, which checks user:read, user:disable, user:import and
+// privacy:manage itself.
+func (h *Handler) proxyUsers(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	if ctx.Request.URL.RawQuery != "" {
+		path += "?" + ctx.Request.URL.RawQuery
+	}
+	h.proxyRequest(ctx, h.userURL, ctx.Request.Method, path, ctx.Request.Body)
 }
 
 func (h *Handler) getUser(ctx *gin.Context) {
@@ -288,3 +539,23 @@ func (h *Handler) getUser(ctx *gin.Context) {
 		"roles":     user.Roles,
 	})
 }
+
+// updateUserRoles proxies global (/roles) and venue-scoped (/venue-roles) role assignment.
+func (h *Handler) updateUserRoles(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	h.proxyRequest(ctx, h.userURL, http.MethodPut, path, ctx.Request.Body)
+}
+
+// proxyAudit forwards /v1/audit to the audit service, with the search filters.
+func (h *Handler) proxyAudit(ctx *gin.Context) {
+	path := ctx.Request.URL.Path
+	if ctx.Request.URL.RawQuery != "" {
+		path += "?" + ctx.Request.URL.RawQuery
+	}
+	h.proxyRequest(ctx, h.auditURL, http.MethodGet, path, nil)
+}
+
+// proxyRBAC forwards /v1/roles, /v1/permissions and /v1/oauth/clients to the user service unchanged.
+func (h *Handler) proxyRBAC(ctx *gin.Context) {
+	h.proxyRequest(ctx, h.userURL, ctx.Request.Method, ctx.Request.URL.Path, ctx.Request.Body)
+}
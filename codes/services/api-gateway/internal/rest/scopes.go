@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/jwtutil"
+)
+
+// scopeRoutes lists the routes partner tokens (those with a client_id claim) may reach and the
+// scope each needs. Every other route refuses them, whatever permissions they carry.
+var scopeRoutes = map[string]string{
+	"GET /v1/venues":                      authz.ScopeVenuesRead,
+	"GET /v1/venues/:id":                  authz.ScopeVenuesRead,
+	"GET /v1/venues/:id/media":            authz.ScopeVenuesRead,
+	"GET /v1/venues/:id/map":              authz.ScopeVenuesRead,
+	"GET /v1/venues/:id/map/availability": authz.ScopeVenuesRead,
+	"GET /v1/facilities":                  authz.ScopeVenuesRead,
+	"GET /v1/facilities/:id":              authz.ScopeVenuesRead,
+	"GET /v1/facilities/:id/schedule":     authz.ScopeVenuesRead,
+	"GET /v1/facilities/:id/media":        authz.ScopeVenuesRead,
+	"GET /v1/bookings":                    authz.ScopeBookingsRead,
+	"GET /v1/bookings/:id":                authz.ScopeBookingsRead,
+	"POST /v1/bookings":                   authz.ScopeBookingsWrite,
+	"PATCH /v1/bookings/:id/cancel":       authz.ScopeBookingsWrite,
+	"GET /v1/users/me":                    authz.ScopeProfile,
+	"GET /v1/users/:id":                   authz.ScopeProfile,
+}
+
+// scopeAllows reports whether a partner token's scopes cover the matched route. The profile
+// scope only reaches the token's own user (/me or their own id).
+func scopeAllows(ctx *gin.Context, claims *jwtutil.Claims) bool {
+	fullPath := ctx.FullPath()
+	required, ok := scopeRoutes[ctx.Request.Method+" "+fullPath]
+	if !ok {
+		return false
+	}
+	if required == authz.ScopeProfile && !strings.HasSuffix(fullPath, "/me") && ctx.Param("id") != claims.UserID {
+		return false
+	}
+	for _, scope := range claims.Scopes() {
+		if scope == required {
+			return true
+		}
+	}
+	return false
+}
+
+func writeInsufficientScope(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+	ctx.JSON(http.StatusForbidden, gin.H{"error": "insufficient_scope"})
+	ctx.Abort()
+}
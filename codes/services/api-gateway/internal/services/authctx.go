@@ -7,8 +7,13 @@ type authContextKey struct{}
 
 // AuthMetadata carries JWT-derived claims for downstream calls.
 type AuthMetadata struct {
-	UserID string
-	Roles  []string
+	UserID           string
+	Roles            []string
+	Permissions      []string
+	VenuePermissions map[string][]string
+	// ClientIP and RequestID are passed on so downstream audit events name the real caller.
+	ClientIP  string
+	RequestID string
 }
 
 // WithAuth injects auth metadata into a context.
@@ -10,6 +10,9 @@ import (
 	"net/url"
 	"strings"
 	"time"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/logutil"
 )
 
 // NewHTTPClients constructs ServiceClients backed by real HTTP requests.
@@ -50,11 +53,214 @@ func (c *userHTTPClient) fetchUser(ctx context.Context, userID string) (*User, e
 	return dto.asDomain(), nil
 }
 
+func (c *userHTTPClient) UpdateProfile(ctx context.Context, input ProfileInput) (*User, error) {
+	payload := profileWriteRequest{
+		FirstName:   input.FirstName,
+		LastName:    input.LastName,
+		Phone:       input.Phone,
+		Preferences: input.Preferences,
+	}
+	return c.sendUser(ctx, http.MethodPatch, "/v1/users/me", payload)
+}
+
+func (c *userHTTPClient) ListUsers(ctx context.Context, query UserQuery) ([]*User, error) {
+	params := url.Values{}
+	if query.Search != "" {
+		params.Set("q", query.Search)
+	}
+	if query.Role != "" {
+		params.Set("role", query.Role)
+	}
+	if query.Status != "" {
+		params.Set("status", query.Status)
+	}
+	if query.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", query.Limit))
+	}
+	if query.Offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", query.Offset))
+	}
+	endpoint := fmt.Sprintf("%s/v1/users", c.baseURL)
+	if enc := params.Encode(); enc != "" {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, enc)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	var dto []userDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	users := make([]*User, 0, len(dto))
+	for _, u := range dto {
+		users = append(users, u.asDomain())
+	}
+	return users, nil
+}
+
+func (c *userHTTPClient) SetRoles(ctx context.Context, userID string, roles []string) (*User, error) {
+	return c.sendUser(ctx, http.MethodPut, "/v1/users/"+url.PathEscape(userID)+"/roles", map[string][]string{"roles": roles})
+}
+
+func (c *userHTTPClient) SetDisabled(ctx context.Context, userID string, disabled bool) (*User, error) {
+	action := "/enable"
+	if disabled {
+		action = "/disable"
+	}
+	return c.sendUser(ctx, http.MethodPost, "/v1/users/"+url.PathEscape(userID)+action, nil)
+}
+
+func (c *userHTTPClient) Household(ctx context.Context, userID string) (*Household, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/users/%s/household", c.baseURL, url.PathEscape(userID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("http %s %s failed: status=%d body=%s", req.Method, req.URL.Path, resp.StatusCode, string(body))
+	}
+	var dto householdDTO
+	if err := json.NewDecoder(resp.Body).Decode(&dto); err != nil {
+		return nil, err
+	}
+	return dto.asDomain(), nil
+}
+
+// sendUser sends payload (if any) on behalf of the caller and decodes the user in the response.
+func (c *userHTTPClient) sendUser(ctx context.Context, method, path string, payload any) (*User, error) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	injectAuthHeaders(ctx, req)
+	var dto userDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	return dto.asDomain(), nil
+}
+
 type bookingHTTPClient struct {
 	client  *http.Client
 	baseURL string
 }
 
+func (c *bookingHTTPClient) ListVenues(ctx context.Context, query VenueQuery) ([]*Venue, error) {
+	params := url.Values{}
+	if query.IncludeArchived {
+		params.Set("includeArchived", "true")
+	}
+	if query.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", query.Limit))
+	}
+	if query.Offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", query.Offset))
+	}
+	endpoint := fmt.Sprintf("%s/v1/venues", c.baseURL)
+	if enc := params.Encode(); enc != "" {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, enc)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	var dto []venueDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	venues := make([]*Venue, 0, len(dto))
+	for _, v := range dto {
+		venues = append(venues, v.asDomain())
+	}
+	return venues, nil
+}
+
+func (c *bookingHTTPClient) GetVenue(ctx context.Context, venueID string) (*Venue, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/venues/%s", c.baseURL, venueID), nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	var dto venueDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	return dto.asDomain(), nil
+}
+
+func (c *bookingHTTPClient) GetVenueMap(ctx context.Context, venueID string, at *time.Time) (*VenueMap, error) {
+	endpoint := fmt.Sprintf("%s/v1/venues/%s/map/availability", c.baseURL, venueID)
+	if at != nil {
+		endpoint = fmt.Sprintf("%s?at=%s", endpoint, url.QueryEscape(at.Format(time.RFC3339)))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	var dto venueMapDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	return dto.asDomain(), nil
+}
+
+func (c *bookingHTTPClient) SaveVenueMap(ctx context.Context, venueID string, input VenueMapInput) (*VenueMap, error) {
+	payload := venueMapWriteRequest{
+		Width:             input.Width,
+		Height:            input.Height,
+		Unit:              input.Unit,
+		BackgroundMediaID: input.BackgroundMediaID,
+		Facilities:        make([]mapFacilityWriteRequest, 0, len(input.Facilities)),
+	}
+	for _, f := range input.Facilities {
+		payload.Facilities = append(payload.Facilities, mapFacilityWriteRequest{
+			FacilityID: f.FacilityID,
+			Polygon:    mapPointDTOs(f.Polygon),
+			Rotation:   f.Rotation,
+			Label:      f.Label,
+		})
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, fmt.Sprintf("%s/v1/venues/%s/map", c.baseURL, venueID), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	injectAuthHeaders(ctx, req)
+	var dto venueMapDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	return dto.asDomain(), nil
+}
+
 func (c *bookingHTTPClient) ListFacilities(ctx context.Context, query FacilityQuery) ([]*Facility, error) {
 	params := url.Values{}
 	if query.VenueID != "" {
@@ -63,6 +269,9 @@ func (c *bookingHTTPClient) ListFacilities(ctx context.Context, query FacilityQu
 	if query.Available != nil {
 		params.Set("available", fmt.Sprintf("%t", *query.Available))
 	}
+	if query.IncludeArchived {
+		params.Set("includeArchived", "true")
+	}
 	if query.Limit > 0 {
 		params.Set("limit", fmt.Sprintf("%d", query.Limit))
 	}
@@ -90,6 +299,67 @@ func (c *bookingHTTPClient) ListFacilities(ctx context.Context, query FacilityQu
 	return facilities, nil
 }
 
+func (c *bookingHTTPClient) GetFacility(ctx context.Context, facilityID string) (*Facility, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/facilities/%s", c.baseURL, facilityID), nil)
+	if err != nil {
+		return nil, err
+	}
+	injectAuthHeaders(ctx, req)
+	var dto facilityDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	facility := dto.asDomain()
+	return &facility, nil
+}
+
+func (c *bookingHTTPClient) CreateFacility(ctx context.Context, input FacilityInput) (*Facility, error) {
+	return c.writeFacility(ctx, http.MethodPost, fmt.Sprintf("%s/v1/facilities", c.baseURL), input)
+}
+
+func (c *bookingHTTPClient) UpdateFacility(ctx context.Context, facilityID string, input FacilityInput) (*Facility, error) {
+	return c.writeFacility(ctx, http.MethodPut, fmt.Sprintf("%s/v1/facilities/%s", c.baseURL, facilityID), input)
+}
+
+func (c *bookingHTTPClient) writeFacility(ctx context.Context, method, endpoint string, input FacilityInput) (*Facility, error) {
+	payload := facilityWriteRequest{
+		VenueID:     input.VenueID,
+		Name:        input.Name,
+		Description: input.Description,
+		Surface:     input.Surface,
+		OpenAt:      input.OpenAt,
+		CloseAt:     input.CloseAt,
+		WeekdayRate: input.WeekdayRate,
+		WeekendRate: input.WeekendRate,
+		Currency:    input.Currency,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	injectAuthHeaders(ctx, req)
+	var dto facilityDTO
+	if err := doJSONRequest(c.client, req, &dto); err != nil {
+		return nil, err
+	}
+	facility := dto.asDomain()
+	return &facility, nil
+}
+
+func (c *bookingHTTPClient) DeleteFacility(ctx context.Context, facilityID string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, fmt.Sprintf("%s/v1/facilities/%s", c.baseURL, facilityID), nil)
+	if err != nil {
+		return err
+	}
+	injectAuthHeaders(ctx, req)
+	return doJSONRequest[struct{}](c.client, req, nil)
+}
+
 func (c *bookingHTTPClient) ListBookings(ctx context.Context, query BookingQuery) ([]*Booking, error) {
 	params := url.Values{}
 	if query.UserID != "" {
@@ -291,40 +561,104 @@ func doJSONRequest[T any](client *http.Client, req *http.Request, dest *T) error
 }
 
 type userDTO struct {
-	ID        string   `json:"id"`
-	FirstName string   `json:"firstName"`
-	LastName  string   `json:"lastName"`
-	Email     string   `json:"email"`
-	Roles     []string `json:"roles"`
+	ID            string         `json:"id"`
+	FirstName     string         `json:"firstName"`
+	LastName      string         `json:"lastName"`
+	Email         string         `json:"email"`
+	Roles         []string       `json:"roles"`
+	Phone         string         `json:"phone"`
+	AvatarURL     string         `json:"avatarUrl"`
+	Preferences   map[string]any `json:"preferences"`
+	EmailVerified bool           `json:"emailVerified"`
+	Disabled      bool           `json:"disabled"`
+	Dependent     bool           `json:"dependent"`
+	CreatedAt     time.Time      `json:"createdAt"`
 }
 
 func (u userDTO) asDomain() *User {
 	return &User{
-		ID:        u.ID,
-		FirstName: u.FirstName,
-		LastName:  u.LastName,
-		Email:     u.Email,
-		Roles:     u.Roles,
+		ID:            u.ID,
+		FirstName:     u.FirstName,
+		LastName:      u.LastName,
+		Email:         u.Email,
+		Roles:         u.Roles,
+		Phone:         u.Phone,
+		AvatarURL:     u.AvatarURL,
+		Preferences:   u.Preferences,
+		EmailVerified: u.EmailVerified,
+		Disabled:      u.Disabled,
+		Dependent:     u.Dependent,
+		CreatedAt:     u.CreatedAt,
 	}
 }
 
+type householdDTO struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	PrimaryUserID   string `json:"primaryUserId"`
+	PaymentMethodID string `json:"paymentMethodId"`
+	MembershipType  string `json:"membershipType"`
+	Members         []struct {
+		UserID    string `json:"userId"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+		Email     string `json:"email"`
+		Role      string `json:"role"`
+	} `json:"members"`
+}
+
+func (h householdDTO) asDomain() *Household {
+	household := &Household{
+		ID:              h.ID,
+		Name:            h.Name,
+		PrimaryUserID:   h.PrimaryUserID,
+		PaymentMethodID: h.PaymentMethodID,
+		MembershipType:  h.MembershipType,
+		Members:         make([]*HouseholdMember, 0, len(h.Members)),
+	}
+	for _, m := range h.Members {
+		household.Members = append(household.Members, &HouseholdMember{
+			UserID:    m.UserID,
+			FirstName: m.FirstName,
+			LastName:  m.LastName,
+			Email:     m.Email,
+			Role:      m.Role,
+		})
+	}
+	return household
+}
+
+type profileWriteRequest struct {
+	FirstName   *string        `json:"firstName,omitempty"`
+	LastName    *string        `json:"lastName,omitempty"`
+	Phone       *string        `json:"phone,omitempty"`
+	Preferences map[string]any `json:"preferences,omitempty"`
+}
+
 type facilityDTO struct {
-	ID          string `json:"id"`
-	VenueID     string `json:"venueId"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Surface     string `json:"surface"`
-	OpenAt      string `json:"openAt"`
-	CloseAt     string `json:"closeAt"`
-	Available   bool   `json:"available"`
-	WeekdayRate int    `json:"weekdayRateCents"`
-	WeekendRate int    `json:"weekendRateCents"`
-	Currency    string `json:"currency"`
+	ID          string     `json:"id"`
+	VenueID     string     `json:"venueId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Surface     string     `json:"surface"`
+	OpenAt      string     `json:"openAt"`
+	CloseAt     string     `json:"closeAt"`
+	Available   bool       `json:"available"`
+	WeekdayRate int        `json:"weekdayRateCents"`
+	WeekendRate int        `json:"weekendRateCents"`
+	Currency    string     `json:"currency"`
+	ArchivedAt  string     `json:"archivedAt"`
+	Photos      []mediaDTO `json:"photos"`
+	FloorPlan   *mediaDTO  `json:"floorPlan"`
 }
 
 func (f facilityDTO) asDomain() Facility {
 	openAt, _ := time.Parse(time.RFC3339, f.OpenAt)
 	closeAt, _ := time.Parse(time.RFC3339, f.CloseAt)
+	var archivedAt *time.Time
+	if parsed, err := time.Parse(time.RFC3339, f.ArchivedAt); err == nil {
+		archivedAt = &parsed
+	}
 	return Facility{
 		ID:          f.ID,
 		VenueID:     f.VenueID,
@@ -337,7 +671,177 @@ func (f facilityDTO) asDomain() Facility {
 		WeekdayRate: f.WeekdayRate,
 		WeekendRate: f.WeekendRate,
 		Currency:    f.Currency,
+		ArchivedAt:  archivedAt,
+		Photos:      mediaDomain(f.Photos),
+		FloorPlan:   f.FloorPlan.asDomain(),
+	}
+}
+
+type venueDTO struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Address     string     `json:"address"`
+	City        string     `json:"city"`
+	State       string     `json:"state"`
+	ZipCode     string     `json:"zipCode"`
+	Country     string     `json:"country"`
+	Phone       string     `json:"phone"`
+	Email       string     `json:"email"`
+	Website     string     `json:"website"`
+	Timezone    string     `json:"timezone"`
+	ArchivedAt  string     `json:"archivedAt"`
+	Photos      []mediaDTO `json:"photos"`
+	FloorPlan   *mediaDTO  `json:"floorPlan"`
+}
+
+func (v venueDTO) asDomain() *Venue {
+	var archivedAt *time.Time
+	if parsed, err := time.Parse(time.RFC3339, v.ArchivedAt); err == nil {
+		archivedAt = &parsed
+	}
+	return &Venue{
+		ID:          v.ID,
+		Name:        v.Name,
+		Description: v.Description,
+		Address:     v.Address,
+		City:        v.City,
+		State:       v.State,
+		ZipCode:     v.ZipCode,
+		Country:     v.Country,
+		Phone:       v.Phone,
+		Email:       v.Email,
+		Website:     v.Website,
+		Timezone:    v.Timezone,
+		ArchivedAt:  archivedAt,
+		Photos:      mediaDomain(v.Photos),
+		FloorPlan:   v.FloorPlan.asDomain(),
+	}
+}
+
+type venueMapDTO struct {
+	VenueID       string           `json:"venueId"`
+	Width         float64          `json:"width"`
+	Height        float64          `json:"height"`
+	Unit          string           `json:"unit"`
+	BackgroundURL string           `json:"backgroundUrl"`
+	At            string           `json:"at"`
+	Facilities    []mapFacilityDTO `json:"facilities"`
+}
+
+type mapFacilityDTO struct {
+	FacilityID     string        `json:"facilityId"`
+	Name           string        `json:"name"`
+	Label          string        `json:"label"`
+	Polygon        []mapPointDTO `json:"polygon"`
+	Rotation       float64       `json:"rotation"`
+	Status         string        `json:"status"`
+	Colour         string        `json:"colour"`
+	AvailableUntil string        `json:"availableUntil"`
+	BusyUntil      string        `json:"busyUntil"`
+	NextBookingAt  string        `json:"nextBookingAt"`
+}
+
+type mapPointDTO struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type venueMapWriteRequest struct {
+	Width             float64                   `json:"width"`
+	Height            float64                   `json:"height"`
+	Unit              string                    `json:"unit,omitempty"`
+	BackgroundMediaID string                    `json:"backgroundMediaId,omitempty"`
+	Facilities        []mapFacilityWriteRequest `json:"facilities"`
+}
+
+type mapFacilityWriteRequest struct {
+	FacilityID string        `json:"facilityId"`
+	Polygon    []mapPointDTO `json:"polygon"`
+	Rotation   float64       `json:"rotation"`
+	Label      string        `json:"label"`
+}
+
+func (m venueMapDTO) asDomain() *VenueMap {
+	venueMap := &VenueMap{
+		VenueID:       m.VenueID,
+		Width:         m.Width,
+		Height:        m.Height,
+		Unit:          m.Unit,
+		BackgroundURL: m.BackgroundURL,
+		At:            optionalTime(m.At),
+		Facilities:    make([]MapFacility, 0, len(m.Facilities)),
+	}
+	for _, f := range m.Facilities {
+		polygon := make([]MapPoint, 0, len(f.Polygon))
+		for _, p := range f.Polygon {
+			polygon = append(polygon, MapPoint{X: p.X, Y: p.Y})
+		}
+		venueMap.Facilities = append(venueMap.Facilities, MapFacility{
+			FacilityID:     f.FacilityID,
+			Name:           f.Name,
+			Label:          f.Label,
+			Polygon:        polygon,
+			Rotation:       f.Rotation,
+			Status:         f.Status,
+			Colour:         f.Colour,
+			AvailableUntil: optionalTime(f.AvailableUntil),
+			BusyUntil:      optionalTime(f.BusyUntil),
+			NextBookingAt:  optionalTime(f.NextBookingAt),
+		})
+	}
+	return venueMap
+}
+
+func mapPointDTOs(points []MapPoint) []mapPointDTO {
+	out := make([]mapPointDTO, 0, len(points))
+	for _, p := range points {
+		out = append(out, mapPointDTO{X: p.X, Y: p.Y})
 	}
+	return out
+}
+
+func optionalTime(value string) *time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+type mediaDTO struct {
+	ID           string `json:"id"`
+	Kind         string `json:"kind"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	ContentType  string `json:"contentType"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Position     int    `json:"position"`
+}
+
+func (m *mediaDTO) asDomain() *Media {
+	if m == nil {
+		return nil
+	}
+	return &Media{
+		ID:           m.ID,
+		Kind:         m.Kind,
+		URL:          m.URL,
+		ThumbnailURL: m.ThumbnailURL,
+		ContentType:  m.ContentType,
+		Width:        m.Width,
+		Height:       m.Height,
+		Position:     m.Position,
+	}
+}
+
+func mediaDomain(items []mediaDTO) []Media {
+	out := make([]Media, 0, len(items))
+	for i := range items {
+		out = append(out, *items[i].asDomain())
+	}
+	return out
 }
 
 type bookingDTO struct {
@@ -350,6 +854,8 @@ type bookingDTO struct {
 	AmountCents   int64        `json:"amountCents"`
 	Currency      string       `json:"currency"`
 	PaymentIntent string       `json:"paymentIntent"`
+	BookedBy      string       `json:"bookedBy"`
+	CancelledBy   string       `json:"cancelledBy"`
 	Facility      *facilityDTO `json:"facility"`
 }
 
@@ -372,10 +878,24 @@ func (b bookingDTO) asDomain() (*Booking, error) {
 		AmountCents:   b.AmountCents,
 		Currency:      b.Currency,
 		PaymentIntent: b.PaymentIntent,
+		BookedBy:      b.BookedBy,
+		CancelledBy:   b.CancelledBy,
 		Facility:      b.facilityDomain(),
 	}, nil
 }
 
+type facilityWriteRequest struct {
+	VenueID     string `json:"venueId,omitempty"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Surface     string `json:"surface"`
+	OpenAt      string `json:"openAt"`
+	CloseAt     string `json:"closeAt"`
+	WeekdayRate int    `json:"weekdayRateCents"`
+	WeekendRate int    `json:"weekendRateCents"`
+	Currency    string `json:"currency"`
+}
+
 type bookingCreateRequest struct {
 	FacilityID string `json:"facilityId"`
 	UserID     string `json:"userId"`
@@ -482,5 +1002,17 @@ func injectAuthHeaders(ctx context.Context, req *http.Request) {
 		if len(meta.Roles) > 0 {
 			req.Header.Set("X-User-Roles", strings.Join(meta.Roles, ","))
 		}
+		if len(meta.Permissions) > 0 {
+			req.Header.Set("X-User-Permissions", strings.Join(meta.Permissions, ","))
+		}
+		if len(meta.VenuePermissions) > 0 {
+			req.Header.Set(authz.HeaderVenuePermissions, authz.FormatVenuePermissions(meta.VenuePermissions))
+		}
+		if meta.ClientIP != "" {
+			req.Header.Set("X-Forwarded-For", meta.ClientIP)
+		}
+		if meta.RequestID != "" {
+			req.Header.Set(logutil.HeaderRequestID, meta.RequestID)
+		}
 	}
 }
@@ -15,11 +15,25 @@ type ServiceClients struct {
 // UserService exposes user-domain operations needed by the gateway.
 type UserService interface {
 	Me(ctx context.Context, userID string) (*User, error)
+	UpdateProfile(ctx context.Context, input ProfileInput) (*User, error)
+	ListUsers(ctx context.Context, query UserQuery) ([]*User, error)
+	SetRoles(ctx context.Context, userID string, roles []string) (*User, error)
+	SetDisabled(ctx context.Context, userID string, disabled bool) (*User, error)
+	// Household returns the household userID belongs to, or nil when they have none.
+	Household(ctx context.Context, userID string) (*Household, error)
 }
 
 // BookingService exposes facility + booking operations.
 type BookingService interface {
+	ListVenues(ctx context.Context, query VenueQuery) ([]*Venue, error)
+	GetVenue(ctx context.Context, venueID string) (*Venue, error)
+	GetVenueMap(ctx context.Context, venueID string, at *time.Time) (*VenueMap, error)
+	SaveVenueMap(ctx context.Context, venueID string, input VenueMapInput) (*VenueMap, error)
 	ListFacilities(ctx context.Context, query FacilityQuery) ([]*Facility, error)
+	GetFacility(ctx context.Context, facilityID string) (*Facility, error)
+	CreateFacility(ctx context.Context, input FacilityInput) (*Facility, error)
+	UpdateFacility(ctx context.Context, facilityID string, input FacilityInput) (*Facility, error)
+	DeleteFacility(ctx context.Context, facilityID string) error
 	ListBookings(ctx context.Context, query BookingQuery) ([]*Booking, error)
 	CreateBooking(ctx context.Context, input BookingInput) (*Booking, error)
 	CancelBooking(ctx context.Context, bookingID string) (*Booking, error)
@@ -32,11 +46,56 @@ type BookingService interface {
 
 // User mirrors a subset of the user-service DTO.
 type User struct {
-	ID        string
+	ID            string
+	FirstName     string
+	LastName      string
+	Email         string
+	Roles         []string
+	Phone         string
+	AvatarURL     string
+	Preferences   map[string]any
+	EmailVerified bool
+	Disabled      bool
+	// Dependent accounts belong to a household and cannot sign in.
+	Dependent bool
+	CreatedAt time.Time
+}
+
+// Household groups accounts that share a payment method and a membership.
+type Household struct {
+	ID              string
+	Name            string
+	PrimaryUserID   string
+	PaymentMethodID string
+	MembershipType  string
+	Members         []*HouseholdMember
+}
+
+// HouseholdMember is an active member of a household. Email is empty for dependents.
+type HouseholdMember struct {
+	UserID    string
 	FirstName string
 	LastName  string
 	Email     string
-	Roles     []string
+	Role      string
+}
+
+// ProfileInput is used by the updateProfile mutation; nil fields stay unchanged.
+type ProfileInput struct {
+	FirstName   *string
+	LastName    *string
+	Phone       *string
+	Preferences map[string]any
+}
+
+// UserQuery filters the admin user directory.
+type UserQuery struct {
+	Search string
+	Role   string
+	// Status is "active", "disabled" or empty for both.
+	Status string
+	Limit  int
+	Offset int
 }
 
 // Facility captures the minimal data required by the booking UI.
@@ -52,6 +111,102 @@ type Facility struct {
 	WeekdayRate int
 	WeekendRate int
 	Currency    string
+	ArchivedAt  *time.Time
+	Photos      []Media
+	FloorPlan   *Media
+}
+
+// Venue mirrors the booking-service venue DTO.
+type Venue struct {
+	ID          string
+	Name        string
+	Description string
+	Address     string
+	City        string
+	State       string
+	ZipCode     string
+	Country     string
+	Phone       string
+	Email       string
+	Website     string
+	Timezone    string
+	ArchivedAt  *time.Time
+	Photos      []Media
+	FloorPlan   *Media
+}
+
+// VenueMap is a venue floor plan with facility geometry. Facility statuses are only
+// filled in when the map is fetched with live availability.
+type VenueMap struct {
+	VenueID       string
+	Width         float64
+	Height        float64
+	Unit          string
+	BackgroundURL string
+	At            *time.Time
+	Facilities    []MapFacility
+}
+
+// MapFacility places one facility on the venue map.
+type MapFacility struct {
+	FacilityID     string
+	Name           string
+	Label          string
+	Polygon        []MapPoint
+	Rotation       float64
+	Status         string
+	Colour         string
+	AvailableUntil *time.Time
+	BusyUntil      *time.Time
+	NextBookingAt  *time.Time
+}
+
+// MapPoint is a polygon vertex in floor-plan units.
+type MapPoint struct {
+	X float64
+	Y float64
+}
+
+// VenueMapInput replaces a venue's floor plan and facility shapes.
+type VenueMapInput struct {
+	Width             float64
+	Height            float64
+	Unit              string
+	BackgroundMediaID string
+	Facilities        []MapFacilityInput
+}
+
+// MapFacilityInput is the editable geometry of one facility.
+type MapFacilityInput struct {
+	FacilityID string
+	Polygon    []MapPoint
+	Rotation   float64
+	Label      string
+}
+
+// Media is a gallery photo or floor plan with presigned read URLs.
+type Media struct {
+	ID           string
+	Kind         string
+	URL          string
+	ThumbnailURL string
+	ContentType  string
+	Width        int
+	Height       int
+	Position     int
+}
+
+// FacilityInput carries the editable facility fields for create/update mutations.
+type FacilityInput struct {
+	VenueID     string
+	Name        string
+	Description string
+	Surface     string
+	OpenAt      string
+	CloseAt     string
+	WeekdayRate int
+	WeekendRate int
+	Currency    string
 }
 
 type FacilityOverride struct {
@@ -89,7 +244,11 @@ type Booking struct {
 	AmountCents   int64
 	Currency      string
 	PaymentIntent string
-	Facility      *Facility
+	// BookedBy and CancelledBy name who acted when it was not the owner, such as a household
+	// member; empty when unknown.
+	BookedBy    string
+	CancelledBy string
+	Facility    *Facility
 }
 
 // BookingInput is used by the createBooking mutation.
@@ -113,10 +272,18 @@ type FacilityOverrideInput struct {
 
 // FacilityQuery carries pagination/filter filters.
 type FacilityQuery struct {
-	VenueID   string
-	Available *bool
-	Limit     int
-	Offset    int
+	VenueID         string
+	Available       *bool
+	IncludeArchived bool
+	Limit           int
+	Offset          int
+}
+
+// VenueQuery carries pagination filters for venues.
+type VenueQuery struct {
+	IncludeArchived bool
+	Limit           int
+	Offset          int
 }
 
 // BookingQuery carries pagination filters for bookings.
@@ -151,6 +318,131 @@ func (m *mockUserService) Me(_ context.Context, userID string) (*User, error) {
 	}, nil
 }
 
+func (m *mockUserService) UpdateProfile(ctx context.Context, input ProfileInput) (*User, error) {
+	meta, _ := AuthFromContext(ctx)
+	user, err := m.Me(ctx, meta.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if input.FirstName != nil {
+		user.FirstName = *input.FirstName
+	}
+	if input.LastName != nil {
+		user.LastName = *input.LastName
+	}
+	if input.Phone != nil {
+		user.Phone = *input.Phone
+	}
+	user.Preferences = input.Preferences
+	return user, nil
+}
+
+func (m *mockUserService) ListUsers(ctx context.Context, _ UserQuery) ([]*User, error) {
+	user, err := m.Me(ctx, "user-1")
+	if err != nil {
+		return nil, err
+	}
+	return []*User{user}, nil
+}
+
+func (m *mockUserService) SetRoles(ctx context.Context, userID string, roles []string) (*User, error) {
+	user, err := m.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roles
+	return user, nil
+}
+
+func (m *mockUserService) SetDisabled(ctx context.Context, userID string, disabled bool) (*User, error) {
+	user, err := m.Me(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	user.Disabled = disabled
+	return user, nil
+}
+
+func (m *mockUserService) Household(_ context.Context, _ string) (*Household, error) {
+	return nil, nil
+}
+
+func (m *mockBookingService) ListVenues(_ context.Context, _ VenueQuery) ([]*Venue, error) {
+	return []*Venue{
+		{
+			ID:          "venue-1",
+			Name:        "Downtown Pickleball Club",
+			Description: "Six indoor courts in the city centre",
+			City:        "Toronto",
+			Country:     "CA",
+			Timezone:    "America/Toronto",
+			Photos: []Media{
+				{ID: "media-1", Kind: "photo", URL: "https://example.com/venue-1.jpg", ThumbnailURL: "https://example.com/venue-1_thumb.jpg", ContentType: "image/jpeg", Width: 1600, Height: 900},
+			},
+		},
+	}, nil
+}
+
+func (m *mockBookingService) GetVenue(ctx context.Context, venueID string) (*Venue, error) {
+	venues, _ := m.ListVenues(ctx, VenueQuery{})
+	for _, v := range venues {
+		if v.ID == venueID {
+			return v, nil
+		}
+	}
+	return nil, errors.New("venue not found")
+}
+
+func (m *mockBookingService) GetVenueMap(_ context.Context, venueID string, at *time.Time) (*VenueMap, error) {
+	if venueID == "" {
+		return nil, errors.New("venue id required")
+	}
+	now := time.Now()
+	if at != nil {
+		now = *at
+	}
+	freeUntil := now.Add(3 * time.Hour)
+	busyUntil := now.Add(45 * time.Minute)
+	return &VenueMap{
+		VenueID: venueID,
+		Width:   40,
+		Height:  20,
+		Unit:    "m",
+		At:      &now,
+		Facilities: []MapFacility{
+			{
+				FacilityID:     "facility-1",
+				Name:           "Center Court",
+				Label:          "1",
+				Polygon:        []MapPoint{{X: 2, Y: 2}, {X: 15.4, Y: 2}, {X: 15.4, Y: 8.1}, {X: 2, Y: 8.1}},
+				Status:         "AVAILABLE",
+				Colour:         "#2E7D32",
+				AvailableUntil: &freeUntil,
+			},
+			{
+				FacilityID: "facility-2",
+				Name:       "Court B",
+				Label:      "2",
+				Polygon:    []MapPoint{{X: 20, Y: 2}, {X: 33.4, Y: 2}, {X: 33.4, Y: 8.1}, {X: 20, Y: 8.1}},
+				Status:     "BOOKED",
+				Colour:     "#C62828",
+				BusyUntil:  &busyUntil,
+			},
+		},
+	}, nil
+}
+
+func (m *mockBookingService) SaveVenueMap(_ context.Context, venueID string, input VenueMapInput) (*VenueMap, error) {
+	if venueID == "" {
+		return nil, errors.New("venue id required")
+	}
+	venueMap := &VenueMap{VenueID: venueID, Width: input.Width, Height: input.Height, Unit: input.Unit}
+	for _, f := range input.Facilities {
+		venueMap.Facilities = append(venueMap.Facilities, MapFacility{FacilityID: f.FacilityID, Label: f.Label, Polygon: f.Polygon, Rotation: f.Rotation})
+	}
+	return venueMap, nil
+}
+
 func (m *mockBookingService) ListFacilities(_ context.Context, query FacilityQuery) ([]*Facility, error) {
 	venueID := query.VenueID
 	facilities := []*Facility{
@@ -178,6 +470,58 @@ func (m *mockBookingService) ListFacilities(_ context.Context, query FacilityQue
 	return facilities, nil
 }
 
+func (m *mockBookingService) GetFacility(ctx context.Context, facilityID string) (*Facility, error) {
+	facilities, _ := m.ListFacilities(ctx, FacilityQuery{VenueID: "venue-1"})
+	for _, f := range facilities {
+		if f.ID == facilityID {
+			return f, nil
+		}
+	}
+	return nil, errors.New("facility not found")
+}
+
+func (m *mockBookingService) CreateFacility(_ context.Context, input FacilityInput) (*Facility, error) {
+	if input.VenueID == "" || input.Name == "" {
+		return nil, errors.New("venue id and name required")
+	}
+	return mockFacilityFromInput("facility-new", input), nil
+}
+
+func (m *mockBookingService) UpdateFacility(_ context.Context, facilityID string, input FacilityInput) (*Facility, error) {
+	if facilityID == "" {
+		return nil, errors.New("facility id required")
+	}
+	if input.VenueID == "" {
+		input.VenueID = "venue-1"
+	}
+	return mockFacilityFromInput(facilityID, input), nil
+}
+
+func (m *mockBookingService) DeleteFacility(_ context.Context, facilityID string) error {
+	if facilityID == "" {
+		return errors.New("facility id required")
+	}
+	return nil
+}
+
+func mockFacilityFromInput(id string, input FacilityInput) *Facility {
+	openAt, _ := time.Parse("15:04", input.OpenAt)
+	closeAt, _ := time.Parse("15:04", input.CloseAt)
+	return &Facility{
+		ID:          id,
+		VenueID:     input.VenueID,
+		Name:        input.Name,
+		Description: input.Description,
+		Surface:     input.Surface,
+		OpenAt:      openAt,
+		CloseAt:     closeAt,
+		Available:   true,
+		WeekdayRate: input.WeekdayRate,
+		WeekendRate: input.WeekendRate,
+		Currency:    input.Currency,
+	}
+}
+
 func (m *mockBookingService) ListBookings(_ context.Context, query BookingQuery) ([]*Booking, error) {
 	userID := query.UserID
 	if userID == "" {
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/audit-service/internal/store"
+)
+
+// verifyBatch is how many events chain verification reads at a time.
+const verifyBatch = 1000
+
+// csvColumns is the header row of CSV exports.
+var csvColumns = []string{
+	"sequence", "id", "occurredAt", "recordedAt", "service", "actorId", "actorRoles", "action",
+	"targetType", "targetId", "outcome", "changes", "details", "ip", "userAgent", "requestId", "prevHash", "hash",
+}
+
+func main() {
+	srv, err := server.New("audit-service")
+	if err != nil {
+		panic(err)
+	}
+
+	ctx := context.Background()
+	repo, err := initStore(ctx, srv.Config.Database, srv.Logger)
+	if err != nil {
+		panic(err)
+	}
+	defer repo.Close()
+
+	// Events are only accepted from, and identity headers only trusted on, signed calls.
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
+	registerRoutes(srv.Engine, repo)
+
+	if err := srv.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func registerRoutes(router *gin.Engine, repo *store.Store) {
+	group := router.Group("/v1/audit")
+
+	// Services append events here through lib/audit. The service is taken from the signature,
+	// so one service cannot record events in another's name.
+	group.POST("/events", func(ctx *gin.Context) {
+		var event audit.Event
+		if err := ctx.ShouldBindJSON(&event); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.TrimSpace(event.Action) == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "action is required"})
+			return
+		}
+		if event.Outcome != audit.OutcomeSuccess && event.Outcome != audit.OutcomeFailure {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be success or failure"})
+			return
+		}
+		if caller, ok := svcauth.CallerFromContext(ctx.Request.Context()); ok {
+			event.Service = caller
+		}
+		if event.Service == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "service is required"})
+			return
+		}
+		if event.ID == uuid.Nil {
+			event.ID = uuid.New()
+		}
+		if event.OccurredAt.IsZero() {
+			event.OccurredAt = time.Now()
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		stored, err := repo.Append(timeoutCtx, event)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, eventResponse(stored))
+	})
+
+	group.GET("/events", authz.RequirePermission(authz.AuditRead), func(ctx *gin.Context) {
+		query, ok := eventQuery(ctx)
+		if !ok {
+			return
+		}
+		if query.Limit, query.Offset, ok = paginationParams(ctx); !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		events, total, err := repo.ListEvents(timeoutCtx, query)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(events))
+		for _, event := range events {
+			out = append(out, eventResponse(event))
+		}
+		ctx.Header("X-Total-Count", strconv.Itoa(total))
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	// export streams every matching event as CSV, newest first, for offline review.
+	group.GET("/events/export", authz.RequirePermission(authz.AuditRead), func(ctx *gin.Context) {
+		query, ok := eventQuery(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 2*time.Minute)
+		defer cancel()
+
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit-%s.csv"`, time.Now().UTC().Format("20060102T150405Z")))
+		ctx.Status(http.StatusOK)
+		w := csv.NewWriter(ctx.Writer)
+		if err := w.Write(csvColumns); err != nil {
+			return
+		}
+		err := repo.EachEvent(timeoutCtx, query, func(event *audit.Event) error {
+			return w.Write(csvRecord(event))
+		})
+		w.Flush()
+		if err == nil {
+			err = w.Error()
+		}
+		if err != nil {
+			// The status line is already sent; a truncated file is all that can be signalled.
+			ctx.Error(err)
+		}
+	})
+
+	group.GET("/events/:id", authz.RequirePermission(authz.AuditRead), func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid event id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		event, err := repo.GetEvent(timeoutCtx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "audit event not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, eventResponse(event))
+	})
+
+	// verify walks the whole chain and reports the first event that no longer verifies.
+	group.GET("/verify", authz.RequirePermission(authz.AuditRead), func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Minute)
+		defer cancel()
+
+		var (
+			checked int
+			last    int64
+			prev    string
+		)
+		for {
+			page, err := repo.ChainPage(timeoutCtx, last, verifyBatch)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			if len(page) == 0 {
+				break
+			}
+			if page[0].Sequence != last+1 {
+				ctx.JSON(http.StatusOK, gin.H{"verified": false, "checked": checked, "brokenAt": last + 1, "reason": "event missing"})
+				return
+			}
+			if err := audit.VerifyChain(page, prev); err != nil {
+				var broken *audit.ChainError
+				if errors.As(err, &broken) {
+					ctx.JSON(http.StatusOK, gin.H{"verified": false, "checked": checked, "brokenAt": broken.Sequence, "reason": broken.Reason})
+					return
+				}
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			checked += len(page)
+			last = page[len(page)-1].Sequence
+			prev = page[len(page)-1].Hash
+		}
+		ctx.JSON(http.StatusOK, gin.H{"verified": true, "checked": checked, "lastSequence": last, "lastHash": prev})
+	})
+}
+
+// eventQuery reads the search filters shared by the list and export endpoints.
+func eventQuery(ctx *gin.Context) (store.Query, bool) {
+	query := store.Query{
+		ActorID:    ctx.Query("actorId"),
+		Action:     ctx.Query("action"),
+		TargetType: ctx.Query("targetType"),
+		TargetID:   ctx.Query("targetId"),
+		Service:    ctx.Query("service"),
+		Outcome:    ctx.Query("outcome"),
+		RequestID:  ctx.Query("requestId"),
+		Search:     ctx.Query("q"),
+	}
+	if query.Outcome != "" && query.Outcome != audit.OutcomeSuccess && query.Outcome != audit.OutcomeFailure {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be success or failure"})
+		return store.Query{}, false
+	}
+	var ok bool
+	if query.From, ok = timeParam(ctx, "from"); !ok {
+		return store.Query{}, false
+	}
+	if query.To, ok = timeParam(ctx, "to"); !ok {
+		return store.Query{}, false
+	}
+	return query, true
+}
+
+func eventResponse(event *audit.Event) gin.H {
+	return gin.H{
+		"id":         event.ID.String(),
+		"sequence":   event.Sequence,
+		"occurredAt": event.OccurredAt.Format(time.RFC3339Nano),
+		"recordedAt": event.RecordedAt.Format(time.RFC3339Nano),
+		"service":    event.Service,
+		"actorId":    event.ActorID,
+		"actorRoles": event.ActorRoles,
+		"action":     event.Action,
+		"targetType": event.TargetType,
+		"targetId":   event.TargetID,
+		"outcome":    event.Outcome,
+		"changes":    event.Changes,
+		"details":    event.Details,
+		"ip":         event.IP,
+		"userAgent":  event.UserAgent,
+		"requestId":  event.RequestID,
+		"prevHash":   event.PrevHash,
+		"hash":       event.Hash,
+	}
+}
+
+func csvRecord(event *audit.Event) []string {
+	encode := func(value any) string {
+		raw, err := json.Marshal(value)
+		if err != nil || string(raw) == "null" {
+			return ""
+		}
+		return string(raw)
+	}
+	record := []string{
+		strconv.FormatInt(event.Sequence, 10),
+		event.ID.String(),
+		event.OccurredAt.Format(time.RFC3339Nano),
+		event.RecordedAt.Format(time.RFC3339Nano),
+		event.Service,
+		event.ActorID,
+		strings.Join(event.ActorRoles, ","),
+		event.Action,
+		event.TargetType,
+		event.TargetID,
+		event.Outcome,
+		encode(event.Changes),
+		encode(event.Details),
+		event.IP,
+		event.UserAgent,
+		event.RequestID,
+		event.PrevHash,
+		event.Hash,
+	}
+	// Actor, target and client values are caller-supplied; quote any a spreadsheet app would
+	// evaluate as a formula.
+	for i, cell := range record {
+		if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+			record[i] = "'" + cell
+		}
+	}
+	return record
+}
+
+// timeParam reads an optional RFC 3339 query parameter.
+func timeParam(ctx *gin.Context, name string) (time.Time, bool) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an RFC 3339 timestamp"})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+func paginationParams(ctx *gin.Context) (int, int, bool) {
+	limit := 50
+	offset := 0
+	if raw := ctx.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	if raw := ctx.Query("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+	return limit, offset, true
+}
+
+const (
+	dbReadyTimeout = 60 * time.Second
+	dbRetryDelay   = 3 * time.Second
+)
+
+func initStore(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Logger) (*store.Store, error) {
+	deadline := time.Now().Add(dbReadyTimeout)
+	var lastErr error
+
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		attemptCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		repo, err := store.New(attemptCtx, cfg)
+		cancel()
+		if err == nil {
+			if err = repo.Ping(ctx); err == nil {
+				if err = repo.RunMigrations(ctx); err == nil {
+					logger.Info().Msg("connected to Postgres")
+					return repo, nil
+				}
+				err = fmt.Errorf("migration error: %w", err)
+			} else {
+				err = fmt.Errorf("ping error: %w", err)
+			}
+			repo.Close()
+		}
+
+		lastErr = err
+		if time.Now().After(deadline) {
+			break
+		}
+
+		logger.Warn().Err(err).Msg("Postgres not ready, retrying…")
+		time.Sleep(dbRetryDelay)
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,43 @@
+-- The security audit trail. Events are only ever appended: sequence orders the hash chain, and
+-- each hash covers the event and the previous event's hash (see lib/audit).
+CREATE TABLE IF NOT EXISTS audit_events (
+    sequence BIGINT PRIMARY KEY,
+    id UUID NOT NULL UNIQUE,
+    occurred_at TIMESTAMPTZ NOT NULL,
+    recorded_at TIMESTAMPTZ NOT NULL,
+    service TEXT NOT NULL,
+    actor_id TEXT NOT NULL DEFAULT '',
+    actor_roles TEXT[] NOT NULL DEFAULT '{}',
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL,
+    changes JSONB NOT NULL DEFAULT '{}',
+    details JSONB NOT NULL DEFAULT '{}',
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_occurred_idx ON audit_events (occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_action_idx ON audit_events (action, occurred_at DESC);
+CREATE INDEX IF NOT EXISTS audit_events_request_idx ON audit_events (request_id) WHERE request_id <> '';
+
+-- Refuse edits and deletions outright; the hash chain catches anything done around the triggers.
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE OR REPLACE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE ON audit_events
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,329 @@
+package store
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/config"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// chainLock is the advisory lock serialising appends: each event's hash depends on the last.
+const chainLock = 0x61756469 // "audi"
+
+// Store wraps PostgreSQL access for the audit service.
+type Store struct {
+	pool *pgxpool.Pool
+}
+
+// New creates a Store using the shared database config.
+func New(ctx context.Context, cfg config.DatabaseConfig) (*Store, error) {
+	dsn, err := buildDSN(cfg)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{pool: pool}, nil
+}
+
+// Close releases database resources.
+func (s *Store) Close() {
+	s.pool.Close()
+}
+
+// RunMigrations executes embedded SQL migrations in lexical order.
+func (s *Store) RunMigrations(ctx context.Context) error {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		contents, err := migrationFiles.ReadFile("migrations/" + entry.Name())
+		if err != nil {
+			return err
+		}
+		if _, err := s.pool.Exec(ctx, string(contents)); err != nil {
+			return fmt.Errorf("migration %s failed: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Ping verifies the database connection.
+func (s *Store) Ping(ctx context.Context) error {
+	return s.pool.Ping(ctx)
+}
+
+const eventColumns = `sequence, id, occurred_at, recorded_at, service, actor_id, actor_roles, action,
+        target_type, target_id, outcome, changes, details, ip, user_agent, request_id, prev_hash, hash`
+
+// Append seals event onto the end of the chain and stores it. An event whose id is already
+// stored is returned as stored, so a client retrying a send does not append it twice.
+func (s *Store) Append(ctx context.Context, event audit.Event) (*audit.Event, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, chainLock); err != nil {
+		return nil, err
+	}
+	existing, err := scanEvent(tx.QueryRow(ctx, `SELECT `+eventColumns+` FROM audit_events WHERE id = $1`, event.ID))
+	if err == nil {
+		return existing, tx.Commit(ctx)
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	var (
+		last int64
+		prev string
+	)
+	err = tx.QueryRow(ctx, `SELECT sequence, hash FROM audit_events ORDER BY sequence DESC LIMIT 1`).Scan(&last, &prev)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+	event.Sequence = last + 1
+	event.OccurredAt = event.OccurredAt.UTC().Truncate(time.Microsecond)
+	event.RecordedAt = time.Now().UTC().Truncate(time.Microsecond)
+	if event.ActorRoles == nil {
+		event.ActorRoles = []string{}
+	}
+	if err := event.Seal(prev); err != nil {
+		return nil, err
+	}
+	changes, err := jsonObject(event.Changes)
+	if err != nil {
+		return nil, err
+	}
+	details, err := jsonObject(event.Details)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO audit_events (`+eventColumns+`)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+    `, event.Sequence, event.ID, event.OccurredAt, event.RecordedAt, event.Service, event.ActorID, event.ActorRoles,
+		event.Action, event.TargetType, event.TargetID, event.Outcome, changes, details, event.IP, event.UserAgent,
+		event.RequestID, event.PrevHash, event.Hash); err != nil {
+		return nil, err
+	}
+	return &event, tx.Commit(ctx)
+}
+
+// Query filters the audit trail. Empty fields match everything.
+type Query struct {
+	ActorID string
+	// Action matches exactly, or by prefix when it ends in "*" (booking.*).
+	Action     string
+	TargetType string
+	TargetID   string
+	Service    string
+	Outcome    string
+	RequestID  string
+	// Search matches a substring of the actor, target, IP or details, case-insensitively.
+	Search string
+	// From and To bound occurred_at: From inclusive, To exclusive.
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+func (q Query) filter() (string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	equal := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			where = append(where, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	equal("actor_id", q.ActorID)
+	equal("target_type", q.TargetType)
+	equal("target_id", q.TargetID)
+	equal("service", q.Service)
+	equal("outcome", q.Outcome)
+	equal("request_id", q.RequestID)
+	if prefix, ok := strings.CutSuffix(q.Action, "*"); ok {
+		args = append(args, prefix)
+		where = append(where, fmt.Sprintf("starts_with(action, $%d)", len(args)))
+	} else {
+		equal("action", q.Action)
+	}
+	if search := strings.TrimSpace(q.Search); search != "" {
+		args = append(args, "%"+escapeLike(strings.ToLower(search))+"%")
+		where = append(where, fmt.Sprintf(
+			"(LOWER(actor_id) LIKE $%[1]d OR LOWER(target_id) LIKE $%[1]d OR ip LIKE $%[1]d OR LOWER(details::text) LIKE $%[1]d)", len(args)))
+	}
+	if !q.From.IsZero() {
+		args = append(args, q.From)
+		where = append(where, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+	if !q.To.IsZero() {
+		args = append(args, q.To)
+		where = append(where, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+	if len(where) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(where, " AND "), args
+}
+
+// ListEvents returns one page of events matching query, newest first, and the total number of
+// matches.
+func (s *Store) ListEvents(ctx context.Context, query Query) ([]*audit.Event, int, error) {
+	filter, args := query.filter()
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM audit_events `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+        SELECT `+eventColumns+`
+        FROM audit_events
+        %s
+        ORDER BY sequence DESC
+        LIMIT $%d OFFSET $%d
+    `, filter, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	events, err := collectEvents(rows)
+	return events, total, err
+}
+
+// EachEvent calls fn for every event matching query (ignoring Limit and Offset), newest first,
+// stopping at the first error.
+func (s *Store) EachEvent(ctx context.Context, query Query, fn func(*audit.Event) error) error {
+	filter, args := query.filter()
+	rows, err := s.pool.Query(ctx, `SELECT `+eventColumns+` FROM audit_events `+filter+` ORDER BY sequence DESC`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// GetEvent fetches one event by id.
+func (s *Store) GetEvent(ctx context.Context, id uuid.UUID) (*audit.Event, error) {
+	return scanEvent(s.pool.QueryRow(ctx, `SELECT `+eventColumns+` FROM audit_events WHERE id = $1`, id))
+}
+
+// ChainPage returns up to limit events in chain order, starting after sequence after.
+func (s *Store) ChainPage(ctx context.Context, after int64, limit int) ([]audit.Event, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT `+eventColumns+`
+        FROM audit_events
+        WHERE sequence > $1
+        ORDER BY sequence
+        LIMIT $2
+    `, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	events, err := collectEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]audit.Event, 0, len(events))
+	for _, event := range events {
+		out = append(out, *event)
+	}
+	return out, nil
+}
+
+func collectEvents(rows pgx.Rows) ([]*audit.Event, error) {
+	defer rows.Close()
+	events := []*audit.Event{}
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+func scanEvent(row pgx.Row) (*audit.Event, error) {
+	var (
+		event            audit.Event
+		changes, details []byte
+	)
+	if err := row.Scan(&event.Sequence, &event.ID, &event.OccurredAt, &event.RecordedAt, &event.Service, &event.ActorID,
+		&event.ActorRoles, &event.Action, &event.TargetType, &event.TargetID, &event.Outcome, &changes, &details,
+		&event.IP, &event.UserAgent, &event.RequestID, &event.PrevHash, &event.Hash); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(changes, &event.Changes); err != nil {
+		return nil, fmt.Errorf("decode changes of event %d: %w", event.Sequence, err)
+	}
+	if err := json.Unmarshal(details, &event.Details); err != nil {
+		return nil, fmt.Errorf("decode details of event %d: %w", event.Sequence, err)
+	}
+	return &event, nil
+}
+
+// jsonObject encodes value for a JSONB column, with {} for an empty map.
+func jsonObject[M ~map[string]V, V any](value M) ([]byte, error) {
+	if len(value) == 0 {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(value)
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func buildDSN(cfg config.DatabaseConfig) (string, error) {
+	host := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
+	userInfo := url.UserPassword(cfg.User, cfg.Password)
+	dsn := url.URL{
+		Scheme: "postgres",
+		User:   userInfo,
+		Host:   host,
+		Path:   "/" + cfg.Name,
+	}
+	query := dsn.Query()
+	query.Set("sslmode", cfg.SSLMode)
+	dsn.RawQuery = query.Encode()
+	return dsn.String(), nil
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/services/auth-service/internal/notification"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+// emailRateLimit caps account emails and texts per address and flow.
+const (
+	emailRateLimit  = 3
+	emailRateWindow = time.Hour
+)
+
+type emailRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type changePasswordRequest struct {
+	// CurrentPassword may be empty for accounts created through social sign-in.
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password"`
+}
+
+type deleteAccountRequest struct {
+	// Password may be empty for accounts created through social sign-in.
+	Password string `json:"password"`
+}
+
+func registerAccountRoutes(group *gin.RouterGroup, h *handler) {
+	group.POST("/password/forgot", h.forgotPassword)
+	group.POST("/password/reset", h.resetPassword)
+	group.POST("/email/verify", h.verifyEmail)
+	group.POST("/email/resend", h.resendVerification)
+	group.POST("/email/change/confirm", h.confirmEmailChange)
+
+	authed := group.Group("", requireAccessToken(h.jwt, h.revoked))
+	authed.POST("/password/change", h.changePassword)
+	authed.POST("/email/change", h.changeEmail)
+	authed.POST("/account/deletion", h.requestDeletion)
+	authed.DELETE("/account/deletion", h.cancelDeletion)
+}
+
+// forgotPassword emails a reset link. The response is the same whether or not the address is
+// registered, so it cannot be used to enumerate accounts.
+func (h *handler) forgotPassword(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "A valid email is required", err.Error())
+		return
+	}
+	if !h.allowEmail(ctx, "password_forgot", req.Email) {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	token, user, err := h.users.CreateToken(timeoutCtx, req.Email, userclient.PurposePasswordReset)
+	if err != nil && !errors.Is(err, userclient.ErrUserNotFound) {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err == nil {
+		h.sendEmail(timeoutCtx, user.ID, "Reset your password",
+			fmt.Sprintf("Use this link within the next hour to choose a new password: %s. If you didn't ask for this, ignore this email.", h.link("/reset-password", token)))
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered, a reset link is on its way"})
+}
+
+// resetPassword sets a new password and signs the user out everywhere.
+func (h *handler) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "token and a password of at least 8 characters are required", err.Error())
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.ResetPassword(timeoutCtx, req.Token, req.Password)
+	if errors.Is(err, userclient.ErrInvalidToken) {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_token", "Reset link invalid, expired or already used", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	revoked, err := h.sessions.DeleteAll(timeoutCtx, user.ID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err := h.revoked.RevokeUser(timeoutCtx, user.ID); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	// Owning the mailbox is proof enough to lift a brute-force lockout.
+	if _, err := h.guard.Unlock(timeoutCtx, user.Email); err != nil {
+		h.logger.Error().Err(err).Msg("failed to clear lockout after password reset")
+	}
+	h.securityEvent(ctx, "password_reset", user.Email).Str("userId", user.ID).Int("revokedSessions", revoked).Msg("password reset")
+	h.recordAuthEvent(ctx, "auth.password.reset", audit.OutcomeSuccess, user.ID, user.Email, gin.H{"revokedSessions": revoked})
+	h.sendEmail(timeoutCtx, user.ID, "Your password was changed",
+		"Your password was just reset and every device was signed out. If this wasn't you, contact support immediately.")
+	ctx.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+func (h *handler) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "token is required", err.Error())
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.VerifyEmail(timeoutCtx, req.Token)
+	if errors.Is(err, userclient.ErrInvalidToken) {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_token", "Verification link invalid, expired or already used", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// resendVerification emails a fresh verification link, replacing any earlier one. Like
+// forgotPassword it answers the same for unknown and already verified addresses.
+func (h *handler) resendVerification(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "A valid email is required", err.Error())
+		return
+	}
+	if !h.allowEmail(ctx, "email_verify", req.Email) {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if err := h.sendVerification(timeoutCtx, req.Email); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"message": "If the email is registered and unverified, a verification link is on its way"})
+}
+
+// changePassword replaces a signed-in user's password. Every other session is signed out; the
+// one making the change stays signed in.
+func (h *handler) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "A new password of at least 8 characters is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+	// Guessing the current password with a stolen access token is capped like any other flow.
+	if !h.allowAddress(ctx, "password_change", claims.UserID, "Too many password changes; try again later") {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.ChangePassword(timeoutCtx, claims.UserID, req.CurrentPassword, req.NewPassword)
+	if errors.Is(err, userclient.ErrInvalidCredentials) {
+		h.securityEvent(ctx, "password_change_failed", "").Str("userId", claims.UserID).Msg("wrong current password")
+		h.recordAuthEvent(ctx, "auth.password.change", audit.OutcomeFailure, claims.UserID, "", gin.H{"reason": loginFailedCredentials})
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_credentials", "Current password is incorrect", nil)
+		return
+	}
+	if errors.Is(err, userclient.ErrPasswordNotSet) {
+		writePasswordNotSet(ctx)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	revoked, err := h.signOutOtherSessions(timeoutCtx, user.ID, claims.SessionID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "password_changed", user.Email).Str("userId", user.ID).Int("revokedSessions", revoked).Msg("password changed")
+	h.recordAuthEvent(ctx, "auth.password.change", audit.OutcomeSuccess, user.ID, user.Email, gin.H{"revokedSessions": revoked})
+	h.sendEmail(timeoutCtx, user.ID, "Your password was changed",
+		"Your password was just changed and your other devices were signed out. If this wasn't you, reset your password and contact support immediately.")
+	ctx.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+// changeEmail starts an email change: the new address gets a confirmation link and the current
+// one a heads-up. The email on the account only changes once the link is followed.
+func (h *handler) changeEmail(ctx *gin.Context) {
+	var req changeEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "A valid email is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+	if !h.allowAddress(ctx, "email_change", claims.UserID, "Too many email changes requested; try again later") {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	token, user, err := h.users.RequestEmailChange(timeoutCtx, claims.UserID, req.Email, req.Password)
+	switch {
+	case errors.Is(err, userclient.ErrInvalidCredentials):
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_credentials", "Password is incorrect", nil)
+		return
+	case errors.Is(err, userclient.ErrPasswordNotSet):
+		writePasswordNotSet(ctx)
+		return
+	case errors.Is(err, userclient.ErrEmailTaken):
+		errutil.Write(ctx, http.StatusConflict, "email_exists", "Email already registered", nil)
+		return
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	payload := notification.NotifyPayload{
+		UserID:  user.ID,
+		Title:   "Confirm your new email",
+		Message: fmt.Sprintf("Confirm %s as your new email address by opening %s. The link is valid for 24 hours.", req.Email, h.link("/confirm-email-change", token)),
+		Channel: "email",
+		To:      req.Email,
+	}
+	if err := h.notify.Send(timeoutCtx, payload); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.sendEmail(timeoutCtx, user.ID, "Email change requested",
+		fmt.Sprintf("Someone asked to change your account email to %s. Nothing changes until that address is confirmed. If this wasn't you, change your password.", req.Email))
+	h.securityEvent(ctx, "email_change_requested", user.Email).Str("userId", user.ID).Msg("email change requested")
+	ctx.JSON(http.StatusAccepted, gin.H{"pendingEmail": req.Email})
+}
+
+func (h *handler) confirmEmailChange(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "token is required", err.Error())
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.ConfirmEmailChange(timeoutCtx, req.Token)
+	switch {
+	case errors.Is(err, userclient.ErrInvalidToken):
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_token", "Confirmation link invalid, expired or already used", nil)
+		return
+	case errors.Is(err, userclient.ErrEmailTaken):
+		errutil.Write(ctx, http.StatusConflict, "email_exists", "Email already registered", nil)
+		return
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "email_changed", user.Email).Str("userId", user.ID).Msg("email change confirmed")
+	h.recordAuthEvent(ctx, "auth.email.change", audit.OutcomeSuccess, user.ID, user.Email, nil)
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// requestDeletion schedules deletion of the signed-in user's account. It runs after the cooling-off
+// period; the user stays signed in until then so they can change their mind.
+func (h *handler) requestDeletion(ctx *gin.Context) {
+	var req deleteAccountRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "password is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+	if !h.allowAddress(ctx, "account_deletion", claims.UserID, "Too many deletion requests; try again later") {
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.ScheduleDeletion(timeoutCtx, claims.UserID, req.Password)
+	switch {
+	case errors.Is(err, userclient.ErrInvalidCredentials):
+		h.securityEvent(ctx, "account_deletion_failed", "").Str("userId", claims.UserID).Msg("wrong password")
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_credentials", "Password is incorrect", nil)
+		return
+	case errors.Is(err, userclient.ErrPasswordNotSet):
+		writePasswordNotSet(ctx)
+		return
+	case errors.Is(err, userclient.ErrUserNotFound):
+		errutil.Write(ctx, http.StatusNotFound, "user_not_found", "Account not found", nil)
+		return
+	case errors.Is(err, userclient.ErrErasureInProgress):
+		errutil.Write(ctx, http.StatusConflict, "erasure_in_progress", "Your account is already being deleted", nil)
+		return
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if user.DeletionScheduledAt == nil {
+		errutil.HandleInternal(ctx, errors.New("user service did not schedule the deletion"))
+		return
+	}
+
+	when := user.DeletionScheduledAt.UTC().Format("January 2, 2006")
+	h.securityEvent(ctx, "account_deletion_requested", user.Email).Str("userId", user.ID).Time("scheduledAt", *user.DeletionScheduledAt).Msg("account deletion requested")
+	h.sendEmail(timeoutCtx, user.ID, "Your account will be deleted",
+		fmt.Sprintf("You asked us to delete your account. It and the personal information we hold about you will be deleted on %s. Until then you can cancel from your account settings. If this wasn't you, change your password and cancel the deletion.", when))
+	ctx.JSON(http.StatusAccepted, gin.H{"deletionScheduledAt": user.DeletionScheduledAt.Format(time.RFC3339)})
+}
+
+// cancelDeletion cancels a pending deletion of the signed-in user's account.
+func (h *handler) cancelDeletion(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.CancelDeletion(timeoutCtx, claims.UserID)
+	switch {
+	case errors.Is(err, userclient.ErrUserNotFound):
+		errutil.Write(ctx, http.StatusNotFound, "user_not_found", "Account not found", nil)
+		return
+	case errors.Is(err, userclient.ErrErasureInProgress):
+		errutil.Write(ctx, http.StatusConflict, "erasure_in_progress", "Your account is already being deleted", nil)
+		return
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "account_deletion_cancelled", user.Email).Str("userId", user.ID).Msg("account deletion cancelled")
+	h.sendEmail(timeoutCtx, user.ID, "Account deletion cancelled",
+		"Your account is no longer scheduled for deletion.")
+	ctx.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// signOutOtherSessions ends every session of userID except keep and revokes their access tokens.
+func (h *handler) signOutOtherSessions(ctx context.Context, userID, keep string) (int, error) {
+	list, err := h.sessions.List(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, sess := range list {
+		if sess.ID == keep {
+			continue
+		}
+		deleted, err := h.sessions.Delete(ctx, userID, sess.ID)
+		if errors.Is(err, session.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return revoked, err
+		}
+		if err := h.revoked.RevokeToken(ctx, deleted.AccessTokenID, deleted.AccessExpiresAt); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// sendVerification emails a verification link to email. Unknown and verified addresses are
+// silently skipped.
+func (h *handler) sendVerification(ctx context.Context, email string) error {
+	token, user, err := h.users.CreateToken(ctx, email, userclient.PurposeEmailVerify)
+	if errors.Is(err, userclient.ErrUserNotFound) || errors.Is(err, userclient.ErrAlreadyVerified) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	h.sendEmail(ctx, user.ID, "Verify your email",
+		fmt.Sprintf("Confirm your email address by opening %s. The link is valid for 48 hours.", h.link("/verify-email", token)))
+	return nil
+}
+
+// allowEmail applies the per-address rate limit for flow, writing a 429 when it is exceeded.
+func (h *handler) allowEmail(ctx *gin.Context, flow, email string) bool {
+	return h.allowAddress(ctx, flow, email, "Too many emails requested for this address; try again later")
+}
+
+// allowSMS is allowEmail for texts to a phone number.
+func (h *handler) allowSMS(ctx *gin.Context, flow, phone string) bool {
+	return h.allowAddress(ctx, flow, phone, "Too many codes requested for this number; try again later")
+}
+
+// allowAddress rate-limits flow for an email address or phone number. Addresses are hashed so
+// the Redis keys do not hold them in plain text.
+func (h *handler) allowAddress(ctx *gin.Context, flow, address, message string) bool {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(address))))
+	key := flow + ":" + hex.EncodeToString(sum[:])
+	ok, retryAfter, err := h.limiter.Allow(ctx.Request.Context(), key, emailRateLimit, emailRateWindow)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return false
+	}
+	if !ok {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		errutil.Write(ctx, http.StatusTooManyRequests, "rate_limited", message, nil)
+		return false
+	}
+	return true
+}
+
+func (h *handler) sendEmail(ctx context.Context, userID, title, message string) {
+	payload := notification.NotifyPayload{UserID: userID, Title: title, Message: message, Channel: "email"}
+	if err := h.notify.Send(ctx, payload); err != nil {
+		h.logger.Error().Err(err).Str("userId", userID).Str("title", title).Msg("failed to send account email")
+	}
+}
+
+// link builds a web app URL carrying token.
+func (h *handler) link(path, token string) string {
+	return h.webURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// writePasswordNotSet answers account changes from imported members who have not chosen a
+// password yet; until they do there is nothing to re-authenticate them with.
+func writePasswordNotSet(ctx *gin.Context) {
+	errutil.Write(ctx, http.StatusForbidden, "password_not_set", "Choose a password from your invitation email or with Forgot password first", nil)
+}
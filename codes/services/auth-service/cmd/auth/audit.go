@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/jwtutil"
+)
+
+// Reasons recorded on failed sign-ins.
+const (
+	loginFailedCredentials = "invalid_credentials"
+	loginFailedCaptcha     = "captcha_failed"
+	loginFailedDisabled    = "account_disabled"
+	loginFailedUnverified  = "email_not_verified"
+	loginFailedMFA         = "mfa_failed"
+	loginFailedOIDC        = "oidc_rejected"
+	loginFailedCode        = "wrong_code"
+)
+
+// recordAuthEvent adds a sign-in or account-security event to the audit trail. The target is
+// the account: userID when known, otherwise the email the attempt named. The actor is the
+// signed-in caller when the route requires a token (an admin unlocking an account), otherwise
+// the account itself.
+func (h *handler) recordAuthEvent(ctx *gin.Context, action, outcome, userID, email string, details gin.H) {
+	event := audit.FromRequest(ctx, action, "user", userID)
+	event.Outcome = outcome
+	email = strings.ToLower(strings.TrimSpace(email))
+	if userID == "" {
+		event.TargetType, event.TargetID = "email", email
+	}
+	event.ActorID, event.ActorRoles = userID, nil
+	if value, ok := ctx.Get(claimsContextKey); ok {
+		if claims, ok := value.(*jwtutil.Claims); ok && claims != nil {
+			event.ActorID, event.ActorRoles = claims.UserID, claims.Roles
+		}
+	}
+	if email != "" {
+		if details == nil {
+			details = gin.H{}
+		}
+		details["email"] = email
+	}
+	event.Details = details
+	h.audit.Record(event)
+}
+
+// recordLoginFailed records a sign-in attempt that was refused for reason.
+func (h *handler) recordLoginFailed(ctx *gin.Context, userID, email, reason string, details gin.H) {
+	if details == nil {
+		details = gin.H{}
+	}
+	details["reason"] = reason
+	h.recordAuthEvent(ctx, "auth.login", audit.OutcomeFailure, userID, email, details)
+}
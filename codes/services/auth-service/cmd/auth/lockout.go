@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/services/auth-service/internal/lockout"
+)
+
+func registerAdminRoutes(group *gin.RouterGroup, h *handler) {
+	admin := group.Group("/admin", requireAccessToken(h.jwt, h.revoked), requirePermission(authz.UserUnlock))
+	admin.POST("/unlock", h.unlockAccount)
+}
+
+// checkLoginAllowed consults the lockout guard and the CAPTCHA before a password is checked,
+// writing the rejection itself when the attempt may not proceed.
+func (h *handler) checkLoginAllowed(ctx *gin.Context, reqCtx context.Context, email, captchaToken string) bool {
+	status, err := h.guard.Check(reqCtx, email, ctx.ClientIP())
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return false
+	}
+	if status.Blocked {
+		h.securityEvent(ctx, "login_blocked", email).Str("reason", status.Reason).Msg("login attempt rejected by lockout")
+		h.recordLoginFailed(ctx, "", email, status.Reason, nil)
+		writeLockout(ctx, status)
+		return false
+	}
+	if status.CaptchaRequired && h.captcha != nil {
+		ok, err := h.captcha.Verify(reqCtx, captchaToken, ctx.ClientIP())
+		if err != nil {
+			errutil.HandleInternal(ctx, err)
+			return false
+		}
+		if !ok {
+			h.securityEvent(ctx, "captcha_failed", email).Msg("login attempt without a valid CAPTCHA")
+			h.recordLoginFailed(ctx, "", email, loginFailedCaptcha, nil)
+			errutil.Write(ctx, http.StatusUnauthorized, "captcha_required", "Solve the CAPTCHA to continue", lockoutDetails(status))
+			return false
+		}
+	}
+	return true
+}
+
+// recordLoginFailure counts a wrong password and writes the response.
+func (h *handler) recordLoginFailure(ctx *gin.Context, reqCtx context.Context, email string) {
+	status, err := h.guard.Fail(reqCtx, email, ctx.ClientIP())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to record login failure")
+	}
+	h.securityEvent(ctx, "login_failed", email).Int("failures", status.Failures).Msg("login failed")
+	h.recordLoginFailed(ctx, "", email, loginFailedCredentials, gin.H{"failures": status.Failures})
+	if status.Blocked {
+		if status.Reason == lockout.ReasonAccountLocked {
+			h.securityEvent(ctx, "account_locked", email).Dur("lockedFor", status.RetryAfter).Msg("account locked after repeated login failures")
+			h.recordAuthEvent(ctx, "auth.account.lock", audit.OutcomeSuccess, "", email, gin.H{"lockedForSeconds": int(status.RetryAfter.Seconds())})
+		}
+		writeLockout(ctx, status)
+		return
+	}
+	if status.RetryAfter > 0 {
+		ctx.Header("Retry-After", retryAfterSeconds(status.RetryAfter))
+	}
+	errutil.Write(ctx, http.StatusUnauthorized, "invalid_credentials", "Email or password incorrect", lockoutDetails(status))
+}
+
+func (h *handler) unlockAccount(ctx *gin.Context) {
+	var req emailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "A valid email is required", err.Error())
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	wasLocked, err := h.guard.Unlock(timeoutCtx, req.Email)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "account_unlocked", req.Email).Str("adminId", claimsFromContext(ctx).UserID).Bool("wasLocked", wasLocked).Msg("account unlocked by admin")
+	h.recordAuthEvent(ctx, "auth.account.unlock", audit.OutcomeSuccess, "", req.Email, gin.H{"wasLocked": wasLocked})
+	ctx.JSON(http.StatusOK, gin.H{"unlocked": wasLocked})
+}
+
+// securityEvent starts a log entry for the security audit trail. Every entry carries
+// category=security so it can be routed to audit storage.
+func (h *handler) securityEvent(ctx *gin.Context, event, email string) *zerolog.Event {
+	evt := h.logger.Warn().
+		Str("category", "security").
+		Str("event", event).
+		Str("ip", ctx.ClientIP()).
+		Str("userAgent", ctx.Request.UserAgent())
+	if email != "" {
+		evt = evt.Str("email", strings.ToLower(strings.TrimSpace(email)))
+	}
+	return evt
+}
+
+func writeLockout(ctx *gin.Context, status lockout.Status) {
+	ctx.Header("Retry-After", retryAfterSeconds(status.RetryAfter))
+	switch status.Reason {
+	case lockout.ReasonAccountLocked:
+		errutil.Write(ctx, http.StatusLocked, "account_locked", "Too many failed sign-in attempts; the account is temporarily locked", lockoutDetails(status))
+	default:
+		errutil.Write(ctx, http.StatusTooManyRequests, "too_many_attempts", "Too many sign-in attempts; wait before trying again", lockoutDetails(status))
+	}
+}
+
+func lockoutDetails(status lockout.Status) gin.H {
+	details := gin.H{"captchaRequired": status.CaptchaRequired}
+	if status.RetryAfter > 0 {
+		details["retryAfter"] = int(math.Ceil(status.RetryAfter.Seconds()))
+	}
+	return details
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// requirePermission rejects requests whose access token lacks permission. It must follow
+// requireAccessToken.
+func requirePermission(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		for _, p := range claimsFromContext(ctx).Permissions {
+			if p == permission {
+				ctx.Next()
+				return
+			}
+		}
+		errutil.Write(ctx, http.StatusForbidden, "forbidden", permission+" permission required", nil)
+	}
+}
@@ -2,15 +2,27 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
 
 	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/audit"
 	"github.com/venue-master/platform/lib/errutil"
 	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/services/auth-service/internal/captcha"
+	"github.com/venue-master/platform/services/auth-service/internal/lockout"
+	"github.com/venue-master/platform/services/auth-service/internal/notification"
+	"github.com/venue-master/platform/services/auth-service/internal/oidc"
+	"github.com/venue-master/platform/services/auth-service/internal/ratelimit"
 	"github.com/venue-master/platform/services/auth-service/internal/session"
 	"github.com/venue-master/platform/services/auth-service/internal/userclient"
 )
@@ -18,6 +30,9 @@ import (
 type loginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
+	Device   string `json:"device"`
+	// CaptchaToken is required once the lockout guard asks for a CAPTCHA.
+	CaptchaToken string `json:"captchaToken"`
 }
 
 type registerRequest struct {
@@ -26,154 +41,363 @@ type registerRequest struct {
 	FirstName string `json:"firstName" binding:"required"`
 	LastName  string `json:"lastName" binding:"required"`
 	Phone     string `json:"phone"`
+	Device    string `json:"device"`
 }
 
 type refreshRequest struct {
 	RefreshToken string `json:"refreshToken" binding:"required"`
 }
 
+type handler struct {
+	jwt      *jwtutil.Manager
+	users    *userclient.Client
+	sessions *session.Store
+	revoked  *revocation.Store
+	notify   *notification.Client
+	limiter  *ratelimit.Limiter
+	guard    *lockout.Guard
+	captcha  *captcha.Verifier
+	audit    *audit.Client
+	logger   zerolog.Logger
+	// providers are the configured OpenID providers by name.
+	providers map[string]*oidc.Provider
+
+	// webURL is the web app base URL emailed links point at.
+	webURL string
+	// requireVerified rejects logins until the email address is verified.
+	requireVerified bool
+	// mfaRoles are the (upper-case) roles that must sign in with a second factor.
+	mfaRoles map[string]bool
+	// phoneCountry is the calling code national phone numbers are read in.
+	phoneCountry string
+}
+
 func main() {
 	srv, err := server.New("auth-service")
 	if err != nil {
 		panic(err)
 	}
 
-	jwtManager := jwtutil.NewManager(srv.Config.JWT)
+	jwtManager, err := jwtutil.NewManager(srv.Config.JWT)
+	if err != nil {
+		panic(err)
+	}
 	sessions, err := session.NewStore(srv.Config.Redis, jwtManager.RefreshTTL())
 	if err != nil {
 		panic(err)
 	}
 
-	userServiceURL := os.Getenv("USER_SERVICE_URL")
-	if userServiceURL == "" {
-		userServiceURL = "http://user-service:8080"
-	}
-	users := userclient.New(userServiceURL)
+	h := &handler{
+		jwt:      jwtManager,
+		users:    userclient.New(getEnv("USER_SERVICE_URL", "http://user-service:8080"), srv.ServiceAuth.Transport(nil)),
+		sessions: sessions,
+		revoked:  revocation.New(srv.Config.Redis, jwtManager.AccessTTL()),
+		notify:   notification.New(getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"), srv.ServiceAuth.Transport(nil)),
+		limiter:  ratelimit.New(srv.Config.Redis),
+		guard:    lockout.New(srv.Config.Redis, loginPolicy()),
+		captcha:  captcha.New(os.Getenv("CAPTCHA_SECRET"), getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify")),
+		audit:    audit.New("auth-service", getEnv("AUDIT_SERVICE_URL", "http://audit-service:8080"), srv.ServiceAuth.Transport(nil), srv.Logger),
+		logger:   srv.Logger,
 
-	registerRoutes(srv.Engine, jwtManager, users, sessions)
+		providers: loadOIDCProviders(srv.Logger),
+
+		webURL:          strings.TrimRight(getEnv("WEB_APP_URL", "http://localhost:3000"), "/"),
+		requireVerified: getEnvAsBool("REQUIRE_EMAIL_VERIFIED", false),
+		mfaRoles:        roleSet(getEnv("MFA_REQUIRED_ROLES", "ADMIN,VENUE_ADMIN")),
+		phoneCountry:    getEnv("PHONE_DEFAULT_COUNTRY_CODE", "1"),
+	}
+	registerRoutes(srv.Engine, h)
 
 	if err := srv.Run(); err != nil {
 		panic(err)
 	}
 }
 
-func registerRoutes(router *gin.Engine, jwtManager *jwtutil.Manager, users *userclient.Client, sessions *session.Store) {
+func registerRoutes(router *gin.Engine, h *handler) {
+	router.GET("/.well-known/jwks.json", h.jwks)
+
 	group := router.Group("/v1/auth")
-	group.POST("/login", func(ctx *gin.Context) {
-		var req loginRequest
-		if err := ctx.ShouldBindJSON(&req); err != nil {
-			errutil.Write(ctx, http.StatusBadRequest, "invalid_credentials", "Email and password are required", err.Error())
-			return
-		}
+	group.POST("/login", h.login)
+	group.POST("/register", h.register)
+	group.POST("/refresh", h.refresh)
 
-		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
-		defer cancel()
+	registerSessionRoutes(group, h)
+	registerAccountRoutes(group, h)
+	registerAdminRoutes(group, h)
+	registerMFARoutes(group, h)
+	registerOIDCRoutes(group, h)
+	registerPasswordlessRoutes(group, h)
+	registerOAuthRoutes(router, h)
+}
 
-		user, err := users.Authenticate(timeoutCtx, req.Email, req.Password)
-		if err != nil {
-			errutil.Write(ctx, http.StatusUnauthorized, "invalid_credentials", "Email or password incorrect", nil)
-			return
-		}
+func (h *handler) login(ctx *gin.Context) {
+	var req loginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_credentials", "Email and password are required", err.Error())
+		return
+	}
 
-		access, refresh, err := jwtManager.Generate(user.ID, user.Roles, nil)
-		if err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
-		}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
 
-		if err := sessions.Save(timeoutCtx, user.ID, refresh); err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
-		}
+	if !h.checkLoginAllowed(ctx, timeoutCtx, req.Email, req.CaptchaToken) {
+		return
+	}
+
+	user, err := h.users.Authenticate(timeoutCtx, req.Email, req.Password)
+	if errors.Is(err, userclient.ErrInvalidCredentials) {
+		h.recordLoginFailure(ctx, timeoutCtx, req.Email)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if h.requireVerified && !user.EmailVerified {
+		h.recordLoginFailed(ctx, user.ID, user.Email, loginFailedUnverified, nil)
+		errutil.Write(ctx, http.StatusForbidden, "email_not_verified", "Verify your email address before signing in", nil)
+		return
+	}
+
+	h.completeLogin(ctx, timeoutCtx, user, req.Device, jwtutil.AMRPassword)
+}
+
+// completeLogin answers a successful first factor: with an MFA challenge when the user needs a
+// second factor, otherwise with the tokens of a new session. Login failures are only cleared
+// once tokens are issued, so wrong MFA codes keep counting towards the lockout across challenges.
+func (h *handler) completeLogin(ctx *gin.Context, reqCtx context.Context, user *userclient.User, device, factor string) {
+	if h.rejectDisabled(ctx, user) {
+		return
+	}
+	if user.MFAEnabled || h.requiresMFA(user) {
+		h.startMFAChallenge(ctx, reqCtx, user, device, factor)
+		return
+	}
+
+	access, refresh, err := h.issueSession(ctx, reqCtx, user, device, []string{factor})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err := h.guard.Succeed(reqCtx, user.Email); err != nil {
+		h.logger.Error().Err(err).Msg("failed to clear login failures")
+	}
 
-		ctx.JSON(http.StatusOK, gin.H{
-			"accessToken":  access,
-			"refreshToken": refresh,
-			"expiresIn":    int(jwtManager.AccessTTL().Seconds()),
-			"user":         user,
-		})
+	ctx.JSON(http.StatusOK, gin.H{
+		"accessToken":  access.Token,
+		"refreshToken": refresh,
+		"expiresIn":    int(h.jwt.AccessTTL().Seconds()),
+		"user":         user,
 	})
+}
 
-	group.POST("/register", func(ctx *gin.Context) {
-		var req registerRequest
-		if err := ctx.ShouldBindJSON(&req); err != nil {
-			errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "All fields are required", err.Error())
-			return
-		}
+// rejectDisabled answers 403 when an admin has disabled user's account.
+func (h *handler) rejectDisabled(ctx *gin.Context, user *userclient.User) bool {
+	if !user.Disabled {
+		return false
+	}
+	h.securityEvent(ctx, "login_disabled_account", user.Email).Str("userId", user.ID).Msg("sign-in to disabled account refused")
+	h.recordLoginFailed(ctx, user.ID, user.Email, loginFailedDisabled, nil)
+	errutil.Write(ctx, http.StatusForbidden, "account_disabled", "This account has been disabled", nil)
+	return true
+}
 
-		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
-		defer cancel()
+func (h *handler) register(ctx *gin.Context) {
+	var req registerRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "All fields are required", err.Error())
+		return
+	}
 
-		user, err := users.Register(timeoutCtx, req.Email, req.Password, req.FirstName, req.LastName, req.Phone)
-		if err != nil {
-			if err.Error() == "email already registered" {
-				errutil.Write(ctx, http.StatusConflict, "email_exists", "Email already registered", nil)
-				return
-			}
-			errutil.Write(ctx, http.StatusBadRequest, "registration_failed", "Failed to register user", err.Error())
+	if req.Phone != "" {
+		number, ok := h.normalizePhone(ctx, req.Phone)
+		if !ok {
 			return
 		}
+		req.Phone = number
+	}
 
-		access, refresh, err := jwtManager.Generate(user.ID, user.Roles, nil)
-		if err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
-		}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
 
-		if err := sessions.Save(timeoutCtx, user.ID, refresh); err != nil {
-			errutil.HandleInternal(ctx, err)
+	user, err := h.users.Register(timeoutCtx, req.Email, req.Password, req.FirstName, req.LastName, req.Phone)
+	if err != nil {
+		if err.Error() == "email already registered" {
+			errutil.Write(ctx, http.StatusConflict, "email_exists", "Email already registered", nil)
 			return
 		}
+		errutil.Write(ctx, http.StatusBadRequest, "registration_failed", "Failed to register user", err.Error())
+		return
+	}
 
-		ctx.JSON(http.StatusCreated, gin.H{
-			"accessToken":  access,
-			"refreshToken": refresh,
-			"expiresIn":    int(jwtManager.AccessTTL().Seconds()),
-			"user":         user,
-		})
+	if err := h.sendVerification(timeoutCtx, user.Email); err != nil {
+		h.logger.Error().Err(err).Str("userId", user.ID).Msg("failed to send verification email")
+	}
+	if h.requireVerified {
+		ctx.JSON(http.StatusCreated, gin.H{"user": user, "verificationRequired": true})
+		return
+	}
+
+	access, refresh, err := h.issueSession(ctx, timeoutCtx, user, req.Device, []string{jwtutil.AMRPassword})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"accessToken":  access.Token,
+		"refreshToken": refresh,
+		"expiresIn":    int(h.jwt.AccessTTL().Seconds()),
+		"user":         user,
 	})
+}
 
-	group.POST("/refresh", func(ctx *gin.Context) {
-		var req refreshRequest
-		if err := ctx.ShouldBindJSON(&req); err != nil {
-			errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "refreshToken is required", err.Error())
-			return
-		}
+func (h *handler) refresh(ctx *gin.Context) {
+	var req refreshRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "refreshToken is required", err.Error())
+		return
+	}
 
-		claims, err := jwtManager.Validate(req.RefreshToken)
-		if err != nil {
-			errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token invalid", err.Error())
-			return
-		}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
 
-		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
-		defer cancel()
+	parent, err := h.sessions.ConsumeRefresh(timeoutCtx, req.RefreshToken)
+	if errors.Is(err, session.ErrRefreshReused) {
+		h.revokeFamily(timeoutCtx, parent)
+		h.recordAuthEvent(ctx, "auth.refresh_token.reuse", audit.OutcomeFailure, parent.UserID, "", gin.H{"sessionId": parent.SessionID})
+		errutil.Write(ctx, http.StatusUnauthorized, "refresh_token_reused", "Refresh token already used; the session has been signed out", nil)
+		return
+	}
+	if errors.Is(err, session.ErrNotFound) {
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token invalid or expired", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
 
-		exists, err := sessions.Exists(timeoutCtx, claims.UserID, req.RefreshToken)
-		if err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
-		}
-		if !exists {
+	// Reload the user so role and permission changes since the last refresh end up in the new access token.
+	user, err := h.users.GetUser(timeoutCtx, parent.UserID)
+	if errors.Is(err, userclient.ErrUserNotFound) {
+		_, _ = h.sessions.Delete(timeoutCtx, parent.UserID, parent.SessionID)
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "User no longer exists", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if user.Disabled {
+		_, _ = h.sessions.Delete(timeoutCtx, parent.UserID, parent.SessionID)
+		errutil.Write(ctx, http.StatusForbidden, "account_disabled", "This account has been disabled", nil)
+		return
+	}
+
+	sess, err := h.sessions.Get(timeoutCtx, parent.UserID, parent.SessionID)
+	if errors.Is(err, session.ErrNotFound) {
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token revoked", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	access, err := h.jwt.Issue(user.ID, parent.SessionID, user.Roles, user.Permissions, user.VenuePermissions, sess.AMR)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	refresh, err := session.NewRefreshToken()
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	if err := h.sessions.Rotate(timeoutCtx, parent, req.RefreshToken, refresh, access.ID, access.ExpiresAt); err != nil {
+		if errors.Is(err, session.ErrNotFound) {
 			errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token revoked", nil)
 			return
 		}
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.touchActivity(timeoutCtx, user.ID)
 
-		access, newRefresh, err := jwtManager.Refresh(req.RefreshToken)
-		if err != nil {
-			errutil.Write(ctx, http.StatusUnauthorized, "invalid_refresh_token", "Refresh token invalid", err.Error())
-			return
-		}
+	ctx.JSON(http.StatusOK, gin.H{"accessToken": access.Token, "refreshToken": refresh})
+}
 
-		if err := sessions.Delete(timeoutCtx, claims.UserID, req.RefreshToken); err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
-		}
-		if err := sessions.Save(timeoutCtx, claims.UserID, newRefresh); err != nil {
-			errutil.HandleInternal(ctx, err)
-			return
+// jwks publishes the public keys access tokens are signed with, for services that verify them.
+func (h *handler) jwks(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, h.jwt.JWKS())
+}
+
+// revokeFamily signs out the session a reused refresh token belongs to and warns the user.
+// Only the first reuse alerts; later replays find the session already gone.
+func (h *handler) revokeFamily(ctx context.Context, token *session.RefreshToken) {
+	sess, err := h.sessions.Delete(ctx, token.UserID, token.SessionID)
+	if errors.Is(err, session.ErrNotFound) {
+		return
+	}
+	if err != nil {
+		h.logger.Error().Err(err).Str("sessionId", token.SessionID).Msg("failed to revoke refresh token family")
+		return
+	}
+	if err := h.revoked.RevokeToken(ctx, sess.AccessTokenID, sess.AccessExpiresAt); err != nil {
+		h.logger.Error().Err(err).Str("sessionId", sess.ID).Msg("failed to revoke access token of reused family")
+	}
+	h.logger.Warn().Str("userId", sess.UserID).Str("sessionId", sess.ID).Str("ip", sess.IP).Msg("refresh token reuse detected; session revoked")
+
+	device := sess.Device
+	if device == "" {
+		device = "a signed-in device"
+	}
+	payload := notification.NotifyPayload{
+		UserID:  sess.UserID,
+		Title:   "Suspicious sign-in activity",
+		Message: fmt.Sprintf("An old sign-in token for %s was used again, so we signed that device out. If this wasn't you, change your password.", device),
+		Channel: "email",
+	}
+	if err := h.notify.Send(ctx, payload); err != nil {
+		h.logger.Error().Err(err).Msg("failed to send refresh token reuse alert")
+	}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// loginPolicy returns the lockout policy, with the lock threshold and duration overridable.
+func loginPolicy() lockout.Policy {
+	policy := lockout.DefaultPolicy()
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_LOCK_AFTER")); err == nil && n > 0 {
+		policy.LockAfter = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("LOGIN_LOCK_MINUTES")); err == nil && n > 0 {
+		policy.LockDuration = time.Duration(n) * time.Minute
+	}
+	return policy
+}
+
+func roleSet(csv string) map[string]bool {
+	roles := make(map[string]bool)
+	for _, role := range strings.Split(csv, ",") {
+		if role = strings.ToUpper(strings.TrimSpace(role)); role != "" {
+			roles[role] = true
 		}
+	}
+	return roles
+}
 
-		ctx.JSON(http.StatusOK, gin.H{"accessToken": access, "refreshToken": newRefresh})
-	})
+func getEnvAsBool(key string, fallback bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return fallback
 }
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+type mfaLoginRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type mfaTokenRequest struct {
+	MFAToken string `json:"mfaToken" binding:"required"`
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func registerMFARoutes(group *gin.RouterGroup, h *handler) {
+	group.POST("/login/mfa", h.loginMFA)
+	group.POST("/login/mfa/enroll", h.loginMFAEnroll)
+
+	authed := group.Group("/mfa", requireAccessToken(h.jwt, h.revoked))
+	authed.POST("/enroll", h.enrollMFA)
+	authed.POST("/confirm", h.confirmMFA)
+	authed.POST("/recovery-codes", h.regenerateRecoveryCodes)
+	authed.POST("/disable", h.disableMFA)
+}
+
+// requiresMFA reports whether any of the user's roles, global or venue-scoped, must sign in
+// with a second factor.
+func (h *handler) requiresMFA(user *userclient.User) bool {
+	for _, role := range user.Roles {
+		if h.mfaRoles[strings.ToUpper(role)] {
+			return true
+		}
+	}
+	for _, assignment := range user.VenueRoles {
+		if h.mfaRoles[strings.ToUpper(assignment.Role)] {
+			return true
+		}
+	}
+	return false
+}
+
+// startMFAChallenge answers a successful first factor (a password or federated login) with a
+// challenge instead of tokens.
+func (h *handler) startMFAChallenge(ctx *gin.Context, reqCtx context.Context, user *userclient.User, device, factor string) {
+	token, err := h.sessions.CreateChallenge(reqCtx, session.Challenge{
+		UserID:   user.ID,
+		Email:    user.Email,
+		Device:   deviceLabel(device),
+		Enrolled: user.MFAEnabled,
+		Factor:   factor,
+	})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"mfaRequired":        true,
+		"mfaToken":           token,
+		"enrollmentRequired": !user.MFAEnabled,
+		"expiresIn":          int(session.ChallengeTTL.Seconds()),
+	})
+}
+
+// loginMFA completes a challenge with a TOTP or recovery code. For a user enrolling during the
+// challenge, the code confirms the new authenticator and the recovery codes are returned once.
+func (h *handler) loginMFA(ctx *gin.Context) {
+	var req mfaLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "mfaToken and code are required", err.Error())
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	challenge, ok := h.loadChallenge(ctx, timeoutCtx, req.MFAToken)
+	if !ok {
+		return
+	}
+	status, err := h.guard.Check(timeoutCtx, challenge.Email, ctx.ClientIP())
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if status.Blocked {
+		h.securityEvent(ctx, "login_blocked", challenge.Email).Str("reason", status.Reason).Msg("mfa attempt rejected by lockout")
+		h.recordLoginFailed(ctx, challenge.UserID, challenge.Email, status.Reason, nil)
+		writeLockout(ctx, status)
+		return
+	}
+
+	factor := challenge.Factor
+	if factor == "" {
+		factor = jwtutil.AMRPassword
+	}
+	amr := []string{factor, jwtutil.AMROTP, jwtutil.AMRMFA}
+	var recoveryCodes []string
+	method := "otp"
+	if challenge.Enrolled {
+		method, err = h.users.VerifyMFA(timeoutCtx, challenge.UserID, req.Code)
+		if method == "recovery" {
+			amr = []string{factor, jwtutil.AMRMFA}
+		}
+	} else {
+		recoveryCodes, err = h.users.ConfirmMFA(timeoutCtx, challenge.UserID, req.Code)
+	}
+	if errors.Is(err, userclient.ErrMFAInvalidCode) {
+		h.recordMFAFailure(ctx, timeoutCtx, req.MFAToken, challenge)
+		return
+	}
+	if errors.Is(err, userclient.ErrMFAConflict) {
+		errutil.Write(ctx, http.StatusConflict, "mfa_enrollment_required", "Start enrollment with /v1/auth/login/mfa/enroll first", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	// Consuming the challenge last means two racing completions cannot both get tokens.
+	if live, err := h.sessions.DeleteChallenge(timeoutCtx, req.MFAToken); err != nil || !live {
+		errutil.Write(ctx, http.StatusUnauthorized, "mfa_challenge_invalid", "Sign-in challenge invalid or expired", nil)
+		return
+	}
+	if err := h.guard.Succeed(timeoutCtx, challenge.Email); err != nil {
+		h.logger.Error().Err(err).Msg("failed to clear login failures")
+	}
+
+	user, err := h.users.GetUser(timeoutCtx, challenge.UserID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if h.rejectDisabled(ctx, user) {
+		return
+	}
+	access, refresh, err := h.issueSession(ctx, timeoutCtx, user, challenge.Device, amr)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "mfa_login", user.Email).Str("userId", user.ID).Str("method", method).Bool("enrolled", recoveryCodes != nil).Msg("second factor accepted")
+	if method == "recovery" {
+		h.sendEmail(timeoutCtx, user.ID, "A recovery code was used",
+			"Someone signed in to your account with one of your recovery codes. If this wasn't you, reset your password and regenerate your recovery codes.")
+	}
+
+	resp := gin.H{
+		"accessToken":  access.Token,
+		"refreshToken": refresh,
+		"expiresIn":    int(h.jwt.AccessTTL().Seconds()),
+		"user":         user,
+	}
+	if recoveryCodes != nil {
+		resp["recoveryCodes"] = recoveryCodes
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// loginMFAEnroll hands a user who must use MFA but has not enrolled a TOTP secret within the
+// login challenge.
+func (h *handler) loginMFAEnroll(ctx *gin.Context) {
+	var req mfaTokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "mfaToken is required", err.Error())
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	challenge, ok := h.loadChallenge(ctx, timeoutCtx, req.MFAToken)
+	if !ok {
+		return
+	}
+	if challenge.Enrolled {
+		errutil.Write(ctx, http.StatusConflict, "mfa_already_enabled", "MFA is already enabled; submit a code to /v1/auth/login/mfa", nil)
+		return
+	}
+	h.writeEnrollment(ctx, timeoutCtx, challenge.UserID)
+}
+
+func (h *handler) enrollMFA(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	h.writeEnrollment(ctx, timeoutCtx, claimsFromContext(ctx).UserID)
+}
+
+func (h *handler) confirmMFA(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "code is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	codes, err := h.users.ConfirmMFA(timeoutCtx, claims.UserID, req.Code)
+	if !h.handleMFAError(ctx, err, "mfa_not_enrolled", "Start enrollment with /v1/auth/mfa/enroll first") {
+		return
+	}
+	h.securityEvent(ctx, "mfa_enabled", "").Str("userId", claims.UserID).Msg("mfa enabled")
+	h.recordAuthEvent(ctx, "auth.mfa.enable", audit.OutcomeSuccess, claims.UserID, "", nil)
+	h.sendEmail(timeoutCtx, claims.UserID, "Two-factor authentication enabled",
+		"Two-factor authentication is now on for your account. Keep your recovery codes somewhere safe.")
+	ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+func (h *handler) regenerateRecoveryCodes(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "code is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	_, err := h.users.VerifyMFA(timeoutCtx, claims.UserID, req.Code)
+	if !h.handleMFAError(ctx, err, "mfa_not_enrolled", "MFA is not enabled") {
+		return
+	}
+	codes, err := h.users.RegenerateRecoveryCodes(timeoutCtx, claims.UserID)
+	if !h.handleMFAError(ctx, err, "mfa_not_enrolled", "MFA is not enabled") {
+		return
+	}
+	h.securityEvent(ctx, "mfa_recovery_codes_regenerated", "").Str("userId", claims.UserID).Msg("recovery codes regenerated")
+	ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+}
+
+func (h *handler) disableMFA(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "code is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.GetUser(timeoutCtx, claims.UserID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if h.requiresMFA(user) {
+		errutil.Write(ctx, http.StatusConflict, "mfa_required_for_role", "Your role requires two-factor authentication", nil)
+		return
+	}
+
+	_, err = h.users.VerifyMFA(timeoutCtx, claims.UserID, req.Code)
+	if !h.handleMFAError(ctx, err, "mfa_not_enrolled", "MFA is not enabled") {
+		return
+	}
+	if err := h.users.DisableMFA(timeoutCtx, claims.UserID); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "mfa_disabled", "").Str("userId", claims.UserID).Msg("mfa disabled")
+	h.recordAuthEvent(ctx, "auth.mfa.disable", audit.OutcomeSuccess, claims.UserID, "", nil)
+	h.sendEmail(timeoutCtx, claims.UserID, "Two-factor authentication disabled",
+		"Two-factor authentication was turned off for your account. If this wasn't you, reset your password.")
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *handler) writeEnrollment(ctx *gin.Context, reqCtx context.Context, userID string) {
+	enrollment, err := h.users.EnrollMFA(reqCtx, userID)
+	if errors.Is(err, userclient.ErrMFAConflict) {
+		errutil.Write(ctx, http.StatusConflict, "mfa_already_enabled", "MFA is already enabled", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, enrollment)
+}
+
+func (h *handler) loadChallenge(ctx *gin.Context, reqCtx context.Context, token string) (*session.Challenge, bool) {
+	challenge, err := h.sessions.GetChallenge(reqCtx, token)
+	if errors.Is(err, session.ErrNotFound) {
+		errutil.Write(ctx, http.StatusUnauthorized, "mfa_challenge_invalid", "Sign-in challenge invalid or expired", nil)
+		return nil, false
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return nil, false
+	}
+	return challenge, true
+}
+
+// recordMFAFailure counts a wrong code against both the challenge and the account lockout, so
+// a stolen password does not allow unlimited code guessing.
+func (h *handler) recordMFAFailure(ctx *gin.Context, reqCtx context.Context, token string, challenge *session.Challenge) {
+	h.securityEvent(ctx, "mfa_failed", challenge.Email).Str("userId", challenge.UserID).Msg("wrong mfa code")
+	h.recordLoginFailed(ctx, challenge.UserID, challenge.Email, loginFailedMFA, nil)
+	status, err := h.guard.Fail(reqCtx, challenge.Email, ctx.ClientIP())
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to record mfa failure")
+	}
+	live, err := h.sessions.FailChallenge(reqCtx, token)
+	if err != nil {
+		h.logger.Error().Err(err).Msg("failed to count mfa challenge attempt")
+	}
+	if status.Blocked {
+		_, _ = h.sessions.DeleteChallenge(reqCtx, token)
+		writeLockout(ctx, status)
+		return
+	}
+	if !live {
+		errutil.Write(ctx, http.StatusUnauthorized, "mfa_challenge_invalid", "Too many wrong codes; sign in again", nil)
+		return
+	}
+	errutil.Write(ctx, http.StatusUnauthorized, "invalid_mfa_code", "Code incorrect", nil)
+}
+
+// handleMFAError writes the response for a failed MFA call and reports whether err was nil.
+func (h *handler) handleMFAError(ctx *gin.Context, err error, conflictCode, conflictMessage string) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, userclient.ErrMFAInvalidCode):
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_mfa_code", "Code incorrect", nil)
+	case errors.Is(err, userclient.ErrMFAConflict):
+		errutil.Write(ctx, http.StatusConflict, conflictCode, conflictMessage, nil)
+	default:
+		errutil.HandleInternal(ctx, err)
+	}
+	return false
+}
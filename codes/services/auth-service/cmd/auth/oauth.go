@@ -0,0 +1,452 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/services/auth-service/internal/oidc"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+// OAuth grant types the token endpoint accepts.
+const (
+	grantAuthorizationCode = "authorization_code"
+	grantClientCredentials = "client_credentials"
+)
+
+// clientRole is the role of client-credentials tokens, which act for a partner app rather than
+// a user.
+const clientRole = "CLIENT"
+
+// authorizeRequest is an OAuth authorization request, relayed by the web app that shows the
+// consent screen to the signed-in user.
+type authorizeRequest struct {
+	ResponseType        string `form:"response_type" json:"response_type"`
+	ClientID            string `form:"client_id" json:"client_id"`
+	RedirectURI         string `form:"redirect_uri" json:"redirect_uri"`
+	Scope               string `form:"scope" json:"scope"`
+	State               string `form:"state" json:"state"`
+	CodeChallenge       string `form:"code_challenge" json:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method" json:"code_challenge_method"`
+	// Approve is the user's decision; only read when submitting the consent screen.
+	Approve bool `form:"-" json:"approve"`
+}
+
+// registerOAuthRoutes serves the OAuth 2.0 authorization server for partner apps. The token,
+// introspection and revocation endpoints follow RFC 6749, 7662 and 7009 and answer in their
+// error format rather than errutil's.
+func registerOAuthRoutes(router *gin.Engine, h *handler) {
+	group := router.Group("/v1/oauth")
+	group.POST("/token", h.oauthToken)
+	group.POST("/introspect", h.introspectToken)
+	group.POST("/revoke", h.revokeOAuthToken)
+
+	authed := group.Group("", requireAccessToken(h.jwt, h.revoked))
+	authed.GET("/authorize", h.describeAuthorization)
+	authed.POST("/authorize", h.authorize)
+	authed.GET("/consents", h.listConsents)
+	authed.DELETE("/consents/:clientId", h.revokeConsent)
+}
+
+// describeAuthorization validates an authorization request and tells the web app what to show
+// on the consent screen. consentRequired is false when the user already granted every scope.
+func (h *handler) describeAuthorization(ctx *gin.Context) {
+	var req authorizeRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "Invalid authorization request", err.Error())
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	client, scopes, ok := h.validateAuthorization(ctx, timeoutCtx, &req)
+	if !ok {
+		return
+	}
+	granted, err := h.grantedScopes(timeoutCtx, claimsFromContext(ctx).UserID, client.ID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"client":          gin.H{"id": client.ID, "name": client.Name},
+		"scopes":          scopes,
+		"consentRequired": !containsAll(granted, scopes),
+	})
+}
+
+// authorize records the user's decision. Approval stores the consent and issues a single-use
+// code; either way the response names the URL to send the user back to the partner app with.
+func (h *handler) authorize(ctx *gin.Context) {
+	var req authorizeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "Invalid authorization request", err.Error())
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	client, scopes, ok := h.validateAuthorization(ctx, timeoutCtx, &req)
+	if !ok {
+		return
+	}
+	claims := claimsFromContext(ctx)
+	if !req.Approve {
+		ctx.JSON(http.StatusOK, gin.H{"redirectUrl": redirectWith(req.RedirectURI, url.Values{
+			"error": {"access_denied"}, "state": {req.State},
+		})})
+		return
+	}
+
+	if _, err := h.users.GrantConsent(timeoutCtx, claims.UserID, client.ID, scopes); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	code, err := h.sessions.CreateAuthCode(timeoutCtx, session.AuthCode{
+		ClientID:    client.ID,
+		UserID:      claims.UserID,
+		RedirectURI: req.RedirectURI,
+		Challenge:   req.CodeChallenge,
+		Scopes:      scopes,
+		AMR:         claims.AMR,
+	})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "oauth_consent_granted", "").Str("userId", claims.UserID).Str("clientId", client.ID).Strs("scopes", scopes).Msg("partner app authorized")
+	ctx.JSON(http.StatusOK, gin.H{"redirectUrl": redirectWith(req.RedirectURI, url.Values{
+		"code": {code}, "state": {req.State},
+	})})
+}
+
+// validateAuthorization checks the client and redirect URI first; errors about them are never
+// redirected. Later errors carry the redirect URL that reports them to the partner app.
+func (h *handler) validateAuthorization(ctx *gin.Context, reqCtx context.Context, req *authorizeRequest) (*userclient.OAuthClient, []string, bool) {
+	client, err := h.users.GetOAuthClient(reqCtx, req.ClientID)
+	if errors.Is(err, userclient.ErrClientNotFound) {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_client", "Unknown client", nil)
+		return nil, nil, false
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return nil, nil, false
+	}
+	if req.RedirectURI == "" || !client.AllowsRedirect(req.RedirectURI) {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_redirect_uri", "redirect_uri is not registered for this client", nil)
+		return nil, nil, false
+	}
+
+	fail := func(code, message string) (*userclient.OAuthClient, []string, bool) {
+		redirect := redirectWith(req.RedirectURI, url.Values{"error": {code}, "error_description": {message}, "state": {req.State}})
+		errutil.Write(ctx, http.StatusBadRequest, code, message, gin.H{"redirectUrl": redirect})
+		return nil, nil, false
+	}
+	if req.ResponseType != "code" {
+		return fail("unsupported_response_type", "response_type must be code")
+	}
+	if !client.AllowsGrant(grantAuthorizationCode) {
+		return fail("unauthorized_client", "Client may not use the authorization code grant")
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return fail("invalid_request", "PKCE with code_challenge_method S256 is required")
+	}
+	scopes := strings.Fields(req.Scope)
+	if len(scopes) == 0 || !validScopes(scopes) || !client.AllowsScopes(scopes) {
+		return fail("invalid_scope", "Requested scopes are unknown or not allowed for this client")
+	}
+	return client, scopes, true
+}
+
+func (h *handler) grantedScopes(ctx context.Context, userID, clientID string) ([]string, error) {
+	consents, err := h.users.ListConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range consents {
+		if c.ClientID == clientID {
+			return c.Scopes, nil
+		}
+	}
+	return nil, nil
+}
+
+// oauthToken is the token endpoint. Authorization codes become user-delegated tokens whose
+// permissions are the user's, limited to the granted scopes; client credentials become tokens
+// for the app itself carrying the scopes' permissions.
+func (h *handler) oauthToken(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	client, ok := h.authenticateClient(ctx, timeoutCtx, true)
+	if !ok {
+		return
+	}
+	grantType := ctx.PostForm("grant_type")
+	if grantType != grantAuthorizationCode && grantType != grantClientCredentials {
+		oauthError(ctx, http.StatusBadRequest, "unsupported_grant_type", "grant_type must be authorization_code or client_credentials")
+		return
+	}
+	if !client.AllowsGrant(grantType) || (grantType == grantClientCredentials && !client.Confidential) {
+		oauthError(ctx, http.StatusBadRequest, "unauthorized_client", "Client may not use this grant type")
+		return
+	}
+
+	var (
+		subject, email string
+		scopes, roles  []string
+		perms          []string
+		venuePerms     map[string][]string
+		amr            []string
+	)
+	if grantType == grantAuthorizationCode {
+		code, err := h.sessions.ConsumeAuthCode(timeoutCtx, ctx.PostForm("code"))
+		if errors.Is(err, session.ErrNotFound) {
+			oauthError(ctx, http.StatusBadRequest, "invalid_grant", "Authorization code invalid, expired or already used")
+			return
+		}
+		if err != nil {
+			oauthError(ctx, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+		verifier := ctx.PostForm("code_verifier")
+		if code.ClientID != client.ID || code.RedirectURI != ctx.PostForm("redirect_uri") || verifier == "" ||
+			subtle.ConstantTimeCompare([]byte(oidc.Challenge(verifier)), []byte(code.Challenge)) != 1 {
+			oauthError(ctx, http.StatusBadRequest, "invalid_grant", "Authorization code does not match this request")
+			return
+		}
+		user, err := h.users.GetUser(timeoutCtx, code.UserID)
+		if errors.Is(err, userclient.ErrUserNotFound) {
+			oauthError(ctx, http.StatusBadRequest, "invalid_grant", "User no longer exists")
+			return
+		}
+		if err != nil {
+			oauthError(ctx, http.StatusInternalServerError, "server_error", "")
+			return
+		}
+		if user.Disabled {
+			oauthError(ctx, http.StatusBadRequest, "invalid_grant", "User account is disabled")
+			return
+		}
+		subject, email, scopes, roles, amr = user.ID, user.Email, code.Scopes, user.Roles, code.AMR
+		perms = authz.LimitToScopes(user.Permissions, scopes)
+		venuePerms = authz.LimitVenuesToScopes(user.VenuePermissions, scopes)
+	} else {
+		scopes = strings.Fields(ctx.PostForm("scope"))
+		if len(scopes) == 0 {
+			scopes = client.Scopes
+		}
+		if !validScopes(scopes) || !client.AllowsScopes(scopes) {
+			oauthError(ctx, http.StatusBadRequest, "invalid_scope", "Requested scopes are unknown or not allowed for this client")
+			return
+		}
+		subject, roles = client.ID, []string{clientRole}
+		perms = authz.ScopePermissions(scopes)
+	}
+
+	access, err := h.jwt.IssueForClient(client.ID, subject, scopes, roles, perms, venuePerms, amr)
+	if err != nil {
+		oauthError(ctx, http.StatusInternalServerError, "server_error", "")
+		return
+	}
+	h.securityEvent(ctx, "oauth_token_issued", email).Str("clientId", client.ID).Str("sub", subject).Str("grantType", grantType).Strs("scopes", scopes).Msg("partner token issued")
+	ctx.JSON(http.StatusOK, gin.H{
+		"access_token": access.Token,
+		"token_type":   "Bearer",
+		"expires_in":   int(h.jwt.AccessTTL().Seconds()),
+		"scope":        strings.Join(scopes, " "),
+	})
+}
+
+// introspectToken implements RFC 7662 for a client's own tokens. Tokens of other clients,
+// first-party tokens and revoked or invalid ones are all reported as inactive.
+func (h *handler) introspectToken(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	client, ok := h.authenticateClient(ctx, timeoutCtx, false)
+	if !ok {
+		return
+	}
+	claims, err := revocation.Verify(timeoutCtx, h.jwt, h.revoked, ctx.PostForm("token"))
+	if errors.Is(err, revocation.ErrUnavailable) {
+		oauthError(ctx, http.StatusServiceUnavailable, "temporarily_unavailable", "Unable to check token revocation")
+		return
+	}
+	if err != nil || claims.ClientID != client.ID {
+		ctx.JSON(http.StatusOK, gin.H{"active": false})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"active":     true,
+		"scope":      claims.Scope,
+		"client_id":  claims.ClientID,
+		"sub":        claims.UserID,
+		"token_type": "Bearer",
+		"exp":        claims.ExpiresAt.Unix(),
+		"iat":        claims.IssuedAt.Unix(),
+	})
+}
+
+// revokeOAuthToken implements RFC 7009: it denylists one of the client's access tokens. Unknown
+// or foreign tokens are ignored and still answer 200.
+func (h *handler) revokeOAuthToken(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	client, ok := h.authenticateClient(ctx, timeoutCtx, false)
+	if !ok {
+		return
+	}
+	claims, err := h.jwt.Validate(ctx.PostForm("token"))
+	if err == nil && claims.ClientID == client.ID {
+		if err := h.revoked.RevokeToken(timeoutCtx, claims.ID, claims.ExpiresAt.Time); err != nil {
+			oauthError(ctx, http.StatusServiceUnavailable, "temporarily_unavailable", "Unable to revoke token")
+			return
+		}
+	}
+	ctx.Status(http.StatusOK)
+}
+
+func (h *handler) listConsents(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	consents, err := h.users.ListConsents(timeoutCtx, claimsFromContext(ctx).UserID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"consents": consents})
+}
+
+// revokeConsent withdraws the user's consent for a partner app. Tokens already issued to it
+// stay valid until they expire; the app cannot get new ones without asking again.
+func (h *handler) revokeConsent(ctx *gin.Context) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	claims := claimsFromContext(ctx)
+	err := h.users.RevokeConsent(timeoutCtx, claims.UserID, ctx.Param("clientId"))
+	if errors.Is(err, userclient.ErrConsentNotFound) {
+		errutil.Write(ctx, http.StatusNotFound, "consent_not_found", "No consent for this app", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "oauth_consent_revoked", "").Str("userId", claims.UserID).Str("clientId", ctx.Param("clientId")).Msg("partner app access revoked")
+	ctx.Status(http.StatusNoContent)
+}
+
+// authenticateClient reads client credentials from HTTP Basic auth or the form body. Public
+// clients send only client_id; they can redeem codes (bound by PKCE) but not use client
+// credentials, introspection or revocation, so only the token endpoint sets allowPublic.
+func (h *handler) authenticateClient(ctx *gin.Context, reqCtx context.Context, allowPublic bool) (*userclient.OAuthClient, bool) {
+	clientID, secret, basic := ctx.Request.BasicAuth()
+	if basic {
+		// RFC 6749 2.3.1: Basic credentials are form-encoded.
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = ctx.PostForm("client_id"), ctx.PostForm("client_secret")
+	}
+	if clientID == "" {
+		unauthorizedClient(ctx)
+		return nil, false
+	}
+
+	var (
+		client *userclient.OAuthClient
+		err    error
+	)
+	if secret != "" {
+		client, err = h.users.AuthenticateClient(reqCtx, clientID, secret)
+	} else {
+		client, err = h.users.GetOAuthClient(reqCtx, clientID)
+		if err == nil && client.Confidential {
+			err = userclient.ErrInvalidClient
+		}
+	}
+	if errors.Is(err, userclient.ErrInvalidClient) || errors.Is(err, userclient.ErrClientNotFound) {
+		unauthorizedClient(ctx)
+		return nil, false
+	}
+	if err != nil {
+		oauthError(ctx, http.StatusInternalServerError, "server_error", "")
+		return nil, false
+	}
+	if !client.Confidential && !allowPublic {
+		unauthorizedClient(ctx)
+		return nil, false
+	}
+	return client, true
+}
+
+func unauthorizedClient(ctx *gin.Context) {
+	ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	oauthError(ctx, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+}
+
+// oauthError writes an RFC 6749 error response.
+func oauthError(ctx *gin.Context, status int, code, description string) {
+	body := gin.H{"error": code}
+	if description != "" {
+		body["error_description"] = description
+	}
+	ctx.AbortWithStatusJSON(status, body)
+}
+
+func redirectWith(redirectURI string, params url.Values) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+	q := u.Query()
+	for key, values := range params {
+		if len(values) > 0 && values[0] != "" {
+			q.Set(key, values[0])
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+func validScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !authz.ValidScope(scope) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/services/auth-service/internal/oidc"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+// oidcTimeout covers the round trips to the provider (discovery, token, JWKS) and user-service.
+const oidcTimeout = 15 * time.Second
+
+// defaultIssuers lets well-known providers be configured with client credentials only.
+var defaultIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+	"apple":  "https://appleid.apple.com",
+}
+
+type oidcCallbackRequest struct {
+	Code   string `json:"code" binding:"required"`
+	State  string `json:"state" binding:"required"`
+	Device string `json:"device"`
+}
+
+func registerOIDCRoutes(group *gin.RouterGroup, h *handler) {
+	group.GET("/oidc/providers", h.listOIDCProviders)
+	group.POST("/oidc/:provider/authorize", h.authorizeOIDC)
+	group.POST("/oidc/:provider/callback", h.oidcCallback)
+}
+
+// loadOIDCProviders builds the providers named in OIDC_PROVIDERS from OIDC_<NAME>_* variables.
+// Incompletely configured providers are skipped with a warning.
+func loadOIDCProviders(logger zerolog.Logger) map[string]*oidc.Provider {
+	providers := make(map[string]*oidc.Provider)
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		cfg := oidc.Config{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", defaultIssuers[name]),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			logger.Warn().Str("provider", name).Msg("oidc provider is missing its issuer, client id or redirect url; skipping")
+			continue
+		}
+		providers[name] = oidc.NewProvider(cfg)
+	}
+	return providers
+}
+
+func (h *handler) listOIDCProviders(ctx *gin.Context) {
+	names := make([]string, 0, len(h.providers))
+	for name := range h.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ctx.JSON(http.StatusOK, gin.H{"providers": names})
+}
+
+// authorizeOIDC starts a login: it returns the provider URL to redirect the user to and the
+// state the provider will send back. The client should keep state and check it on return.
+func (h *handler) authorizeOIDC(ctx *gin.Context) {
+	provider, ok := h.oidcProvider(ctx)
+	if !ok {
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), oidcTimeout)
+	defer cancel()
+
+	nonce, err := oidc.NewVerifier()
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	state, err := h.sessions.CreateAuthState(timeoutCtx, session.AuthState{Provider: provider.Name(), Nonce: nonce, Verifier: verifier})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	authURL, err := provider.AuthCodeURL(timeoutCtx, state, nonce, verifier)
+	if err != nil {
+		h.logger.Error().Err(err).Str("provider", provider.Name()).Msg("oidc discovery failed")
+		errutil.Write(ctx, http.StatusBadGateway, "oidc_unavailable", "Sign-in provider is unavailable", nil)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"authorizationUrl": authURL,
+		"state":            state,
+		"expiresIn":        int(session.AuthStateTTL.Seconds()),
+	})
+}
+
+// oidcCallback completes a login with the code and state the provider redirected back with.
+// The external identity is resolved to a user (linked by verified email, or created), and the
+// user is signed in, or challenged for a second factor like after a password login.
+func (h *handler) oidcCallback(ctx *gin.Context) {
+	provider, ok := h.oidcProvider(ctx)
+	if !ok {
+		return
+	}
+	var req oidcCallbackRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "code and state are required", err.Error())
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), oidcTimeout)
+	defer cancel()
+
+	state, err := h.sessions.ConsumeAuthState(timeoutCtx, req.State)
+	if errors.Is(err, session.ErrNotFound) || (err == nil && state.Provider != provider.Name()) {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_state", "Sign-in request invalid or expired; start again", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+
+	identity, err := provider.Exchange(timeoutCtx, req.Code, state.Verifier, state.Nonce)
+	if err != nil {
+		h.securityEvent(ctx, "oidc_login_failed", "").Str("provider", provider.Name()).Err(err).Msg("oidc callback rejected")
+		h.recordLoginFailed(ctx, "", "", loginFailedOIDC, gin.H{"provider": provider.Name()})
+		errutil.Write(ctx, http.StatusUnauthorized, "oidc_failed", "Sign-in with the provider failed", nil)
+		return
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		errutil.Write(ctx, http.StatusForbidden, "email_not_verified", "The provider did not confirm a verified email address", nil)
+		return
+	}
+
+	user, outcome, err := h.users.ResolveIdentity(timeoutCtx, userclient.ExternalIdentity{
+		Provider:      provider.Name(),
+		Subject:       identity.Subject,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		FirstName:     identity.GivenName,
+		LastName:      identity.FamilyName,
+	})
+	if errors.Is(err, userclient.ErrIdentityConflict) {
+		h.securityEvent(ctx, "oidc_link_refused", identity.Email).Str("provider", provider.Name()).Msg("email belongs to an unverified account")
+		errutil.Write(ctx, http.StatusConflict, "account_exists",
+			"An account with this email already exists; sign in with your password and verify your email first", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.securityEvent(ctx, "oidc_login", user.Email).Str("userId", user.ID).Str("provider", provider.Name()).Str("outcome", outcome).Msg("federated sign-in")
+	if outcome == "linked" {
+		h.sendEmail(timeoutCtx, user.ID, "New sign-in method added",
+			fmt.Sprintf("Your %s account was linked to your account and can now be used to sign in. If this wasn't you, contact support.", provider.Name()))
+	}
+
+	if h.rejectDisabled(ctx, user) {
+		return
+	}
+	if user.MFAEnabled || h.requiresMFA(user) {
+		h.startMFAChallenge(ctx, timeoutCtx, user, req.Device, jwtutil.AMRFederated)
+		return
+	}
+	access, refresh, err := h.issueSession(ctx, timeoutCtx, user, req.Device, []string{jwtutil.AMRFederated})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	status := http.StatusOK
+	if outcome == "created" {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, gin.H{
+		"accessToken":  access.Token,
+		"refreshToken": refresh,
+		"expiresIn":    int(h.jwt.AccessTTL().Seconds()),
+		"user":         user,
+	})
+}
+
+func (h *handler) oidcProvider(ctx *gin.Context) (*oidc.Provider, bool) {
+	provider, ok := h.providers[strings.ToLower(ctx.Param("provider"))]
+	if !ok {
+		errutil.Write(ctx, http.StatusNotFound, "unknown_provider", "Sign-in provider not configured", nil)
+		return nil, false
+	}
+	return provider, true
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/phone"
+	"github.com/venue-master/platform/services/auth-service/internal/notification"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+type passwordlessStartRequest struct {
+	Email string `json:"email" binding:"omitempty,email"`
+	Phone string `json:"phone"`
+}
+
+type passwordlessVerifyRequest struct {
+	// Token is the emailed link's token; Phone and Code an SMS code.
+	Token  string `json:"token"`
+	Phone  string `json:"phone"`
+	Code   string `json:"code"`
+	Device string `json:"device"`
+}
+
+type phoneRequest struct {
+	Phone string `json:"phone" binding:"required"`
+}
+
+func registerPasswordlessRoutes(group *gin.RouterGroup, h *handler) {
+	group.POST("/passwordless/start", h.startPasswordless)
+	group.POST("/passwordless/verify", h.verifyPasswordless)
+
+	authed := group.Group("/phone", requireAccessToken(h.jwt, h.revoked))
+	authed.PUT("", h.setPhone)
+	authed.POST("/verify", h.verifyPhone)
+}
+
+// startPasswordless emails a sign-in link, or texts a code to a verified number. Like
+// forgotPassword it answers the same whether or not the address is registered.
+func (h *handler) startPasswordless(ctx *gin.Context) {
+	var req passwordlessStartRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Email == "") == (req.Phone == "") {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "Exactly one of email and phone is required", nil)
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	if req.Email != "" {
+		if !h.allowEmail(ctx, "passwordless_email", req.Email) {
+			return
+		}
+		user, err := h.users.FindByEmail(timeoutCtx, req.Email)
+		if err != nil && !errors.Is(err, userclient.ErrUserNotFound) {
+			errutil.HandleInternal(ctx, err)
+			return
+		}
+		if err == nil {
+			token, err := h.sessions.CreateLoginLink(timeoutCtx, user.ID)
+			if err != nil {
+				errutil.HandleInternal(ctx, err)
+				return
+			}
+			h.sendEmail(timeoutCtx, user.ID, "Your sign-in link",
+				fmt.Sprintf("Open %s within the next 15 minutes to sign in. If you didn't ask for this, ignore this email.", h.link("/login/link", token)))
+		}
+		ctx.JSON(http.StatusAccepted, gin.H{
+			"message":   "If the email is registered, a sign-in link is on its way",
+			"expiresIn": int(session.LoginLinkTTL.Seconds()),
+		})
+		return
+	}
+
+	number, ok := h.normalizePhone(ctx, req.Phone)
+	if !ok || !h.allowSMS(ctx, "passwordless_sms", number) {
+		return
+	}
+	user, err := h.users.FindByPhone(timeoutCtx, number)
+	if err != nil && !errors.Is(err, userclient.ErrUserNotFound) {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err == nil {
+		code, err := h.sessions.CreateCode(timeoutCtx, session.CodeLogin, number, session.Code{UserID: user.ID, Target: number})
+		if err != nil {
+			errutil.HandleInternal(ctx, err)
+			return
+		}
+		h.sendSMS(timeoutCtx, user.ID, number, fmt.Sprintf("Your Venue Master sign-in code is %s. It expires in 5 minutes.", code))
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message":   "If the number is registered, a code is on its way",
+		"expiresIn": int(session.CodeTTL.Seconds()),
+	})
+}
+
+// verifyPasswordless redeems a sign-in link or SMS code and signs the user in, or challenges
+// for a second factor like after a password login.
+func (h *handler) verifyPasswordless(ctx *gin.Context) {
+	var req passwordlessVerifyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || (req.Token == "") == (req.Phone == "" || req.Code == "") {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "Either token, or phone and code, are required", nil)
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	var userID, number string
+	factor := jwtutil.AMREmailLink
+	if req.Token != "" {
+		var err error
+		userID, err = h.sessions.ConsumeLoginLink(timeoutCtx, req.Token)
+		if errors.Is(err, session.ErrNotFound) {
+			errutil.Write(ctx, http.StatusUnauthorized, "invalid_token", "Sign-in link invalid, expired or already used", nil)
+			return
+		}
+		if err != nil {
+			errutil.HandleInternal(ctx, err)
+			return
+		}
+	} else {
+		var ok bool
+		if number, ok = h.normalizePhone(ctx, req.Phone); !ok {
+			return
+		}
+		code, ok := h.checkCode(ctx, timeoutCtx, session.CodeLogin, number, req.Code)
+		if !ok {
+			return
+		}
+		userID, factor = code.UserID, jwtutil.AMRSMS
+	}
+
+	user, err := h.users.GetUser(timeoutCtx, userID)
+	if errors.Is(err, userclient.ErrUserNotFound) {
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_token", "User no longer exists", nil)
+		return
+	}
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	// The number may have been changed or given up since the code was sent.
+	if factor == jwtutil.AMRSMS && (!user.PhoneVerified || user.Phone != number) {
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_code", "Code invalid or expired; request a new one", nil)
+		return
+	}
+	if h.requireVerified && !user.EmailVerified {
+		h.recordLoginFailed(ctx, user.ID, user.Email, loginFailedUnverified, nil)
+		errutil.Write(ctx, http.StatusForbidden, "email_not_verified", "Verify your email address before signing in", nil)
+		return
+	}
+	h.securityEvent(ctx, "passwordless_login", user.Email).Str("userId", user.ID).Str("method", factor).Msg("passwordless sign-in")
+	h.completeLogin(ctx, timeoutCtx, user, req.Device, factor)
+}
+
+// setPhone replaces the caller's phone number and texts a code to verify it. Setting the current,
+// already verified number again changes nothing.
+func (h *handler) setPhone(ctx *gin.Context) {
+	var req phoneRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "phone is required", err.Error())
+		return
+	}
+	number, ok := h.normalizePhone(ctx, req.Phone)
+	if !ok || !h.allowSMS(ctx, "phone_verify", number) {
+		return
+	}
+	claims := claimsFromContext(ctx)
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := h.users.SetPhone(timeoutCtx, claims.UserID, number)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if user.PhoneVerified {
+		ctx.JSON(http.StatusOK, gin.H{"user": user})
+		return
+	}
+	code, err := h.sessions.CreateCode(timeoutCtx, session.CodePhone, user.ID, session.Code{UserID: user.ID, Target: number})
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	h.sendSMS(timeoutCtx, user.ID, number, fmt.Sprintf("Your Venue Master verification code is %s. It expires in 5 minutes.", code))
+	ctx.JSON(http.StatusAccepted, gin.H{"user": user, "expiresIn": int(session.CodeTTL.Seconds())})
+}
+
+// verifyPhone confirms the caller's number with the code texted by setPhone.
+func (h *handler) verifyPhone(ctx *gin.Context) {
+	var req mfaCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_request", "code is required", err.Error())
+		return
+	}
+	claims := claimsFromContext(ctx)
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	code, ok := h.checkCode(ctx, timeoutCtx, session.CodePhone, claims.UserID, req.Code)
+	if !ok {
+		return
+	}
+	user, err := h.users.ConfirmPhone(timeoutCtx, claims.UserID, code.Target)
+	switch {
+	case errors.Is(err, userclient.ErrPhoneTaken):
+		errutil.Write(ctx, http.StatusConflict, "phone_taken", "This number is already verified on another account", nil)
+	case errors.Is(err, userclient.ErrPhoneChanged):
+		errutil.Write(ctx, http.StatusConflict, "phone_changed", "The number changed since the code was sent; request a new code", nil)
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+	default:
+		h.securityEvent(ctx, "phone_verified", user.Email).Str("userId", user.ID).Msg("phone number verified")
+		ctx.JSON(http.StatusOK, gin.H{"user": user})
+	}
+}
+
+// checkCode verifies a one-time code, writing the rejection itself when it does not match.
+func (h *handler) checkCode(ctx *gin.Context, reqCtx context.Context, purpose, key, input string) (*session.Code, bool) {
+	code, err := h.sessions.VerifyCode(reqCtx, purpose, key, input)
+	switch {
+	case errors.Is(err, session.ErrWrongCode):
+		h.securityEvent(ctx, "otp_failed", "").Str("purpose", purpose).Msg("wrong one-time code")
+		if purpose == session.CodeLogin {
+			h.recordLoginFailed(ctx, "", "", loginFailedCode, gin.H{"phone": key})
+		}
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_code", "Code incorrect", nil)
+	case errors.Is(err, session.ErrNotFound):
+		errutil.Write(ctx, http.StatusUnauthorized, "invalid_code", "Code invalid or expired; request a new one", nil)
+	case err != nil:
+		errutil.HandleInternal(ctx, err)
+	default:
+		return code, true
+	}
+	return nil, false
+}
+
+// normalizePhone reads raw in E.164, writing a 400 when it is not a phone number.
+func (h *handler) normalizePhone(ctx *gin.Context, raw string) (string, bool) {
+	number, err := phone.Normalize(raw, h.phoneCountry)
+	if err != nil {
+		errutil.Write(ctx, http.StatusBadRequest, "invalid_phone", "Phone number is not valid", nil)
+		return "", false
+	}
+	return number, true
+}
+
+func (h *handler) sendSMS(ctx context.Context, userID, number, message string) {
+	payload := notification.NotifyPayload{UserID: userID, Title: "Venue Master", Message: message, Channel: "sms", To: number}
+	if err := h.notify.Send(ctx, payload); err != nil {
+		h.logger.Error().Err(err).Str("userId", userID).Msg("failed to send sms")
+	}
+}
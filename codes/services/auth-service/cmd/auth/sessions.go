@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/errutil"
+	"github.com/venue-master/platform/lib/jwtutil"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/services/auth-service/internal/session"
+	"github.com/venue-master/platform/services/auth-service/internal/userclient"
+)
+
+const claimsContextKey = "claims"
+
+// maxDeviceLength caps the client-supplied device label stored with a session.
+const maxDeviceLength = 100
+
+// issueSession starts a new session (refresh-token family) for user and returns its tokens.
+// amr records how the user authenticated and is carried over to refreshed access tokens.
+func (h *handler) issueSession(ctx *gin.Context, reqCtx context.Context, user *userclient.User, device string, amr []string) (jwtutil.AccessToken, string, error) {
+	sessionID := session.NewID()
+	access, err := h.jwt.Issue(user.ID, sessionID, user.Roles, user.Permissions, user.VenuePermissions, amr)
+	if err != nil {
+		return jwtutil.AccessToken{}, "", err
+	}
+	refresh, err := session.NewRefreshToken()
+	if err != nil {
+		return jwtutil.AccessToken{}, "", err
+	}
+	err = h.sessions.Create(reqCtx, session.Session{
+		ID:              sessionID,
+		UserID:          user.ID,
+		Device:          deviceLabel(device),
+		IP:              ctx.ClientIP(),
+		UserAgent:       ctx.Request.UserAgent(),
+		AccessTokenID:   access.ID,
+		AccessExpiresAt: access.ExpiresAt,
+		AMR:             amr,
+	}, refresh)
+	if err == nil {
+		h.touchActivity(reqCtx, user.ID)
+		h.recordAuthEvent(ctx, "auth.login", audit.OutcomeSuccess, user.ID, user.Email, gin.H{"amr": amr, "sessionId": sessionID})
+	}
+	return access, refresh, err
+}
+
+// touchActivity tells the user-service the user is active, which keeps the account from being
+// deleted for inactivity. Signing in does not depend on it.
+func (h *handler) touchActivity(ctx context.Context, userID string) {
+	if err := h.users.TouchActivity(ctx, userID); err != nil {
+		h.logger.Error().Err(err).Str("userId", userID).Msg("failed to record account activity")
+	}
+}
+
+func registerSessionRoutes(group *gin.RouterGroup, h *handler) {
+	authed := group.Group("", requireAccessToken(h.jwt, h.revoked))
+	authed.POST("/logout", h.logout)
+	authed.POST("/logout-all", h.logoutAll)
+	authed.GET("/sessions", h.listSessions)
+	authed.DELETE("/sessions/:id", h.revokeSession)
+}
+
+func (h *handler) logout(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	if claims.SessionID != "" {
+		if _, err := h.sessions.Delete(ctx.Request.Context(), claims.UserID, claims.SessionID); err != nil && !errors.Is(err, session.ErrNotFound) {
+			errutil.HandleInternal(ctx, err)
+			return
+		}
+	}
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if err := h.revoked.RevokeToken(ctx.Request.Context(), claims.ID, expiresAt); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+func (h *handler) logoutAll(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	revoked, err := h.sessions.DeleteAll(ctx.Request.Context(), claims.UserID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err := h.revoked.RevokeUser(ctx.Request.Context(), claims.UserID); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"revoked": revoked})
+}
+
+func (h *handler) listSessions(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	list, err := h.sessions.List(ctx.Request.Context(), claims.UserID)
+	if err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	resp := make([]gin.H, 0, len(list))
+	for _, sess := range list {
+		resp = append(resp, gin.H{
+			"id":         sess.ID,
+			"device":     sess.Device,
+			"ip":         sess.IP,
+			"userAgent":  sess.UserAgent,
+			"createdAt":  sess.CreatedAt.Format(time.RFC3339),
+			"lastUsedAt": sess.LastUsedAt.Format(time.RFC3339),
+			"current":    sess.ID == claims.SessionID,
+		})
+	}
+	ctx.JSON(http.StatusOK, gin.H{"sessions": resp})
+}
+
+func (h *handler) revokeSession(ctx *gin.Context) {
+	claims := claimsFromContext(ctx)
+	sess, err := h.sessions.Delete(ctx.Request.Context(), claims.UserID, ctx.Param("id"))
+	if err != nil {
+		if errors.Is(err, session.ErrNotFound) {
+			errutil.Write(ctx, http.StatusNotFound, "session_not_found", "Session not found", nil)
+			return
+		}
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	if err := h.revoked.RevokeToken(ctx.Request.Context(), sess.AccessTokenID, sess.AccessExpiresAt); err != nil {
+		errutil.HandleInternal(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// requireAccessToken validates the bearer token, rejects revoked tokens and stores the claims
+// on the request.
+func requireAccessToken(jwtManager *jwtutil.Manager, revoked revocation.Checker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		token, ok := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			errutil.Write(ctx, http.StatusUnauthorized, "unauthorized", "Bearer token required", nil)
+			return
+		}
+		claims, err := revocation.Verify(ctx.Request.Context(), jwtManager, revoked, token)
+		if errors.Is(err, revocation.ErrUnavailable) {
+			errutil.HandleInternal(ctx, err)
+			return
+		}
+		if err != nil {
+			errutil.Write(ctx, http.StatusUnauthorized, "invalid_token", "Access token invalid", err.Error())
+			return
+		}
+		// Partner tokens act for an app; they cannot manage the user's sessions, MFA or consents.
+		if claims.ClientID != "" {
+			errutil.Write(ctx, http.StatusForbidden, "insufficient_scope", "Partner app tokens cannot use this endpoint", nil)
+			return
+		}
+		ctx.Set(claimsContextKey, claims)
+		ctx.Next()
+	}
+}
+
+func claimsFromContext(ctx *gin.Context) *jwtutil.Claims {
+	claims, _ := ctx.MustGet(claimsContextKey).(*jwtutil.Claims)
+	return claims
+}
+
+func deviceLabel(device string) string {
+	device = strings.TrimSpace(device)
+	if runes := []rune(device); len(runes) > maxDeviceLength {
+		device = string(runes[:maxDeviceLength])
+	}
+	return device
+}
@@ -0,0 +1,211 @@
+// Package lockout throttles password guessing. Failed logins are counted per account and per
+// client IP in Redis; repeated failures first ask for a CAPTCHA, then impose an exponentially
+// growing wait between attempts, and finally lock the account for a while.
+package lockout
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+// Policy sets the thresholds of a Guard. Counters reset after Window without failures.
+type Policy struct {
+	Window time.Duration
+
+	// CaptchaAfter failures on an account require a CAPTCHA and start the backoff, which begins at
+	// BaseBackoff and doubles with every further failure up to MaxBackoff.
+	CaptchaAfter int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// LockAfter failures lock the account for LockDuration.
+	LockAfter    int
+	LockDuration time.Duration
+
+	// IPCaptchaAfter and IPBlockAfter apply to failures from one client IP across all accounts,
+	// which is what credential stuffing looks like.
+	IPCaptchaAfter int
+	IPBlockAfter   int
+}
+
+// DefaultPolicy returns the thresholds used unless overridden.
+func DefaultPolicy() Policy {
+	return Policy{
+		Window:         time.Hour,
+		CaptchaAfter:   3,
+		BaseBackoff:    time.Second,
+		MaxBackoff:     5 * time.Minute,
+		LockAfter:      10,
+		LockDuration:   15 * time.Minute,
+		IPCaptchaAfter: 10,
+		IPBlockAfter:   100,
+	}
+}
+
+// Block reasons reported in Status.
+const (
+	ReasonAccountLocked = "account_locked"
+	ReasonIPBlocked     = "ip_blocked"
+	ReasonBackoff       = "backoff"
+)
+
+// Status describes whether a login attempt may proceed.
+type Status struct {
+	// Blocked is set when the attempt must be rejected without checking the password; Reason and
+	// RetryAfter say why and for how long.
+	Blocked    bool
+	Reason     string
+	RetryAfter time.Duration
+	// CaptchaRequired asks the client to solve a CAPTCHA with its next attempt.
+	CaptchaRequired bool
+	// Failures is the number of recent failures on the account.
+	Failures int
+}
+
+// failScript bumps the account and IP counters, starting their windows on the first failure.
+var failScript = redis.NewScript(`
+local acct = redis.call('INCR', KEYS[1])
+if acct == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+local ip = redis.call('INCR', KEYS[2])
+if ip == 1 then redis.call('PEXPIRE', KEYS[2], ARGV[1]) end
+return {acct, ip, redis.call('PTTL', KEYS[2])}
+`)
+
+// Guard tracks failed logins.
+type Guard struct {
+	client *redis.Client
+	policy Policy
+}
+
+// New creates a Guard. The Redis connection is established lazily.
+func New(cfg config.RedisConfig, policy Policy) *Guard {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	return &Guard{client: client, policy: policy}
+}
+
+// Check reports whether a login for email from ip may be attempted now.
+func (g *Guard) Check(ctx context.Context, email, ip string) (Status, error) {
+	acct := accountID(email)
+	var lock, wait, ipTTL *redis.DurationCmd
+	var acctFails, ipFails *redis.StringCmd
+	cmds, _ := g.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		lock = pipe.PTTL(ctx, lockKey(acct))
+		wait = pipe.PTTL(ctx, backoffKey(acct))
+		acctFails = pipe.Get(ctx, failKey(acct))
+		ipFails = pipe.Get(ctx, ipKey(ip))
+		ipTTL = pipe.PTTL(ctx, ipKey(ip))
+		return nil
+	})
+	for _, cmd := range cmds {
+		// Missing counters come back as redis.Nil, which just means no failures yet.
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return Status{}, err
+		}
+	}
+
+	acctCount, _ := acctFails.Int()
+	ipCount, _ := ipFails.Int()
+	status := g.status(acctCount, ipCount)
+	switch {
+	case lock.Val() > 0:
+		status.Blocked, status.Reason, status.RetryAfter = true, ReasonAccountLocked, lock.Val()
+	case ipCount >= g.policy.IPBlockAfter && ipTTL.Val() > 0:
+		status.Blocked, status.Reason, status.RetryAfter = true, ReasonIPBlocked, ipTTL.Val()
+	case wait.Val() > 0:
+		status.Blocked, status.Reason, status.RetryAfter = true, ReasonBackoff, wait.Val()
+	}
+	return status, nil
+}
+
+// Fail records a failed login and returns the resulting status. The attempt that crosses
+// LockAfter comes back Blocked with ReasonAccountLocked.
+func (g *Guard) Fail(ctx context.Context, email, ip string) (Status, error) {
+	acct := accountID(email)
+	res, err := failScript.Run(ctx, g.client, []string{failKey(acct), ipKey(ip)}, g.policy.Window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return Status{}, err
+	}
+	acctCount, ipCount := int(res[0]), int(res[1])
+	status := g.status(acctCount, ipCount)
+
+	switch {
+	case acctCount >= g.policy.LockAfter:
+		_, err = g.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, lockKey(acct), "1", g.policy.LockDuration)
+			pipe.Del(ctx, failKey(acct), backoffKey(acct))
+			return nil
+		})
+		status.Blocked, status.Reason, status.RetryAfter = true, ReasonAccountLocked, g.policy.LockDuration
+	case ipCount >= g.policy.IPBlockAfter:
+		status.Blocked, status.Reason, status.RetryAfter = true, ReasonIPBlocked, time.Duration(res[2])*time.Millisecond
+	case acctCount >= g.policy.CaptchaAfter:
+		backoff := g.backoff(acctCount)
+		err = g.client.Set(ctx, backoffKey(acct), "1", backoff).Err()
+		status.RetryAfter = backoff
+	}
+	return status, err
+}
+
+// Succeed clears the account's failures after a correct password. IP counters are left alone
+// so one valid account cannot launder a stuffing run.
+func (g *Guard) Succeed(ctx context.Context, email string) error {
+	acct := accountID(email)
+	return g.client.Del(ctx, failKey(acct), backoffKey(acct)).Err()
+}
+
+// Unlock lifts a lockout and clears the account's failures. It reports whether the account was
+// locked.
+func (g *Guard) Unlock(ctx context.Context, email string) (bool, error) {
+	acct := accountID(email)
+	var locked *redis.IntCmd
+	_, err := g.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		locked = pipe.Del(ctx, lockKey(acct))
+		pipe.Del(ctx, failKey(acct), backoffKey(acct))
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return locked.Val() > 0, nil
+}
+
+func (g *Guard) status(acctCount, ipCount int) Status {
+	return Status{
+		Failures:        acctCount,
+		CaptchaRequired: acctCount >= g.policy.CaptchaAfter || ipCount >= g.policy.IPCaptchaAfter,
+	}
+}
+
+// backoff doubles BaseBackoff for every failure past CaptchaAfter, capped at MaxBackoff.
+func (g *Guard) backoff(failures int) time.Duration {
+	wait := g.policy.BaseBackoff
+	for i := g.policy.CaptchaAfter; i < failures && wait < g.policy.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > g.policy.MaxBackoff {
+		wait = g.policy.MaxBackoff
+	}
+	return wait
+}
+
+// accountID hashes the normalised email so Redis keys do not hold addresses.
+func accountID(email string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(sum[:])
+}
+
+func failKey(acct string) string    { return "login:fail:acct:" + acct }
+func backoffKey(acct string) string { return "login:backoff:acct:" + acct }
+func lockKey(acct string) string    { return "login:lock:acct:" + acct }
+func ipKey(ip string) string        { return "login:fail:ip:" + ip }
@@ -0,0 +1,272 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/venue-master/platform/services/auth-service/internal/oidc"
+)
+
+const (
+	clientID    = "venue-master"
+	redirectURL = "https://app.example.com/auth/callback"
+)
+
+// fakeProvider is a minimal OpenID provider: discovery, JWKS and a token endpoint that redeems
+// codes issued through authorize.
+type fakeProvider struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	// issuer is the issuer the discovery document announces.
+	issuer string
+
+	// claims are merged into every ID token; tests tweak them to produce bad tokens.
+	claims jwt.MapClaims
+	// codes maps issued codes to the PKCE challenge and nonce of their authorization request.
+	codes map[string]pending
+}
+
+type pending struct {
+	challenge string
+	nonce     string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{t: t, key: key, codes: map[string]pending{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 f.issuer,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", f.token)
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	f.issuer = f.server.URL
+
+	f.claims = jwt.MapClaims{
+		"iss":            f.server.URL,
+		"aud":            clientID,
+		"sub":            "provider-user-1",
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+	return f
+}
+
+// authorize plays the user approving the request at authURL and returns the code the provider
+// would redirect back with.
+func (f *fakeProvider) authorize(authURL string) (code, state string) {
+	f.t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("client_id") != clientID || q.Get("redirect_uri") != redirectURL {
+		f.t.Fatalf("unexpected authorization request %s", authURL)
+	}
+	code = "code-" + q.Get("state")
+	f.codes[code] = pending{challenge: q.Get("code_challenge"), nonce: q.Get("nonce")}
+	return code, q.Get("state")
+}
+
+func (f *fakeProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	p, ok := f.codes[r.PostForm.Get("code")]
+	if !ok || r.PostForm.Get("client_secret") != "secret" || r.PostForm.Get("redirect_uri") != redirectURL {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if oidc.Challenge(r.PostForm.Get("code_verifier")) != p.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+	delete(f.codes, r.PostForm.Get("code"))
+
+	now := time.Now()
+	claims := jwt.MapClaims{"nonce": p.nonce, "iat": now.Unix(), "exp": now.Add(time.Hour).Unix()}
+	for k, v := range f.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": signed})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// login runs a full authorization code round trip and returns the verified identity.
+func login(t *testing.T, f *fakeProvider, mutate func(verifier, nonce *string)) (*oidc.Identity, error) {
+	t.Helper()
+	provider := oidc.NewProvider(oidc.Config{
+		Name:         "fake",
+		Issuer:       f.server.URL,
+		ClientID:     clientID,
+		ClientSecret: "secret",
+		RedirectURL:  redirectURL,
+	})
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce, err := oidc.NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", nonce, verifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	if strings.Contains(authURL, verifier) {
+		t.Fatal("authorization URL leaks the PKCE verifier")
+	}
+	code, state := f.authorize(authURL)
+	if state != "state-1" {
+		t.Fatalf("state = %q, want state-1", state)
+	}
+	if mutate != nil {
+		mutate(&verifier, &nonce)
+	}
+	return provider.Exchange(context.Background(), code, verifier, nonce)
+}
+
+func TestExchangeReturnsVerifiedIdentity(t *testing.T) {
+	f := newFakeProvider(t)
+	identity, err := login(t, f, nil)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.Subject != "provider-user-1" || identity.Email != "jane@example.com" || !identity.EmailVerified {
+		t.Fatalf("unexpected identity %+v", identity)
+	}
+	if identity.GivenName != "Jane" || identity.FamilyName != "Doe" {
+		t.Fatalf("unexpected names %+v", identity)
+	}
+}
+
+func TestExchangeAcceptsStringEmailVerified(t *testing.T) {
+	f := newFakeProvider(t)
+	f.claims["email_verified"] = "true"
+	identity, err := login(t, f, nil)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !identity.EmailVerified {
+		t.Fatal("email_verified \"true\" should count as verified")
+	}
+}
+
+func TestExchangeReportsUnverifiedEmail(t *testing.T) {
+	f := newFakeProvider(t)
+	f.claims["email_verified"] = false
+	identity, err := login(t, f, nil)
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if identity.EmailVerified {
+		t.Fatal("identity should not be verified")
+	}
+}
+
+func TestExchangeRejectsWrongPKCEVerifier(t *testing.T) {
+	f := newFakeProvider(t)
+	_, err := login(t, f, func(verifier, _ *string) { *verifier = "not-the-verifier" })
+	if !errors.Is(err, oidc.ErrExchange) {
+		t.Fatalf("err = %v, want ErrExchange", err)
+	}
+}
+
+func TestExchangeRejectsBadIDTokens(t *testing.T) {
+	cases := map[string]struct {
+		claims jwt.MapClaims
+		mutate func(verifier, nonce *string)
+	}{
+		"nonce mismatch": {mutate: func(_, nonce *string) { *nonce = "other-nonce" }},
+		"wrong audience": {claims: jwt.MapClaims{"aud": "someone-else"}},
+		"wrong issuer":   {claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		"expired":        {claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}},
+		"foreign azp":    {claims: jwt.MapClaims{"aud": []string{clientID, "other"}, "azp": "other"}},
+		"missing sub":    {claims: jwt.MapClaims{"sub": ""}},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			f := newFakeProvider(t)
+			for k, v := range tc.claims {
+				f.claims[k] = v
+			}
+			_, err := login(t, f, tc.mutate)
+			if !errors.Is(err, oidc.ErrInvalidIDToken) {
+				t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+			}
+		})
+	}
+}
+
+func TestExchangeRejectsTokenSignedByAnotherKey(t *testing.T) {
+	f := newFakeProvider(t)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.key = other
+	_, err = login(t, f, nil)
+	if !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Fatalf("err = %v, want ErrInvalidIDToken", err)
+	}
+}
+
+func TestDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	f := newFakeProvider(t)
+	f.issuer = "https://accounts.example.com"
+	provider := oidc.NewProvider(oidc.Config{Name: "fake", Issuer: f.server.URL, ClientID: clientID})
+	if _, err := provider.AuthCodeURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Fatal("expected discovery to fail for a mismatched issuer")
+	}
+}
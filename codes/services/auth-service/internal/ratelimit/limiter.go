@@ -0,0 +1,49 @@
+// Package ratelimit counts requests per key in fixed Redis windows.
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+// hitScript increments the counter, starts the window on the first hit and returns the count
+// and the milliseconds left in the window.
+var hitScript = redis.NewScript(`
+local n = redis.call('INCR', KEYS[1])
+if n == 1 then
+    redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return {n, redis.call('PTTL', KEYS[1])}
+`)
+
+// Limiter enforces "at most limit hits per window" per key.
+type Limiter struct {
+	client *redis.Client
+}
+
+// New creates a Limiter. The Redis connection is established lazily.
+func New(cfg config.RedisConfig) *Limiter {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cfg.Addr,
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+	return &Limiter{client: client}
+}
+
+// Allow records a hit for key and reports whether it is within limit. When it is not, retryAfter
+// is how long until the window resets.
+func (l *Limiter) Allow(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Duration, error) {
+	res, err := hitScript.Run(ctx, l.client, []string{"ratelimit:" + key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	if res[0] <= int64(limit) {
+		return true, 0, nil
+	}
+	return false, time.Duration(res[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,107 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// ChallengeTTL is how long a user has to complete the second login step.
+	ChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts bounds wrong codes per challenge; the user must then sign in again.
+	maxChallengeAttempts = 5
+)
+
+// Challenge is the pending second step of a login: the password was correct and an MFA code
+// is still required. Enrolled is false for users whose role requires MFA but who have not set it
+// up yet; they enroll within the challenge. Factor is the amr value of the first step ("pwd" for
+// passwords).
+type Challenge struct {
+	UserID   string
+	Email    string
+	Device   string
+	Enrolled bool
+	Factor   string
+}
+
+// CreateChallenge stores a challenge and returns the opaque token that identifies it.
+func (s *Store) CreateChallenge(ctx context.Context, c Challenge) (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	key := challengeKey(hashToken(token))
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]any{
+			"userId":   c.UserID,
+			"email":    c.Email,
+			"device":   c.Device,
+			"enrolled": strconv.FormatBool(c.Enrolled),
+			"factor":   c.Factor,
+			"attempts": 0,
+		})
+		pipe.Expire(ctx, key, ChallengeTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetChallenge loads a live challenge; expired or exhausted ones return ErrNotFound.
+func (s *Store) GetChallenge(ctx context.Context, token string) (*Challenge, error) {
+	fields, err := s.client.HGetAll(ctx, challengeKey(hashToken(token))).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
+	}
+	enrolled, _ := strconv.ParseBool(fields["enrolled"])
+	return &Challenge{
+		UserID:   fields["userId"],
+		Email:    fields["email"],
+		Device:   fields["device"],
+		Enrolled: enrolled,
+		Factor:   fields["factor"],
+	}, nil
+}
+
+// failChallengeScript bumps the attempt counter of an existing challenge and deletes it once the
+// limit is reached. It returns -1 for a missing challenge, 0 when it was used up, 1 otherwise.
+var failChallengeScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return -1
+end
+if redis.call("HINCRBY", KEYS[1], "attempts", 1) >= tonumber(ARGV[1]) then
+    redis.call("DEL", KEYS[1])
+    return 0
+end
+return 1
+`)
+
+// FailChallenge counts a wrong code and deletes the challenge once attempts run out. It reports
+// whether the challenge is still usable.
+func (s *Store) FailChallenge(ctx context.Context, token string) (bool, error) {
+	res, err := failChallengeScript.Run(ctx, s.client, []string{challengeKey(hashToken(token))}, maxChallengeAttempts).Int()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+// DeleteChallenge consumes a challenge. It reports whether the challenge still existed, so two
+// racing completions cannot both succeed.
+func (s *Store) DeleteChallenge(ctx context.Context, token string) (bool, error) {
+	n, err := s.client.Del(ctx, challengeKey(hashToken(token))).Result()
+	return n > 0, err
+}
+
+func challengeKey(hash string) string {
+	return fmt.Sprintf("mfa:challenge:%s", hash)
+}
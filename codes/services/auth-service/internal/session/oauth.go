@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthCodeTTL is how long an OAuth authorization code can be redeemed.
+const AuthCodeTTL = time.Minute
+
+// AuthCode is an issued OAuth authorization code waiting to be exchanged for a token. AMR is
+// copied from the session that approved it.
+type AuthCode struct {
+	ClientID    string
+	UserID      string
+	RedirectURI string
+	Challenge   string
+	Scopes      []string
+	AMR         []string
+}
+
+// CreateAuthCode stores an authorization code and returns it.
+func (s *Store) CreateAuthCode(ctx context.Context, code AuthCode) (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	key := authCodeKey(hashToken(token))
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]any{
+			"clientId":    code.ClientID,
+			"userId":      code.UserID,
+			"redirectUri": code.RedirectURI,
+			"challenge":   code.Challenge,
+			"scopes":      strings.Join(code.Scopes, " "),
+			"amr":         strings.Join(code.AMR, " "),
+		})
+		pipe.Expire(ctx, key, AuthCodeTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeAuthCode loads and deletes an authorization code, so it is redeemed at most once.
+// Unknown or expired codes return ErrNotFound.
+func (s *Store) ConsumeAuthCode(ctx context.Context, code string) (*AuthCode, error) {
+	key := authCodeKey(hashToken(code))
+	var fields *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := fields.Val()
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+	return &AuthCode{
+		ClientID:    values["clientId"],
+		UserID:      values["userId"],
+		RedirectURI: values["redirectUri"],
+		Challenge:   values["challenge"],
+		Scopes:      strings.Fields(values["scopes"]),
+		AMR:         strings.Fields(values["amr"]),
+	}, nil
+}
+
+func authCodeKey(hash string) string {
+	return fmt.Sprintf("oauth:code:%s", hash)
+}
@@ -0,0 +1,70 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AuthStateTTL is how long a user has to come back from the OpenID provider.
+const AuthStateTTL = 10 * time.Minute
+
+// AuthState is an OpenID Connect login between the redirect to the provider and the callback.
+// The nonce and PKCE verifier never leave the auth-service.
+type AuthState struct {
+	Provider string
+	Nonce    string
+	Verifier string
+}
+
+// CreateAuthState stores a pending login and returns the state parameter that identifies it.
+func (s *Store) CreateAuthState(ctx context.Context, st AuthState) (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	key := authStateKey(hashToken(token))
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, map[string]any{
+			"provider": st.Provider,
+			"nonce":    st.Nonce,
+			"verifier": st.Verifier,
+		})
+		pipe.Expire(ctx, key, AuthStateTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeAuthState loads and deletes a pending login, so each state is accepted once. Unknown
+// or expired states return ErrNotFound.
+func (s *Store) ConsumeAuthState(ctx context.Context, state string) (*AuthState, error) {
+	key := authStateKey(hashToken(state))
+	var fields *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := fields.Val()
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+	return &AuthState{
+		Provider: values["provider"],
+		Nonce:    values["nonce"],
+		Verifier: values["verifier"],
+	}, nil
+}
+
+func authStateKey(hash string) string {
+	return fmt.Sprintf("oidc:state:%s", hash)
+}
@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// LoginLinkTTL is how long an emailed sign-in link stays usable.
+	LoginLinkTTL = 15 * time.Minute
+	// CodeTTL is how long a code sent by SMS stays usable.
+	CodeTTL = 5 * time.Minute
+	// maxCodeAttempts bounds wrong guesses per code; a new code must then be requested.
+	maxCodeAttempts = 5
+)
+
+// One-time code purposes.
+const (
+	CodeLogin = "login"
+	CodePhone = "phone"
+)
+
+// ErrWrongCode is returned by VerifyCode for a wrong code that still has attempts left.
+var ErrWrongCode = errors.New("wrong code")
+
+// Code is a pending one-time code: the user it signs in or verifies, and the phone number it was
+// sent to.
+type Code struct {
+	UserID string
+	Target string
+}
+
+// CreateLoginLink stores a single-use sign-in link for the user and returns its token.
+func (s *Store) CreateLoginLink(ctx context.Context, userID string) (string, error) {
+	token, err := NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.client.Set(ctx, loginLinkKey(hashToken(token)), userID, LoginLinkTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConsumeLoginLink redeems a sign-in link and returns its user. Unknown, expired or used links
+// return ErrNotFound.
+func (s *Store) ConsumeLoginLink(ctx context.Context, token string) (string, error) {
+	userID, err := s.client.GetDel(ctx, loginLinkKey(hashToken(token))).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return userID, err
+}
+
+// CreateCode stores a six-digit code for purpose under key (the phone number for logins, the user
+// for phone verification) and returns it. An earlier code for the same key stops working.
+func (s *Store) CreateCode(ctx context.Context, purpose, key string, c Code) (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	code := fmt.Sprintf("%06d", n.Int64())
+	redisKey := codeKey(purpose, key)
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, redisKey)
+		pipe.HSet(ctx, redisKey, map[string]any{
+			"hash":     hashToken(code),
+			"userId":   c.UserID,
+			"target":   c.Target,
+			"attempts": 0,
+		})
+		pipe.Expire(ctx, redisKey, CodeTTL)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// verifyCodeScript consumes a matching code, or counts a wrong guess and deletes the code once
+// attempts run out. It returns {1, userId, target} on a match, {0} for a wrong code with
+// attempts left and {-1} when there is no usable code.
+var verifyCodeScript = redis.NewScript(`
+local fields = redis.call("HMGET", KEYS[1], "hash", "userId", "target")
+if not fields[1] then
+    return {-1}
+end
+if fields[1] == ARGV[1] then
+    redis.call("DEL", KEYS[1])
+    return {1, fields[2], fields[3]}
+end
+if redis.call("HINCRBY", KEYS[1], "attempts", 1) >= tonumber(ARGV[2]) then
+    redis.call("DEL", KEYS[1])
+    return {-1}
+end
+return {0}
+`)
+
+// VerifyCode checks code against the pending one for purpose and key, consuming it on a match.
+// It returns ErrWrongCode while attempts remain and ErrNotFound once the code is gone.
+func (s *Store) VerifyCode(ctx context.Context, purpose, key, code string) (*Code, error) {
+	res, err := verifyCodeScript.Run(ctx, s.client, []string{codeKey(purpose, key)}, hashToken(code), maxCodeAttempts).Slice()
+	if err != nil {
+		return nil, err
+	}
+	switch status, _ := res[0].(int64); status {
+	case 1:
+		userID, _ := res[1].(string)
+		target, _ := res[2].(string)
+		return &Code{UserID: userID, Target: target}, nil
+	case 0:
+		return nil, ErrWrongCode
+	default:
+		return nil, ErrNotFound
+	}
+}
+
+func loginLinkKey(hash string) string {
+	return fmt.Sprintf("passwordless:link:%s", hash)
+}
+
+// codeKey hashes key so phone numbers are not stored in key names.
+func codeKey(purpose, key string) string {
+	return fmt.Sprintf("passwordless:code:%s:%s", purpose, hashToken(key))
+}
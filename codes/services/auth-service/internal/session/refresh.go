@@ -0,0 +1,116 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrRefreshReused is returned when a refresh token that was already rotated is presented
+// again, which means it leaked. The returned RefreshToken names the family to revoke.
+var ErrRefreshReused = errors.New("refresh token reused")
+
+const refreshActive = "active"
+
+// RefreshToken is the stored record of an opaque refresh token. Only its SHA-256 is kept; the
+// record lives at refresh:<hash> and points back at its session (the token family) and the
+// token it replaced.
+type RefreshToken struct {
+	UserID     string
+	SessionID  string
+	ParentHash string
+}
+
+// NewRefreshToken returns a random, URL-safe refresh token.
+func NewRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// consumeScript atomically marks an active refresh token as consumed. It returns
+// {status, userId, sessionId, parent} where status is 0 (unknown), 1 (consumed now) or
+// 2 (already consumed, i.e. reuse).
+var consumeScript = redis.NewScript(`
+local rec = redis.call("HMGET", KEYS[1], "userId", "sessionId", "parent", "state")
+if not rec[1] then
+    return {0}
+end
+if rec[4] ~= "active" then
+    return {2, rec[1], rec[2], rec[3] or ""}
+end
+redis.call("HSET", KEYS[1], "state", "consumed", "consumedAt", ARGV[1])
+return {1, rec[1], rec[2], rec[3] or ""}
+`)
+
+// ConsumeRefresh redeems a refresh token. Each token works once: presenting it again returns
+// the record together with ErrRefreshReused. Unknown or expired tokens return ErrNotFound.
+func (s *Store) ConsumeRefresh(ctx context.Context, refreshToken string) (*RefreshToken, error) {
+	res, err := consumeScript.Run(ctx, s.client, []string{refreshKey(hashToken(refreshToken))}, time.Now().UTC().Format(time.RFC3339)).Slice()
+	if err != nil {
+		return nil, err
+	}
+	status, _ := res[0].(int64)
+	if status == 0 || len(res) < 4 {
+		return nil, ErrNotFound
+	}
+	rec := &RefreshToken{}
+	rec.UserID, _ = res[1].(string)
+	rec.SessionID, _ = res[2].(string)
+	rec.ParentHash, _ = res[3].(string)
+	if status == 2 {
+		return rec, ErrRefreshReused
+	}
+	return rec, nil
+}
+
+// rotateScript moves a session to its next refresh token, but only if the session still
+// exists, so a refresh racing a logout or family revocation cannot resurrect it.
+var rotateScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 0 then
+    return 0
+end
+redis.call("HSET", KEYS[1], "refreshHash", ARGV[1], "lastUsedAt", ARGV[2], "accessJti", ARGV[4], "accessExp", ARGV[5])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+redis.call("EXPIRE", KEYS[2], ARGV[3])
+redis.call("HSET", KEYS[3], "userId", ARGV[6], "sessionId", ARGV[7], "parent", ARGV[8], "state", "active")
+redis.call("EXPIRE", KEYS[3], ARGV[3])
+return 1
+`)
+
+// Rotate records refreshToken as the successor of parent in the session's family, stores the
+// new access token id, marks the session as used and extends its lifetime.
+func (s *Store) Rotate(ctx context.Context, parent *RefreshToken, parentToken, refreshToken, accessTokenID string, accessExpiresAt time.Time) error {
+	newHash := hashToken(refreshToken)
+	updated, err := rotateScript.Run(ctx, s.client,
+		[]string{s.key(parent.UserID, parent.SessionID), s.indexKey(parent.UserID), refreshKey(newHash)},
+		newHash, time.Now().UTC().Format(time.RFC3339), int(s.ttl.Seconds()),
+		accessTokenID, accessExpiresAt.UTC().Format(time.RFC3339),
+		parent.UserID, parent.SessionID, hashToken(parentToken),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// addRefreshToken queues the record of a family's first refresh token.
+func (s *Store) addRefreshToken(ctx context.Context, pipe redis.Pipeliner, userID, sessionID, refreshToken string) {
+	key := refreshKey(hashToken(refreshToken))
+	pipe.HSet(ctx, key, "userId", userID, "sessionId", sessionID, "parent", "", "state", refreshActive)
+	pipe.Expire(ctx, key, s.ttl)
+}
+
+func refreshKey(hash string) string {
+	return fmt.Sprintf("refresh:%s", hash)
+}
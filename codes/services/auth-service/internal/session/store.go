@@ -2,15 +2,46 @@ package session
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/venue-master/platform/lib/config"
 )
 
-// Store persists refresh tokens to Redis so they can be revoked.
+// ErrNotFound is returned when a session does not exist, has expired or belongs to another user.
+var ErrNotFound = errors.New("session not found")
+
+// Session describes one signed-in device.
+type Session struct {
+	ID         string
+	UserID     string
+	Device     string
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+
+	// AccessTokenID and AccessExpiresAt identify the newest access token of the session so it
+	// can be denylisted when the session is revoked.
+	AccessTokenID   string
+	AccessExpiresAt time.Time
+
+	// AMR records how the user signed in, so refreshed access tokens keep the same amr claim.
+	AMR []string
+}
+
+// Store persists sessions to Redis so they can be listed and revoked. Each session is a hash at
+// session:<user>:<id> holding its metadata and a SHA-256 of the current refresh token; the set
+// sessions:<user> indexes a user's session ids. A session is also a refresh-token family: see
+// refresh.go for how individual refresh tokens are tracked.
 type Store struct {
 	client *redis.Client
 	ttl    time.Duration
@@ -32,25 +63,167 @@ func NewStore(cfg config.RedisConfig, ttl time.Duration) (*Store, error) {
 	return &Store{client: client, ttl: ttl}, nil
 }
 
-// Save stores a refresh token for a user.
-func (s *Store) Save(ctx context.Context, userID, refreshToken string) error {
-	return s.client.Set(ctx, s.key(userID, refreshToken), "active", s.ttl).Err()
+// NewID returns a fresh session id to embed in tokens before the session is created.
+func NewID() string {
+	return uuid.NewString()
 }
 
-// Exists checks whether the refresh token is still active.
-func (s *Store) Exists(ctx context.Context, userID, refreshToken string) (bool, error) {
-	count, err := s.client.Exists(ctx, s.key(userID, refreshToken)).Result()
+// Create records a new session with the first refresh token of its family.
+func (s *Store) Create(ctx context.Context, sess Session, refreshToken string) error {
+	now := time.Now().UTC()
+	if sess.CreatedAt.IsZero() {
+		sess.CreatedAt = now
+	}
+	sess.LastUsedAt = sess.CreatedAt
+	key := s.key(sess.UserID, sess.ID)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key, toHash(sess, hashToken(refreshToken)))
+		pipe.Expire(ctx, key, s.ttl)
+		pipe.SAdd(ctx, s.indexKey(sess.UserID), sess.ID)
+		pipe.Expire(ctx, s.indexKey(sess.UserID), s.ttl)
+		s.addRefreshToken(ctx, pipe, sess.UserID, sess.ID, refreshToken)
+		return nil
+	})
+	return err
+}
+
+// List returns the user's live sessions, most recently used first. Expired ids are pruned from
+// the index as a side effect.
+func (s *Store) List(ctx context.Context, userID string) ([]Session, error) {
+	ids, err := s.client.SMembers(ctx, s.indexKey(userID)).Result()
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	if _, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, s.key(userID, id))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(ids))
+	var stale []any
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			stale = append(stale, ids[i])
+			continue
+		}
+		sessions = append(sessions, fromHash(userID, ids[i], fields))
+	}
+	if len(stale) > 0 {
+		if err := s.client.SRem(ctx, s.indexKey(userID), stale...).Err(); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// Get loads one session of the user.
+func (s *Store) Get(ctx context.Context, userID, sessionID string) (*Session, error) {
+	fields, err := s.client.HGetAll(ctx, s.key(userID, sessionID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, ErrNotFound
 	}
-	return count == 1, nil
+	sess := fromHash(userID, sessionID, fields)
+	return &sess, nil
 }
 
-// Delete removes a refresh token (used when rotating tokens).
-func (s *Store) Delete(ctx context.Context, userID, refreshToken string) error {
-	return s.client.Del(ctx, s.key(userID, refreshToken)).Err()
+// Delete revokes one session of the user and returns it.
+func (s *Store) Delete(ctx context.Context, userID, sessionID string) (*Session, error) {
+	key := s.key(userID, sessionID)
+	var fields *redis.MapStringStringCmd
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		pipe.SRem(ctx, s.indexKey(userID), sessionID)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if len(fields.Val()) == 0 {
+		return nil, ErrNotFound
+	}
+	sess := fromHash(userID, sessionID, fields.Val())
+	return &sess, nil
+}
+
+// DeleteAll revokes every session of the user and returns how many were live.
+func (s *Store) DeleteAll(ctx context.Context, userID string) (int, error) {
+	ids, err := s.client.SMembers(ctx, s.indexKey(userID)).Result()
+	if err != nil {
+		return 0, err
+	}
+	keys := make([]string, 0, len(ids)+1)
+	for _, id := range ids {
+		keys = append(keys, s.key(userID, id))
+	}
+	var del *redis.IntCmd
+	if _, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(keys) > 0 {
+			del = pipe.Del(ctx, keys...)
+		}
+		pipe.Del(ctx, s.indexKey(userID))
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	if del == nil {
+		return 0, nil
+	}
+	return int(del.Val()), nil
+}
+
+func (s *Store) key(userID, sessionID string) string {
+	return fmt.Sprintf("session:%s:%s", userID, sessionID)
+}
+
+func (s *Store) indexKey(userID string) string {
+	return fmt.Sprintf("sessions:%s", userID)
+}
+
+// toHash is the inverse of fromHash, plus the hash of the session's current refresh token.
+func toHash(sess Session, refreshHash string) map[string]any {
+	return map[string]any{
+		"device":      sess.Device,
+		"ip":          sess.IP,
+		"userAgent":   sess.UserAgent,
+		"createdAt":   sess.CreatedAt.Format(time.RFC3339),
+		"lastUsedAt":  sess.LastUsedAt.Format(time.RFC3339),
+		"refreshHash": refreshHash,
+		"accessJti":   sess.AccessTokenID,
+		"accessExp":   sess.AccessExpiresAt.Format(time.RFC3339),
+		"amr":         strings.Join(sess.AMR, " "),
+	}
+}
+
+func fromHash(userID, id string, fields map[string]string) Session {
+	sess := Session{
+		ID:        id,
+		UserID:    userID,
+		Device:    fields["device"],
+		IP:        fields["ip"],
+		UserAgent: fields["userAgent"],
+	}
+	sess.CreatedAt, _ = time.Parse(time.RFC3339, fields["createdAt"])
+	sess.LastUsedAt, _ = time.Parse(time.RFC3339, fields["lastUsedAt"])
+	sess.AccessTokenID = fields["accessJti"]
+	sess.AccessExpiresAt, _ = time.Parse(time.RFC3339, fields["accessExp"])
+	sess.AMR = strings.Fields(fields["amr"])
+	return sess
 }
 
-func (s *Store) key(userID, token string) string {
-	return fmt.Sprintf("session:%s:%s", userID, token)
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
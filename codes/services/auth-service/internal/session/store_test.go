@@ -0,0 +1,77 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/venue-master/platform/lib/config"
+)
+
+func TestHashRoundTrip(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	sess := Session{
+		ID:              "s1",
+		UserID:          "u1",
+		Device:          "laptop",
+		IP:              "203.0.113.7",
+		UserAgent:       "test",
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		AccessTokenID:   "jti",
+		AccessExpiresAt: now.Add(15 * time.Minute),
+		AMR:             []string{"pwd", "otp", "mfa"},
+	}
+	fields := map[string]string{}
+	for k, v := range toHash(sess, "hash") {
+		fields[k] = fmt.Sprint(v)
+	}
+	if got := fromHash(sess.UserID, sess.ID, fields); !reflect.DeepEqual(got, sess) {
+		t.Fatalf("fromHash(toHash()) = %+v, want %+v", got, sess)
+	}
+}
+
+// TestRefreshKeepsAMR runs against the Redis at SESSION_TEST_REDIS_ADDR: a session created
+// after an MFA sign-in must still report its amr once its refresh token has been rotated.
+func TestRefreshKeepsAMR(t *testing.T) {
+	addr := os.Getenv("SESSION_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("SESSION_TEST_REDIS_ADDR not set")
+	}
+	store, err := NewStore(config.RedisConfig{Addr: addr}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	sess := Session{ID: NewID(), UserID: NewID(), Device: "test", AMR: []string{"pwd", "otp", "mfa"}}
+	first, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(ctx, sess, first); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = store.DeleteAll(ctx, sess.UserID) })
+
+	parent, err := store.ConsumeRefresh(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewRefreshToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Rotate(ctx, parent, first, second, "jti-2", time.Now().Add(15*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := store.Get(ctx, sess.UserID, sess.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got.AMR, sess.AMR) {
+		t.Fatalf("AMR after refresh = %v, want %v", got.AMR, sess.AMR)
+	}
+}
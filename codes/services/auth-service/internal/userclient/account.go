@@ -0,0 +1,150 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// ErrEmailTaken is returned when changing to an address another account already uses.
+var ErrEmailTaken = errors.New("email already registered")
+
+// ErrErasureInProgress is returned when changing a deletion that is already being carried out.
+var ErrErasureInProgress = errors.New("account erasure in progress")
+
+// ErrPasswordNotSet is returned for imported members who have not yet chosen a password from
+// their invitation, so a password check cannot be made.
+var ErrPasswordNotSet = errors.New("password not set")
+
+// ChangePassword replaces the user's password after checking the current one. It returns
+// ErrInvalidCredentials when current is wrong, ErrPasswordNotSet while an invitation is pending.
+func (c *Client) ChangePassword(ctx context.Context, userID, current, next string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/"+url.PathEscape(userID)+"/password",
+		map[string]string{"currentPassword": current, "newPassword": next}, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidCredentials
+	case http.StatusForbidden:
+		return nil, ErrPasswordNotSet
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// RequestEmailChange records email as the user's pending address, after checking password, and
+// returns the token that confirms it.
+func (c *Client) RequestEmailChange(ctx context.Context, userID, email, password string) (string, *User, error) {
+	var out struct {
+		Token string `json:"token"`
+		User  User   `json:"user"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/"+url.PathEscape(userID)+"/email/change",
+		map[string]string{"email": email, "password": password}, &out)
+	if err != nil {
+		return "", nil, err
+	}
+	switch status {
+	case http.StatusCreated:
+		return out.Token, &out.User, nil
+	case http.StatusUnauthorized:
+		return "", nil, ErrInvalidCredentials
+	case http.StatusForbidden:
+		return "", nil, ErrPasswordNotSet
+	case http.StatusNotFound:
+		return "", nil, ErrUserNotFound
+	case http.StatusConflict:
+		return "", nil, ErrEmailTaken
+	default:
+		return "", nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// ConfirmEmailChange redeems an email change token.
+func (c *Client) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/email/change/confirm", map[string]string{"token": token}, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusBadRequest:
+		return nil, ErrInvalidToken
+	case http.StatusConflict:
+		return nil, ErrEmailTaken
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// ScheduleDeletion schedules deletion of the user's account after checking password. The
+// user-service decides when it runs.
+func (c *Client) ScheduleDeletion(ctx context.Context, userID, password string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, deletionPath(userID), map[string]string{"password": password}, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidCredentials
+	case http.StatusForbidden:
+		return nil, ErrPasswordNotSet
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	case http.StatusConflict:
+		return nil, ErrErasureInProgress
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// CancelDeletion cancels a pending account deletion. It returns ErrErasureInProgress once the
+// account is being erased.
+func (c *Client) CancelDeletion(ctx context.Context, userID string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodDelete, deletionPath(userID), nil, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	case http.StatusConflict:
+		return nil, ErrErasureInProgress
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// TouchActivity records that the user signed in or refreshed a session, which keeps the account
+// from being deleted for inactivity.
+func (c *Client) TouchActivity(ctx context.Context, userID string) error {
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/"+url.PathEscape(userID)+"/activity", nil, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusNoContent {
+		return fmt.Errorf("user service responded with %d", status)
+	}
+	return nil
+}
+
+func deletionPath(userID string) string {
+	return "/v1/users/" + url.PathEscape(userID) + "/deletion"
+}
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -23,14 +26,37 @@ type User struct {
 	FirstName string   `json:"firstName"`
 	LastName  string   `json:"lastName"`
 	Roles     []string `json:"roles"`
+	// Permissions are resolved by the user-service from the user's roles.
+	Permissions []string `json:"permissions"`
+	// VenueRoles and VenuePermissions describe roles granted at a single venue.
+	VenueRoles       []VenueRole         `json:"venueRoles"`
+	VenuePermissions map[string][]string `json:"venuePermissions"`
+	// EmailVerified is set once the user followed a verification or password reset link.
+	EmailVerified bool `json:"emailVerified"`
+	MFAEnabled    bool `json:"mfaEnabled"`
+	// Phone is in E.164 form; only a verified number can be used to sign in.
+	Phone         string `json:"phone"`
+	PhoneVerified bool   `json:"phoneVerified"`
+	// Disabled accounts cannot sign in or refresh their tokens.
+	Disabled bool `json:"disabled"`
+	// DeletionScheduledAt is when a pending account deletion will run.
+	DeletionScheduledAt *time.Time `json:"deletionScheduledAt"`
 }
 
-// New creates a new Client.
-func New(baseURL string) *Client {
+// VenueRole is a role assignment limited to one venue.
+type VenueRole struct {
+	Role    string `json:"role"`
+	VenueID string `json:"venueId"`
+}
+
+// New creates a new Client. transport signs requests for the user-service (see svcauth); nil
+// uses the default transport.
+func New(baseURL string, transport http.RoundTripper) *Client {
 	return &Client{
 		baseURL: strings.TrimRight(baseURL, "/"),
 		http: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: transport,
 		},
 	}
 }
@@ -56,6 +82,41 @@ func (c *Client) Authenticate(ctx context.Context, email, password string) (*Use
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, ErrInvalidCredentials
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service responded with %d", resp.StatusCode)
+	}
+
+	var user User
+	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ErrInvalidCredentials is returned by Authenticate when the email or password is wrong.
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// ErrUserNotFound is returned when user-service has no user with the requested id.
+var ErrUserNotFound = errors.New("user not found")
+
+// GetUser loads a user by id, e.g. to pick up role changes when refreshing tokens.
+func (c *Client) GetUser(ctx context.Context, userID string) (*User, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/v1/users/"+url.PathEscape(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrUserNotFound
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("user service responded with %d", resp.StatusCode)
 	}
@@ -104,3 +165,232 @@ func (c *Client) Register(ctx context.Context, email, password, firstName, lastN
 	}
 	return &user, nil
 }
+
+// Token purposes understood by user-service.
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeEmailVerify   = "email_verify"
+)
+
+var (
+	// ErrAlreadyVerified is returned by CreateToken when asking to verify a verified email.
+	ErrAlreadyVerified = errors.New("email already verified")
+	// ErrInvalidToken is returned when a reset or verification token is unknown, expired or used.
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// CreateToken asks user-service for a single-use token for the user owning email.
+func (c *Client) CreateToken(ctx context.Context, email, purpose string) (string, *User, error) {
+	var out struct {
+		Token string `json:"token"`
+		User  User   `json:"user"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/tokens", map[string]string{"email": email, "purpose": purpose}, &out)
+	if err != nil {
+		return "", nil, err
+	}
+	switch status {
+	case http.StatusCreated:
+		return out.Token, &out.User, nil
+	case http.StatusNotFound:
+		return "", nil, ErrUserNotFound
+	case http.StatusConflict:
+		return "", nil, ErrAlreadyVerified
+	default:
+		return "", nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// ResetPassword redeems a password reset token and sets the new password.
+func (c *Client) ResetPassword(ctx context.Context, token, password string) (*User, error) {
+	return c.redeemToken(ctx, "/v1/users/password/reset", map[string]string{"token": token, "password": password})
+}
+
+// VerifyEmail redeems an email verification token.
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	return c.redeemToken(ctx, "/v1/users/email/verify", map[string]string{"token": token})
+}
+
+func (c *Client) redeemToken(ctx context.Context, path string, payload map[string]string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, path, payload, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusBadRequest:
+		return nil, ErrInvalidToken
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// ErrIdentityConflict is returned by ResolveIdentity when the external identity's email belongs
+// to a local account whose address has not been verified.
+var ErrIdentityConflict = errors.New("email belongs to an unverified account")
+
+// ExternalIdentity is a verified OpenID Connect sign-in to resolve to a user.
+type ExternalIdentity struct {
+	Provider      string `json:"provider"`
+	Subject       string `json:"subject"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"emailVerified"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+}
+
+// ResolveIdentity returns the user an external identity signs in as, linking it to the user
+// with the same email or creating one on first sign-in. outcome is "existing", "linked" or
+// "created".
+func (c *Client) ResolveIdentity(ctx context.Context, identity ExternalIdentity) (*User, string, error) {
+	var out struct {
+		User    User   `json:"user"`
+		Outcome string `json:"outcome"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/identities/resolve", identity, &out)
+	if err != nil {
+		return nil, "", err
+	}
+	switch status {
+	case http.StatusOK, http.StatusCreated:
+		return &out.User, out.Outcome, nil
+	case http.StatusConflict:
+		return nil, "", ErrIdentityConflict
+	default:
+		return nil, "", fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// doJSON sends payload (if any) and decodes a 2xx response into out (if any), returning the
+// status code.
+func (c *Client) doJSON(ctx context.Context, method, path string, payload any, out any) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return 0, err
+		}
+		body = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 && resp.StatusCode != http.StatusNoContent {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, err
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+var (
+	// ErrMFAInvalidCode is returned when a TOTP or recovery code does not match.
+	ErrMFAInvalidCode = errors.New("invalid mfa code")
+	// ErrMFAConflict is returned when MFA is already enabled (enroll, confirm) or not enrolled
+	// (confirm, verify, recovery codes).
+	ErrMFAConflict = errors.New("mfa enrollment state conflict")
+)
+
+// MFAEnrollment is a pending TOTP secret and its otpauth:// URI for QR codes.
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+// EnrollMFA starts (or restarts) TOTP enrollment for the user.
+func (c *Client) EnrollMFA(ctx context.Context, userID string) (*MFAEnrollment, error) {
+	var out MFAEnrollment
+	status, err := c.doJSON(ctx, http.MethodPost, mfaPath(userID, "/enroll"), struct{}{}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if err := mfaStatusError(status, http.StatusCreated); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ConfirmMFA activates a pending enrollment and returns the recovery codes.
+func (c *Client) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, mfaPath(userID, "/confirm"), map[string]string{"code": code}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if err := mfaStatusError(status, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return out.RecoveryCodes, nil
+}
+
+// VerifyMFA checks a TOTP or recovery code and returns which one matched ("otp" or "recovery").
+func (c *Client) VerifyMFA(ctx context.Context, userID, code string) (string, error) {
+	var out struct {
+		Method string `json:"method"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, mfaPath(userID, "/verify"), map[string]string{"code": code}, &out)
+	if err != nil {
+		return "", err
+	}
+	if err := mfaStatusError(status, http.StatusOK); err != nil {
+		return "", err
+	}
+	return out.Method, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes.
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	var out struct {
+		RecoveryCodes []string `json:"recoveryCodes"`
+	}
+	status, err := c.doJSON(ctx, http.MethodPost, mfaPath(userID, "/recovery-codes"), struct{}{}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if err := mfaStatusError(status, http.StatusOK); err != nil {
+		return nil, err
+	}
+	return out.RecoveryCodes, nil
+}
+
+// DisableMFA removes the user's TOTP secret and recovery codes.
+func (c *Client) DisableMFA(ctx context.Context, userID string) error {
+	status, err := c.doJSON(ctx, http.MethodDelete, mfaPath(userID, ""), nil, nil)
+	if err != nil {
+		return err
+	}
+	return mfaStatusError(status, http.StatusNoContent)
+}
+
+func mfaPath(userID, suffix string) string {
+	return "/v1/users/" + url.PathEscape(userID) + "/mfa" + suffix
+}
+
+func mfaStatusError(status, want int) error {
+	switch status {
+	case want:
+		return nil
+	case http.StatusUnauthorized:
+		return ErrMFAInvalidCode
+	case http.StatusConflict:
+		return ErrMFAConflict
+	case http.StatusNotFound:
+		return ErrUserNotFound
+	default:
+		return fmt.Errorf("user service responded with %d", status)
+	}
+}
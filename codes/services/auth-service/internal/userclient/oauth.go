@@ -0,0 +1,154 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrClientNotFound is returned when no OAuth client has the requested id.
+	ErrClientNotFound = errors.New("oauth client not found")
+	// ErrInvalidClient is returned when a client id and secret do not match.
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// ErrConsentNotFound is returned when revoking a consent the user never gave.
+	ErrConsentNotFound = errors.New("consent not found")
+)
+
+// OAuthClient is a registered partner app.
+type OAuthClient struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Confidential bool     `json:"confidential"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes"`
+	GrantTypes   []string `json:"grantTypes"`
+}
+
+// AllowsGrant reports whether the client may use grantType.
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return contains(c.GrantTypes, grantType)
+}
+
+// AllowsRedirect reports whether uri exactly matches a registered redirect URI.
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	return contains(c.RedirectURIs, uri)
+}
+
+// AllowsScopes reports whether every scope is registered for the client.
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthConsent is the set of scopes a user granted a client.
+type OAuthConsent struct {
+	ClientID   string   `json:"clientId"`
+	ClientName string   `json:"clientName"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"createdAt"`
+	UpdatedAt  string   `json:"updatedAt"`
+}
+
+// GetOAuthClient loads a client's registration.
+func (c *Client) GetOAuthClient(ctx context.Context, clientID string) (*OAuthClient, error) {
+	var out OAuthClient
+	status, err := c.doJSON(ctx, http.MethodGet, clientPath(clientID, ""), nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &out, nil
+	case http.StatusNotFound:
+		return nil, ErrClientNotFound
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// AuthenticateClient checks a confidential client's secret.
+func (c *Client) AuthenticateClient(ctx context.Context, clientID, secret string) (*OAuthClient, error) {
+	var out OAuthClient
+	status, err := c.doJSON(ctx, http.MethodPost, clientPath(clientID, "/authenticate"), map[string]string{"secret": secret}, &out)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &out, nil
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidClient
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// ListConsents returns the clients the user has authorized.
+func (c *Client) ListConsents(ctx context.Context, userID string) ([]OAuthConsent, error) {
+	var out []OAuthConsent
+	status, err := c.doJSON(ctx, http.MethodGet, consentPath(userID, ""), nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+	return out, nil
+}
+
+// GrantConsent adds scopes to the user's consent for a client.
+func (c *Client) GrantConsent(ctx context.Context, userID, clientID string, scopes []string) (*OAuthConsent, error) {
+	var out OAuthConsent
+	status, err := c.doJSON(ctx, http.MethodPut, consentPath(userID, clientID), map[string][]string{"scopes": scopes}, &out)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+	return &out, nil
+}
+
+// RevokeConsent removes the user's consent for a client.
+func (c *Client) RevokeConsent(ctx context.Context, userID, clientID string) error {
+	status, err := c.doJSON(ctx, http.MethodDelete, consentPath(userID, clientID), nil, nil)
+	if err != nil {
+		return err
+	}
+	switch status {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		return ErrConsentNotFound
+	default:
+		return fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+func clientPath(clientID, suffix string) string {
+	return "/v1/oauth/clients/" + url.PathEscape(clientID) + suffix
+}
+
+func consentPath(userID, clientID string) string {
+	path := "/v1/users/" + url.PathEscape(userID) + "/consents"
+	if clientID != "" {
+		path += "/" + url.PathEscape(clientID)
+	}
+	return path
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
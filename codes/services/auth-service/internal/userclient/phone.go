@@ -0,0 +1,87 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrInvalidPhone is returned when user-service cannot read a phone number.
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrPhoneTaken is returned when another user has already verified the number.
+	ErrPhoneTaken = errors.New("phone number already in use")
+	// ErrPhoneChanged is returned when confirming a number that is no longer the user's.
+	ErrPhoneChanged = errors.New("phone number changed")
+)
+
+// FindByEmail loads the user with the given email.
+func (c *Client) FindByEmail(ctx context.Context, email string) (*User, error) {
+	return c.lookup(ctx, map[string]string{"email": email})
+}
+
+// FindByPhone loads the user who verified phone.
+func (c *Client) FindByPhone(ctx context.Context, phone string) (*User, error) {
+	return c.lookup(ctx, map[string]string{"phone": phone})
+}
+
+func (c *Client) lookup(ctx context.Context, payload map[string]string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, "/v1/users/lookup", payload, &user)
+	if err != nil {
+		return nil, err
+	}
+	switch status {
+	case http.StatusOK:
+		return &user, nil
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	case http.StatusBadRequest:
+		return nil, ErrInvalidPhone
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
+
+// SetPhone replaces the user's phone number, unverified unless it is unchanged.
+func (c *Client) SetPhone(ctx context.Context, userID, phone string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPut, phonePath(userID, ""), map[string]string{"phone": phone}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return phoneResult(&user, status)
+}
+
+// ConfirmPhone marks phone verified, provided it is still the user's number.
+func (c *Client) ConfirmPhone(ctx context.Context, userID, phone string) (*User, error) {
+	var user User
+	status, err := c.doJSON(ctx, http.MethodPost, phonePath(userID, "/confirm"), map[string]string{"phone": phone}, &user)
+	if err != nil {
+		return nil, err
+	}
+	return phoneResult(&user, status)
+}
+
+func phonePath(userID, suffix string) string {
+	return "/v1/users/" + url.PathEscape(userID) + "/phone" + suffix
+}
+
+func phoneResult(user *User, status int) (*User, error) {
+	switch status {
+	case http.StatusOK:
+		return user, nil
+	case http.StatusBadRequest:
+		return nil, ErrInvalidPhone
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	case http.StatusConflict:
+		return nil, ErrPhoneTaken
+	case http.StatusUnprocessableEntity:
+		return nil, ErrPhoneChanged
+	default:
+		return nil, fmt.Errorf("user service responded with %d", status)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -16,7 +17,13 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
 	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/s3util"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/booking-service/internal/households"
+	"github.com/venue-master/platform/services/booking-service/internal/media"
 	"github.com/venue-master/platform/services/booking-service/internal/middleware"
 	"github.com/venue-master/platform/services/booking-service/internal/notification"
 	"github.com/venue-master/platform/services/booking-service/internal/payment"
@@ -29,10 +36,13 @@ const (
 )
 
 type handler struct {
-	store   *store.Store
-	payment *payment.Client
-	notify  *notification.Client
-	logger  zerolog.Logger
+	store      *store.Store
+	payment    *payment.Client
+	notify     *notification.Client
+	households *households.Client
+	media      *media.Service
+	audit      *audit.Client
+	logger     zerolog.Logger
 }
 
 const paymentRetryMaxAttempts = 5
@@ -54,10 +64,22 @@ func main() {
 		panic(err)
 	}
 
-	paymentClient := payment.New(getEnv("PAYMENT_SERVICE_URL", "http://payment-service:8080"))
-	notificationClient := notification.New(getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"))
-	h := &handler{store: repo, payment: paymentClient, notify: notificationClient, logger: srv.Logger}
-	registerRoutes(srv.Engine, h)
+	paymentClient := payment.New(getEnv("PAYMENT_SERVICE_URL", "http://payment-service:8080"), srv.ServiceAuth.Transport(nil))
+	notificationClient := notification.New(getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"), srv.ServiceAuth.Transport(nil))
+	householdClient := households.New(getEnv("USER_SERVICE_URL", "http://user-service:8080"), srv.ServiceAuth.Transport(nil))
+	storage, err := s3util.NewFromConfig(ctx, srv.Config.AWS)
+	if err != nil {
+		panic(err)
+	}
+	if fsStorage, ok := storage.(*s3util.FilesystemProvider); ok {
+		// Registered before registerRoutes so signed URLs bypass RequireAuth.
+		srv.Engine.Any(fsStorage.BasePath()+"/*key", gin.WrapH(fsStorage.Handler()))
+	}
+	auditClient := audit.New("booking-service", getEnv("AUDIT_SERVICE_URL", "http://audit-service:8080"), srv.ServiceAuth.Transport(nil), srv.Logger)
+	h := &handler{store: repo, payment: paymentClient, notify: notificationClient, households: householdClient, media: media.NewService(storage), audit: auditClient, logger: srv.Logger}
+	// Identity headers are only trusted on calls signed by another service.
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
+	registerRoutes(srv.Engine, h, srv.ServiceAuth)
 
 	appCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -68,33 +90,42 @@ func main() {
 	}
 }
 
-func registerRoutes(router *gin.Engine, h *handler) {
+func registerRoutes(router *gin.Engine, h *handler, serviceAuth *svcauth.Keys) {
+	registerPrivacyRoutes(router, h, serviceAuth)
 	router.Use(middleware.RequireAuth())
 
-	readRoles := []string{middleware.RoleMember, middleware.RoleOperator, middleware.RoleAdmin, middleware.RoleVenueAdmin}
-	memberWriteRoles := []string{middleware.RoleMember, middleware.RoleAdmin, middleware.RoleVenueAdmin}
-	adminRoles := []string{middleware.RoleAdmin, middleware.RoleVenueAdmin}
-
 	// Venue routes
-	router.GET("/v1/venues", middleware.RequireRoles(readRoles...), h.listVenues)
-	router.GET("/v1/venues/:id", middleware.RequireRoles(readRoles...), h.getVenue)
-	router.POST("/v1/venues", middleware.RequireRoles(adminRoles...), h.createVenue)
-	router.PUT("/v1/venues/:id", middleware.RequireRoles(adminRoles...), h.updateVenue)
-	router.DELETE("/v1/venues/:id", middleware.RequireRoles(adminRoles...), h.deleteVenue)
+	router.GET("/v1/venues", authz.RequirePermission(authz.VenueRead), h.listVenues)
+	router.GET("/v1/venues/:id", authz.RequirePermission(authz.VenueRead), h.getVenue)
+	router.POST("/v1/venues", authz.RequirePermission(authz.VenueCreate), h.createVenue)
+	router.PUT("/v1/venues/:id", authz.RequireScopedPermission(authz.VenueUpdate), h.updateVenue)
+	router.DELETE("/v1/venues/:id", authz.RequireScopedPermission(authz.VenueDelete), h.deleteVenue)
+	router.POST("/v1/venues/:id/restore", authz.RequireScopedPermission(authz.VenueDelete), h.restoreVenue)
+	registerMediaRoutes(router, h, "/v1/venues", media.OwnerVenue, authz.VenueRead, authz.VenueUpdate)
+	router.GET("/v1/venues/:id/map", authz.RequirePermission(authz.VenueRead), h.getVenueMap)
+	router.PUT("/v1/venues/:id/map", authz.RequireScopedPermission(authz.VenueUpdate), h.saveVenueMap)
+	router.GET("/v1/venues/:id/map/availability", authz.RequirePermission(authz.VenueRead), h.getVenueMapAvailability)
+	router.PUT("/v1/venues/:id/map/facilities/:facilityId", authz.RequireScopedPermission(authz.VenueUpdate), h.saveFacilityShape)
+	router.DELETE("/v1/venues/:id/map/facilities/:facilityId", authz.RequireScopedPermission(authz.VenueUpdate), h.deleteFacilityShape)
 
 	// Booking routes
-	router.GET("/v1/bookings", middleware.RequireRoles(readRoles...), h.listBookings)
-	router.GET("/v1/bookings/:id", middleware.RequireRoles(readRoles...), h.getBooking)
-	router.POST("/v1/bookings", middleware.RequireRoles(memberWriteRoles...), h.createBooking)
-	router.DELETE("/v1/bookings/:id", middleware.RequireRoles(memberWriteRoles...), h.cancelBooking)
+	router.GET("/v1/bookings", authz.RequireScopedPermission(authz.BookingReadOwn, authz.BookingReadAny), h.listBookings)
+	router.GET("/v1/bookings/:id", authz.RequireScopedPermission(authz.BookingReadOwn, authz.BookingReadAny), h.getBooking)
+	router.POST("/v1/bookings", authz.RequireScopedPermission(authz.BookingCreate, authz.BookingCreateAny), h.createBooking)
+	router.DELETE("/v1/bookings/:id", authz.RequireScopedPermission(authz.BookingCancelOwn, authz.BookingCancelAny), h.cancelBooking)
 
 	// Facility routes
-	router.GET("/v1/facilities", middleware.RequireRoles(readRoles...), h.listFacilities)
-	router.POST("/v1/facilities", middleware.RequireRoles(adminRoles...), h.createFacility)
-	router.PATCH("/v1/facilities/:id", middleware.RequireRoles(adminRoles...), h.updateFacilityAvailability)
-	router.GET("/v1/facilities/:id/schedule", middleware.RequireRoles(readRoles...), h.getFacilitySchedule)
-	router.POST("/v1/facilities/:id/overrides", middleware.RequireRoles(adminRoles...), h.createFacilityOverride)
-	router.DELETE("/v1/facilities/:id/overrides/:overrideId", middleware.RequireRoles(adminRoles...), h.deleteFacilityOverride)
+	router.GET("/v1/facilities", authz.RequirePermission(authz.FacilityRead), h.listFacilities)
+	router.POST("/v1/facilities", authz.RequireScopedPermission(authz.FacilityCreate), h.createFacility)
+	router.GET("/v1/facilities/:id", authz.RequirePermission(authz.FacilityRead), h.getFacility)
+	router.PUT("/v1/facilities/:id", authz.RequireScopedPermission(authz.FacilityUpdate), h.updateFacility)
+	router.PATCH("/v1/facilities/:id", authz.RequireScopedPermission(authz.FacilityUpdate), h.updateFacilityAvailability)
+	router.DELETE("/v1/facilities/:id", authz.RequireScopedPermission(authz.FacilityDelete), h.deleteFacility)
+	router.POST("/v1/facilities/:id/restore", authz.RequireScopedPermission(authz.FacilityDelete), h.restoreFacility)
+	router.GET("/v1/facilities/:id/schedule", authz.RequirePermission(authz.FacilityRead), h.getFacilitySchedule)
+	router.POST("/v1/facilities/:id/overrides", authz.RequireScopedPermission(authz.FacilitySchedule), h.createFacilityOverride)
+	router.DELETE("/v1/facilities/:id/overrides/:overrideId", authz.RequireScopedPermission(authz.FacilitySchedule), h.deleteFacilityOverride)
+	registerMediaRoutes(router, h, "/v1/facilities", media.OwnerFacility, authz.FacilityRead, authz.FacilityUpdate)
 }
 
 type bookingRequest struct {
@@ -116,6 +147,17 @@ type facilityRequest struct {
 	Currency    string `json:"currency"`
 }
 
+type facilityUpdateRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Surface     string `json:"surface"`
+	OpenAt      string `json:"openAt" binding:"required"`
+	CloseAt     string `json:"closeAt" binding:"required"`
+	WeekdayRate int    `json:"weekdayRateCents"`
+	WeekendRate int    `json:"weekendRateCents"`
+	Currency    string `json:"currency"`
+}
+
 type availabilityRequest struct {
 	Available bool `json:"available"`
 }
@@ -147,30 +189,37 @@ type venueRequest struct {
 func (h *handler) listBookings(ctx *gin.Context) {
 	user, _ := middleware.GetUser(ctx)
 	var filter uuid.UUID
+	// Venue-scoped admins only see other people's bookings at their venues.
+	venues := user.ScopedVenues(authz.BookingReadAny)
+	readsOthers := venues == nil || len(venues) > 0
 
 	if userIDParam := ctx.Query("userId"); userIDParam != "" {
 		id, ok := uuidFromString(ctx, userIDParam, "userId")
 		if !ok {
 			return
 		}
-		if !isAdmin(user) && id.String() != user.UserID {
+		// Household members see each other's bookings in full.
+		if id.String() == user.UserID || h.sharesHousehold(ctx, user, id) {
+			venues = nil
+		} else if !readsOthers {
 			ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 			return
 		}
 		filter = id
-	} else if !isAdmin(user) {
+	} else if !readsOthers {
 		id, ok := uuidFromString(ctx, user.UserID, "userId")
 		if !ok {
 			return
 		}
 		filter = id
+		venues = nil
 	}
 
 	limit, offset, ok := paginationParams(ctx)
 	if !ok {
 		return
 	}
-	bookings, err := h.store.ListBookings(ctx, filter, limit, offset)
+	bookings, err := h.store.ListBookings(ctx, filter, venues, limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -193,7 +242,7 @@ func (h *handler) getBooking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if !isAdmin(user) && booking.UserID.String() != user.UserID {
+	if !h.canActOnBooking(ctx, user, booking, authz.BookingReadOwn, authz.BookingReadAny) {
 		ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
@@ -221,21 +270,39 @@ func (h *handler) createBooking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if !facility.Available && !isAdmin(user) {
+	if facility.ArchivedAt != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+		return
+	}
+	createAny := user.CanAt(facility.VenueID, authz.BookingCreateAny)
+	if !facility.Available && !createAny {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "facility unavailable"})
 		return
 	}
 	if req.UserID == "" {
 		req.UserID = user.UserID
 	}
-	if !isAdmin(user) && req.UserID != user.UserID {
-		ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	userID, ok := uuidFromString(ctx, req.UserID, "userId")
+	if !ok {
 		return
 	}
-	userID, ok := uuidFromString(ctx, req.UserID, "userId")
+	bookedBy, ok := uuidFromString(ctx, user.UserID, "userId")
 	if !ok {
 		return
 	}
+	// The owner's household pays for the booking, and its members may book for each other.
+	household, err := h.households.Get(ctx, userID)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("user_id", userID.String()).Msg("household lookup failed")
+	}
+	if !createAny && (!user.CanAt(facility.VenueID, authz.BookingCreate) || (userID != bookedBy && !household.Has(bookedBy))) {
+		if err != nil && userID != bookedBy {
+			ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "household lookup failed"})
+			return
+		}
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		return
+	}
 	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid startsAt"})
@@ -251,20 +318,25 @@ func (h *handler) createBooking(ctx *gin.Context) {
 	booking, err := h.store.CreateBooking(ctx, store.CreateBookingInput{
 		FacilityID:  facilityID,
 		UserID:      userID,
+		BookedBy:    bookedBy,
 		StartsAt:    startsAt,
 		EndsAt:      endsAt,
 		AmountCents: amount,
 		Currency:    facility.Currency,
 	})
+	if errors.Is(err, store.ErrFacilityArchived) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	intent, err := h.payment.Charge(ctx, booking.AmountCents, booking.Currency, map[string]string{
+	intent, err := h.payment.Charge(ctx, booking.AmountCents, booking.Currency, householdMetadata(map[string]string{
 		"booking_id":  booking.ID.String(),
 		"facility_id": booking.FacilityID.String(),
-	})
+	}, household))
 	if err != nil {
 		h.logger.Warn().Err(err).Str("booking_id", booking.ID.String()).Msg("payment intent failed")
 		if _, updateErr := h.store.UpdateBookingStatus(ctx, booking.ID, "PAYMENT_RETRY", ""); updateErr != nil {
@@ -288,6 +360,13 @@ func (h *handler) createBooking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if userID != bookedBy {
+		details := gin.H{"onBehalfOf": userID.String()}
+		if household.Has(bookedBy) {
+			details["householdId"] = household.ID.String()
+		}
+		h.audit.RecordChange(ctx, "booking.create", "booking", booking.ID.String(), nil, bookingResponse(*booking), details)
+	}
 
 	ctx.JSON(http.StatusCreated, bookingResponse(*booking))
 }
@@ -307,11 +386,15 @@ func (h *handler) cancelBooking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	if !isAdmin(user) && existing.UserID.String() != user.UserID {
+	if !h.canActOnBooking(ctx, user, existing, authz.BookingCancelOwn, authz.BookingCancelAny) {
 		ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
 		return
 	}
-	booking, err := h.store.CancelBooking(ctx, id)
+	cancelledBy, ok := uuidFromString(ctx, user.UserID, "userId")
+	if !ok {
+		return
+	}
+	booking, err := h.store.CancelBooking(ctx, id, cancelledBy)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -320,6 +403,7 @@ func (h *handler) cancelBooking(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "booking.cancel", "booking", booking.ID.String(), bookingResponse(*existing), bookingResponse(*booking), gin.H{"ownerId": existing.UserID.String()})
 	ctx.JSON(http.StatusOK, bookingResponse(*booking))
 }
 
@@ -353,14 +437,48 @@ func (h *handler) listFacilities(ctx *gin.Context) {
 		}
 	}
 
-	facilities, err := h.store.ListFacilities(ctx, venueID, availablePtr, limit, offset)
+	user, _ := middleware.GetUser(ctx)
+	includeArchived, venues := archivedView(ctx, user, authz.FacilityReadArchived)
+
+	facilities, err := h.store.ListFacilities(ctx, venueID, venues, availablePtr, includeArchived, limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.attachFacilityMedia(ctx, facilities); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	ctx.JSON(http.StatusOK, facilitiesResponse(facilities))
 }
 
+func (h *handler) getFacility(ctx *gin.Context) {
+	user, _ := middleware.GetUser(ctx)
+	facilityID, ok := uuidFromString(ctx, ctx.Param("id"), "facility id")
+	if !ok {
+		return
+	}
+	facility, err := h.store.GetFacility(ctx, facilityID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if facility.ArchivedAt != nil && !user.CanAt(facility.VenueID, authz.FacilityReadArchived) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+		return
+	}
+	hydrated := []store.Facility{*facility}
+	if err := h.attachFacilityMedia(ctx, hydrated); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, facilityResponse(hydrated[0]))
+}
+
 func (h *handler) createFacility(ctx *gin.Context) {
 	var req facilityRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -372,28 +490,19 @@ func (h *handler) createFacility(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid venueId"})
 		return
 	}
-	openAt, err := time.Parse("15:04", req.OpenAt)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid openAt, use HH:MM"})
+	venue, err := h.store.GetVenue(ctx, venueID)
+	if err != nil || venue.ArchivedAt != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "venue not found"})
 		return
 	}
-	closeAt, err := time.Parse("15:04", req.CloseAt)
-	if err != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid closeAt, use HH:MM"})
+	if !authorizeVenue(ctx, venue.ID, authz.FacilityCreate) {
 		return
 	}
-	weekdayRate := req.WeekdayRate
-	if weekdayRate <= 0 {
-		weekdayRate = 4500
-	}
-	weekendRate := req.WeekendRate
-	if weekendRate <= 0 {
-		weekendRate = weekdayRate
-	}
-	currency := req.Currency
-	if currency == "" {
-		currency = "CAD"
+	openAt, closeAt, ok := parseFacilityHours(ctx, req.OpenAt, req.CloseAt)
+	if !ok {
+		return
 	}
+	weekdayRate, weekendRate, currency := facilityPricing(req.WeekdayRate, req.WeekendRate, req.Currency)
 
 	facility := store.Facility{
 		ID:               uuid.New(),
@@ -413,15 +522,141 @@ func (h *handler) createFacility(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "facility.create", "facility", created.ID.String(), nil, facilityResponse(*created), nil)
 	ctx.JSON(http.StatusCreated, facilityResponse(*created))
 }
 
+func (h *handler) updateFacility(ctx *gin.Context) {
+	facilityID, ok := uuidFromString(ctx, ctx.Param("id"), "facility id")
+	if !ok {
+		return
+	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilityUpdate) {
+		return
+	}
+	var req facilityUpdateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	openAt, closeAt, ok := parseFacilityHours(ctx, req.OpenAt, req.CloseAt)
+	if !ok {
+		return
+	}
+	weekdayRate, weekendRate, currency := facilityPricing(req.WeekdayRate, req.WeekendRate, req.Currency)
+
+	before, err := h.store.GetFacility(ctx, facilityID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	updated, err := h.store.UpdateFacility(ctx, facilityID, store.Facility{
+		Name:             req.Name,
+		Description:      req.Description,
+		Surface:          req.Surface,
+		OpenAt:           openAt,
+		CloseAt:          closeAt,
+		WeekdayRateCents: weekdayRate,
+		WeekendRateCents: weekendRate,
+		Currency:         currency,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.audit.RecordChange(ctx, "facility.update", "facility", facilityID.String(), facilityResponse(*before), facilityResponse(*updated), nil)
+	ctx.JSON(http.StatusOK, facilityResponse(*updated))
+}
+
+func (h *handler) deleteFacility(ctx *gin.Context) {
+	facilityID, ok := uuidFromString(ctx, ctx.Param("id"), "facility id")
+	if !ok {
+		return
+	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilityDelete) {
+		return
+	}
+	if err := h.store.ArchiveFacility(ctx, facilityID); err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+		case errors.Is(err, store.ErrFacilityHasUpcomingBookings):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	h.audit.RecordChange(ctx, "facility.archive", "facility", facilityID.String(), nil, nil, nil)
+	ctx.JSON(http.StatusOK, gin.H{"message": "facility archived"})
+}
+
+func (h *handler) restoreFacility(ctx *gin.Context) {
+	facilityID, ok := uuidFromString(ctx, ctx.Param("id"), "facility id")
+	if !ok {
+		return
+	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilityDelete) {
+		return
+	}
+	facility, err := h.store.RestoreFacility(ctx, facilityID)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+		case errors.Is(err, store.ErrNotArchived), errors.Is(err, store.ErrVenueArchived):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	h.audit.RecordChange(ctx, "facility.restore", "facility", facilityID.String(), nil, nil, nil)
+	ctx.JSON(http.StatusOK, facilityResponse(*facility))
+}
+
+// parseFacilityHours validates HH:MM opening hours and writes a 400 on failure.
+func parseFacilityHours(ctx *gin.Context, openStr, closeStr string) (time.Time, time.Time, bool) {
+	openAt, err := time.Parse("15:04", openStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid openAt, use HH:MM"})
+		return time.Time{}, time.Time{}, false
+	}
+	closeAt, err := time.Parse("15:04", closeStr)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid closeAt, use HH:MM"})
+		return time.Time{}, time.Time{}, false
+	}
+	return openAt, closeAt, true
+}
+
+// facilityPricing applies the default rates and currency for omitted values.
+func facilityPricing(weekdayRate, weekendRate int, currency string) (int, int, string) {
+	if weekdayRate <= 0 {
+		weekdayRate = 4500
+	}
+	if weekendRate <= 0 {
+		weekendRate = weekdayRate
+	}
+	if currency == "" {
+		currency = "CAD"
+	}
+	return weekdayRate, weekendRate, currency
+}
+
 func (h *handler) updateFacilityAvailability(ctx *gin.Context) {
 	facilityID, err := uuid.Parse(ctx.Param("id"))
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid facility id"})
 		return
 	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilityUpdate) {
+		return
+	}
 	var req availabilityRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -429,9 +664,14 @@ func (h *handler) updateFacilityAvailability(ctx *gin.Context) {
 	}
 	facility, err := h.store.UpdateFacilityAvailability(ctx, facilityID, req.Available)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "facility.availability", "facility", facilityID.String(), nil, nil, gin.H{"available": facility.Available})
 	ctx.JSON(http.StatusOK, facilityResponse(*facility))
 }
 
@@ -469,6 +709,9 @@ func (h *handler) createFacilityOverride(ctx *gin.Context) {
 	if !ok {
 		return
 	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilitySchedule) {
+		return
+	}
 	var req facilityOverrideRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -532,11 +775,16 @@ func (h *handler) createFacilityOverride(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "facility.override.create", "facility_override", created.ID.String(), nil, facilityOverrideResponse(*created), nil)
 	ctx.JSON(http.StatusCreated, facilityOverrideResponse(*created))
 }
 
 func (h *handler) deleteFacilityOverride(ctx *gin.Context) {
-	if _, ok := uuidFromString(ctx, ctx.Param("id"), "facility id"); !ok {
+	facilityID, ok := uuidFromString(ctx, ctx.Param("id"), "facility id")
+	if !ok {
+		return
+	}
+	if !h.authorizeFacility(ctx, facilityID, authz.FacilitySchedule) {
 		return
 	}
 	overrideID, ok := uuidFromString(ctx, ctx.Param("overrideId"), "override id")
@@ -551,6 +799,7 @@ func (h *handler) deleteFacilityOverride(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "facility.override.delete", "facility_override", overrideID.String(), nil, nil, gin.H{"facilityId": facilityID.String()})
 	ctx.Status(http.StatusNoContent)
 }
 
@@ -611,6 +860,8 @@ func bookingResponse(b store.Booking) gin.H {
 		"amountCents":   b.AmountCents,
 		"currency":      b.Currency,
 		"paymentIntent": b.PaymentIntent,
+		"bookedBy":      b.BookedBy,
+		"cancelledBy":   b.CancelledBy,
 	}
 	if b.Facility != nil {
 		resp["facility"] = facilityResponse(*b.Facility)
@@ -627,7 +878,7 @@ func bookingsResponse(items []store.Booking) []gin.H {
 }
 
 func facilityResponse(f store.Facility) gin.H {
-	return gin.H{
+	resp := gin.H{
 		"id":               f.ID,
 		"venueId":          f.VenueID,
 		"name":             f.Name,
@@ -640,6 +891,11 @@ func facilityResponse(f store.Facility) gin.H {
 		"weekendRateCents": f.WeekendRateCents,
 		"currency":         f.Currency,
 	}
+	if f.ArchivedAt != nil {
+		resp["archivedAt"] = f.ArchivedAt.Format(time.RFC3339)
+	}
+	resp["photos"], resp["floorPlan"] = galleryResponse(f.Media)
+	return resp
 }
 
 func facilitiesResponse(items []store.Facility) []gin.H {
@@ -696,8 +952,85 @@ func uuidFromString(ctx *gin.Context, value, field string) (uuid.UUID, bool) {
 	return id, true
 }
 
-func isAdmin(user middleware.ContextUser) bool {
-	return user.HasAnyRole(middleware.RoleAdmin, middleware.RoleVenueAdmin)
+// authorizeVenue writes a 403 unless the caller holds one of permissions globally or at venueID.
+func authorizeVenue(ctx *gin.Context, venueID uuid.UUID, permissions ...string) bool {
+	user, _ := middleware.GetUser(ctx)
+	if user.CanAt(venueID, permissions...) {
+		return true
+	}
+	ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+	return false
+}
+
+// authorizeFacility looks up the facility's venue and applies authorizeVenue, writing a 404
+// for unknown facilities.
+func (h *handler) authorizeFacility(ctx *gin.Context, facilityID uuid.UUID, permissions ...string) bool {
+	facility, err := h.store.GetFacility(ctx, facilityID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility not found"})
+			return false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return authorizeVenue(ctx, facility.VenueID, permissions...)
+}
+
+// canActOnBooking reports whether the user may act on booking: anyone's with anyPerm at its
+// venue, their own or their household's with ownPerm.
+func (h *handler) canActOnBooking(ctx context.Context, user middleware.ContextUser, booking *store.Booking, ownPerm, anyPerm string) bool {
+	venueID := uuid.Nil
+	if booking.Facility != nil {
+		venueID = booking.Facility.VenueID
+	}
+	if user.CanAt(venueID, anyPerm) {
+		return true
+	}
+	if !user.CanAt(venueID, ownPerm) {
+		return false
+	}
+	return booking.UserID.String() == user.UserID || h.sharesHousehold(ctx, user, booking.UserID)
+}
+
+// sharesHousehold reports whether userID is in the user's household. A failed lookup counts as
+// no, so household access degrades to own bookings only.
+func (h *handler) sharesHousehold(ctx context.Context, user middleware.ContextUser, userID uuid.UUID) bool {
+	callerID, err := uuid.Parse(user.UserID)
+	if err != nil {
+		return false
+	}
+	household, err := h.households.Get(ctx, callerID)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("user_id", user.UserID).Msg("household lookup failed")
+		return false
+	}
+	return household.Has(userID)
+}
+
+// householdMetadata adds the household paying for a booking to its charge metadata.
+func householdMetadata(metadata map[string]string, household *households.Household) map[string]string {
+	if household == nil {
+		return metadata
+	}
+	metadata["household_id"] = household.ID.String()
+	if household.PaymentMethodID != "" {
+		metadata["payment_method_id"] = household.PaymentMethodID
+	}
+	return metadata
+}
+
+// archivedView resolves ?includeArchived=true. Callers holding permission only at some venues
+// get those venues, archived or not; the returned venue list is nil when unrestricted.
+func archivedView(ctx *gin.Context, user middleware.ContextUser, permission string) (bool, []uuid.UUID) {
+	if ctx.Query("includeArchived") != "true" {
+		return false, nil
+	}
+	venues := user.ScopedVenues(permission)
+	if venues != nil && len(venues) == 0 {
+		return false, nil
+	}
+	return true, venues
 }
 
 func paginationParams(ctx *gin.Context) (int, int, bool) {
@@ -779,11 +1112,15 @@ func (h *handler) handleSingleRetry(ctx context.Context, retry store.PaymentRetr
 		return
 	}
 
-	metadata := map[string]string{
+	household, err := h.households.Get(ctxTimeout, booking.UserID)
+	if err != nil {
+		h.logger.Warn().Err(err).Str("booking_id", booking.ID.String()).Msg("household lookup failed")
+	}
+	metadata := householdMetadata(map[string]string{
 		"booking_id":    booking.ID.String(),
 		"facility_id":   booking.FacilityID.String(),
 		"retry_attempt": fmt.Sprintf("%d", attempt),
-	}
+	}, household)
 	intent, err := h.payment.Charge(ctxTimeout, booking.AmountCents, booking.Currency, metadata)
 	if err == nil {
 		if _, updateErr := h.store.UpdateBookingStatus(ctxTimeout, booking.ID, "CONFIRMED", intent.ID); updateErr != nil {
@@ -858,11 +1195,18 @@ func (h *handler) listVenues(ctx *gin.Context) {
 		return
 	}
 
-	venues, err := h.store.ListVenues(ctx, limit, offset)
+	user, _ := middleware.GetUser(ctx)
+	includeArchived, scoped := archivedView(ctx, user, authz.VenueReadArchived)
+
+	venues, err := h.store.ListVenues(ctx, includeArchived, scoped, limit, offset)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.attachVenueMedia(ctx, venues); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	ctx.JSON(http.StatusOK, venuesResponse(venues))
 }
@@ -878,8 +1222,17 @@ func (h *handler) getVenue(ctx *gin.Context) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
 		return
 	}
+	if user, _ := middleware.GetUser(ctx); venue.ArchivedAt != nil && !user.CanAt(venue.ID, authz.VenueReadArchived) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
+		return
+	}
+	hydrated := []store.Venue{*venue}
+	if err := h.attachVenueMedia(ctx, hydrated); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
-	ctx.JSON(http.StatusOK, venueResponse(*venue))
+	ctx.JSON(http.StatusOK, venueResponse(hydrated[0]))
 }
 
 func (h *handler) createVenue(ctx *gin.Context) {
@@ -908,6 +1261,7 @@ func (h *handler) createVenue(ctx *gin.Context) {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "venue.create", "venue", created.ID.String(), nil, venueResponse(*created), nil)
 
 	ctx.JSON(http.StatusCreated, venueResponse(*created))
 }
@@ -918,6 +1272,10 @@ func (h *handler) updateVenue(ctx *gin.Context) {
 		return
 	}
 
+	if !authorizeVenue(ctx, id, authz.VenueUpdate) {
+		return
+	}
+
 	var req venueRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -938,35 +1296,141 @@ func (h *handler) updateVenue(ctx *gin.Context) {
 		Timezone:    req.Timezone,
 	}
 
+	before, err := h.store.GetVenue(ctx, id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	updated, err := h.store.UpdateVenue(ctx, id, venue)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	h.audit.RecordChange(ctx, "venue.update", "venue", id.String(), venueResponse(*before), venueResponse(*updated), nil)
 
 	ctx.JSON(http.StatusOK, venueResponse(*updated))
 }
 
+// deleteVenue archives a venue. Upcoming bookings block the archive with 409 unless
+// cancelBookings=true is passed, which cancels, refunds and notifies them in bulk.
 func (h *handler) deleteVenue(ctx *gin.Context) {
 	id, ok := uuidFromString(ctx, ctx.Param("id"), "id")
 	if !ok {
 		return
 	}
+	if !authorizeVenue(ctx, id, authz.VenueDelete) {
+		return
+	}
+	cancelBookings := ctx.Query("cancelBookings") == "true"
 
-	if err := h.store.DeleteVenue(ctx, id); err != nil {
-		if err == pgx.ErrNoRows {
+	cancelled, err := h.store.ArchiveVenue(ctx, id, cancelBookings)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
 			ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
-		} else {
+		case errors.Is(err, store.ErrVenueHasUpcomingBookings):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error(), "hint": "retry with cancelBookings=true to cancel and refund them"})
+		default:
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
 		return
 	}
 
-	ctx.JSON(http.StatusOK, gin.H{"message": "venue deleted"})
+	failedRefunds := h.refundCancelledBookings(ctx, cancelled)
+	cancelledIDs := make([]string, 0, len(cancelled))
+	for _, booking := range cancelled {
+		cancelledIDs = append(cancelledIDs, booking.ID.String())
+	}
+	h.audit.RecordChange(ctx, "venue.archive", "venue", id.String(), nil, nil, gin.H{
+		"cancelledBookings": cancelledIDs,
+		"failedRefunds":     failedRefunds,
+	})
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":           "venue archived",
+		"cancelledBookings": len(cancelled),
+		"failedRefunds":     failedRefunds,
+	})
+}
+
+func (h *handler) restoreVenue(ctx *gin.Context) {
+	id, ok := uuidFromString(ctx, ctx.Param("id"), "id")
+	if !ok {
+		return
+	}
+	if !authorizeVenue(ctx, id, authz.VenueDelete) {
+		return
+	}
+	venue, err := h.store.RestoreVenue(ctx, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
+		case errors.Is(err, store.ErrNotArchived):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	h.audit.RecordChange(ctx, "venue.restore", "venue", id.String(), nil, nil, nil)
+	ctx.JSON(http.StatusOK, venueResponse(*venue))
+}
+
+// refundCancelledBookings settles the payments of bookings cancelled by a venue archive and
+// tells their owners. It returns the ids of bookings whose payment could not be settled so an
+// admin can follow up.
+func (h *handler) refundCancelledBookings(ctx context.Context, bookings []store.Booking) []string {
+	failed := []string{}
+	for _, booking := range bookings {
+		message := fmt.Sprintf("Your booking on %s was cancelled because the venue is closing.", booking.StartsAt.Format("2006-01-02 15:04"))
+		refunded, err := h.settleCancelledPayment(ctx, booking)
+		if err != nil {
+			h.logger.Error().Err(err).Str("booking_id", booking.ID.String()).Msg("settling payment after venue archive failed")
+			failed = append(failed, booking.ID.String())
+		} else if refunded {
+			message += " A full refund has been issued."
+		}
+		payload := notification.NotifyPayload{
+			UserID:  booking.UserID.String(),
+			Title:   "Booking Cancelled",
+			Message: message,
+			Channel: "in_app",
+		}
+		if err := h.notify.Send(ctx, payload); err != nil {
+			h.logger.Error().Err(err).Str("booking_id", booking.ID.String()).Msg("failed to send cancellation notification")
+		}
+	}
+	return failed
+}
+
+// settleCancelledPayment releases the payment of a booking cancelled in bulk. booking.Status
+// is the status before the cancellation: confirmed bookings were paid and are refunded in full,
+// while the intent of an unpaid one is cancelled so it can no longer be captured. It reports
+// whether a refund was issued.
+func (h *handler) settleCancelledPayment(ctx context.Context, booking store.Booking) (bool, error) {
+	if booking.PaymentIntent == nil || *booking.PaymentIntent == "" {
+		return false, nil
+	}
+	if booking.Status != "CONFIRMED" {
+		_, err := h.payment.CancelIntent(ctx, *booking.PaymentIntent)
+		return false, err
+	}
+	if _, err := h.payment.Refund(ctx, *booking.PaymentIntent, booking.AmountCents); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 func venueResponse(v store.Venue) gin.H {
-	return gin.H{
+	resp := gin.H{
 		"id":          v.ID,
 		"name":        v.Name,
 		"description": v.Description,
@@ -982,6 +1446,11 @@ func venueResponse(v store.Venue) gin.H {
 		"createdAt":   v.CreatedAt,
 		"updatedAt":   v.UpdatedAt,
 	}
+	if v.ArchivedAt != nil {
+		resp["archivedAt"] = v.ArchivedAt.Format(time.RFC3339)
+	}
+	resp["photos"], resp["floorPlan"] = galleryResponse(v.Media)
+	return resp
 }
 
 func venuesResponse(venues []store.Venue) []gin.H {
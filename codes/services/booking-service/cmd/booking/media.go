@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/services/booking-service/internal/media"
+	"github.com/venue-master/platform/services/booking-service/internal/store"
+)
+
+type mediaUploadRequest struct {
+	Kind        string `json:"kind" binding:"required"`
+	ContentType string `json:"contentType" binding:"required"`
+	SizeBytes   int64  `json:"sizeBytes" binding:"required"`
+}
+
+type mediaOrderRequest struct {
+	Kind     string   `json:"kind" binding:"required"`
+	MediaIDs []string `json:"mediaIds" binding:"required"`
+}
+
+// registerMediaRoutes wires gallery/floor-plan endpoints under basePath (/v1/venues or /v1/facilities).
+func registerMediaRoutes(router *gin.Engine, h *handler, basePath, ownerType, readPerm, writePerm string) {
+	router.GET(basePath+"/:id/media", authz.RequirePermission(readPerm), h.listMedia(ownerType))
+	router.POST(basePath+"/:id/media", authz.RequireScopedPermission(writePerm), h.createMediaUpload(ownerType, writePerm))
+	router.POST(basePath+"/:id/media/:mediaId/complete", authz.RequireScopedPermission(writePerm), h.completeMediaUpload(ownerType, writePerm))
+	router.PUT(basePath+"/:id/media/order", authz.RequireScopedPermission(writePerm), h.reorderMedia(ownerType, writePerm))
+	router.DELETE(basePath+"/:id/media/:mediaId", authz.RequireScopedPermission(writePerm), h.deleteMedia(ownerType, writePerm))
+}
+
+func (h *handler) listMedia(ownerType string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerID, ok := h.mediaOwner(ctx, ownerType, "")
+		if !ok {
+			return
+		}
+		assets, err := h.loadMedia(ctx, ownerType, []uuid.UUID{ownerID})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, mediaListResponse(assets[ownerID]))
+	}
+}
+
+func (h *handler) createMediaUpload(ownerType, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerID, ok := h.mediaOwner(ctx, ownerType, permission)
+		if !ok {
+			return
+		}
+		var req mediaUploadRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := media.ValidateUpload(req.Kind, req.ContentType, req.SizeBytes); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		mediaID := uuid.New()
+		asset, err := h.store.CreateMediaAsset(ctx, store.MediaAsset{
+			ID:           mediaID,
+			OwnerType:    ownerType,
+			OwnerID:      ownerID,
+			Kind:         req.Kind,
+			ObjectKey:    media.ObjectKey(ownerType, ownerID, mediaID, req.ContentType),
+			ThumbnailKey: media.ThumbnailKey(ownerType, ownerID, mediaID),
+			ContentType:  req.ContentType,
+			SizeBytes:    int(req.SizeBytes),
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		uploadURL, expiresAt, err := h.media.PresignUpload(ctx, asset.ObjectKey, asset.ContentType)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{
+			"media":           mediaResponse(*asset),
+			"uploadUrl":       uploadURL,
+			"uploadMethod":    http.MethodPut,
+			"uploadExpiresAt": expiresAt.Format(time.RFC3339),
+		})
+	}
+}
+
+func (h *handler) completeMediaUpload(ownerType, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerID, ok := h.mediaOwner(ctx, ownerType, permission)
+		if !ok {
+			return
+		}
+		mediaID, ok := uuidFromString(ctx, ctx.Param("mediaId"), "media id")
+		if !ok {
+			return
+		}
+		asset, err := h.store.GetMediaAsset(ctx, ownerType, ownerID, mediaID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		processed, err := h.media.Finalize(ctx, asset.ObjectKey, asset.ThumbnailKey)
+		if err != nil {
+			if errors.Is(err, media.ErrUnsupportedType) || errors.Is(err, media.ErrTooLarge) || errors.Is(err, media.ErrTooManyPixels) || errors.Is(err, media.ErrInvalidImage) {
+				// Reject the upload outright so bad files never linger in the bucket.
+				_ = h.media.Remove(ctx, asset.ObjectKey)
+				_, _ = h.store.DeleteMediaAsset(ctx, ownerType, ownerID, asset.ID)
+				ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+			return
+		}
+
+		ready, err := h.store.MarkMediaReady(ctx, asset.ID, processed.ContentType, processed.SizeBytes, processed.Width, processed.Height)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if ready.Kind == media.KindFloorPlan {
+			replaced, err := h.store.DeleteOtherFloorPlans(ctx, ownerType, ownerID, ready.ID)
+			if err != nil {
+				h.logger.Error().Err(err).Str("media_id", ready.ID.String()).Msg("failed to replace floor plan")
+			}
+			for _, old := range replaced {
+				h.removeMediaObjects(ctx, old)
+			}
+		}
+		h.presignMedia(ctx, ready)
+		ctx.JSON(http.StatusOK, mediaResponse(*ready))
+	}
+}
+
+func (h *handler) reorderMedia(ownerType, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerID, ok := h.mediaOwner(ctx, ownerType, permission)
+		if !ok {
+			return
+		}
+		var req mediaOrderRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ids := make([]uuid.UUID, 0, len(req.MediaIDs))
+		for _, raw := range req.MediaIDs {
+			id, ok := uuidFromString(ctx, raw, "media id")
+			if !ok {
+				return
+			}
+			ids = append(ids, id)
+		}
+		if err := h.store.ReorderMedia(ctx, ownerType, ownerID, req.Kind, ids); err != nil {
+			if errors.Is(err, store.ErrMediaMismatch) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		assets, err := h.loadMedia(ctx, ownerType, []uuid.UUID{ownerID})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, mediaListResponse(assets[ownerID]))
+	}
+}
+
+func (h *handler) deleteMedia(ownerType, permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ownerID, ok := h.mediaOwner(ctx, ownerType, permission)
+		if !ok {
+			return
+		}
+		mediaID, ok := uuidFromString(ctx, ctx.Param("mediaId"), "media id")
+		if !ok {
+			return
+		}
+		asset, err := h.store.DeleteMediaAsset(ctx, ownerType, ownerID, mediaID)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "media not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.removeMediaObjects(ctx, *asset)
+		ctx.Status(http.StatusNoContent)
+	}
+}
+
+// mediaOwner parses :id and checks that the venue or facility exists and is not archived. A
+// non-empty permission must also be held at the owner's venue.
+func (h *handler) mediaOwner(ctx *gin.Context, ownerType, permission string) (uuid.UUID, bool) {
+	id, ok := uuidFromString(ctx, ctx.Param("id"), ownerType+" id")
+	if !ok {
+		return uuid.Nil, false
+	}
+	var archivedAt *time.Time
+	var venueID uuid.UUID
+	var err error
+	switch ownerType {
+	case media.OwnerVenue:
+		var venue *store.Venue
+		if venue, err = h.store.GetVenue(ctx, id); err == nil {
+			archivedAt, venueID = venue.ArchivedAt, venue.ID
+		}
+	default:
+		var facility *store.Facility
+		if facility, err = h.store.GetFacility(ctx, id); err == nil {
+			archivedAt, venueID = facility.ArchivedAt, facility.VenueID
+		}
+	}
+	if err != nil || archivedAt != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": ownerType + " not found"})
+		return uuid.Nil, false
+	}
+	if permission != "" && !authorizeVenue(ctx, venueID, permission) {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// loadMedia fetches ready media for the owners and presigns their read URLs, grouped by owner.
+func (h *handler) loadMedia(ctx context.Context, ownerType string, ownerIDs []uuid.UUID) (map[uuid.UUID][]store.MediaAsset, error) {
+	assets, err := h.store.ListMedia(ctx, ownerType, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	grouped := make(map[uuid.UUID][]store.MediaAsset, len(ownerIDs))
+	for i := range assets {
+		h.presignMedia(ctx, &assets[i])
+		grouped[assets[i].OwnerID] = append(grouped[assets[i].OwnerID], assets[i])
+	}
+	return grouped, nil
+}
+
+// attachVenueMedia hydrates venues with their galleries and floor plans.
+func (h *handler) attachVenueMedia(ctx context.Context, venues []store.Venue) error {
+	ids := make([]uuid.UUID, 0, len(venues))
+	for _, v := range venues {
+		ids = append(ids, v.ID)
+	}
+	grouped, err := h.loadMedia(ctx, media.OwnerVenue, ids)
+	if err != nil {
+		return err
+	}
+	for i := range venues {
+		venues[i].Media = grouped[venues[i].ID]
+	}
+	return nil
+}
+
+// attachFacilityMedia hydrates facilities with their galleries and floor plans.
+func (h *handler) attachFacilityMedia(ctx context.Context, facilities []store.Facility) error {
+	ids := make([]uuid.UUID, 0, len(facilities))
+	for _, f := range facilities {
+		ids = append(ids, f.ID)
+	}
+	grouped, err := h.loadMedia(ctx, media.OwnerFacility, ids)
+	if err != nil {
+		return err
+	}
+	for i := range facilities {
+		facilities[i].Media = grouped[facilities[i].ID]
+	}
+	return nil
+}
+
+func (h *handler) presignMedia(ctx context.Context, asset *store.MediaAsset) {
+	var err error
+	if asset.URL, err = h.media.ReadURL(ctx, asset.ObjectKey); err != nil {
+		h.logger.Warn().Err(err).Str("media_id", asset.ID.String()).Msg("failed to presign media url")
+	}
+	if asset.ThumbnailURL, err = h.media.ReadURL(ctx, asset.ThumbnailKey); err != nil {
+		h.logger.Warn().Err(err).Str("media_id", asset.ID.String()).Msg("failed to presign thumbnail url")
+	}
+}
+
+func (h *handler) removeMediaObjects(ctx context.Context, asset store.MediaAsset) {
+	if err := h.media.Remove(ctx, asset.ObjectKey, asset.ThumbnailKey); err != nil {
+		h.logger.Warn().Err(err).Str("media_id", asset.ID.String()).Msg("failed to delete media objects")
+	}
+}
+
+func mediaResponse(m store.MediaAsset) gin.H {
+	return gin.H{
+		"id":           m.ID,
+		"kind":         m.Kind,
+		"url":          m.URL,
+		"thumbnailUrl": m.ThumbnailURL,
+		"contentType":  m.ContentType,
+		"sizeBytes":    m.SizeBytes,
+		"width":        m.Width,
+		"height":       m.Height,
+		"position":     m.Position,
+		"status":       m.Status,
+		"createdAt":    m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func mediaListResponse(items []store.MediaAsset) []gin.H {
+	out := make([]gin.H, 0, len(items))
+	for _, m := range items {
+		out = append(out, mediaResponse(m))
+	}
+	return out
+}
+
+// galleryResponse splits media into the photo gallery and the (single) floor plan.
+func galleryResponse(items []store.MediaAsset) ([]gin.H, any) {
+	photos := make([]gin.H, 0, len(items))
+	var floorPlan any
+	for _, m := range items {
+		if m.Kind == media.KindFloorPlan {
+			floorPlan = mediaResponse(m)
+			continue
+		}
+		photos = append(photos, mediaResponse(m))
+	}
+	return photos, floorPlan
+}
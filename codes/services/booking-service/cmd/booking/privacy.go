@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/booking-service/internal/store"
+)
+
+// exportPageSize is how many bookings a data export reads per query.
+const exportPageSize = 100
+
+// registerPrivacyRoutes exposes the data user-service gathers for right-of-access exports and
+// the erasure it runs when an account is deleted. The routes are service-only, so they are
+// registered before RequireAuth.
+func registerPrivacyRoutes(router *gin.Engine, h *handler, serviceAuth *svcauth.Keys) {
+	router.GET("/v1/privacy/users/:id/export", serviceAuth.RequireCaller("user-service"), h.exportUserData)
+	router.POST("/v1/privacy/users/:id/erase", serviceAuth.RequireCaller("user-service"), h.eraseUserData)
+}
+
+// exportUserData returns every booking of the user, including amounts and payment intents.
+func (h *handler) exportUserData(ctx *gin.Context) {
+	userID, ok := uuidFromString(ctx, ctx.Param("id"), "user id")
+	if !ok {
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	var bookings []store.Booking
+	for offset := 0; ; offset += exportPageSize {
+		page, err := h.store.ListBookings(timeoutCtx, userID, nil, exportPageSize, offset)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		bookings = append(bookings, page...)
+		if len(page) < exportPageSize {
+			break
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"bookings": bookingsResponse(bookings)})
+}
+
+// eraseUserData cancels the user's upcoming bookings, settling their payments, and pseudonymises
+// their booking history. Calling it again for the same user finds nothing left to erase.
+func (h *handler) eraseUserData(ctx *gin.Context) {
+	userID, ok := uuidFromString(ctx, ctx.Param("id"), "user id")
+	if !ok {
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+	defer cancel()
+
+	cancelled, moved, err := h.store.PseudonymiseUserBookings(timeoutCtx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// The account is being erased, so there is nobody left to notify; failed refunds are logged
+	// for finance to settle by hand.
+	failedRefunds := 0
+	for _, booking := range cancelled {
+		if _, err := h.settleCancelledPayment(timeoutCtx, booking); err != nil {
+			h.logger.Error().Err(err).Str("booking_id", booking.ID.String()).Msg("settling payment after account erasure failed")
+			failedRefunds++
+		}
+	}
+	ctx.JSON(http.StatusOK, gin.H{"records": gin.H{
+		"bookings":          moved,
+		"cancelledBookings": len(cancelled),
+		"failedRefunds":     failedRefunds,
+	}})
+}
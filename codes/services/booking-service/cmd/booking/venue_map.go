@@ -0,0 +1,460 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/services/booking-service/internal/media"
+	"github.com/venue-master/platform/services/booking-service/internal/store"
+)
+
+// Live map statuses, coloured for the map widget.
+const (
+	mapStatusAvailable = "AVAILABLE"
+	mapStatusLimited   = "LIMITED"
+	mapStatusBooked    = "BOOKED"
+	mapStatusClosed    = "CLOSED"
+
+	// mapSlotLength is how much free time a facility needs to count as fully available.
+	mapSlotLength = time.Hour
+	// mapLookahead bounds the booking query for the live map.
+	mapLookahead = 24 * time.Hour
+
+	maxPolygonPoints = 64
+	maxShapeLabelLen = 40
+)
+
+var mapStatusColours = map[string]string{
+	mapStatusAvailable: "#2E7D32",
+	mapStatusLimited:   "#F9A825",
+	mapStatusBooked:    "#C62828",
+	mapStatusClosed:    "#9E9E9E",
+}
+
+type venueMapRequest struct {
+	Width             float64                `json:"width" binding:"required"`
+	Height            float64                `json:"height" binding:"required"`
+	Unit              string                 `json:"unit"`
+	BackgroundMediaID string                 `json:"backgroundMediaId"`
+	Facilities        []facilityShapeRequest `json:"facilities"`
+}
+
+type facilityShapeRequest struct {
+	FacilityID string           `json:"facilityId"`
+	Polygon    []store.MapPoint `json:"polygon"`
+	Rotation   float64          `json:"rotation"`
+	Label      string           `json:"label"`
+}
+
+// facilityMapState is the live status of one facility on the map.
+type facilityMapState struct {
+	Status         string
+	AvailableUntil *time.Time
+	BusyUntil      *time.Time
+	NextBookingAt  *time.Time
+}
+
+func (h *handler) getVenueMap(ctx *gin.Context) {
+	venue, ok := h.activeVenue(ctx)
+	if !ok {
+		return
+	}
+	venueMap, ok := h.loadVenueMap(ctx, venue.ID)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, h.venueMapResponse(ctx, venueMap, nil, nil))
+}
+
+func (h *handler) getVenueMapAvailability(ctx *gin.Context) {
+	venue, ok := h.activeVenue(ctx)
+	if !ok {
+		return
+	}
+	at := time.Now()
+	if raw := ctx.Query("at"); raw != "" {
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid at, expected RFC3339"})
+			return
+		}
+		at = parsed
+	}
+	venueMap, ok := h.loadVenueMap(ctx, venue.ID)
+	if !ok {
+		return
+	}
+
+	loc, err := time.LoadLocation(venue.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := at.In(loc)
+	// Schedules are keyed by calendar date, which the schedule endpoint parses as UTC midnight.
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+
+	ids := make([]uuid.UUID, 0, len(venueMap.Shapes))
+	for _, shape := range venueMap.Shapes {
+		ids = append(ids, shape.FacilityID)
+	}
+	windows, err := h.store.ListBookingWindows(ctx, ids, at, at.Add(mapLookahead))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	byFacility := make(map[uuid.UUID][]store.BookingWindow, len(ids))
+	for _, w := range windows {
+		byFacility[w.FacilityID] = append(byFacility[w.FacilityID], w)
+	}
+
+	names := make(map[uuid.UUID]string, len(ids))
+	states := make(map[uuid.UUID]facilityMapState, len(ids))
+	for _, id := range ids {
+		facility, err := h.store.GetFacility(ctx, id)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		names[id] = facility.Name
+		schedule, err := h.store.GetFacilitySchedule(ctx, id, day, day)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		open, closesAt := false, time.Time{}
+		if facility.Available && len(schedule) == 1 {
+			open, closesAt = openAt(schedule[0], local)
+		}
+		states[id] = computeMapState(at, open, closesAt, byFacility[id])
+	}
+
+	resp := h.venueMapResponse(ctx, venueMap, names, states)
+	resp["at"] = at.Format(time.RFC3339)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+func (h *handler) saveVenueMap(ctx *gin.Context) {
+	venue, ok := h.activeVenue(ctx)
+	if !ok || !authorizeVenue(ctx, venue.ID, authz.VenueUpdate) {
+		return
+	}
+	var req venueMapRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Unit == "" {
+		req.Unit = "m"
+	}
+	if req.Unit != "m" && req.Unit != "ft" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "unit must be m or ft"})
+		return
+	}
+	if req.Width <= 0 || req.Height <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "width and height must be positive"})
+		return
+	}
+
+	venueMap := store.VenueMap{VenueID: venue.ID, Width: req.Width, Height: req.Height, Unit: req.Unit}
+	if req.BackgroundMediaID != "" {
+		mediaID, ok := uuidFromString(ctx, req.BackgroundMediaID, "backgroundMediaId")
+		if !ok {
+			return
+		}
+		asset, err := h.store.GetMediaAsset(ctx, media.OwnerVenue, venue.ID, mediaID)
+		if err != nil || asset.Status != store.MediaStatusReady {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "backgroundMediaId must reference an uploaded venue image"})
+			return
+		}
+		venueMap.BackgroundKey = asset.ObjectKey
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.Facilities))
+	for _, item := range req.Facilities {
+		facilityID, ok := uuidFromString(ctx, item.FacilityID, "facilityId")
+		if !ok {
+			return
+		}
+		if seen[facilityID] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("facility %s listed twice", facilityID)})
+			return
+		}
+		seen[facilityID] = true
+		shape, err := shapeFromRequest(facilityID, item, req.Width, req.Height)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		venueMap.Shapes = append(venueMap.Shapes, shape)
+	}
+
+	saved, err := h.store.SaveVenueMap(ctx, venueMap)
+	if err != nil {
+		if errors.Is(err, store.ErrFacilityNotInVenue) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, h.venueMapResponse(ctx, saved, nil, nil))
+}
+
+func (h *handler) saveFacilityShape(ctx *gin.Context) {
+	venue, ok := h.activeVenue(ctx)
+	if !ok || !authorizeVenue(ctx, venue.ID, authz.VenueUpdate) {
+		return
+	}
+	facilityID, ok := uuidFromString(ctx, ctx.Param("facilityId"), "facility id")
+	if !ok {
+		return
+	}
+	venueMap, ok := h.loadVenueMap(ctx, venue.ID)
+	if !ok {
+		return
+	}
+	var req facilityShapeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	shape, err := shapeFromRequest(facilityID, req, venueMap.Width, venueMap.Height)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	saved, err := h.store.SaveFacilityShape(ctx, venue.ID, shape)
+	if err != nil {
+		if errors.Is(err, store.ErrFacilityNotInVenue) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, facilityShapeResponse(*saved, "", nil))
+}
+
+func (h *handler) deleteFacilityShape(ctx *gin.Context) {
+	venue, ok := h.activeVenue(ctx)
+	if !ok || !authorizeVenue(ctx, venue.ID, authz.VenueUpdate) {
+		return
+	}
+	facilityID, ok := uuidFromString(ctx, ctx.Param("facilityId"), "facility id")
+	if !ok {
+		return
+	}
+	if err := h.store.DeleteFacilityShape(ctx, venue.ID, facilityID); err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "facility is not on the map"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// activeVenue loads :id and rejects missing or archived venues.
+func (h *handler) activeVenue(ctx *gin.Context) (*store.Venue, bool) {
+	id, ok := uuidFromString(ctx, ctx.Param("id"), "id")
+	if !ok {
+		return nil, false
+	}
+	venue, err := h.store.GetVenue(ctx, id)
+	if err != nil || venue.ArchivedAt != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "venue not found"})
+		return nil, false
+	}
+	return venue, true
+}
+
+func (h *handler) loadVenueMap(ctx *gin.Context, venueID uuid.UUID) (*store.VenueMap, bool) {
+	venueMap, err := h.store.GetVenueMap(ctx, venueID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "venue map not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return venueMap, true
+}
+
+// backgroundURL presigns the map background, falling back to the venue's floor-plan upload.
+func (h *handler) backgroundURL(ctx *gin.Context, venueMap *store.VenueMap) string {
+	if venueMap.BackgroundKey != "" {
+		url, err := h.media.ReadURL(ctx, venueMap.BackgroundKey)
+		if err != nil {
+			h.logger.Warn().Err(err).Str("venue_id", venueMap.VenueID.String()).Msg("failed to presign map background")
+		}
+		return url
+	}
+	grouped, err := h.loadMedia(ctx, media.OwnerVenue, []uuid.UUID{venueMap.VenueID})
+	if err != nil {
+		h.logger.Warn().Err(err).Str("venue_id", venueMap.VenueID.String()).Msg("failed to load floor plan for map")
+		return ""
+	}
+	for _, asset := range grouped[venueMap.VenueID] {
+		if asset.Kind == media.KindFloorPlan {
+			return asset.URL
+		}
+	}
+	return ""
+}
+
+// shapeFromRequest validates a polygon against the map bounds and normalises its rotation.
+func shapeFromRequest(facilityID uuid.UUID, req facilityShapeRequest, width, height float64) (store.FacilityShape, error) {
+	if n := len(req.Polygon); n < 3 || n > maxPolygonPoints {
+		return store.FacilityShape{}, fmt.Errorf("polygon for facility %s needs between 3 and %d points", facilityID, maxPolygonPoints)
+	}
+	for _, p := range req.Polygon {
+		if p.X < 0 || p.Y < 0 || p.X > width || p.Y > height {
+			return store.FacilityShape{}, fmt.Errorf("polygon for facility %s falls outside the %gx%g floor plan", facilityID, width, height)
+		}
+	}
+	if polygonArea(req.Polygon) == 0 {
+		return store.FacilityShape{}, fmt.Errorf("polygon for facility %s has no area", facilityID)
+	}
+	if len([]rune(req.Label)) > maxShapeLabelLen {
+		return store.FacilityShape{}, fmt.Errorf("label must be at most %d characters", maxShapeLabelLen)
+	}
+	rotation := math.Mod(req.Rotation, 360)
+	if rotation < 0 {
+		rotation += 360
+	}
+	return store.FacilityShape{FacilityID: facilityID, Polygon: req.Polygon, Rotation: rotation, Label: req.Label}, nil
+}
+
+// polygonArea uses the shoelace formula.
+func polygonArea(points []store.MapPoint) float64 {
+	var sum float64
+	for i := range points {
+		j := (i + 1) % len(points)
+		sum += points[i].X*points[j].Y - points[j].X*points[i].Y
+	}
+	return math.Abs(sum) / 2
+}
+
+// openAt reports whether local falls inside one of the day's opening slots and when that slot closes.
+func openAt(day store.FacilityScheduleDay, local time.Time) (bool, time.Time) {
+	if day.Closed {
+		return false, time.Time{}
+	}
+	for _, slot := range day.Slots {
+		start, err1 := time.ParseInLocation("15:04", slot.OpenAt, local.Location())
+		end, err2 := time.ParseInLocation("15:04", slot.CloseAt, local.Location())
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		opens := time.Date(local.Year(), local.Month(), local.Day(), start.Hour(), start.Minute(), 0, 0, local.Location())
+		closes := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, local.Location())
+		if !closes.After(opens) {
+			closes = closes.AddDate(0, 0, 1)
+		}
+		if !local.Before(opens) && local.Before(closes) {
+			return true, closes
+		}
+	}
+	return false, time.Time{}
+}
+
+// computeMapState derives the current/next-slot status from opening hours and bookings sorted by start.
+func computeMapState(now time.Time, open bool, closesAt time.Time, bookings []store.BookingWindow) facilityMapState {
+	var state facilityMapState
+	for _, b := range bookings {
+		if b.StartsAt.After(now) {
+			next := b.StartsAt
+			state.NextBookingAt = &next
+			break
+		}
+	}
+	if !open {
+		state.Status = mapStatusClosed
+		return state
+	}
+
+	// Bookings are sorted by start, so back-to-back bookings extend the busy stretch.
+	busyUntil := now
+	for _, b := range bookings {
+		if b.StartsAt.After(busyUntil) {
+			break
+		}
+		if b.EndsAt.After(busyUntil) {
+			busyUntil = b.EndsAt
+		}
+	}
+	if busyUntil.After(now) {
+		state.Status = mapStatusBooked
+		state.BusyUntil = &busyUntil
+		state.NextBookingAt = nil
+		return state
+	}
+
+	freeUntil := closesAt
+	if state.NextBookingAt != nil && state.NextBookingAt.Before(freeUntil) {
+		freeUntil = *state.NextBookingAt
+	}
+	state.AvailableUntil = &freeUntil
+	state.Status = mapStatusAvailable
+	if freeUntil.Sub(now) < mapSlotLength {
+		state.Status = mapStatusLimited
+	}
+	return state
+}
+
+func (h *handler) venueMapResponse(ctx *gin.Context, venueMap *store.VenueMap, names map[uuid.UUID]string, states map[uuid.UUID]facilityMapState) gin.H {
+	facilities := make([]gin.H, 0, len(venueMap.Shapes))
+	for _, shape := range venueMap.Shapes {
+		var state *facilityMapState
+		if s, ok := states[shape.FacilityID]; ok {
+			state = &s
+		}
+		facilities = append(facilities, facilityShapeResponse(shape, names[shape.FacilityID], state))
+	}
+	return gin.H{
+		"venueId":       venueMap.VenueID,
+		"width":         venueMap.Width,
+		"height":        venueMap.Height,
+		"unit":          venueMap.Unit,
+		"backgroundUrl": h.backgroundURL(ctx, venueMap),
+		"updatedAt":     venueMap.UpdatedAt.Format(time.RFC3339),
+		"facilities":    facilities,
+	}
+}
+
+func facilityShapeResponse(shape store.FacilityShape, name string, state *facilityMapState) gin.H {
+	resp := gin.H{
+		"facilityId": shape.FacilityID,
+		"label":      shape.Label,
+		"polygon":    shape.Polygon,
+		"rotation":   shape.Rotation,
+	}
+	if name != "" {
+		resp["name"] = name
+	}
+	if state != nil {
+		resp["status"] = state.Status
+		resp["colour"] = mapStatusColours[state.Status]
+		resp["availableUntil"] = formatOptional(state.AvailableUntil)
+		resp["busyUntil"] = formatOptional(state.BusyUntil)
+		resp["nextBookingAt"] = formatOptional(state.NextBookingAt)
+	}
+	return resp
+}
+
+func formatOptional(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,79 @@
+package households
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Client reads households from the user-service API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a household client. transport signs requests for the user-service (see svcauth);
+// nil uses the default transport.
+func New(baseURL string, transport http.RoundTripper) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: transport},
+	}
+}
+
+// Household is the part of a user-service household that bookings need.
+type Household struct {
+	ID              uuid.UUID `json:"id"`
+	PrimaryUserID   uuid.UUID `json:"primaryUserId"`
+	PaymentMethodID string    `json:"paymentMethodId"`
+	MembershipType  string    `json:"membershipType"`
+	Members         []Member  `json:"members"`
+}
+
+// Member is an active household member.
+type Member struct {
+	UserID uuid.UUID `json:"userId"`
+	Role   string    `json:"role"`
+}
+
+// Has reports whether userID is an active member of the household.
+func (h *Household) Has(userID uuid.UUID) bool {
+	if h == nil {
+		return false
+	}
+	for _, member := range h.Members {
+		if member.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the household userID belongs to, or nil when they have none. The request carries no
+// user identity; the user-service lets the booking-service read any household as a service caller.
+func (c *Client) Get(ctx context.Context, userID uuid.UUID) (*Household, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/v1/users/%s/household", c.baseURL, userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("user service responded with %d", resp.StatusCode)
+	}
+	var household Household
+	if err := json.NewDecoder(resp.Body).Decode(&household); err != nil {
+		return nil, err
+	}
+	return &household, nil
+}
@@ -0,0 +1,233 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoder
+	"image/jpeg"
+	_ "image/png" // register decoder
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/s3util"
+)
+
+// Media kinds attached to venues and facilities.
+const (
+	KindPhoto     = "photo"
+	KindFloorPlan = "floor_plan"
+)
+
+// Owner types that can carry media.
+const (
+	OwnerVenue    = "venue"
+	OwnerFacility = "facility"
+)
+
+const (
+	// MaxUploadBytes caps a single image upload.
+	MaxUploadBytes = 10 << 20
+	// MaxPixels caps an image's declared width times height, so a small file that claims huge
+	// dimensions is rejected before it is decoded.
+	MaxPixels = 40_000_000
+	// ThumbnailMaxEdge is the longest edge of generated thumbnails in pixels.
+	ThumbnailMaxEdge = 320
+
+	uploadURLExpiry = 15 * time.Minute
+	readURLExpiry   = time.Hour
+)
+
+var (
+	// ErrUnsupportedType is returned for anything other than JPEG, PNG or GIF.
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrTooLarge is returned when an upload exceeds MaxUploadBytes.
+	ErrTooLarge = errors.New("image exceeds maximum size")
+	// ErrTooManyPixels is returned when an image's dimensions exceed MaxPixels.
+	ErrTooManyPixels = errors.New("image dimensions exceed maximum")
+	// ErrInvalidImage is returned when the uploaded bytes cannot be decoded.
+	ErrInvalidImage = errors.New("uploaded file is not a valid image")
+	// ErrUnknownKind is returned for media kinds other than photo and floor_plan.
+	ErrUnknownKind = errors.New("unknown media kind")
+)
+
+var ownerPrefixes = map[string]string{
+	OwnerVenue:    "venues",
+	OwnerFacility: "facilities",
+}
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ValidateUpload checks the declared kind, content type and size before an upload URL is issued.
+func ValidateUpload(kind, contentType string, sizeBytes int64) error {
+	if kind != KindPhoto && kind != KindFloorPlan {
+		return ErrUnknownKind
+	}
+	if _, ok := extensions[contentType]; !ok {
+		return ErrUnsupportedType
+	}
+	if sizeBytes <= 0 || sizeBytes > MaxUploadBytes {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// ObjectKey returns the storage key for an original upload.
+func ObjectKey(ownerType string, ownerID, mediaID uuid.UUID, contentType string) string {
+	return path.Join(ownerPrefixes[ownerType], ownerID.String(), "media", mediaID.String()+extensions[contentType])
+}
+
+// ThumbnailKey returns the storage key for the generated thumbnail.
+func ThumbnailKey(ownerType string, ownerID, mediaID uuid.UUID) string {
+	return path.Join(ownerPrefixes[ownerType], ownerID.String(), "media", mediaID.String()+"_thumb.jpg")
+}
+
+// Processed describes an upload after server-side validation.
+type Processed struct {
+	ContentType string
+	SizeBytes   int
+	Width       int
+	Height      int
+}
+
+// Service wraps a StorageProvider with the media upload workflow.
+type Service struct {
+	storage s3util.StorageProvider
+}
+
+// NewService creates a media service on top of any StorageProvider.
+func NewService(storage s3util.StorageProvider) *Service {
+	return &Service{storage: storage}
+}
+
+// PresignUpload returns a URL the client can PUT the original file to.
+func (s *Service) PresignUpload(ctx context.Context, key, contentType string) (string, time.Time, error) {
+	url, err := s.storage.PresignPut(ctx, key, contentType, MaxUploadBytes, uploadURLExpiry)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return url, time.Now().Add(uploadURLExpiry), nil
+}
+
+// Finalize downloads an uploaded original, verifies it really is an allowed image of at most
+// MaxPixels and stores a JPEG thumbnail under thumbKey.
+func (s *Service) Finalize(ctx context.Context, key, thumbKey string) (*Processed, error) {
+	info, err := s.storage.Head(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("fetch upload: %w", err)
+	}
+	if info.Size > MaxUploadBytes {
+		return nil, ErrTooLarge
+	}
+	data, err := s.storage.Get(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("fetch upload: %w", err)
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return nil, ErrUnsupportedType
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, Thumbnail(img, ThumbnailMaxEdge), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	if _, err := s.storage.Upload(ctx, thumbKey, &buf, "image/jpeg"); err != nil {
+		return nil, fmt.Errorf("store thumbnail: %w", err)
+	}
+
+	bounds := img.Bounds()
+	return &Processed{
+		ContentType: contentType,
+		SizeBytes:   len(data),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+	}, nil
+}
+
+// ReadURL presigns a GET URL for a stored object; empty keys yield an empty URL.
+func (s *Service) ReadURL(ctx context.Context, key string) (string, error) {
+	if key == "" {
+		return "", nil
+	}
+	return s.storage.PresignGet(ctx, key, readURLExpiry)
+}
+
+// Remove deletes stored objects, skipping empty keys.
+func (s *Service) Remove(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Thumbnail scales img down so its longest edge is at most maxEdge, averaging the
+// source pixels that fall into each destination pixel. Smaller images are copied as-is.
+func Thumbnail(img image.Image, maxEdge int) image.Image {
+	src := img.Bounds()
+	w, h := src.Dx(), src.Dy()
+	if w <= maxEdge && h <= maxEdge {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dst.Set(x, y, img.At(src.Min.X+x, src.Min.Y+y))
+			}
+		}
+		return dst
+	}
+
+	dw, dh := maxEdge, h*maxEdge/w
+	if h > w {
+		dw, dh = w*maxEdge/h, maxEdge
+	}
+	dw, dh = max(dw, 1), max(dh, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := src.Min.Y+dy*h/dh, src.Min.Y+(dy+1)*h/dh
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := src.Min.X+dx*w/dw, src.Min.X+(dx+1)*w/dw
+			dst.Set(dx, dy, averageColor(img, x0, y0, max(x1, x0+1), max(y1, y0+1)))
+		}
+	}
+	return dst
+}
+
+func averageColor(img image.Image, x0, y0, x1, y1 int) color.RGBA64 {
+	var r, g, b, a, n uint64
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			cr, cg, cb, ca := img.At(x, y).RGBA()
+			r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+			n++
+		}
+	}
+	return color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: uint16(a / n)}
+}
@@ -0,0 +1,188 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/s3util"
+)
+
+func newTestService(t *testing.T) (*Service, s3util.StorageProvider) {
+	t.Helper()
+	provider, err := s3util.NewFilesystem(t.TempDir(), "http://localhost:8080/storage", []byte("test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService(provider), provider
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestValidateUpload(t *testing.T) {
+	cases := []struct {
+		name        string
+		kind        string
+		contentType string
+		size        int64
+		want        error
+	}{
+		{"photo jpeg", KindPhoto, "image/jpeg", 1024, nil},
+		{"floor plan png", KindFloorPlan, "image/png", MaxUploadBytes, nil},
+		{"unknown kind", "video", "image/png", 1024, ErrUnknownKind},
+		{"svg rejected", KindPhoto, "image/svg+xml", 1024, ErrUnsupportedType},
+		{"too large", KindPhoto, "image/gif", MaxUploadBytes + 1, ErrTooLarge},
+		{"empty", KindPhoto, "image/gif", 0, ErrTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateUpload(tc.kind, tc.contentType, tc.size); !errors.Is(err, tc.want) {
+				t.Fatalf("ValidateUpload() = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestObjectKeys(t *testing.T) {
+	owner := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	id := uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc")
+	if got, want := ObjectKey(OwnerVenue, owner, id, "image/png"), "venues/"+owner.String()+"/media/"+id.String()+".png"; got != want {
+		t.Fatalf("ObjectKey() = %q, want %q", got, want)
+	}
+	if got, want := ThumbnailKey(OwnerFacility, owner, id), "facilities/"+owner.String()+"/media/"+id.String()+"_thumb.jpg"; got != want {
+		t.Fatalf("ThumbnailKey() = %q, want %q", got, want)
+	}
+}
+
+func TestFinalizeCreatesThumbnail(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestService(t)
+	if _, err := provider.Upload(ctx, "venues/v/media/m.png", bytes.NewReader(encodePNG(t, testImage(800, 400))), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := svc.Finalize(ctx, "venues/v/media/m.png", "venues/v/media/m_thumb.jpg")
+	if err != nil {
+		t.Fatalf("Finalize() error = %v", err)
+	}
+	if processed.ContentType != "image/png" || processed.Width != 800 || processed.Height != 400 {
+		t.Fatalf("unexpected metadata: %+v", processed)
+	}
+
+	thumbBytes, err := provider.Get(ctx, "venues/v/media/m_thumb.jpg")
+	if err != nil {
+		t.Fatalf("thumbnail not stored: %v", err)
+	}
+	thumb, err := jpeg.Decode(bytes.NewReader(thumbBytes))
+	if err != nil {
+		t.Fatalf("thumbnail is not a jpeg: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != ThumbnailMaxEdge || b.Dy() != ThumbnailMaxEdge/2 {
+		t.Fatalf("thumbnail size = %dx%d, want %dx%d", b.Dx(), b.Dy(), ThumbnailMaxEdge, ThumbnailMaxEdge/2)
+	}
+}
+
+func TestFinalizeRejectsNonImages(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestService(t)
+	if _, err := provider.Upload(ctx, "x.png", strings.NewReader("<svg xmlns='http://www.w3.org/2000/svg'></svg>"), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Finalize(ctx, "x.png", "x_thumb.jpg"); !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("Finalize() error = %v, want ErrUnsupportedType", err)
+	}
+	if _, err := provider.Get(ctx, "x_thumb.jpg"); !errors.Is(err, s3util.ErrNotFound) {
+		t.Fatalf("thumbnail should not exist, got %v", err)
+	}
+}
+
+func TestFinalizeRejectsCorruptImage(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestService(t)
+	data := encodePNG(t, testImage(10, 10))
+	if _, err := provider.Upload(ctx, "broken.png", bytes.NewReader(data[:len(data)/2]), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Finalize(ctx, "broken.png", "broken_thumb.jpg"); !errors.Is(err, ErrInvalidImage) {
+		t.Fatalf("Finalize() error = %v, want ErrInvalidImage", err)
+	}
+}
+
+// TestFinalizeRejectsDecompressionBomb uploads a tiny PNG whose header claims 100000x100000
+// pixels; it must be refused from the header alone.
+func TestFinalizeRejectsDecompressionBomb(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestService(t)
+	data := encodePNG(t, testImage(1, 1))
+	// The IHDR chunk follows the 8-byte signature: length, type, width, height, ..., CRC.
+	binary.BigEndian.PutUint32(data[16:], 100000)
+	binary.BigEndian.PutUint32(data[20:], 100000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	if _, err := provider.Upload(ctx, "bomb.png", bytes.NewReader(data), "image/png"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := svc.Finalize(ctx, "bomb.png", "bomb_thumb.jpg"); !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("Finalize() error = %v, want ErrTooManyPixels", err)
+	}
+}
+
+func TestRemoveSkipsEmptyKeys(t *testing.T) {
+	ctx := context.Background()
+	svc, provider := newTestService(t)
+	if _, err := provider.Upload(ctx, "a.jpg", strings.NewReader("a"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	if err := svc.Remove(ctx, "a.jpg", ""); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, err := provider.Get(ctx, "a.jpg"); !errors.Is(err, s3util.ErrNotFound) {
+		t.Fatalf("object should be deleted, got %v", err)
+	}
+	if url, err := svc.ReadURL(ctx, ""); err != nil || url != "" {
+		t.Fatalf("ReadURL(\"\") = %q, %v", url, err)
+	}
+}
+
+func TestThumbnailSizing(t *testing.T) {
+	cases := []struct {
+		w, h, wantW, wantH int
+	}{
+		{1000, 500, 320, 160},
+		{500, 1000, 160, 320},
+		{200, 100, 200, 100},
+		{5000, 3, 320, 1},
+	}
+	for _, tc := range cases {
+		b := Thumbnail(testImage(tc.w, tc.h), ThumbnailMaxEdge).Bounds()
+		if b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+			t.Errorf("Thumbnail(%dx%d) = %dx%d, want %dx%d", tc.w, tc.h, b.Dx(), b.Dy(), tc.wantW, tc.wantH)
+		}
+	}
+}
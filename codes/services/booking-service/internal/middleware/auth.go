@@ -5,21 +5,49 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/authz"
 )
 
 const contextUserKey = "authUser"
 
-const (
-	RoleAdmin      = "ADMIN"
-	RoleVenueAdmin = "VENUE_ADMIN"
-	RoleOperator   = "OPERATOR"
-	RoleMember     = "MEMBER"
-)
-
 // ContextUser represents the authenticated caller.
 type ContextUser struct {
-	UserID string
-	Roles  []string
+	UserID      string
+	Roles       []string
+	Permissions []string
+	// VenuePermissions are granted by venue-scoped roles, keyed by venue id.
+	VenuePermissions map[string][]string
+}
+
+func (u ContextUser) principal() authz.Principal {
+	return authz.Principal{UserID: u.UserID, Roles: u.Roles, Permissions: u.Permissions, VenuePermissions: u.VenuePermissions}
+}
+
+// Can reports whether the user holds any of permissions globally.
+func (u ContextUser) Can(permissions ...string) bool {
+	return u.principal().Can(permissions...)
+}
+
+// CanAt reports whether the user holds any of permissions globally or at venueID.
+func (u ContextUser) CanAt(venueID uuid.UUID, permissions ...string) bool {
+	return u.principal().CanAt(venueID.String(), permissions...)
+}
+
+// ScopedVenues returns the venues where the user holds any of permissions only through a
+// venue-scoped role. It returns nil when the user holds one globally (no restriction).
+func (u ContextUser) ScopedVenues(permissions ...string) []uuid.UUID {
+	if u.Can(permissions...) {
+		return nil
+	}
+	ids := []uuid.UUID{}
+	for _, raw := range u.principal().VenuesWith(permissions...) {
+		if id, err := uuid.Parse(raw); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
 }
 
 // HasRole checks for a role.
@@ -63,27 +91,14 @@ func RequireAuth() gin.HandlerFunc {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing roles"})
 			return
 		}
-		ctx.Set(contextUserKey, ContextUser{UserID: userID, Roles: roles})
-		ctx.Next()
-	}
-}
-
-// RequireRoles enforces allowed roles.
-func RequireRoles(allowed ...string) gin.HandlerFunc {
-	normalized := make([]string, 0, len(allowed))
-	for _, r := range allowed {
-		normalized = append(normalized, strings.ToUpper(strings.TrimSpace(r)))
-	}
-	return func(ctx *gin.Context) {
-		user, ok := GetUser(ctx)
-		if !ok {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-			return
-		}
-		if !user.HasAnyRole(normalized...) {
-			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "forbidden"})
-			return
+		user := ContextUser{
+			UserID:           userID,
+			Roles:            roles,
+			Permissions:      authz.ParseList(ctx.GetHeader(authz.HeaderPermissions), false),
+			VenuePermissions: authz.ParseVenuePermissions(ctx.GetHeader(authz.HeaderVenuePermissions)),
 		}
+		ctx.Set(contextUserKey, user)
+		ctx.Request = ctx.Request.WithContext(authz.WithPrincipal(ctx.Request.Context(), user.principal()))
 		ctx.Next()
 	}
 }
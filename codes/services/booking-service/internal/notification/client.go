@@ -15,11 +15,12 @@ type Client struct {
 	httpClient *http.Client
 }
 
-// New creates a notification client.
-func New(baseURL string) *Client {
+// New creates a notification client. transport signs requests for the notification-service
+// (see svcauth); nil uses the default transport.
+func New(baseURL string, transport http.RoundTripper) *Client {
 	return &Client{
 		baseURL:    baseURL,
-		httpClient: &http.Client{Timeout: 5 * time.Second},
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: transport},
 	}
 }
 
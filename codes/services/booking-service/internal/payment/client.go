@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -15,12 +16,14 @@ type Client struct {
 	baseURL    string
 }
 
-// New returns a payment client.
-func New(baseURL string) *Client {
+// New returns a payment client. transport signs requests for the payment-service (see
+// svcauth); nil uses the default transport.
+func New(baseURL string, transport http.RoundTripper) *Client {
 	return &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 5 * time.Second,
+			Timeout:   5 * time.Second,
+			Transport: transport,
 		},
 	}
 }
@@ -61,3 +64,61 @@ func (c *Client) Charge(ctx context.Context, amountCents int, currency string, m
 	}
 	return &intent, nil
 }
+
+// Refund represents a simplified refund response.
+type Refund struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// Refund returns the given amount of a captured payment to the customer.
+func (c *Client) Refund(ctx context.Context, paymentID string, amountCents int) (*Refund, error) {
+	payload := map[string]any{
+		"paymentId":   paymentID,
+		"amountCents": amountCents,
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/payments/refunds", c.baseURL), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("payment service returned %d", resp.StatusCode)
+	}
+	var refund Refund
+	if err := json.NewDecoder(resp.Body).Decode(&refund); err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// CancelIntent cancels a payment intent that was never captured, so it can no longer be
+// charged.
+func (c *Client) CancelIntent(ctx context.Context, intentID string) (*Intent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/v1/payments/intents/%s/cancel", c.baseURL, url.PathEscape(intentID)), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("payment service returned %d", resp.StatusCode)
+	}
+	var intent Intent
+	if err := json.NewDecoder(resp.Body).Decode(&intent); err != nil {
+		return nil, err
+	}
+	return &intent, nil
+}
@@ -0,0 +1,172 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Media upload states.
+const (
+	MediaStatusPending = "PENDING"
+	MediaStatusReady   = "READY"
+)
+
+// ErrMediaMismatch is returned when a reorder request does not list exactly the owner's media.
+var ErrMediaMismatch = errors.New("media ids do not match the gallery")
+
+// MediaAsset is an image attached to a venue or facility.
+type MediaAsset struct {
+	ID           uuid.UUID
+	OwnerType    string
+	OwnerID      uuid.UUID
+	Kind         string
+	ObjectKey    string
+	ThumbnailKey string
+	ContentType  string
+	SizeBytes    int
+	Width        int
+	Height       int
+	Position     int
+	Status       string
+	CreatedAt    time.Time
+
+	// URL and ThumbnailURL are presigned by the handler before responding; they are not persisted.
+	URL          string
+	ThumbnailURL string
+}
+
+const mediaColumns = `id, owner_type, owner_id, kind, object_key, thumbnail_key, content_type, size_bytes, width, height, position, status, created_at`
+
+// CreateMediaAsset records a pending upload at the end of the owner's gallery.
+func (s *Store) CreateMediaAsset(ctx context.Context, m MediaAsset) (*MediaAsset, error) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	row := s.pool.QueryRow(ctx, `
+        INSERT INTO media_assets (id, owner_type, owner_id, kind, object_key, thumbnail_key, content_type, size_bytes, position, status)
+        VALUES ($1,$2,$3,$4,$5,$6,$7,$8,
+                (SELECT COALESCE(MAX(position) + 1, 0) FROM media_assets WHERE owner_type=$2 AND owner_id=$3 AND kind=$4),
+                'PENDING')
+        RETURNING `+mediaColumns,
+		m.ID, m.OwnerType, m.OwnerID, m.Kind, m.ObjectKey, m.ThumbnailKey, m.ContentType, m.SizeBytes)
+	return scanMedia(row)
+}
+
+// GetMediaAsset fetches a single asset that belongs to the given owner.
+func (s *Store) GetMediaAsset(ctx context.Context, ownerType string, ownerID, id uuid.UUID) (*MediaAsset, error) {
+	row := s.pool.QueryRow(ctx, `SELECT `+mediaColumns+` FROM media_assets WHERE id=$1 AND owner_type=$2 AND owner_id=$3`, id, ownerType, ownerID)
+	return scanMedia(row)
+}
+
+// MarkMediaReady stores the verified image metadata once the upload has been processed.
+func (s *Store) MarkMediaReady(ctx context.Context, id uuid.UUID, contentType string, sizeBytes, width, height int) (*MediaAsset, error) {
+	row := s.pool.QueryRow(ctx, `
+        UPDATE media_assets SET status='READY', content_type=$2, size_bytes=$3, width=$4, height=$5, updated_at=NOW()
+        WHERE id=$1
+        RETURNING `+mediaColumns,
+		id, contentType, sizeBytes, width, height)
+	return scanMedia(row)
+}
+
+// ListMedia returns ready media for the given owners, ordered by gallery position.
+func (s *Store) ListMedia(ctx context.Context, ownerType string, ownerIDs []uuid.UUID) ([]MediaAsset, error) {
+	if len(ownerIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+        SELECT `+mediaColumns+`
+        FROM media_assets
+        WHERE owner_type=$1 AND owner_id = ANY($2) AND status='READY'
+        ORDER BY owner_id, kind, position ASC
+    `, ownerType, ownerIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MediaAsset
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *m)
+	}
+	return items, rows.Err()
+}
+
+// ReorderMedia rewrites gallery positions of one kind to follow ids. The list must
+// contain every ready asset of that kind exactly once.
+func (s *Store) ReorderMedia(ctx context.Context, ownerType string, ownerID uuid.UUID, kind string, ids []uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var count int
+	if err := tx.QueryRow(ctx, `
+        SELECT COUNT(*) FROM media_assets
+        WHERE owner_type=$1 AND owner_id=$2 AND kind=$3 AND status='READY'
+    `, ownerType, ownerID, kind).Scan(&count); err != nil {
+		return err
+	}
+	if count != len(ids) {
+		return ErrMediaMismatch
+	}
+	for position, id := range ids {
+		res, err := tx.Exec(ctx, `
+            UPDATE media_assets SET position=$5, updated_at=NOW()
+            WHERE id=$1 AND owner_type=$2 AND owner_id=$3 AND kind=$4 AND status='READY'
+        `, id, ownerType, ownerID, kind, position)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() == 0 {
+			return ErrMediaMismatch
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// DeleteMediaAsset removes an asset row and returns it so the caller can delete the stored objects.
+func (s *Store) DeleteMediaAsset(ctx context.Context, ownerType string, ownerID, id uuid.UUID) (*MediaAsset, error) {
+	row := s.pool.QueryRow(ctx, `
+        DELETE FROM media_assets WHERE id=$1 AND owner_type=$2 AND owner_id=$3
+        RETURNING `+mediaColumns,
+		id, ownerType, ownerID)
+	return scanMedia(row)
+}
+
+// DeleteOtherFloorPlans removes every floor plan of the owner except keep, returning the removed rows.
+func (s *Store) DeleteOtherFloorPlans(ctx context.Context, ownerType string, ownerID, keep uuid.UUID) ([]MediaAsset, error) {
+	rows, err := s.pool.Query(ctx, `
+        DELETE FROM media_assets
+        WHERE owner_type=$1 AND owner_id=$2 AND kind='floor_plan' AND id <> $3
+        RETURNING `+mediaColumns,
+		ownerType, ownerID, keep)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var removed []MediaAsset
+	for rows.Next() {
+		m, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		removed = append(removed, *m)
+	}
+	return removed, rows.Err()
+}
+
+func scanMedia(row pgx.Row) (*MediaAsset, error) {
+	var m MediaAsset
+	if err := row.Scan(&m.ID, &m.OwnerType, &m.OwnerID, &m.Kind, &m.ObjectKey, &m.ThumbnailKey, &m.ContentType, &m.SizeBytes, &m.Width, &m.Height, &m.Position, &m.Status, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,13 @@
+-- Soft deletion for facilities: archived rows stay around so historic bookings keep their facility
+ALTER TABLE facilities
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_facilities_archived_at ON facilities(archived_at);
+
+-- Bookings must never disappear together with their facility
+ALTER TABLE bookings
+    DROP CONSTRAINT IF EXISTS bookings_facility_id_fkey;
+
+ALTER TABLE bookings
+    ADD CONSTRAINT bookings_facility_id_fkey
+    FOREIGN KEY (facility_id) REFERENCES facilities(id) ON DELETE RESTRICT;
@@ -0,0 +1,13 @@
+-- Venues are archived instead of deleted so facilities and bookings keep their history
+ALTER TABLE venues
+    ADD COLUMN IF NOT EXISTS archived_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_venues_archived_at ON venues(archived_at);
+
+-- Replace the cascading venue foreign key: removing a venue must never wipe facilities
+ALTER TABLE facilities
+    DROP CONSTRAINT IF EXISTS fk_facilities_venue_id;
+
+ALTER TABLE facilities
+    ADD CONSTRAINT fk_facilities_venue_id
+    FOREIGN KEY (venue_id) REFERENCES venues(id) ON DELETE RESTRICT;
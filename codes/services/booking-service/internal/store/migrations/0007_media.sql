@@ -0,0 +1,26 @@
+-- Photo galleries and floor plans for venues and facilities
+CREATE TABLE IF NOT EXISTS media_assets (
+    id UUID PRIMARY KEY,
+    owner_type TEXT NOT NULL CHECK (owner_type IN ('venue', 'facility')),
+    owner_id UUID NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('photo', 'floor_plan')),
+    object_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes INTEGER NOT NULL DEFAULT 0,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    position INTEGER NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'PENDING',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_media_assets_owner
+    ON media_assets (owner_type, owner_id, kind, position);
+
+DROP TRIGGER IF EXISTS media_assets_set_updated_at ON media_assets;
+CREATE TRIGGER media_assets_set_updated_at
+BEFORE UPDATE ON media_assets
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,35 @@
+-- Venue floor plans for the interactive court map
+CREATE TABLE IF NOT EXISTS venue_maps (
+    venue_id UUID PRIMARY KEY REFERENCES venues(id) ON DELETE CASCADE,
+    width DOUBLE PRECISION NOT NULL CHECK (width > 0),
+    height DOUBLE PRECISION NOT NULL CHECK (height > 0),
+    unit TEXT NOT NULL DEFAULT 'm' CHECK (unit IN ('m', 'ft')),
+    background_key TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS venue_maps_set_updated_at ON venue_maps;
+CREATE TRIGGER venue_maps_set_updated_at
+BEFORE UPDATE ON venue_maps
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+
+-- Court geometry in floor-plan coordinates (origin top-left, same unit as the venue map)
+CREATE TABLE IF NOT EXISTS facility_map_shapes (
+    facility_id UUID PRIMARY KEY REFERENCES facilities(id) ON DELETE CASCADE,
+    venue_id UUID NOT NULL REFERENCES venues(id) ON DELETE CASCADE,
+    polygon JSONB NOT NULL,
+    rotation DOUBLE PRECISION NOT NULL DEFAULT 0,
+    label TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_facility_map_shapes_venue_id ON facility_map_shapes(venue_id);
+
+DROP TRIGGER IF EXISTS facility_map_shapes_set_updated_at ON facility_map_shapes;
+CREATE TRIGGER facility_map_shapes_set_updated_at
+BEFORE UPDATE ON facility_map_shapes
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
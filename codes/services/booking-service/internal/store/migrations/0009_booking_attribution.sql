@@ -0,0 +1,3 @@
+-- Who made or cancelled a booking, when that differs from its owner (household members and staff)
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS booked_by UUID;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancelled_by UUID;
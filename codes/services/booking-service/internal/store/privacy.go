@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// PseudonymiseUserBookings erases userID from booking history in one transaction. Upcoming
+// bookings are cancelled (and returned with the new user id and their previous status, so the
+// caller can settle their payments) and every booking is moved to a fresh random id, so venue
+// statistics keep the rows but nothing links them to the member any more. It returns the number of bookings moved.
+func (s *Store) PseudonymiseUserBookings(ctx context.Context, userID uuid.UUID) ([]Booking, int, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	pseudonym := uuid.New()
+	rows, err := tx.Query(ctx, `
+		WITH upcoming AS (
+			SELECT id, status FROM bookings
+			WHERE user_id = $1 AND status IN ('PENDING_PAYMENT','PAYMENT_RETRY','CONFIRMED') AND ends_at > NOW()
+			FOR UPDATE
+		)
+		UPDATE bookings b SET status = 'CANCELLED', updated_at = NOW()
+		FROM upcoming u
+		WHERE b.id = u.id
+		RETURNING b.id, b.facility_id, $2::uuid, b.starts_at, b.ends_at, u.status, b.amount_cents, b.currency, b.payment_intent
+	`, userID, pseudonym)
+	if err != nil {
+		return nil, 0, err
+	}
+	var cancelled []Booking
+	for rows.Next() {
+		var b Booking
+		if err := rows.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent); err != nil {
+			rows.Close()
+			return nil, 0, err
+		}
+		cancelled = append(cancelled, b)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM payment_retries r USING bookings b
+		WHERE r.booking_id = b.id AND b.user_id = $1 AND b.status = 'CANCELLED'
+	`, userID); err != nil {
+		return nil, 0, err
+	}
+	tag, err := tx.Exec(ctx, `UPDATE bookings SET user_id = $2, updated_at = NOW() WHERE user_id = $1`, userID, pseudonym)
+	if err != nil {
+		return nil, 0, err
+	}
+	// Bookings made or cancelled for household members keep the attribution, pseudonymised.
+	if _, err := tx.Exec(ctx, `
+		UPDATE bookings SET
+			booked_by = CASE WHEN booked_by = $1 THEN $2 ELSE booked_by END,
+			cancelled_by = CASE WHEN cancelled_by = $1 THEN $2 ELSE cancelled_by END
+		WHERE booked_by = $1 OR cancelled_by = $1
+	`, userID, pseudonym); err != nil {
+		return nil, 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, 0, err
+	}
+	return cancelled, int(tag.RowsAffected()), nil
+}
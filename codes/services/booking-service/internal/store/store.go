@@ -20,6 +20,19 @@ import (
 //go:embed migrations/*.sql
 var migrationFiles embed.FS
 
+var (
+	// ErrFacilityHasUpcomingBookings is returned when archiving a facility that still has future reservations.
+	ErrFacilityHasUpcomingBookings = errors.New("facility has upcoming bookings")
+	// ErrVenueHasUpcomingBookings is returned when archiving a venue whose facilities still have future reservations.
+	ErrVenueHasUpcomingBookings = errors.New("venue has upcoming bookings")
+	// ErrFacilityArchived is returned when booking a facility that is archived.
+	ErrFacilityArchived = errors.New("facility is archived")
+	// ErrVenueArchived is returned when writing to a facility that belongs to an archived venue.
+	ErrVenueArchived = errors.New("venue is archived")
+	// ErrNotArchived is returned when restoring a record that is still active.
+	ErrNotArchived = errors.New("record is not archived")
+)
+
 // Store manages booking persistence.
 type Store struct {
 	pool *pgxpool.Pool
@@ -83,6 +96,8 @@ type Venue struct {
 	Timezone    string
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	ArchivedAt  *time.Time
+	Media       []MediaAsset
 }
 
 // Facility represents a bookable resource.
@@ -98,6 +113,8 @@ type Facility struct {
 	WeekdayRateCents int
 	WeekendRateCents int
 	Currency         string
+	ArchivedAt       *time.Time
+	Media            []MediaAsset
 }
 
 // Booking aggregates booking data plus facility linkage.
@@ -111,7 +128,11 @@ type Booking struct {
 	AmountCents   int
 	Currency      string
 	PaymentIntent *string // nullable in database
-	Facility      *Facility
+	// BookedBy and CancelledBy record who acted, which may be a household member or staff
+	// rather than the owner; nil on bookings made before attribution was tracked.
+	BookedBy    *uuid.UUID
+	CancelledBy *uuid.UUID
+	Facility    *Facility
 }
 
 // FacilityOverride describes temporary overrides or blackouts.
@@ -162,18 +183,20 @@ func (s *Store) SeedFacility(ctx context.Context, f Facility) error {
 // GetFacility returns a facility by ID.
 func (s *Store) GetFacility(ctx context.Context, id uuid.UUID) (*Facility, error) {
 	row := s.pool.QueryRow(ctx, `
-        SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency
+        SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at
         FROM facilities WHERE id = $1
     `, id)
 	var f Facility
-	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency); err != nil {
+	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
 		return nil, err
 	}
 	return &f, nil
 }
 
 // ListFacilities fetches facilities with optional availability filter.
-func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, onlyAvailable *bool, limit, offset int) ([]Facility, error) {
+// Archived facilities are skipped unless includeArchived is set; a non-nil venueIDs limits the
+// result to those venues.
+func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, venueIDs []uuid.UUID, onlyAvailable *bool, includeArchived bool, limit, offset int) ([]Facility, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -183,7 +206,7 @@ func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, onlyAvail
 	if offset < 0 {
 		offset = 0
 	}
-	query := `SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency FROM facilities WHERE 1=1`
+	query := `SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at FROM facilities WHERE 1=1`
 	args := []any{}
 	idx := 1
 	if venueID != uuid.Nil {
@@ -191,11 +214,19 @@ func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, onlyAvail
 		args = append(args, venueID)
 		idx++
 	}
+	if venueIDs != nil {
+		query += fmt.Sprintf(" AND venue_id = ANY($%d)", idx)
+		args = append(args, venueIDs)
+		idx++
+	}
 	if onlyAvailable != nil {
 		query += fmt.Sprintf(" AND available = $%d", idx)
 		args = append(args, *onlyAvailable)
 		idx++
 	}
+	if !includeArchived {
+		query += " AND archived_at IS NULL"
+	}
 	query += fmt.Sprintf(" ORDER BY name ASC LIMIT %d OFFSET %d", limit, offset)
 	rows, err := s.pool.Query(ctx, query, args...)
 	if err != nil {
@@ -206,7 +237,7 @@ func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, onlyAvail
 	var facilities []Facility
 	for rows.Next() {
 		var f Facility
-		if err := rows.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency); err != nil {
+		if err := rows.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
 			return nil, err
 		}
 		facilities = append(facilities, f)
@@ -218,10 +249,10 @@ func (s *Store) ListFacilities(ctx context.Context, venueID uuid.UUID, onlyAvail
 func (s *Store) UpdateFacilityAvailability(ctx context.Context, id uuid.UUID, available bool) (*Facility, error) {
 	row := s.pool.QueryRow(ctx, `
         UPDATE facilities SET available=$2, updated_at=NOW()
-        WHERE id=$1 RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency
+        WHERE id=$1 AND archived_at IS NULL RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at
     `, id, available)
 	var f Facility
-	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency); err != nil {
+	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
 		return nil, err
 	}
 	return &f, nil
@@ -232,16 +263,92 @@ func (s *Store) CreateFacility(ctx context.Context, f Facility) (*Facility, erro
 	row := s.pool.QueryRow(ctx, `
         INSERT INTO facilities (id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency)
         VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11)
-        RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency
+        RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at
     `, f.ID, f.VenueID, f.Name, f.Description, f.Surface, f.OpenAt, f.CloseAt, f.Available, f.WeekdayRateCents, f.WeekendRateCents, f.Currency)
-	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency); err != nil {
+	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// UpdateFacility replaces the editable fields of an active facility.
+func (s *Store) UpdateFacility(ctx context.Context, id uuid.UUID, f Facility) (*Facility, error) {
+	row := s.pool.QueryRow(ctx, `
+        UPDATE facilities
+        SET name=$2, description=$3, surface=$4, open_at=$5, close_at=$6, weekday_rate_cents=$7, weekend_rate_cents=$8, currency=$9, updated_at=NOW()
+        WHERE id=$1 AND archived_at IS NULL
+        RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at
+    `, id, f.Name, f.Description, f.Surface, f.OpenAt, f.CloseAt, f.WeekdayRateCents, f.WeekendRateCents, f.Currency)
+	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// ArchiveFacility soft-deletes a facility. Bookings keep pointing at the archived row,
+// so history stays intact; the call is refused while upcoming bookings exist.
+func (s *Store) ArchiveFacility(ctx context.Context, id uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var archivedAt *time.Time
+	if err := tx.QueryRow(ctx, `SELECT archived_at FROM facilities WHERE id=$1 FOR UPDATE`, id).Scan(&archivedAt); err != nil {
+		return err
+	}
+	if archivedAt != nil {
+		return pgx.ErrNoRows
+	}
+
+	var upcoming bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (
+            SELECT 1 FROM bookings
+            WHERE facility_id=$1 AND status IN ('PENDING_PAYMENT','PAYMENT_RETRY','CONFIRMED') AND ends_at > NOW()
+        )
+    `, id).Scan(&upcoming); err != nil {
+		return err
+	}
+	if upcoming {
+		return ErrFacilityHasUpcomingBookings
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE facilities SET archived_at=NOW(), updated_at=NOW() WHERE id=$1`, id); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// RestoreFacility un-archives a facility whose venue is still active.
+func (s *Store) RestoreFacility(ctx context.Context, id uuid.UUID) (*Facility, error) {
+	var archivedAt, venueArchivedAt *time.Time
+	if err := s.pool.QueryRow(ctx, `
+        SELECT f.archived_at, v.archived_at FROM facilities f JOIN venues v ON v.id = f.venue_id WHERE f.id=$1
+    `, id).Scan(&archivedAt, &venueArchivedAt); err != nil {
+		return nil, err
+	}
+	if archivedAt == nil {
+		return nil, ErrNotArchived
+	}
+	if venueArchivedAt != nil {
+		return nil, ErrVenueArchived
+	}
+	row := s.pool.QueryRow(ctx, `
+        UPDATE facilities SET archived_at=NULL, updated_at=NOW()
+        WHERE id=$1 RETURNING id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at
+    `, id)
+	var f Facility
+	if err := row.Scan(&f.ID, &f.VenueID, &f.Name, &f.Description, &f.Surface, &f.OpenAt, &f.CloseAt, &f.Available, &f.WeekdayRateCents, &f.WeekendRateCents, &f.Currency, &f.ArchivedAt); err != nil {
 		return nil, err
 	}
 	return &f, nil
 }
 
-// ListBookings returns bookings for a user (optional) with facility data.
-func (s *Store) ListBookings(ctx context.Context, userID uuid.UUID, limit, offset int) ([]Booking, error) {
+// ListBookings returns bookings for a user (optional) with facility data. A non-nil venueIDs
+// limits the result to bookings at those venues.
+func (s *Store) ListBookings(ctx context.Context, userID uuid.UUID, venueIDs []uuid.UUID, limit, offset int) ([]Booking, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -252,15 +359,20 @@ func (s *Store) ListBookings(ctx context.Context, userID uuid.UUID, limit, offse
 		offset = 0
 	}
 	query := `
-        SELECT b.id, b.facility_id, b.user_id, b.starts_at, b.ends_at, b.status, b.amount_cents, b.currency, b.payment_intent,
-               f.id, f.venue_id, f.name, f.description, f.surface, f.open_at, f.close_at, f.available, f.weekday_rate_cents, f.weekend_rate_cents, f.currency
+        SELECT b.id, b.facility_id, b.user_id, b.starts_at, b.ends_at, b.status, b.amount_cents, b.currency, b.payment_intent, b.booked_by, b.cancelled_by,
+               f.id, f.venue_id, f.name, f.description, f.surface, f.open_at, f.close_at, f.available, f.weekday_rate_cents, f.weekend_rate_cents, f.currency, f.archived_at
         FROM bookings b
         JOIN facilities f ON f.id = b.facility_id
     `
 	args := []any{}
+	query += " WHERE 1=1"
 	if userID != uuid.Nil {
-		query += " WHERE b.user_id = $1"
 		args = append(args, userID)
+		query += fmt.Sprintf(" AND b.user_id = $%d", len(args))
+	}
+	if venueIDs != nil {
+		args = append(args, venueIDs)
+		query += fmt.Sprintf(" AND f.venue_id = ANY($%d)", len(args))
 	}
 	query += fmt.Sprintf(" ORDER BY b.starts_at DESC LIMIT %d OFFSET %d", limit, offset)
 
@@ -274,8 +386,8 @@ func (s *Store) ListBookings(ctx context.Context, userID uuid.UUID, limit, offse
 	for rows.Next() {
 		var b Booking
 		var facility Facility
-		if err := rows.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent,
-			&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency); err != nil {
+		if err := rows.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent, &b.BookedBy, &b.CancelledBy,
+			&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency, &facility.ArchivedAt); err != nil {
 			return nil, err
 		}
 		b.Facility = &facility
@@ -288,15 +400,24 @@ func (s *Store) ListBookings(ctx context.Context, userID uuid.UUID, limit, offse
 type CreateBookingInput struct {
 	FacilityID  uuid.UUID
 	UserID      uuid.UUID
+	BookedBy    uuid.UUID
 	StartsAt    time.Time
 	EndsAt      time.Time
 	AmountCents int
 	Currency    string
 }
 
-// CreateBooking inserts a booking row if no conflict exists.
+// CreateBooking inserts a booking row if no conflict exists. The insert share-locks the facility
+// row, so it cannot slip past a concurrent archive; ErrFacilityArchived is returned when the
+// facility is archived.
 func (s *Store) CreateBooking(ctx context.Context, input CreateBookingInput) (*Booking, error) {
-	conflict, err := s.hasConflict(ctx, input.FacilityID, input.StartsAt, input.EndsAt)
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	conflict, err := hasConflict(ctx, tx, input.FacilityID, input.StartsAt, input.EndsAt)
 	if err != nil {
 		return nil, err
 	}
@@ -305,14 +426,22 @@ func (s *Store) CreateBooking(ctx context.Context, input CreateBookingInput) (*B
 	}
 
 	bookingID := uuid.New()
-	row := s.pool.QueryRow(ctx, `
-        INSERT INTO bookings (id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency)
-        VALUES ($1,$2,$3,$4,$5,'PENDING_PAYMENT',$6,$7)
-        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent
-    `, bookingID, input.FacilityID, input.UserID, input.StartsAt, input.EndsAt, input.AmountCents, input.Currency)
+	row := tx.QueryRow(ctx, `
+        INSERT INTO bookings (id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, booked_by)
+        SELECT $1, f.id, $3, $4, $5, 'PENDING_PAYMENT', $6, $7, $8
+        FROM facilities f WHERE f.id=$2 AND f.archived_at IS NULL
+        FOR SHARE
+        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent, booked_by, cancelled_by
+    `, bookingID, input.FacilityID, input.UserID, input.StartsAt, input.EndsAt, input.AmountCents, input.Currency, input.BookedBy)
 
 	var b Booking
-	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent); err != nil {
+	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent, &b.BookedBy, &b.CancelledBy); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFacilityArchived
+		}
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
 		return nil, err
 	}
 	return &b, nil
@@ -323,9 +452,9 @@ func (s *Store) AttachFacility(ctx context.Context, booking *Booking) error {
 	if booking == nil {
 		return nil
 	}
-	row := s.pool.QueryRow(ctx, `SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency FROM facilities WHERE id=$1`, booking.FacilityID)
+	row := s.pool.QueryRow(ctx, `SELECT id, venue_id, name, description, surface, open_at, close_at, available, weekday_rate_cents, weekend_rate_cents, currency, archived_at FROM facilities WHERE id=$1`, booking.FacilityID)
 	var facility Facility
-	if err := row.Scan(&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency); err != nil {
+	if err := row.Scan(&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency, &facility.ArchivedAt); err != nil {
 		return err
 	}
 	booking.Facility = &facility
@@ -337,24 +466,24 @@ func (s *Store) UpdateBookingStatus(ctx context.Context, id uuid.UUID, status, p
 	row := s.pool.QueryRow(ctx, `
         UPDATE bookings SET status=$2, payment_intent=$3, updated_at=NOW()
         WHERE id=$1
-        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent
+        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent, booked_by, cancelled_by
     `, id, status, paymentIntent)
 	var b Booking
-	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent); err != nil {
+	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent, &b.BookedBy, &b.CancelledBy); err != nil {
 		return nil, err
 	}
 	return &b, nil
 }
 
-// CancelBooking marks a booking cancelled.
-func (s *Store) CancelBooking(ctx context.Context, id uuid.UUID) (*Booking, error) {
+// CancelBooking marks a booking cancelled by cancelledBy.
+func (s *Store) CancelBooking(ctx context.Context, id, cancelledBy uuid.UUID) (*Booking, error) {
 	row := s.pool.QueryRow(ctx, `
-        UPDATE bookings SET status='CANCELLED', updated_at=NOW()
+        UPDATE bookings SET status='CANCELLED', cancelled_by=$2, updated_at=NOW()
         WHERE id=$1
-        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent
-    `, id)
+        RETURNING id, facility_id, user_id, starts_at, ends_at, status, amount_cents, currency, payment_intent, booked_by, cancelled_by
+    `, id, cancelledBy)
 	var b Booking
-	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent); err != nil {
+	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent, &b.BookedBy, &b.CancelledBy); err != nil {
 		return nil, err
 	}
 	return &b, nil
@@ -363,24 +492,24 @@ func (s *Store) CancelBooking(ctx context.Context, id uuid.UUID) (*Booking, erro
 // GetBooking fetches a single booking by id.
 func (s *Store) GetBooking(ctx context.Context, id uuid.UUID) (*Booking, error) {
 	row := s.pool.QueryRow(ctx, `
-        SELECT b.id, b.facility_id, b.user_id, b.starts_at, b.ends_at, b.status, b.amount_cents, b.currency, b.payment_intent,
-               f.id, f.venue_id, f.name, f.description, f.surface, f.open_at, f.close_at, f.available, f.weekday_rate_cents, f.weekend_rate_cents, f.currency
+        SELECT b.id, b.facility_id, b.user_id, b.starts_at, b.ends_at, b.status, b.amount_cents, b.currency, b.payment_intent, b.booked_by, b.cancelled_by,
+               f.id, f.venue_id, f.name, f.description, f.surface, f.open_at, f.close_at, f.available, f.weekday_rate_cents, f.weekend_rate_cents, f.currency, f.archived_at
         FROM bookings b
         JOIN facilities f ON f.id = b.facility_id
         WHERE b.id = $1
     `, id)
 	var b Booking
 	var facility Facility
-	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent,
-		&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency); err != nil {
+	if err := row.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent, &b.BookedBy, &b.CancelledBy,
+		&facility.ID, &facility.VenueID, &facility.Name, &facility.Description, &facility.Surface, &facility.OpenAt, &facility.CloseAt, &facility.Available, &facility.WeekdayRateCents, &facility.WeekendRateCents, &facility.Currency, &facility.ArchivedAt); err != nil {
 		return nil, err
 	}
 	b.Facility = &facility
 	return &b, nil
 }
 
-func (s *Store) hasConflict(ctx context.Context, facilityID uuid.UUID, start, end time.Time) (bool, error) {
-	row := s.pool.QueryRow(ctx, `
+func hasConflict(ctx context.Context, tx pgx.Tx, facilityID uuid.UUID, start, end time.Time) (bool, error) {
+	row := tx.QueryRow(ctx, `
         SELECT EXISTS (
             SELECT 1 FROM bookings
             WHERE facility_id=$1 AND status IN ('PENDING_PAYMENT','CONFIRMED')
@@ -614,8 +743,9 @@ func (s *Store) DeletePaymentRetry(ctx context.Context, bookingID uuid.UUID) err
 
 // === VENUE CRUD OPERATIONS ===
 
-// ListVenues fetches all venues with pagination.
-func (s *Store) ListVenues(ctx context.Context, limit, offset int) ([]Venue, error) {
+// ListVenues fetches venues with pagination. Archived venues are skipped unless includeArchived is set;
+// a non-nil venueIDs limits the result to those venues.
+func (s *Store) ListVenues(ctx context.Context, includeArchived bool, venueIDs []uuid.UUID, limit, offset int) ([]Venue, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -627,11 +757,12 @@ func (s *Store) ListVenues(ctx context.Context, limit, offset int) ([]Venue, err
 	}
 
 	rows, err := s.pool.Query(ctx, `
-		SELECT id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at
+		SELECT id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at, archived_at
 		FROM venues
+		WHERE ($3 OR archived_at IS NULL) AND ($4::uuid[] IS NULL OR id = ANY($4))
 		ORDER BY name ASC
 		LIMIT $1 OFFSET $2
-	`, limit, offset)
+	`, limit, offset, includeArchived, venueIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -651,7 +782,7 @@ func (s *Store) ListVenues(ctx context.Context, limit, offset int) ([]Venue, err
 // GetVenue returns a venue by ID.
 func (s *Store) GetVenue(ctx context.Context, id uuid.UUID) (*Venue, error) {
 	row := s.pool.QueryRow(ctx, `
-		SELECT id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at
+		SELECT id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at, archived_at
 		FROM venues
 		WHERE id = $1
 	`, id)
@@ -674,7 +805,7 @@ func (s *Store) CreateVenue(ctx context.Context, v Venue) (*Venue, error) {
 	row := s.pool.QueryRow(ctx, `
 		INSERT INTO venues (id, name, description, address, city, state, zip_code, country, phone, email, website, timezone)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at
+		RETURNING id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at, archived_at
 	`, v.ID, v.Name, v.Description, v.Address, v.City, v.State, v.ZipCode, v.Country, v.Phone, v.Email, v.Website, v.Timezone)
 
 	return scanVenue(row)
@@ -685,23 +816,133 @@ func (s *Store) UpdateVenue(ctx context.Context, id uuid.UUID, v Venue) (*Venue,
 	row := s.pool.QueryRow(ctx, `
 		UPDATE venues
 		SET name = $2, description = $3, address = $4, city = $5, state = $6, zip_code = $7, country = $8, phone = $9, email = $10, website = $11, timezone = $12, updated_at = NOW()
-		WHERE id = $1
-		RETURNING id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at
+		WHERE id = $1 AND archived_at IS NULL
+		RETURNING id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at, archived_at
 	`, id, v.Name, v.Description, v.Address, v.City, v.State, v.ZipCode, v.Country, v.Phone, v.Email, v.Website, v.Timezone)
 
 	return scanVenue(row)
 }
 
-// DeleteVenue removes a venue by ID.
-func (s *Store) DeleteVenue(ctx context.Context, id uuid.UUID) error {
-	res, err := s.pool.Exec(ctx, `DELETE FROM venues WHERE id = $1`, id)
+// ArchiveVenue archives a venue together with its active facilities in one transaction.
+// Upcoming bookings block the archive unless cancelUpcoming is set, in which case they are
+// cancelled in the same transaction and returned, with the status they had before, so the
+// caller can settle their payments and notify.
+func (s *Store) ArchiveVenue(ctx context.Context, id uuid.UUID, cancelUpcoming bool) ([]Booking, error) {
+	tx, err := s.pool.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if res.RowsAffected() == 0 {
-		return pgx.ErrNoRows
+	defer tx.Rollback(ctx)
+
+	var archivedAt *time.Time
+	if err := tx.QueryRow(ctx, `SELECT archived_at FROM venues WHERE id = $1 FOR UPDATE`, id).Scan(&archivedAt); err != nil {
+		return nil, err
 	}
-	return nil
+	if archivedAt != nil {
+		return nil, pgx.ErrNoRows
+	}
+	// Locking the facilities first waits for bookings being inserted (they share-lock their
+	// facility) and keeps new ones out until the archive commits.
+	if _, err := tx.Exec(ctx, `SELECT id FROM facilities WHERE venue_id = $1 AND archived_at IS NULL FOR UPDATE`, id); err != nil {
+		return nil, err
+	}
+
+	var cancelled []Booking
+	if cancelUpcoming {
+		rows, err := tx.Query(ctx, `
+			WITH upcoming AS (
+				SELECT b.id, b.status FROM bookings b
+				JOIN facilities f ON f.id = b.facility_id
+				WHERE f.venue_id = $1 AND b.status IN ('PENDING_PAYMENT','PAYMENT_RETRY','CONFIRMED') AND b.ends_at > NOW()
+				FOR UPDATE OF b
+			)
+			UPDATE bookings b SET status = 'CANCELLED', updated_at = NOW()
+			FROM upcoming u
+			WHERE b.id = u.id
+			RETURNING b.id, b.facility_id, b.user_id, b.starts_at, b.ends_at, u.status, b.amount_cents, b.currency, b.payment_intent
+		`, id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var b Booking
+			if err := rows.Scan(&b.ID, &b.FacilityID, &b.UserID, &b.StartsAt, &b.EndsAt, &b.Status, &b.AmountCents, &b.Currency, &b.PaymentIntent); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			cancelled = append(cancelled, b)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+			DELETE FROM payment_retries r USING bookings b, facilities f
+			WHERE r.booking_id = b.id AND b.facility_id = f.id AND f.venue_id = $1 AND b.status = 'CANCELLED'
+		`, id); err != nil {
+			return nil, err
+		}
+	} else {
+		var upcoming bool
+		if err := tx.QueryRow(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM bookings b
+				JOIN facilities f ON f.id = b.facility_id
+				WHERE f.venue_id = $1 AND b.status IN ('PENDING_PAYMENT','PAYMENT_RETRY','CONFIRMED') AND b.ends_at > NOW()
+			)
+		`, id).Scan(&upcoming); err != nil {
+			return nil, err
+		}
+		if upcoming {
+			return nil, ErrVenueHasUpcomingBookings
+		}
+	}
+
+	// NOW() is fixed for the transaction, so facilities archived here share the venue's
+	// archived_at and RestoreVenue can tell them apart from ones archived individually.
+	if _, err := tx.Exec(ctx, `UPDATE facilities SET archived_at = NOW(), updated_at = NOW() WHERE venue_id = $1 AND archived_at IS NULL`, id); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE venues SET archived_at = NOW(), updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return cancelled, nil
+}
+
+// RestoreVenue un-archives a venue and the facilities that were archived along with it.
+func (s *Store) RestoreVenue(ctx context.Context, id uuid.UUID) (*Venue, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var archivedAt *time.Time
+	if err := tx.QueryRow(ctx, `SELECT archived_at FROM venues WHERE id = $1 FOR UPDATE`, id).Scan(&archivedAt); err != nil {
+		return nil, err
+	}
+	if archivedAt == nil {
+		return nil, ErrNotArchived
+	}
+	if _, err := tx.Exec(ctx, `UPDATE facilities SET archived_at = NULL, updated_at = NOW() WHERE venue_id = $1 AND archived_at = $2`, id, *archivedAt); err != nil {
+		return nil, err
+	}
+	row := tx.QueryRow(ctx, `
+		UPDATE venues SET archived_at = NULL, updated_at = NOW()
+		WHERE id = $1
+		RETURNING id, name, description, address, city, state, zip_code, country, phone, email, website, timezone, created_at, updated_at, archived_at
+	`, id)
+	venue, err := scanVenue(row)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return venue, nil
 }
 
 // scanVenue scans a venue row with proper NULL handling.
@@ -724,6 +965,7 @@ func scanVenue(row interface{ Scan(dest ...any) error }) (*Venue, error) {
 		&v.Timezone,
 		&v.CreatedAt,
 		&v.UpdatedAt,
+		&v.ArchivedAt,
 	); err != nil {
 		return nil, err
 	}
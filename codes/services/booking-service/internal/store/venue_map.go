@@ -0,0 +1,206 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrFacilityNotInVenue is returned when a map shape references a facility of another venue
+// or an archived facility.
+var ErrFacilityNotInVenue = errors.New("facility does not belong to this venue")
+
+// MapPoint is a vertex in floor-plan coordinates (origin top-left).
+type MapPoint struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// VenueMap is a venue floor plan plus the geometry of its facilities.
+type VenueMap struct {
+	VenueID       uuid.UUID
+	Width         float64
+	Height        float64
+	Unit          string
+	BackgroundKey string
+	UpdatedAt     time.Time
+	Shapes        []FacilityShape
+}
+
+// FacilityShape places a facility on the venue map.
+type FacilityShape struct {
+	FacilityID uuid.UUID
+	Polygon    []MapPoint
+	Rotation   float64
+	Label      string
+	UpdatedAt  time.Time
+}
+
+// BookingWindow is the time span a booking blocks on a facility.
+type BookingWindow struct {
+	FacilityID uuid.UUID
+	StartsAt   time.Time
+	EndsAt     time.Time
+}
+
+// GetVenueMap returns the floor plan and the shapes of the venue's active facilities.
+func (s *Store) GetVenueMap(ctx context.Context, venueID uuid.UUID) (*VenueMap, error) {
+	var m VenueMap
+	var background *string
+	if err := s.pool.QueryRow(ctx, `
+        SELECT venue_id, width, height, unit, background_key, updated_at
+        FROM venue_maps WHERE venue_id=$1
+    `, venueID).Scan(&m.VenueID, &m.Width, &m.Height, &m.Unit, &background, &m.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if background != nil {
+		m.BackgroundKey = *background
+	}
+
+	rows, err := s.pool.Query(ctx, `
+        SELECT s.facility_id, s.polygon, s.rotation, s.label, s.updated_at
+        FROM facility_map_shapes s
+        JOIN facilities f ON f.id = s.facility_id
+        WHERE s.venue_id=$1 AND f.archived_at IS NULL
+        ORDER BY f.name ASC
+    `, venueID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		shape, err := scanFacilityShape(rows)
+		if err != nil {
+			return nil, err
+		}
+		m.Shapes = append(m.Shapes, *shape)
+	}
+	return &m, rows.Err()
+}
+
+// SaveVenueMap replaces the floor plan and every facility shape of the venue in one transaction.
+func (s *Store) SaveVenueMap(ctx context.Context, m VenueMap) (*VenueMap, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO venue_maps (venue_id, width, height, unit, background_key)
+        VALUES ($1,$2,$3,$4,NULLIF($5,''))
+        ON CONFLICT (venue_id) DO UPDATE
+        SET width=EXCLUDED.width, height=EXCLUDED.height, unit=EXCLUDED.unit, background_key=EXCLUDED.background_key
+    `, m.VenueID, m.Width, m.Height, m.Unit, m.BackgroundKey); err != nil {
+		return nil, err
+	}
+
+	keep := make([]uuid.UUID, 0, len(m.Shapes))
+	for _, shape := range m.Shapes {
+		keep = append(keep, shape.FacilityID)
+	}
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM facility_map_shapes WHERE venue_id=$1 AND NOT (facility_id = ANY($2))
+    `, m.VenueID, keep); err != nil {
+		return nil, err
+	}
+	for _, shape := range m.Shapes {
+		if err := upsertFacilityShape(ctx, tx, m.VenueID, shape); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetVenueMap(ctx, m.VenueID)
+}
+
+// SaveFacilityShape creates or replaces the shape of a single facility.
+func (s *Store) SaveFacilityShape(ctx context.Context, venueID uuid.UUID, shape FacilityShape) (*FacilityShape, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := upsertFacilityShape(ctx, tx, venueID, shape); err != nil {
+		return nil, err
+	}
+	saved, err := scanFacilityShape(tx.QueryRow(ctx, `
+        SELECT facility_id, polygon, rotation, label, updated_at FROM facility_map_shapes WHERE facility_id=$1
+    `, shape.FacilityID))
+	if err != nil {
+		return nil, err
+	}
+	return saved, tx.Commit(ctx)
+}
+
+// DeleteFacilityShape removes a facility from the venue map.
+func (s *Store) DeleteFacilityShape(ctx context.Context, venueID, facilityID uuid.UUID) error {
+	res, err := s.pool.Exec(ctx, `DELETE FROM facility_map_shapes WHERE venue_id=$1 AND facility_id=$2`, venueID, facilityID)
+	if err != nil {
+		return err
+	}
+	if res.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// ListBookingWindows returns slot-holding bookings on the facilities that overlap [from, to).
+func (s *Store) ListBookingWindows(ctx context.Context, facilityIDs []uuid.UUID, from, to time.Time) ([]BookingWindow, error) {
+	if len(facilityIDs) == 0 {
+		return nil, nil
+	}
+	rows, err := s.pool.Query(ctx, `
+        SELECT facility_id, starts_at, ends_at
+        FROM bookings
+        WHERE facility_id = ANY($1)
+          AND status IN ('PENDING_PAYMENT','PAYMENT_RETRY','CONFIRMED')
+          AND starts_at < $3 AND ends_at > $2
+        ORDER BY facility_id, starts_at ASC
+    `, facilityIDs, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var windows []BookingWindow
+	for rows.Next() {
+		var w BookingWindow
+		if err := rows.Scan(&w.FacilityID, &w.StartsAt, &w.EndsAt); err != nil {
+			return nil, err
+		}
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+func upsertFacilityShape(ctx context.Context, tx pgx.Tx, venueID uuid.UUID, shape FacilityShape) error {
+	var belongs bool
+	if err := tx.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM facilities WHERE id=$1 AND venue_id=$2 AND archived_at IS NULL)
+    `, shape.FacilityID, venueID).Scan(&belongs); err != nil {
+		return err
+	}
+	if !belongs {
+		return ErrFacilityNotInVenue
+	}
+	_, err := tx.Exec(ctx, `
+        INSERT INTO facility_map_shapes (facility_id, venue_id, polygon, rotation, label)
+        VALUES ($1,$2,$3,$4,$5)
+        ON CONFLICT (facility_id) DO UPDATE
+        SET venue_id=EXCLUDED.venue_id, polygon=EXCLUDED.polygon, rotation=EXCLUDED.rotation, label=EXCLUDED.label
+    `, shape.FacilityID, venueID, shape.Polygon, shape.Rotation, shape.Label)
+	return err
+}
+
+func scanFacilityShape(row pgx.Row) (*FacilityShape, error) {
+	var shape FacilityShape
+	if err := row.Scan(&shape.FacilityID, &shape.Polygon, &shape.Rotation, &shape.Label, &shape.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &shape, nil
+}
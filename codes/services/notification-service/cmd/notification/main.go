@@ -1,19 +1,39 @@
 package main
 
 import (
+	"context"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/notification-service/internal/consent"
 )
 
+// categoryMarketing marks promotional messages, which are only sent to members who opted in to
+// marketing on the message's channel.
+const categoryMarketing = "marketing"
+
+// marketingPurposes maps a channel to the consent purpose marketing on it needs.
+var marketingPurposes = map[string]string{
+	"email":  "marketing_email",
+	"sms":    "marketing_sms",
+	"push":   "marketing_push",
+	"in_app": "marketing_push",
+}
+
 type notificationRequest struct {
 	UserID  string `json:"userId" binding:"required"`
 	Title   string `json:"title" binding:"required"`
 	Message string `json:"message" binding:"required"`
 	Channel string `json:"channel" binding:"required"`
+	// To is an explicit recipient (email address or E.164 number); by default the user's own.
+	To string `json:"to"`
+	// Category is transactional (the default) or marketing.
+	Category string `json:"category" binding:"omitempty,oneof=transactional marketing"`
 }
 
 func main() {
@@ -22,14 +42,31 @@ func main() {
 		panic(err)
 	}
 
-	registerRoutes(srv.Engine)
+	userURL := os.Getenv("USER_SERVICE_URL")
+	if userURL == "" {
+		userURL = "http://user-service:8080"
+	}
+	consents := consent.New(userURL, srv.ServiceAuth.Transport(nil))
+
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
+	registerRoutes(srv.Engine, srv.ServiceAuth, consents)
 
 	if err := srv.Run(); err != nil {
 		panic(err)
 	}
 }
 
-func registerRoutes(router *gin.Engine) {
+func registerRoutes(router *gin.Engine, serviceAuth *svcauth.Keys, consents *consent.Client) {
+	// Right-of-access export for user-service. Sent notifications are not persisted yet, so
+	// there is nothing to return.
+	router.GET("/v1/privacy/users/:id/export", serviceAuth.RequireCaller("user-service"), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"notifications": []gin.H{}})
+	})
+	// Erasure when an account is deleted; with nothing persisted there is nothing to erase.
+	router.POST("/v1/privacy/users/:id/erase", serviceAuth.RequireCaller("user-service"), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"records": gin.H{}})
+	})
+
 	router.GET("/v1/notifications", func(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, []gin.H{
 			{"id": "notif-1", "title": "Booking Confirmed", "message": "See you tomorrow!", "channel": "push", "read": false},
@@ -42,13 +79,45 @@ func registerRoutes(router *gin.Engine) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if req.Category == categoryMarketing && !allowMarketing(ctx, consents, req) {
+			return
+		}
 		ctx.JSON(http.StatusAccepted, gin.H{
 			"id":        "notif-" + req.Channel,
 			"userId":    req.UserID,
 			"title":     req.Title,
 			"message":   req.Message,
 			"channel":   req.Channel,
+			"to":        req.To,
+			"category":  req.Category,
 			"createdAt": time.Now().Format(time.RFC3339),
 		})
 	})
 }
+
+// allowMarketing refuses a marketing message unless the member consented to marketing on its
+// channel. Consent belongs to the member's own address, so marketing cannot name another
+// recipient in To. It fails closed: when the user-service cannot answer, nothing is sent.
+func allowMarketing(ctx *gin.Context, consents *consent.Client, req notificationRequest) bool {
+	if req.To != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "marketing is only sent to the member's own address; omit to"})
+		return false
+	}
+	purpose, ok := marketingPurposes[req.Channel]
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "marketing is not sent on channel " + req.Channel})
+		return false
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	granted, err := consents.Granted(timeoutCtx, req.UserID, purpose)
+	if err != nil {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": "could not check marketing consent: " + err.Error()})
+		return false
+	}
+	if !granted {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "recipient has not consented to " + purpose})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,50 @@
+package consent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client asks the user-service whether a member has consented to a purpose.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a consent client. transport signs requests for the user-service (see svcauth); nil
+// uses the default transport.
+func New(baseURL string, transport http.RoundTripper) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Second, Transport: transport},
+	}
+}
+
+// Granted reports whether userID's latest decision for purpose is a grant.
+func (c *Client) Granted(ctx context.Context, userID, purpose string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/v1/users/%s/privacy/consents/%s", c.baseURL, url.PathEscape(userID), url.PathEscape(purpose))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("user service responded with %d", resp.StatusCode)
+	}
+	var body struct {
+		Granted bool `json:"granted"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, err
+	}
+	return body.Granted, nil
+}
@@ -21,6 +21,7 @@ func main() {
 		panic(err)
 	}
 
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
 	registerRoutes(srv.Engine)
 
 	if err := srv.Run(); err != nil {
@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/svcauth"
 )
 
 type paymentIntentRequest struct {
@@ -26,14 +27,25 @@ func main() {
 		panic(err)
 	}
 
-	registerRoutes(srv.Engine)
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
+	registerRoutes(srv.Engine, srv.ServiceAuth)
 
 	if err := srv.Run(); err != nil {
 		panic(err)
 	}
 }
 
-func registerRoutes(router *gin.Engine) {
+func registerRoutes(router *gin.Engine, serviceAuth *svcauth.Keys) {
+	// Right-of-access export for user-service. Intents and refunds are not persisted yet, so
+	// there is nothing to return; booking-service exports each booking's amount and intent id.
+	router.GET("/v1/privacy/users/:id/export", serviceAuth.RequireCaller("user-service"), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"payments": []gin.H{}})
+	})
+	// Erasure when an account is deleted; with nothing persisted there is nothing to erase.
+	router.POST("/v1/privacy/users/:id/erase", serviceAuth.RequireCaller("user-service"), func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{"records": gin.H{}})
+	})
+
 	router.POST("/v1/payments/intents", func(ctx *gin.Context) {
 		var req paymentIntentRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -50,6 +62,13 @@ func registerRoutes(router *gin.Engine) {
 		})
 	})
 
+	router.POST("/v1/payments/intents/:id/cancel", func(ctx *gin.Context) {
+		ctx.JSON(http.StatusOK, gin.H{
+			"id":     ctx.Param("id"),
+			"status": "canceled",
+		})
+	})
+
 	router.POST("/v1/payments/refunds", func(ctx *gin.Context) {
 		var req refundRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // register decoder
+	_ "image/jpeg" // register decoder
+	_ "image/png"  // register decoder
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/s3util"
+)
+
+const (
+	// maxAvatarBytes caps a profile picture upload.
+	maxAvatarBytes = 5 << 20
+
+	avatarUploadExpiry = 15 * time.Minute
+	avatarReadExpiry   = time.Hour
+)
+
+var (
+	errAvatarType    = errors.New("avatar must be a JPEG, PNG or GIF image")
+	errAvatarSize    = errors.New("avatar exceeds maximum size")
+	errAvatarInvalid = errors.New("uploaded file is not a valid image")
+	errAvatarKey     = errors.New("key is not an avatar upload of this user")
+)
+
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// avatars stores profile pictures in the configured StorageProvider. Clients upload straight
+// to storage with a presigned URL and then confirm the key, like venue media.
+type avatars struct {
+	storage s3util.StorageProvider
+}
+
+// presignUpload validates the declared upload and returns a new key with a URL to PUT it to.
+func (a *avatars) presignUpload(ctx context.Context, userID uuid.UUID, contentType string, sizeBytes int64) (string, string, time.Time, error) {
+	ext, ok := avatarExtensions[contentType]
+	if !ok {
+		return "", "", time.Time{}, errAvatarType
+	}
+	if sizeBytes <= 0 || sizeBytes > maxAvatarBytes {
+		return "", "", time.Time{}, errAvatarSize
+	}
+	key := path.Join(avatarPrefix(userID), uuid.NewString()+ext)
+	url, err := a.storage.PresignPut(ctx, key, contentType, maxAvatarBytes, avatarUploadExpiry)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	return key, url, time.Now().Add(avatarUploadExpiry), nil
+}
+
+// verify checks that key was uploaded for userID and really is an allowed image.
+func (a *avatars) verify(ctx context.Context, userID uuid.UUID, key string) error {
+	if !strings.HasPrefix(key, avatarPrefix(userID)+"/") || path.Clean(key) != key {
+		return errAvatarKey
+	}
+	info, err := a.storage.Head(ctx, key)
+	if err != nil {
+		return fmt.Errorf("fetch upload: %w", err)
+	}
+	if info.Size > maxAvatarBytes {
+		return errAvatarSize
+	}
+	data, err := a.storage.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("fetch upload: %w", err)
+	}
+	if _, ok := avatarExtensions[http.DetectContentType(data)]; !ok {
+		return errAvatarType
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil {
+		return errAvatarInvalid
+	}
+	return nil
+}
+
+// url presigns a read URL for key; an empty key yields an empty URL.
+func (a *avatars) url(ctx context.Context, key string) string {
+	if key == "" || a == nil {
+		return ""
+	}
+	url, err := a.storage.PresignGet(ctx, key, avatarReadExpiry)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+// remove deletes a replaced avatar; a missing object is not an error.
+func (a *avatars) remove(ctx context.Context, key string) error {
+	if key == "" {
+		return nil
+	}
+	if err := a.storage.Delete(ctx, key); err != nil && !errors.Is(err, s3util.ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func avatarPrefix(userID uuid.UUID) string {
+	return path.Join("users", userID.String(), "avatar")
+}
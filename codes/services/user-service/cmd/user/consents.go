@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type policyRequest struct {
+	Title    string `json:"title" binding:"required"`
+	URL      string `json:"url" binding:"omitempty,url"`
+	Summary  string `json:"summary"`
+	Body     string `json:"body"`
+	Required bool   `json:"required"`
+}
+
+type consentsRequest struct {
+	// Source is where the decision was made, e.g. "signup", "banner", "settings" or "support".
+	Source   string `json:"source" binding:"required,max=40"`
+	Consents []struct {
+		Purpose string `json:"purpose" binding:"required"`
+		Granted *bool  `json:"granted" binding:"required"`
+		// Version is the policy version the member was shown; it must still be the latest.
+		Version int `json:"version"`
+	} `json:"consents" binding:"required,min=1,dive"`
+}
+
+// registerPolicyRoutes publishes the versioned policy documents. Reading them needs no sign-in,
+// so consent banners can show them before registration.
+func registerPolicyRoutes(router *gin.Engine, repo *store.Store, trail *audit.Client) {
+	policies := router.Group("/v1/policies")
+
+	policies.GET("", func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		docs, err := repo.LatestPolicies(timeoutCtx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, policiesResponse(docs))
+	})
+
+	policies.GET("/:kind", func(ctx *gin.Context) {
+		version := 0
+		if raw := ctx.Query("version"); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid version"})
+				return
+			}
+			version = parsed
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		doc, err := repo.GetPolicy(timeoutCtx, ctx.Param("kind"), version)
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "policy not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, policyResponse(doc))
+	})
+
+	policies.GET("/:kind/versions", func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		docs, err := repo.ListPolicyVersions(timeoutCtx, ctx.Param("kind"))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, policiesResponse(docs))
+	})
+
+	policies.POST("/:kind", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		kind := ctx.Param("kind")
+		if kind != store.PolicyTerms && kind != store.PolicyPrivacy && kind != store.PolicyMarketing {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "kind must be terms, privacy or marketing"})
+			return
+		}
+		var req policyRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.URL == "" && req.Body == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "a policy needs a url or a body"})
+			return
+		}
+		doc := store.PolicyDocument{
+			Kind:     kind,
+			Title:    req.Title,
+			URL:      req.URL,
+			Summary:  req.Summary,
+			Body:     req.Body,
+			Required: req.Required,
+		}
+		if admin, err := uuid.Parse(ctx.GetHeader(authz.HeaderUserID)); err == nil {
+			doc.PublishedBy = &admin
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		published, err := repo.PublishPolicy(timeoutCtx, doc)
+		if errors.Is(err, store.ErrPolicyVersionConflict) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		trail.RecordChange(ctx, "policy.publish", "policy", published.ID.String(), nil, nil,
+			map[string]any{"kind": published.Kind, "version": published.Version, "required": published.Required})
+		ctx.JSON(http.StatusCreated, policyResponse(published))
+	})
+}
+
+// registerConsentRoutes exposes members' consent decisions: their own under /me, any member's to
+// privacy admins, and a yes/no check for the notification-service.
+func registerConsentRoutes(group *gin.RouterGroup, repo *store.Store, serviceAuth *svcauth.Keys, trail *audit.Client) {
+	group.GET("/me/privacy/consents", func(ctx *gin.Context) {
+		if userID, ok := currentUserID(ctx); ok {
+			listConsents(ctx, repo, userID)
+		}
+	})
+
+	group.PUT("/me/privacy/consents", func(ctx *gin.Context) {
+		if userID, ok := currentUserID(ctx); ok {
+			recordConsents(ctx, repo, userID, nil)
+		}
+	})
+
+	group.GET("/me/privacy/consents/history", func(ctx *gin.Context) {
+		if userID, ok := currentUserID(ctx); ok {
+			consentHistory(ctx, repo, userID)
+		}
+	})
+
+	// The apps show a banner for whatever is outstanding: required policies not yet accepted in
+	// their latest version, and opt-ins the member has not decided on.
+	group.GET("/me/privacy/consents/outstanding", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		current, latest, err := loadConsents(timeoutCtx, repo, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := []gin.H{}
+		for _, p := range store.ConsentPurposes {
+			doc := latest[p.Policy]
+			isPolicy := p.Policy == p.Purpose
+			if isPolicy && doc == nil {
+				continue
+			}
+			reason := ""
+			switch decision := current[p.Purpose]; {
+			case decision == nil:
+				reason = "undecided"
+			case decision.Granted && doc != nil && decision.PolicyVersion < doc.Version:
+				reason = "policy_updated"
+			case !decision.Granted && isPolicy && doc.Required:
+				reason = "withdrawn"
+			}
+			if reason == "" {
+				continue
+			}
+			item := gin.H{
+				"purpose":  p.Purpose,
+				"required": isPolicy && doc.Required,
+				"reason":   reason,
+				"policy":   nil,
+			}
+			if doc != nil {
+				item["policy"] = policyResponse(doc)
+			}
+			out = append(out, item)
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.GET("/:id/privacy/consents", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		if userID, ok := userIDParam(ctx); ok {
+			listConsents(ctx, repo, userID)
+		}
+	})
+
+	group.PUT("/:id/privacy/consents", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		userID, ok := userIDParam(ctx)
+		if !ok {
+			return
+		}
+		var recordedBy *uuid.UUID
+		if admin, err := uuid.Parse(ctx.GetHeader(authz.HeaderUserID)); err == nil {
+			recordedBy = &admin
+		}
+		recordConsents(ctx, repo, userID, recordedBy)
+		if ctx.Writer.Status() < http.StatusMultipleChoices {
+			trail.RecordChange(ctx, "user.consents.record", "user", userID.String(), nil, nil, nil)
+		}
+	})
+
+	group.GET("/:id/privacy/consents/history", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		if userID, ok := userIDParam(ctx); ok {
+			consentHistory(ctx, repo, userID)
+		}
+	})
+
+	group.GET("/:id/privacy/consents/:purpose", serviceAuth.RequireCaller("notification-service"), func(ctx *gin.Context) {
+		userID, ok := userIDParam(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		granted, err := repo.HasConsent(timeoutCtx, userID, ctx.Param("purpose"))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"purpose": ctx.Param("purpose"), "granted": granted})
+	})
+}
+
+func userIDParam(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// loadConsents returns the user's current decision per purpose and the latest policy per kind.
+func loadConsents(ctx context.Context, repo *store.Store, userID uuid.UUID) (map[string]*store.ConsentEvent, map[string]*store.PolicyDocument, error) {
+	decisions, err := repo.CurrentConsents(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	docs, err := repo.LatestPolicies(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	current := make(map[string]*store.ConsentEvent, len(decisions))
+	for _, d := range decisions {
+		current[d.Purpose] = d
+	}
+	latest := make(map[string]*store.PolicyDocument, len(docs))
+	for _, d := range docs {
+		latest[d.Kind] = d
+	}
+	return current, latest, nil
+}
+
+// listConsents renders the user's current decision for every purpose; undecided ones are not
+// granted and have no recordedAt.
+func listConsents(ctx *gin.Context, repo *store.Store, userID uuid.UUID) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	current, _, err := loadConsents(timeoutCtx, repo, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(store.ConsentPurposes))
+	for _, p := range store.ConsentPurposes {
+		if decision := current[p.Purpose]; decision != nil {
+			out = append(out, consentEventResponse(decision, false))
+			continue
+		}
+		out = append(out, gin.H{"purpose": p.Purpose, "granted": false, "policyVersion": nil, "source": "", "recordedAt": nil})
+	}
+	ctx.JSON(http.StatusOK, out)
+}
+
+// recordConsents appends the decisions in the request, skipping those that change nothing.
+// Grants are recorded against the latest policy of the purpose's kind; a stale version is refused
+// so a member never accepts text they were not shown.
+func recordConsents(ctx *gin.Context, repo *store.Store, userID uuid.UUID, recordedBy *uuid.UUID) {
+	var req consentsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	policies := make(map[string]string, len(store.ConsentPurposes))
+	for _, p := range store.ConsentPurposes {
+		policies[p.Purpose] = p.Policy
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	if _, err := repo.GetUserByID(timeoutCtx, userID); err != nil {
+		handleStoreError(ctx, err)
+		return
+	}
+	current, latest, err := loadConsents(timeoutCtx, repo, userID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	seen := map[string]bool{}
+	var events []store.ConsentEvent
+	for _, c := range req.Consents {
+		kind, ok := policies[c.Purpose]
+		if !ok {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "unknown consent purpose " + strconv.Quote(c.Purpose)})
+			return
+		}
+		if seen[c.Purpose] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "purpose " + c.Purpose + " appears more than once"})
+			return
+		}
+		seen[c.Purpose] = true
+
+		event := store.ConsentEvent{
+			UserID:     userID,
+			Purpose:    c.Purpose,
+			Granted:    *c.Granted,
+			Source:     strings.ToLower(strings.TrimSpace(req.Source)),
+			IP:         ctx.ClientIP(),
+			UserAgent:  ctx.Request.UserAgent(),
+			RecordedBy: recordedBy,
+		}
+		doc := latest[kind]
+		if event.Granted {
+			switch {
+			case doc == nil && kind == c.Purpose:
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "no " + kind + " policy has been published"})
+				return
+			case doc != nil && c.Version != 0 && c.Version != doc.Version:
+				ctx.JSON(http.StatusConflict, gin.H{"error": "the " + kind + " policy has changed; show version " + strconv.Itoa(doc.Version)})
+				return
+			case doc != nil:
+				event.PolicyID = &doc.ID
+				event.PolicyVersion = doc.Version
+			}
+		}
+		if prev := current[c.Purpose]; prev != nil && prev.Granted == event.Granted && prev.PolicyVersion == event.PolicyVersion {
+			continue
+		}
+		events = append(events, event)
+	}
+	if len(events) > 0 {
+		if err := repo.RecordConsents(timeoutCtx, events); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	listConsents(ctx, repo, userID)
+}
+
+func consentHistory(ctx *gin.Context, repo *store.Store, userID uuid.UUID) {
+	limit, offset, ok := paginationParams(ctx)
+	if !ok {
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	events, total, err := repo.ConsentHistory(timeoutCtx, userID, limit, offset)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(events))
+	for _, event := range events {
+		out = append(out, consentEventResponse(event, true))
+	}
+	ctx.Header("X-Total-Count", strconv.Itoa(total))
+	ctx.JSON(http.StatusOK, out)
+}
+
+// consentEventResponse renders a decision; detailed adds where it was recorded from, for the
+// history.
+func consentEventResponse(event *store.ConsentEvent, detailed bool) gin.H {
+	var version any
+	if event.PolicyVersion > 0 {
+		version = event.PolicyVersion
+	}
+	resp := gin.H{
+		"purpose":       event.Purpose,
+		"granted":       event.Granted,
+		"policyVersion": version,
+		"source":        event.Source,
+		"recordedAt":    event.RecordedAt.Format(time.RFC3339),
+	}
+	if detailed {
+		var recordedBy any
+		if event.RecordedBy != nil {
+			recordedBy = event.RecordedBy.String()
+		}
+		resp["id"] = event.ID.String()
+		resp["ip"] = event.IP
+		resp["userAgent"] = event.UserAgent
+		resp["recordedBy"] = recordedBy
+	}
+	return resp
+}
+
+func policyResponse(doc *store.PolicyDocument) gin.H {
+	return gin.H{
+		"kind":        doc.Kind,
+		"version":     doc.Version,
+		"title":       doc.Title,
+		"url":         doc.URL,
+		"summary":     doc.Summary,
+		"body":        doc.Body,
+		"required":    doc.Required,
+		"publishedAt": doc.PublishedAt.Format(time.RFC3339),
+	}
+}
+
+func policiesResponse(docs []*store.PolicyDocument) []gin.H {
+	out := make([]gin.H, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, policyResponse(doc))
+	}
+	return out
+}
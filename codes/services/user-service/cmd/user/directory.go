@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+// registerDirectoryRoutes exposes the admin user directory: searching and paging accounts, and
+// disabling or re-enabling them.
+func registerDirectoryRoutes(group *gin.RouterGroup, repo *store.Store, revoked *revocation.Store, pics *avatars, trail *audit.Client) {
+	group.GET("", authz.RequirePermission(authz.UserRead), func(ctx *gin.Context) {
+		limit, offset, ok := paginationParams(ctx)
+		if !ok {
+			return
+		}
+		status := ctx.Query("status")
+		if status != "" && status != store.StatusActive && status != store.StatusDisabled {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or disabled"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		users, total, err := repo.ListUsers(timeoutCtx, store.UserQuery{
+			Search: ctx.Query("q"),
+			Role:   ctx.Query("role"),
+			Status: status,
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(users))
+		for _, user := range users {
+			out = append(out, profileResponse(timeoutCtx, pics, user))
+		}
+		ctx.Header("X-Total-Count", strconv.Itoa(total))
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.POST("/:id/disable", authz.RequirePermission(authz.UserDisable), func(ctx *gin.Context) {
+		setDisabled(ctx, repo, revoked, pics, trail, true)
+	})
+
+	group.POST("/:id/enable", authz.RequirePermission(authz.UserDisable), func(ctx *gin.Context) {
+		setDisabled(ctx, repo, revoked, pics, trail, false)
+	})
+}
+
+// setDisabled disables or re-enables the :id account. Disabling also revokes the user's access
+// tokens; the auth-service refuses to sign in or refresh a disabled account.
+func setDisabled(ctx *gin.Context, repo *store.Store, revoked *revocation.Store, pics *avatars, trail *audit.Client, disabled bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	if disabled && ctx.GetHeader(authz.HeaderUserID) == id.String() {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "you cannot disable your own account"})
+		return
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	user, err := repo.SetDisabled(timeoutCtx, id, disabled)
+	if err != nil {
+		handleStoreError(ctx, err)
+		return
+	}
+	action := "user.enable"
+	if disabled {
+		action = "user.disable"
+	}
+	trail.RecordChange(ctx, action, "user", user.ID.String(), nil, nil, nil)
+	if disabled {
+		if err := revoked.RevokeUser(timeoutCtx, user.ID.String()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "account disabled but existing tokens could not be revoked: " + err.Error()})
+			return
+		}
+	}
+	ctx.JSON(http.StatusOK, profileResponse(timeoutCtx, pics, user))
+}
+
+// paginationParams reads limit (default 20, at most 100) and offset from the query string.
+func paginationParams(ctx *gin.Context) (int, int, bool) {
+	limit := 20
+	offset := 0
+	if raw := ctx.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return 0, 0, false
+		}
+		limit = parsed
+	}
+	if limit > 100 {
+		limit = 100
+	}
+	if raw := ctx.Query("offset"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+			return 0, 0, false
+		}
+		offset = parsed
+	}
+	return limit, offset, true
+}
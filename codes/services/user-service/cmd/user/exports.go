@@ -0,0 +1,367 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/s3util"
+	"github.com/venue-master/platform/services/user-service/internal/dataexport"
+	"github.com/venue-master/platform/services/user-service/internal/notification"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+const (
+	exportPollInterval = 15 * time.Second
+	// exportMaxAttempts bounds retries when a source service is down; later attempts wait
+	// exportRetryDelay times the attempt number.
+	exportMaxAttempts  = 3
+	exportRetryDelay   = 5 * time.Minute
+	exportBuildTimeout = 5 * time.Minute
+	// maxExportLinkTTL is the longest an S3 presigned URL can be valid.
+	maxExportLinkTTL = 7 * 24 * time.Hour
+)
+
+// dataExports fulfils right-of-access requests. Requests are queued in data_exports; run builds
+// each into a zip of the member's records here and at every source service, stores it and
+// emails the member a download link valid for linkTTL.
+type dataExports struct {
+	repo    *store.Store
+	storage s3util.StorageProvider
+	sources []*dataexport.Source
+	notify  *notification.Client
+	linkTTL time.Duration
+	logger  zerolog.Logger
+}
+
+// registerExportRoutes exposes self-service export requests under /me and the admin
+// compliance log.
+func registerExportRoutes(group *gin.RouterGroup, repo *store.Store, exports *dataExports, trail *audit.Client) {
+	group.POST("/me/exports", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		queueExport(ctx, repo, exports, userID, nil)
+	})
+
+	group.GET("/me/exports", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		listExports(ctx, repo, exports, store.DataExportQuery{UserID: userID}, false)
+	})
+
+	group.GET("/exports", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		var query store.DataExportQuery
+		if raw := ctx.Query("userId"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid userId"})
+				return
+			}
+			query.UserID = id
+		}
+		query.Status = ctx.Query("status")
+		var ok bool
+		if query.From, ok = timeParam(ctx, "from"); !ok {
+			return
+		}
+		if query.To, ok = timeParam(ctx, "to"); !ok {
+			return
+		}
+		listExports(ctx, repo, exports, query, true)
+	})
+
+	group.POST("/:id/exports", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		user, err := fetchUser(ctx, repo, ctx.Param("id"))
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		var requestedBy *uuid.UUID
+		if admin, err := uuid.Parse(ctx.GetHeader(authz.HeaderUserID)); err == nil {
+			requestedBy = &admin
+		}
+		queueExport(ctx, repo, exports, user.ID, requestedBy)
+		if ctx.Writer.Status() < http.StatusMultipleChoices {
+			trail.RecordChange(ctx, "user.export.request", "user", user.ID.String(), nil, nil, nil)
+		}
+	})
+}
+
+// timeParam reads an optional RFC 3339 query parameter.
+func timeParam(ctx *gin.Context, name string) (time.Time, bool) {
+	raw := ctx.Query(name)
+	if raw == "" {
+		return time.Time{}, true
+	}
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": name + " must be an RFC 3339 timestamp"})
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+func queueExport(ctx *gin.Context, repo *store.Store, exports *dataExports, userID uuid.UUID, requestedBy *uuid.UUID) {
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	export, err := repo.CreateDataExport(timeoutCtx, userID, requestedBy)
+	if errors.Is(err, store.ErrExportInProgress) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, exports.response(timeoutCtx, export, requestedBy != nil))
+}
+
+func listExports(ctx *gin.Context, repo *store.Store, exports *dataExports, query store.DataExportQuery, detailed bool) {
+	limit, offset, ok := paginationParams(ctx)
+	if !ok {
+		return
+	}
+	query.Limit, query.Offset = limit, offset
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	items, total, err := repo.ListDataExports(timeoutCtx, query)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	out := make([]gin.H, 0, len(items))
+	for _, export := range items {
+		out = append(out, exports.response(timeoutCtx, export, detailed))
+	}
+	ctx.Header("X-Total-Count", fmt.Sprint(total))
+	ctx.JSON(http.StatusOK, out)
+}
+
+// response renders an export. For the member, ready exports carry a fresh download URL valid
+// until they expire. detailed renders it for the compliance log instead: the requesting admin
+// and the last failure are added, and no download URL is issued, since the archive is the
+// member's alone.
+func (d *dataExports) response(ctx context.Context, export *store.DataExport, detailed bool) gin.H {
+	resp := gin.H{
+		"id":          export.ID.String(),
+		"userId":      export.UserID.String(),
+		"status":      export.Status,
+		"sizeBytes":   export.SizeBytes,
+		"requestedAt": export.RequestedAt.Format(time.RFC3339),
+		"completedAt": formatOptionalTime(export.CompletedAt),
+		"expiresAt":   formatOptionalTime(export.ExpiresAt),
+	}
+	if !detailed && export.Status == store.ExportReady && export.ExpiresAt != nil {
+		if remaining := time.Until(*export.ExpiresAt); remaining > 0 {
+			if url, err := d.storage.PresignGet(ctx, export.ObjectKey, remaining); err == nil {
+				resp["downloadUrl"] = url
+			}
+		}
+	}
+	if detailed {
+		var requestedBy any
+		if export.RequestedBy != nil {
+			requestedBy = export.RequestedBy.String()
+		}
+		resp["requestedBy"] = requestedBy
+		resp["attempts"] = export.Attempts
+		resp["startedAt"] = formatOptionalTime(export.StartedAt)
+		resp["error"] = export.LastError
+	}
+	return resp
+}
+
+// run processes queued exports and deletes expired archives until ctx is done.
+func (d *dataExports) run(ctx context.Context) {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for d.processNext(ctx) {
+			}
+			d.expire(ctx)
+		}
+	}
+}
+
+// processNext builds one due export and reports whether there was one.
+func (d *dataExports) processNext(ctx context.Context) bool {
+	export, err := d.repo.ClaimDataExport(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		d.logger.Error().Err(err).Msg("claim data export failed")
+		return false
+	}
+	if err := d.build(ctx, export); err != nil {
+		var retryAt *time.Time
+		if export.Attempts < exportMaxAttempts {
+			next := time.Now().Add(time.Duration(export.Attempts) * exportRetryDelay)
+			retryAt = &next
+		}
+		d.logger.Error().Err(err).Str("exportId", export.ID.String()).Int("attempt", export.Attempts).
+			Bool("willRetry", retryAt != nil).Msg("data export failed")
+		if _, err := d.repo.FailDataExport(ctx, export.ID, err.Error(), retryAt); err != nil {
+			d.logger.Error().Err(err).Str("exportId", export.ID.String()).Msg("record data export failure failed")
+		}
+	}
+	return true
+}
+
+// build gathers the member's records, stores the archive and emails the link.
+func (d *dataExports) build(ctx context.Context, export *store.DataExport) error {
+	buildCtx, cancel := context.WithTimeout(ctx, exportBuildTimeout)
+	defer cancel()
+
+	user, err := d.repo.GetUserByID(buildCtx, export.UserID)
+	if err != nil {
+		return fmt.Errorf("load user: %w", err)
+	}
+	sections, err := d.localSections(buildCtx, user)
+	if err != nil {
+		return err
+	}
+	for _, source := range d.sources {
+		fetched, err := source.Fetch(buildCtx, user.ID.String())
+		if err != nil {
+			return err
+		}
+		sections = append(sections, fetched...)
+	}
+
+	var archive bytes.Buffer
+	if err := dataexport.WriteArchive(&archive, user.ID.String(), time.Now(), sections); err != nil {
+		return err
+	}
+	size := int64(archive.Len())
+	key := path.Join("exports", user.ID.String(), export.ID.String()+".zip")
+	if _, err := d.storage.Upload(buildCtx, key, &archive, "application/zip"); err != nil {
+		return fmt.Errorf("store archive: %w", err)
+	}
+	expiresAt := time.Now().Add(d.linkTTL)
+	url, err := d.storage.PresignGet(buildCtx, key, d.linkTTL)
+	if err != nil {
+		return fmt.Errorf("presign archive: %w", err)
+	}
+	if _, err := d.repo.CompleteDataExport(buildCtx, export.ID, key, size, expiresAt); err != nil {
+		return err
+	}
+
+	// The export stays downloadable from GET /v1/users/me/exports if the email is lost.
+	payload := notification.NotifyPayload{
+		UserID:  user.ID.String(),
+		Title:   "Your data export is ready",
+		Message: fmt.Sprintf("Download a copy of the personal information we hold about you from %s. The link expires on %s.", url, expiresAt.UTC().Format(time.RFC1123)),
+		Channel: "email",
+	}
+	if err := d.notify.Send(buildCtx, payload); err != nil {
+		d.logger.Error().Err(err).Str("exportId", export.ID.String()).Msg("failed to send data export email")
+	}
+	return nil
+}
+
+// localSections renders what user-service itself holds about user.
+func (d *dataExports) localSections(ctx context.Context, user *store.User) ([]dataexport.Section, error) {
+	identities, err := d.repo.ListIdentities(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load identities: %w", err)
+	}
+	appConsents, err := d.repo.ListOAuthConsents(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("load app consents: %w", err)
+	}
+
+	household, err := d.repo.GetHouseholdByUser(ctx, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("load household: %w", err)
+	}
+
+	var consents []*store.ConsentEvent
+	for offset := 0; ; offset += 100 {
+		page, _, err := d.repo.ConsentHistory(ctx, user.ID, 100, offset)
+		if err != nil {
+			return nil, fmt.Errorf("load consents: %w", err)
+		}
+		consents = append(consents, page...)
+		if len(page) < 100 {
+			break
+		}
+	}
+
+	profile := userResponse(user)
+	profile["avatarKey"] = user.AvatarKey
+	linked := make([]dataexport.Record, 0, len(identities))
+	for _, identity := range identities {
+		linked = append(linked, dataexport.Record{
+			"provider":  identity.Provider,
+			"subject":   identity.Subject,
+			"email":     identity.Email,
+			"createdAt": identity.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	apps := make([]dataexport.Record, 0, len(appConsents))
+	for _, consent := range appConsents {
+		apps = append(apps, dataexport.Record{
+			"clientId":   consent.ClientID,
+			"clientName": consent.ClientName,
+			"scopes":     consent.Scopes,
+			"grantedAt":  consent.CreatedAt.Format(time.RFC3339),
+			"updatedAt":  consent.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	decisions := make([]dataexport.Record, 0, len(consents))
+	for _, consent := range consents {
+		decisions = append(decisions, consentEventResponse(consent, true))
+	}
+	memberships := []dataexport.Record{}
+	for _, membership := range membershipsResponse(user, household) {
+		memberships = append(memberships, membership)
+	}
+	households := []dataexport.Record{}
+	if household != nil {
+		households = append(households, householdResponse(household))
+	}
+	return []dataexport.Section{
+		{Name: "profile", Source: "user-service", Records: []dataexport.Record{profile}},
+		{Name: "memberships", Source: "user-service", Records: memberships},
+		{Name: "household", Source: "user-service", Records: households},
+		{Name: "linked_accounts", Source: "user-service", Records: linked},
+		{Name: "connected_apps", Source: "user-service", Records: apps},
+		{Name: "consents", Source: "user-service", Records: decisions},
+	}, nil
+}
+
+// expire marks lapsed exports expired and deletes their archives.
+func (d *dataExports) expire(ctx context.Context) {
+	expired, err := d.repo.ExpireDataExports(ctx)
+	if err != nil {
+		d.logger.Error().Err(err).Msg("expire data exports failed")
+		return
+	}
+	for _, export := range expired {
+		if err := d.storage.Delete(ctx, export.ObjectKey); err != nil && !errors.Is(err, s3util.ErrNotFound) {
+			d.logger.Error().Err(err).Str("exportId", export.ID.String()).Msg("delete expired data export failed")
+		}
+	}
+}
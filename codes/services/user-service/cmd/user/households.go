@@ -0,0 +1,414 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/user-service/internal/notification"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type householdRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type householdUpdateRequest struct {
+	Name            *string `json:"name" binding:"omitempty,max=100"`
+	PaymentMethodID *string `json:"paymentMethodId" binding:"omitempty,max=255"`
+	MembershipType  *string `json:"membershipType" binding:"omitempty,max=64"`
+}
+
+type householdInviteRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type dependentRequest struct {
+	FirstName string `json:"firstName" binding:"required,max=100"`
+	LastName  string `json:"lastName" binding:"required,max=100"`
+}
+
+// registerHouseholdRoutes exposes households: a primary account holder, adults they invite and
+// dependents they manage, sharing a payment method and a membership. Members manage theirs under
+// /me; booking-service reads any member's household to allow booking on each other's behalf.
+func registerHouseholdRoutes(group *gin.RouterGroup, repo *store.Store, notify *notification.Client, trail *audit.Client) {
+	group.GET("/me/household", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.GetHouseholdByUser(timeoutCtx, userID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, householdResponse(household))
+	})
+
+	group.POST("/me/household", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		var req householdRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		name := strings.TrimSpace(req.Name)
+		if name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.CreateHousehold(timeoutCtx, userID, name)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, householdResponse(household))
+	})
+
+	group.PATCH("/me/household", func(ctx *gin.Context) {
+		var req householdUpdateRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Name, req.PaymentMethodID, req.MembershipType = trimmed(req.Name), trimmed(req.PaymentMethodID), trimmed(req.MembershipType)
+		if req.Name != nil && *req.Name == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		if req.MembershipType != nil {
+			upper := strings.ToUpper(*req.MembershipType)
+			req.MembershipType = &upper
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		before, ok := primaryHousehold(ctx, timeoutCtx, repo)
+		if !ok {
+			return
+		}
+		household, err := repo.UpdateHousehold(timeoutCtx, before.ID, store.HouseholdUpdate{
+			Name:            req.Name,
+			PaymentMethodID: req.PaymentMethodID,
+			MembershipType:  req.MembershipType,
+		})
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "household.update", "household", household.ID.String(),
+			householdSettings(before), householdSettings(household), nil)
+		ctx.JSON(http.StatusOK, householdResponse(household))
+	})
+
+	// Adults join by invitation, since members may book and cancel for each other.
+	group.POST("/me/household/members", func(ctx *gin.Context) {
+		var req householdInviteRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, ok := primaryHousehold(ctx, timeoutCtx, repo)
+		if !ok {
+			return
+		}
+		invitee, err := repo.GetUserByEmail(timeoutCtx, strings.TrimSpace(req.Email))
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && (invitee.Dependent || invitee.ErasedAt != nil)) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "no account with that email; ask them to sign up first"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if err := repo.InviteHouseholdMember(timeoutCtx, household.ID, invitee.ID, household.PrimaryUserID); err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		payload := notification.NotifyPayload{
+			UserID:  invitee.ID.String(),
+			Title:   "You're invited to join a household",
+			Message: fmt.Sprintf("You've been invited to join the %q household on Venue Master. Household members share a membership and payment method and can book for each other. Accept or decline the invitation in the app.", household.Name),
+			Channel: "email",
+		}
+		if err := notify.Send(timeoutCtx, payload); err != nil {
+			ctx.Error(err)
+		}
+		household, err = repo.GetHousehold(timeoutCtx, household.ID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "household.member.invite", "household", household.ID.String(), nil, nil,
+			map[string]any{"userId": invitee.ID.String()})
+		ctx.JSON(http.StatusCreated, householdResponse(household))
+	})
+
+	group.POST("/me/household/dependents", func(ctx *gin.Context) {
+		var req dependentRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		firstName, lastName := strings.TrimSpace(req.FirstName), strings.TrimSpace(req.LastName)
+		if firstName == "" || lastName == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "firstName and lastName cannot be empty"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, ok := primaryHousehold(ctx, timeoutCtx, repo)
+		if !ok {
+			return
+		}
+		dependent, err := repo.AddDependent(timeoutCtx, household.ID, household.PrimaryUserID, firstName, lastName)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "household.dependent.add", "household", household.ID.String(), nil, nil,
+			map[string]any{"userId": dependent.ID.String()})
+		ctx.JSON(http.StatusCreated, userResponse(dependent))
+	})
+
+	// The primary removes members, dependents and invitations; any other member may remove
+	// themselves.
+	group.DELETE("/me/household/members/:userId", func(ctx *gin.Context) {
+		callerID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		memberID, err := uuid.Parse(ctx.Param("userId"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.GetHouseholdByUser(timeoutCtx, callerID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		if memberID != callerID && household.PrimaryUserID != callerID {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "only the primary account holder can remove other members"})
+			return
+		}
+		role, err := repo.RemoveHouseholdMember(timeoutCtx, household.ID, memberID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "household.member.remove", "household", household.ID.String(), nil, nil,
+			map[string]any{"userId": memberID.String(), "role": role})
+		ctx.Status(http.StatusNoContent)
+	})
+
+	group.GET("/me/household/invitations", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		households, err := repo.ListHouseholdInvitations(timeoutCtx, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(households))
+		for _, household := range households {
+			out = append(out, gin.H{
+				"householdId": household.ID.String(),
+				"name":        household.Name,
+				"primary":     memberResponse(household.Member(household.PrimaryUserID)),
+			})
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.POST("/me/household/invitations/:householdId/accept", func(ctx *gin.Context) {
+		userID, householdID, ok := invitationParams(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.AcceptHouseholdInvitation(timeoutCtx, householdID, userID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "household.member.join", "household", household.ID.String(), nil, nil,
+			map[string]any{"userId": userID.String()})
+		ctx.JSON(http.StatusOK, householdResponse(household))
+	})
+
+	group.DELETE("/me/household/invitations/:householdId", func(ctx *gin.Context) {
+		userID, householdID, ok := invitationParams(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.GetHousehold(timeoutCtx, householdID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		if member := household.Member(userID); member != nil {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "already a member; leave the household instead"})
+			return
+		}
+		if _, err := repo.RemoveHouseholdMember(timeoutCtx, householdID, userID); err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+
+	// Readable by the member, their household, directory admins and booking-service, which checks
+	// who may book for whom.
+	group.GET("/:id/household", func(ctx *gin.Context) {
+		userID, ok := userIDParam(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.GetHouseholdByUser(timeoutCtx, userID)
+		if err != nil {
+			handleHouseholdError(ctx, err)
+			return
+		}
+		if !canReadHousehold(ctx, household) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+			return
+		}
+		ctx.JSON(http.StatusOK, householdResponse(household))
+	})
+}
+
+func canReadHousehold(ctx *gin.Context, household *store.Household) bool {
+	if caller, ok := svcauth.CallerFromContext(ctx.Request.Context()); ok && caller == "booking-service" {
+		return true
+	}
+	principal, ok := authz.FromHeaders(ctx.Request.Header)
+	if !ok {
+		return false
+	}
+	if principal.Can(authz.UserRead) {
+		return true
+	}
+	callerID, err := uuid.Parse(principal.UserID)
+	return err == nil && household.Member(callerID) != nil
+}
+
+// primaryHousehold loads the caller's household, answering 403 unless they are its primary
+// account holder.
+func primaryHousehold(ctx *gin.Context, reqCtx context.Context, repo *store.Store) (*store.Household, bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return nil, false
+	}
+	household, err := repo.GetHouseholdByUser(reqCtx, userID)
+	if err != nil {
+		handleHouseholdError(ctx, err)
+		return nil, false
+	}
+	if household.PrimaryUserID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "only the primary account holder can manage the household"})
+		return nil, false
+	}
+	return household, true
+}
+
+func invitationParams(ctx *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := currentUserID(ctx)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	householdID, err := uuid.Parse(ctx.Param("householdId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid household id"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, householdID, true
+}
+
+func handleHouseholdError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "household or member not found"})
+	case errors.Is(err, store.ErrInHousehold), errors.Is(err, store.ErrAlreadyInvited), errors.Is(err, store.ErrPrimaryMember):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func householdResponse(household *store.Household) gin.H {
+	members := make([]gin.H, 0, len(household.Members))
+	invited := []gin.H{}
+	for i := range household.Members {
+		if household.Members[i].Status == store.MemberActive {
+			members = append(members, memberResponse(&household.Members[i]))
+		} else {
+			invited = append(invited, memberResponse(&household.Members[i]))
+		}
+	}
+	return gin.H{
+		"id":              household.ID.String(),
+		"name":            household.Name,
+		"primaryUserId":   household.PrimaryUserID.String(),
+		"paymentMethodId": household.PaymentMethodID,
+		"membershipType":  household.MembershipType,
+		"members":         members,
+		"invited":         invited,
+		"createdAt":       household.CreatedAt.Format(time.RFC3339),
+		"updatedAt":       household.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func memberResponse(member *store.HouseholdMember) gin.H {
+	if member == nil {
+		return nil
+	}
+	return gin.H{
+		"userId":    member.UserID.String(),
+		"firstName": member.FirstName,
+		"lastName":  member.LastName,
+		"email":     member.Email,
+		"role":      member.Role,
+		"status":    member.Status,
+		"addedAt":   member.AddedAt.Format(time.RFC3339),
+		"joinedAt":  formatOptionalTime(member.JoinedAt),
+	}
+}
+
+// householdSettings is the part of a household the primary edits, for audit diffs.
+func householdSettings(household *store.Household) gin.H {
+	return gin.H{
+		"name":            household.Name,
+		"paymentMethodId": household.PaymentMethodID,
+		"membershipType":  household.MembershipType,
+	}
+}
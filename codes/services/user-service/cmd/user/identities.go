@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type resolveIdentityRequest struct {
+	Provider      string `json:"provider" binding:"required"`
+	Subject       string `json:"subject" binding:"required"`
+	Email         string `json:"email" binding:"required,email"`
+	EmailVerified bool   `json:"emailVerified"`
+	FirstName     string `json:"firstName"`
+	LastName      string `json:"lastName"`
+}
+
+// registerIdentityRoutes exposes the endpoint the auth-service resolves OpenID Connect sign-ins
+// with. Like the token routes it is not routed through the gateway.
+func registerIdentityRoutes(group *gin.RouterGroup, repo *store.Store) {
+	group.POST("/identities/resolve", func(ctx *gin.Context) {
+		var req resolveIdentityRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		// Only provider-verified addresses may be matched against local accounts.
+		if !req.EmailVerified {
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": "email not verified by provider"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, outcome, err := repo.ResolveIdentity(timeoutCtx, store.ExternalIdentity{
+			Provider:  req.Provider,
+			Subject:   req.Subject,
+			Email:     req.Email,
+			FirstName: req.FirstName,
+			LastName:  req.LastName,
+		})
+		if errors.Is(err, store.ErrIdentityConflict) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		status := http.StatusOK
+		if outcome == store.IdentityCreated {
+			status = http.StatusCreated
+		}
+		ctx.JSON(status, gin.H{"user": userResponse(user), "outcome": outcome})
+	})
+}
@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/services/user-service/internal/notification"
+	"github.com/venue-master/platform/services/user-service/internal/spreadsheet"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+const (
+	importPollInterval = 15 * time.Second
+	importBatchSize    = 50
+	importMaxBytes     = 10 << 20
+	importMaxRows      = 10000
+	// importRowRetention is how long the names and emails of an import's rows are kept for its
+	// error report once it completes.
+	importRowRetention = 30 * 24 * time.Hour
+)
+
+// membershipPattern limits membership types to the upper-case codes used elsewhere, e.g.
+// MONTHLY_PREMIUM.
+var membershipPattern = regexp.MustCompile(`^[A-Z0-9_]{1,64}$`)
+
+// importHeaders maps normalised column headings to the fields they fill. Other columns are
+// ignored, so a directory export can be imported again as it is.
+var importHeaders = map[string]string{
+	"email":          "email",
+	"emailaddress":   "email",
+	"firstname":      "firstName",
+	"givenname":      "firstName",
+	"lastname":       "lastName",
+	"surname":        "lastName",
+	"familyname":     "lastName",
+	"phone":          "phone",
+	"phonenumber":    "phone",
+	"mobile":         "phone",
+	"membership":     "membership",
+	"membershiptype": "membership",
+}
+
+// exportHeaders are the directory export's columns; the first five are the ones imports read.
+var exportHeaders = []string{"Email", "First Name", "Last Name", "Phone", "Membership", "Roles", "Status", "Email Verified", "Created At", "Last Active At"}
+
+// userImports creates accounts from member spreadsheets, e.g. when a venue moves its members
+// over. Uploads are validated and queued in user_imports; run creates the accounts row by row
+// and emails each new member a link to choose their password.
+type userImports struct {
+	repo   *store.Store
+	notify *notification.Client
+	webURL string
+	logger zerolog.Logger
+}
+
+// registerImportRoutes exposes bulk imports, their progress and error reports, and the matching
+// directory export.
+func registerImportRoutes(group *gin.RouterGroup, repo *store.Store, imports *userImports, trail *audit.Client) {
+	group.POST("/imports", authz.RequirePermission(authz.UserImport), func(ctx *gin.Context) {
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, importMaxBytes+1<<20)
+		file, err := ctx.FormFile("file")
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "upload the spreadsheet as the multipart field file"})
+			return
+		}
+		if file.Size > importMaxBytes {
+			ctx.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("spreadsheets are limited to %d MB", importMaxBytes>>20)})
+			return
+		}
+		format, err := spreadsheet.FormatOf(file.Filename)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		membership := strings.ToUpper(strings.TrimSpace(ctx.PostForm("membershipType")))
+		if membership != "" && !membershipPattern.MatchString(membership) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "membershipType must be letters, digits and underscores"})
+			return
+		}
+		sendInvitations := true
+		if raw := ctx.PostForm("sendInvitations"); raw != "" {
+			if sendInvitations, err = strconv.ParseBool(raw); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "sendInvitations must be true or false"})
+				return
+			}
+		}
+
+		f, err := file.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		table, err := spreadsheet.Read(data, format)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "could not read the spreadsheet: " + err.Error()})
+			return
+		}
+		rows, err := parseImportRows(table, membership)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 30*time.Second)
+		defer cancel()
+		var requestedBy *uuid.UUID
+		if admin, err := uuid.Parse(ctx.GetHeader(authz.HeaderUserID)); err == nil {
+			requestedBy = &admin
+		}
+		imp, err := repo.CreateUserImport(timeoutCtx, &store.UserImport{
+			RequestedBy:     requestedBy,
+			Filename:        file.Filename,
+			MembershipType:  membership,
+			SendInvitations: sendInvitations,
+		}, rows)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		trail.RecordChange(ctx, "user.import", "user_import", imp.ID.String(), nil, nil, map[string]any{
+			"filename":        imp.Filename,
+			"rows":            imp.TotalRows,
+			"membershipType":  imp.MembershipType,
+			"sendInvitations": imp.SendInvitations,
+		})
+		ctx.JSON(http.StatusAccepted, importResponse(imp))
+	})
+
+	group.GET("/imports", authz.RequirePermission(authz.UserImport), func(ctx *gin.Context) {
+		limit, offset, ok := paginationParams(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		items, total, err := repo.ListUserImports(timeoutCtx, limit, offset)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(items))
+		for _, imp := range items {
+			out = append(out, importResponse(imp))
+		}
+		ctx.Header("X-Total-Count", strconv.Itoa(total))
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.GET("/imports/:id", authz.RequirePermission(authz.UserImport), func(ctx *gin.Context) {
+		imp, ok := fetchImport(ctx, repo)
+		if !ok {
+			return
+		}
+		ctx.JSON(http.StatusOK, importResponse(imp))
+	})
+
+	// The error report lists failed rows and created rows with a problem, such as an invitation
+	// that could not be sent; ?format=csv or xlsx downloads it as a spreadsheet.
+	group.GET("/imports/:id/errors", authz.RequirePermission(authz.UserImport), func(ctx *gin.Context) {
+		format := ctx.Query("format")
+		if format != "" && format != spreadsheet.CSV && format != spreadsheet.XLSX {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": spreadsheet.ErrUnsupportedFormat.Error()})
+			return
+		}
+		imp, ok := fetchImport(ctx, repo)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 10*time.Second)
+		defer cancel()
+		rows, err := repo.ListImportRows(timeoutCtx, imp.ID, "")
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var report []store.ImportRow
+		for _, row := range rows {
+			if row.Error != "" {
+				report = append(report, row)
+			}
+		}
+		if format == "" {
+			out := make([]gin.H, 0, len(report))
+			for _, row := range report {
+				out = append(out, gin.H{
+					"row":       row.RowNumber,
+					"email":     row.Email,
+					"firstName": row.FirstName,
+					"lastName":  row.LastName,
+					"status":    row.Status,
+					"error":     row.Error,
+				})
+			}
+			ctx.JSON(http.StatusOK, out)
+			return
+		}
+		table := [][]string{{"Row", "Email", "First Name", "Last Name", "Status", "Error"}}
+		for _, row := range report {
+			table = append(table, []string{strconv.Itoa(row.RowNumber), row.Email, row.FirstName, row.LastName, row.Status, row.Error})
+		}
+		writeSpreadsheet(ctx, format, "import-"+imp.ID.String()+"-errors", table)
+	})
+
+	group.GET("/export", authz.RequirePermission(authz.UserRead), func(ctx *gin.Context) {
+		format := ctx.DefaultQuery("format", spreadsheet.CSV)
+		if format != spreadsheet.CSV && format != spreadsheet.XLSX {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": spreadsheet.ErrUnsupportedFormat.Error()})
+			return
+		}
+		query := store.UserQuery{Search: ctx.Query("q"), Role: ctx.Query("role"), Status: ctx.Query("status"), Limit: 100}
+		if query.Status != "" && query.Status != store.StatusActive && query.Status != store.StatusDisabled {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or disabled"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Minute)
+		defer cancel()
+
+		table := [][]string{exportHeaders}
+		for {
+			users, _, err := repo.ListUsers(timeoutCtx, query)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
+			}
+			for _, user := range users {
+				// Erased accounts and dependents have placeholder emails and cannot be imported.
+				if user.ErasedAt != nil || user.Dependent {
+					continue
+				}
+				table = append(table, exportRow(user))
+			}
+			if len(users) < query.Limit {
+				break
+			}
+			query.Offset += query.Limit
+		}
+		trail.RecordChange(ctx, "user.directory.export", "user", "", nil, nil, map[string]any{
+			"format": format,
+			"users":  len(table) - 1,
+			"q":      query.Search,
+			"role":   query.Role,
+			"status": query.Status,
+		})
+		writeSpreadsheet(ctx, format, "users-"+time.Now().UTC().Format("2006-01-02"), table)
+	})
+}
+
+func fetchImport(ctx *gin.Context, repo *store.Store) (*store.UserImport, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid import id"})
+		return nil, false
+	}
+	timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+	defer cancel()
+	imp, err := repo.GetUserImport(timeoutCtx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "import not found"})
+		return nil, false
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return imp, true
+}
+
+// parseImportRows reads the member rows of an uploaded table. The first non-blank row is the
+// heading and must have an email column. Rows that fail validation, or repeat an email seen
+// earlier in the file, are returned already failed so they show up in the error report.
+func parseImportRows(table [][]string, defaultMembership string) ([]store.ImportRow, error) {
+	header := -1
+	for i, row := range table {
+		if len(row) > 0 {
+			header = i
+			break
+		}
+	}
+	if header < 0 {
+		return nil, errors.New("the spreadsheet is empty")
+	}
+	columns := map[string]int{}
+	for i, heading := range table[header] {
+		key := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(heading))
+		if field, ok := importHeaders[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, errors.New("the first row must name the columns, including email")
+	}
+
+	var rows []store.ImportRow
+	seen := map[string]int{}
+	for i := header + 1; i < len(table); i++ {
+		cells := table[i]
+		if len(cells) == 0 {
+			continue
+		}
+		cell := func(field string) string {
+			if col, ok := columns[field]; ok && col < len(cells) {
+				return cells[col]
+			}
+			return ""
+		}
+		row := store.ImportRow{
+			RowNumber:      i + 1,
+			Email:          strings.ToLower(cell("email")),
+			FirstName:      cell("firstName"),
+			LastName:       cell("lastName"),
+			Phone:          cell("phone"),
+			MembershipType: strings.ToUpper(cell("membership")),
+		}
+		if row.MembershipType == "" {
+			row.MembershipType = defaultMembership
+		}
+		if problem := validateImportRow(&row); problem != "" {
+			row.Status, row.Error = store.RowFailed, problem
+		} else if first, dup := seen[row.Email]; dup {
+			row.Status, row.Error = store.RowFailed, fmt.Sprintf("duplicate of row %d", first)
+		} else {
+			seen[row.Email] = row.RowNumber
+		}
+		rows = append(rows, row)
+		if len(rows) > importMaxRows {
+			return nil, fmt.Errorf("imports are limited to %d rows; split the spreadsheet", importMaxRows)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("the spreadsheet has no member rows")
+	}
+	return rows, nil
+}
+
+// validateImportRow checks row and normalises its phone number, returning what is wrong with it.
+func validateImportRow(row *store.ImportRow) string {
+	if row.Email == "" {
+		return "email is required"
+	}
+	if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email || addr.Name != "" {
+		return "email is not a valid address"
+	}
+	if len(row.FirstName) > 100 || len(row.LastName) > 100 {
+		return "names are limited to 100 characters"
+	}
+	if row.Phone != "" {
+		number, err := normalizePhone(row.Phone)
+		if err != nil {
+			return "phone: " + err.Error()
+		}
+		row.Phone = number
+	}
+	if row.MembershipType != "" && !membershipPattern.MatchString(row.MembershipType) {
+		return "membership must be letters, digits and underscores"
+	}
+	return ""
+}
+
+func exportRow(user *store.User) []string {
+	status := store.StatusActive
+	if user.DisabledAt != nil {
+		status = store.StatusDisabled
+	}
+	return []string{
+		user.Email,
+		user.FirstName,
+		user.LastName,
+		user.Phone,
+		user.MembershipType,
+		strings.Join(user.Roles, ","),
+		status,
+		strconv.FormatBool(user.EmailVerified),
+		user.CreatedAt.UTC().Format(time.RFC3339),
+		user.LastActiveAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// writeSpreadsheet sends table as a download named name plus the format's extension.
+func writeSpreadsheet(ctx *gin.Context, format, name string, table [][]string) {
+	ctx.Header("Content-Type", spreadsheet.ContentType(format))
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	ctx.Status(http.StatusOK)
+	if err := spreadsheet.Write(ctx.Writer, format, table); err != nil {
+		ctx.Error(err)
+	}
+}
+
+func importResponse(imp *store.UserImport) gin.H {
+	var requestedBy any
+	if imp.RequestedBy != nil {
+		requestedBy = imp.RequestedBy.String()
+	}
+	return gin.H{
+		"id":              imp.ID.String(),
+		"filename":        imp.Filename,
+		"status":          imp.Status,
+		"requestedBy":     requestedBy,
+		"membershipType":  imp.MembershipType,
+		"sendInvitations": imp.SendInvitations,
+		"totalRows":       imp.TotalRows,
+		"processedRows":   imp.ProcessedRows,
+		"createdRows":     imp.CreatedRows,
+		"failedRows":      imp.FailedRows,
+		"createdAt":       imp.CreatedAt.Format(time.RFC3339),
+		"startedAt":       formatOptionalTime(imp.StartedAt),
+		"completedAt":     formatOptionalTime(imp.CompletedAt),
+	}
+}
+
+// run processes queued imports and redacts old import rows until ctx is done.
+func (u *userImports) run(ctx context.Context) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for u.processNext(ctx) {
+			}
+			if _, err := u.repo.RedactImportRows(ctx, time.Now().Add(-importRowRetention)); err != nil {
+				u.logger.Error().Err(err).Msg("redact import rows failed")
+			}
+		}
+	}
+}
+
+// processNext works through one import and reports whether there was one. If the database
+// fails mid-way the import stays processing and is resumed once it counts as stale.
+func (u *userImports) processNext(ctx context.Context) bool {
+	imp, err := u.repo.ClaimUserImport(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	if err != nil {
+		u.logger.Error().Err(err).Msg("claim user import failed")
+		return false
+	}
+	for {
+		rows, err := u.repo.PendingImportRows(ctx, imp.ID, importBatchSize)
+		if err != nil {
+			u.logger.Error().Err(err).Str("importId", imp.ID.String()).Msg("load import rows failed")
+			return false
+		}
+		if len(rows) == 0 {
+			break
+		}
+		for _, row := range rows {
+			if err := u.importRow(ctx, imp, row); err != nil {
+				u.logger.Error().Err(err).Str("importId", imp.ID.String()).Int("row", row.RowNumber).Msg("import row failed")
+				return false
+			}
+		}
+	}
+	if err := u.repo.CompleteUserImport(ctx, imp.ID); err != nil {
+		u.logger.Error().Err(err).Str("importId", imp.ID.String()).Msg("complete user import failed")
+		return false
+	}
+	done, err := u.repo.GetUserImport(ctx, imp.ID)
+	if err == nil {
+		u.logger.Info().Str("importId", imp.ID.String()).Int("created", done.CreatedRows).Int("failed", done.FailedRows).Msg("user import completed")
+	}
+	return true
+}
+
+// importRow creates the account for one row and invites the member. Problems with the row are
+// recorded on it; only database failures are returned.
+func (u *userImports) importRow(ctx context.Context, imp *store.UserImport, row store.ImportRow) error {
+	rowCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := u.repo.GetUserByEmail(rowCtx, row.Email)
+	if err == nil {
+		return u.repo.FailImportRow(rowCtx, imp.ID, row.RowNumber, store.ErrEmailTaken.Error())
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	user, err := u.repo.CreateImportedUser(rowCtx, imp.ID, row)
+	if errors.Is(err, store.ErrEmailTaken) {
+		return u.repo.FailImportRow(rowCtx, imp.ID, row.RowNumber, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	if !imp.SendInvitations {
+		return nil
+	}
+	if err := u.invite(rowCtx, user); err != nil {
+		return u.repo.NoteImportRow(rowCtx, imp.ID, row.RowNumber, "account created but the invitation was not sent: "+err.Error())
+	}
+	return nil
+}
+
+// invite emails an imported member a link to choose their first password. The link redeems
+// through the usual password reset page.
+func (u *userImports) invite(ctx context.Context, user *store.User) error {
+	token, err := u.repo.CreateToken(ctx, user.ID, store.PurposeInvitation)
+	if err != nil {
+		return err
+	}
+	link := u.webURL + "/reset-password?token=" + url.QueryEscape(token)
+	payload := notification.NotifyPayload{
+		UserID:  user.ID.String(),
+		Title:   "Your Venue Master account is ready",
+		Message: fmt.Sprintf("Your club has moved its members to Venue Master. Choose a password within the next 14 days to sign in: %s. After that, use \"Forgot password\" on the sign-in page.", link),
+		Channel: "email",
+	}
+	return u.notify.Send(ctx, payload)
+}
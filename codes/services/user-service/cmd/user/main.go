@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,10 +16,22 @@ import (
 	"github.com/rs/zerolog"
 
 	"github.com/venue-master/platform/internal/server"
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
 	"github.com/venue-master/platform/lib/config"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/lib/s3util"
+	"github.com/venue-master/platform/lib/svcauth"
+	"github.com/venue-master/platform/services/user-service/internal/dataexport"
+	"github.com/venue-master/platform/services/user-service/internal/erasure"
+	"github.com/venue-master/platform/services/user-service/internal/notification"
 	"github.com/venue-master/platform/services/user-service/internal/store"
 )
 
+type rolesRequest struct {
+	Roles []string `json:"roles" binding:"required,min=1"`
+}
+
 type authRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
@@ -40,7 +54,60 @@ func main() {
 		panic(err)
 	}
 
-	registerRoutes(srv.Engine, repo)
+	storage, err := s3util.NewFromConfig(ctx, srv.Config.AWS)
+	if err != nil {
+		panic(err)
+	}
+	if fsStorage, ok := storage.(*s3util.FilesystemProvider); ok {
+		// Avatars and data exports: registered before the service-auth middleware, since the
+		// browser fetches signed URLs directly through the gateway.
+		srv.Engine.Any(fsStorage.BasePath()+"/*key", gin.WrapH(fsStorage.Handler()))
+	}
+
+	revoked := revocation.New(srv.Config.Redis, srv.Config.JWT.AccessExpiry)
+	transport := srv.ServiceAuth.Transport(nil)
+	exports := &dataExports{
+		repo:    repo,
+		storage: storage,
+		sources: []*dataexport.Source{
+			dataexport.NewSource("booking-service", getEnv("BOOKING_SERVICE_URL", "http://booking-service:8080"), transport),
+			dataexport.NewSource("payment-service", getEnv("PAYMENT_SERVICE_URL", "http://payment-service:8080"), transport),
+			dataexport.NewSource("notification-service", getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"), transport),
+		},
+		notify:  notification.New(getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"), transport),
+		linkTTL: exportLinkTTL(),
+		logger:  srv.Logger,
+	}
+	ret := &retention{
+		repo:    repo,
+		revoked: revoked,
+		storage: storage,
+		services: []*erasure.Service{
+			erasure.NewService("booking-service", getEnv("BOOKING_SERVICE_URL", "http://booking-service:8080"), transport),
+			erasure.NewService("payment-service", getEnv("PAYMENT_SERVICE_URL", "http://payment-service:8080"), transport),
+			erasure.NewService("notification-service", getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8080"), transport),
+		},
+		notify:        exports.notify,
+		inactiveAfter: envDays("RETENTION_INACTIVE_DAYS", 730),
+		coolingOff:    envDays("ACCOUNT_DELETION_COOLING_OFF_DAYS", 30),
+		logger:        srv.Logger,
+	}
+	imports := &userImports{
+		repo:   repo,
+		notify: exports.notify,
+		webURL: strings.TrimRight(getEnv("WEB_APP_URL", "http://localhost:3000"), "/"),
+		logger: srv.Logger,
+	}
+	trail := audit.New("user-service", getEnv("AUDIT_SERVICE_URL", "http://audit-service:8080"), transport, srv.Logger)
+	// Identity headers are only trusted on calls signed by another service.
+	srv.Engine.Use(srv.ServiceAuth.Middleware())
+	registerRoutes(srv.Engine, repo, revoked, &avatars{storage: storage}, exports, imports, ret, srv.ServiceAuth, trail)
+
+	appCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go exports.run(appCtx)
+	go ret.run(appCtx)
+	go imports.run(appCtx)
 
 	if err := srv.Run(); err != nil {
 		panic(err)
@@ -61,8 +128,11 @@ func seedDefaultUser(ctx context.Context, repo *store.Store, logger zerolog.Logg
 	return nil
 }
 
-func registerRoutes(router *gin.Engine, repo *store.Store) {
+func registerRoutes(router *gin.Engine, repo *store.Store, revoked *revocation.Store, pics *avatars, exports *dataExports, imports *userImports, ret *retention, serviceAuth *svcauth.Keys, trail *audit.Client) {
 	group := router.Group("/v1/users")
+	// authOnly limits the credential, token and account-security endpoints to the auth-service.
+	authOnly := serviceAuth.RequireCaller("auth-service")
+	internal := group.Group("", authOnly)
 
 	group.GET("/me", func(ctx *gin.Context) {
 		userID := ctx.GetHeader("X-User-ID")
@@ -70,11 +140,11 @@ func registerRoutes(router *gin.Engine, repo *store.Store) {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header required"})
 			return
 		}
-		handleGetUser(ctx, repo, userID)
+		handleGetUser(ctx, repo, pics, userID)
 	})
 
 	group.GET("/:id", func(ctx *gin.Context) {
-		handleGetUser(ctx, repo, ctx.Param("id"))
+		handleGetUser(ctx, repo, pics, ctx.Param("id"))
 	})
 
 	group.GET("/:id/memberships", func(ctx *gin.Context) {
@@ -83,19 +153,64 @@ func registerRoutes(router *gin.Engine, repo *store.Store) {
 			handleStoreError(ctx, err)
 			return
 		}
-		ctx.JSON(http.StatusOK, []gin.H{
-			{
-				"id":         "membership-" + user.ID.String(),
-				"type":       "MONTHLY_PREMIUM",
-				"status":     "ACTIVE",
-				"startDate":  user.CreatedAt.Format(time.RFC3339),
-				"expiryDate": user.CreatedAt.AddDate(0, 1, 0).Format(time.RFC3339),
-				"autoRenew":  true,
-			},
-		})
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		household, err := repo.GetHouseholdByUser(timeoutCtx, user.ID)
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, membershipsResponse(user, household))
 	})
 
-	group.POST("/authenticate", func(ctx *gin.Context) {
+	group.PUT("/:id/roles", authz.RequirePermission(authz.UserRolesAssign), func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		var req rolesRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		roles := make([]string, 0, len(req.Roles))
+		seen := make(map[string]bool, len(req.Roles))
+		for _, role := range req.Roles {
+			role = strings.ToUpper(strings.TrimSpace(role))
+			if !seen[role] {
+				seen[role] = true
+				roles = append(roles, role)
+			}
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		if !checkGrantable(ctx, timeoutCtx, repo, roles) {
+			return
+		}
+
+		before, err := repo.GetUserByID(timeoutCtx, id)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		user, err := repo.UpdateRoles(timeoutCtx, id, roles)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "user.roles.update", "user", user.ID.String(), userResponse(before), userResponse(user), nil)
+		// Tokens minted with the old roles must stop working; refreshes pick up the new ones.
+		if err := revoked.RevokeUser(timeoutCtx, user.ID.String()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "roles updated but existing tokens could not be revoked: " + err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	internal.POST("/authenticate", func(ctx *gin.Context) {
 		var req authRequest
 		if err := ctx.ShouldBindJSON(&req); err != nil {
 			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -116,7 +231,7 @@ func registerRoutes(router *gin.Engine, repo *store.Store) {
 		ctx.JSON(http.StatusOK, userResponse(user))
 	})
 
-	group.POST("/register", func(ctx *gin.Context) {
+	internal.POST("/register", func(ctx *gin.Context) {
 		var req struct {
 			Email     string `json:"email" binding:"required,email"`
 			Password  string `json:"password" binding:"required,min=8"`
@@ -132,6 +247,15 @@ func registerRoutes(router *gin.Engine, repo *store.Store) {
 		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
 		defer cancel()
 
+		if req.Phone != "" {
+			number, err := normalizePhone(req.Phone)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			req.Phone = number
+		}
+
 		// Check if email already exists
 		existingUser, err := repo.GetUserByEmail(timeoutCtx, req.Email)
 		if err == nil && existingUser != nil {
@@ -158,24 +282,45 @@ func registerRoutes(router *gin.Engine, repo *store.Store) {
 			LastName:     req.LastName,
 			Roles:        []string{"MEMBER"},
 			PasswordHash: hash,
+			Phone:        req.Phone,
 		}
 
 		if err := repo.UpsertUser(timeoutCtx, user); err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
 			return
 		}
+		// Reload so the response carries the timestamps and permissions the database resolved.
+		if created, err := repo.GetUserByID(timeoutCtx, user.ID); err == nil {
+			user = created
+		}
 
 		ctx.JSON(http.StatusCreated, userResponse(user))
 	})
+
+	registerTokenRoutes(internal, repo)
+	registerProfileRoutes(group, internal, repo, pics)
+	registerDirectoryRoutes(group, repo, revoked, pics, trail)
+	registerExportRoutes(group, repo, exports, trail)
+	registerDeletionRoutes(group, internal, repo, ret)
+	registerConsentRoutes(group, repo, serviceAuth, trail)
+	registerPhoneRoutes(internal, repo)
+	registerIdentityRoutes(internal, repo)
+	registerMFARoutes(internal, repo)
+	registerVenueRoleRoutes(group, repo, revoked, trail)
+	registerRBACRoutes(router, repo, revoked, trail)
+	registerPolicyRoutes(router, repo, trail)
+	registerOAuthRoutes(router, internal, repo, authOnly, trail)
+	registerHouseholdRoutes(group, repo, exports.notify, trail)
+	registerImportRoutes(group, repo, imports, trail)
 }
 
-func handleGetUser(ctx *gin.Context, repo *store.Store, idParam string) {
+func handleGetUser(ctx *gin.Context, repo *store.Store, pics *avatars, idParam string) {
 	user, err := fetchUser(ctx, repo, idParam)
 	if err != nil {
 		handleStoreError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, userResponse(user))
+	ctx.JSON(http.StatusOK, profileResponse(ctx.Request.Context(), pics, user))
 }
 
 func fetchUser(ctx *gin.Context, repo *store.Store, idParam string) (*store.User, error) {
@@ -193,21 +338,82 @@ func handleStoreError(ctx *gin.Context, err error) {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
+	if errors.Is(err, store.ErrErasureInProgress) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
 func userResponse(user *store.User) gin.H {
 	return gin.H{
-		"id":        user.ID.String(),
-		"email":     user.Email,
-		"firstName": user.FirstName,
-		"lastName":  user.LastName,
-		"roles":     user.Roles,
-		"createdAt": user.CreatedAt.Format(time.RFC3339),
-		"updatedAt": user.UpdatedAt.Format(time.RFC3339),
+		"id":                  user.ID.String(),
+		"email":               user.Email,
+		"firstName":           user.FirstName,
+		"lastName":            user.LastName,
+		"roles":               user.Roles,
+		"permissions":         user.Permissions,
+		"venueRoles":          venueRolesResponse(user.VenueRoles),
+		"venuePermissions":    user.VenuePermissions,
+		"emailVerified":       user.EmailVerified,
+		"mfaEnabled":          user.MFAEnabled,
+		"phone":               user.Phone,
+		"phoneVerified":       user.PhoneVerified,
+		"pendingEmail":        user.PendingEmail,
+		"preferences":         user.Preferences,
+		"disabled":            user.DisabledAt != nil,
+		"disabledAt":          formatOptionalTime(user.DisabledAt),
+		"lastActiveAt":        user.LastActiveAt.Format(time.RFC3339),
+		"deletionScheduledAt": formatOptionalTime(user.DeletionScheduledAt),
+		"deletionReason":      user.DeletionReason,
+		"erased":              user.ErasedAt != nil,
+		"dependent":           user.Dependent,
+		"membershipType":      user.MembershipType,
+		"createdAt":           user.CreatedAt.Format(time.RFC3339),
+		"updatedAt":           user.UpdatedAt.Format(time.RFC3339),
 	}
 }
 
+// membershipsResponse lists user's memberships. Members of a household with a membership
+// hold it through the household, which may be nil; others hold the type they were imported
+// with, if any.
+func membershipsResponse(user *store.User, household *store.Household) []gin.H {
+	membership := gin.H{
+		"id":         "membership-" + user.ID.String(),
+		"type":       "MONTHLY_PREMIUM",
+		"status":     "ACTIVE",
+		"startDate":  user.CreatedAt.Format(time.RFC3339),
+		"expiryDate": user.CreatedAt.AddDate(0, 1, 0).Format(time.RFC3339),
+		"autoRenew":  true,
+	}
+	if user.MembershipType != "" {
+		membership["type"] = user.MembershipType
+	}
+	if household != nil && household.MembershipType != "" {
+		membership["id"] = "membership-" + household.ID.String()
+		membership["type"] = household.MembershipType
+		membership["startDate"] = household.CreatedAt.Format(time.RFC3339)
+		membership["expiryDate"] = household.CreatedAt.AddDate(0, 1, 0).Format(time.RFC3339)
+		membership["householdId"] = household.ID.String()
+	}
+	return []gin.H{membership}
+}
+
+func formatOptionalTime(value *time.Time) any {
+	if value == nil {
+		return nil
+	}
+	return value.Format(time.RFC3339)
+}
+
+func venueRolesResponse(assignments []store.VenueRole) []gin.H {
+	out := make([]gin.H, 0, len(assignments))
+	for _, a := range assignments {
+		out = append(out, gin.H{"role": a.Role, "venueId": a.VenueID.String()})
+	}
+	return out
+}
+
 const (
 	dbReadyTimeout = 60 * time.Second
 	dbRetryDelay   = 3 * time.Second
@@ -249,3 +455,20 @@ func initStore(ctx context.Context, cfg config.DatabaseConfig, logger zerolog.Lo
 
 	return nil, lastErr
 }
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// exportLinkTTL reads how long data export links stay valid (DATA_EXPORT_LINK_HOURS, default 72),
+// capped at the 7 days S3 allows for presigned URLs.
+func exportLinkTTL() time.Duration {
+	hours, err := strconv.Atoi(os.Getenv("DATA_EXPORT_LINK_HOURS"))
+	if err != nil || hours <= 0 {
+		hours = 72
+	}
+	return min(time.Duration(hours)*time.Hour, maxExportLinkTTL)
+}
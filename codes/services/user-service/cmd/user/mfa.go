@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/services/user-service/internal/store"
+	"github.com/venue-master/platform/services/user-service/internal/totp"
+)
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// registerMFARoutes exposes TOTP enrollment and verification to the auth-service. Secrets
+// never leave this service after enrollment; the auth-service only learns whether a code
+// matched. The routes are not proxied by the gateway.
+func registerMFARoutes(group *gin.RouterGroup, repo *store.Store) {
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "Venue Master"
+	}
+
+	group.POST("/:id/mfa/enroll", func(ctx *gin.Context) {
+		user, err := fetchUser(ctx, repo, ctx.Param("id"))
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		secret, err := repo.BeginMFAEnrollment(timeoutCtx, user.ID)
+		if err != nil {
+			handleMFAError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{
+			"secret":          secret,
+			"provisioningUri": totp.ProvisioningURI(issuer, user.Email, secret),
+		})
+	})
+
+	group.POST("/:id/mfa/confirm", func(ctx *gin.Context) {
+		id, req, ok := bindMFACode(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		codes, err := repo.ConfirmMFA(timeoutCtx, id, req.Code)
+		if err != nil {
+			handleMFAError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+	})
+
+	group.POST("/:id/mfa/verify", func(ctx *gin.Context) {
+		id, req, ok := bindMFACode(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		method, err := repo.VerifyMFA(timeoutCtx, id, req.Code)
+		if err != nil {
+			handleMFAError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"method": method})
+	})
+
+	group.POST("/:id/mfa/recovery-codes", func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		codes, err := repo.RegenerateRecoveryCodes(timeoutCtx, id)
+		if err != nil {
+			handleMFAError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, gin.H{"recoveryCodes": codes})
+	})
+
+	group.DELETE("/:id/mfa", func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		if err := repo.DisableMFA(timeoutCtx, id); err != nil {
+			handleMFAError(ctx, err)
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+}
+
+func bindMFACode(ctx *gin.Context) (uuid.UUID, mfaCodeRequest, bool) {
+	var req mfaCodeRequest
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, req, false
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return uuid.Nil, req, false
+	}
+	return id, req, true
+}
+
+func handleMFAError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, store.ErrInvalidMFACode):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrMFAAlreadyEnabled), errors.Is(err, store.ErrMFANotEnrolled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		handleStoreError(ctx, err)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type oauthClientRequest struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirectUris"`
+	Scopes       []string `json:"scopes" binding:"required"`
+	GrantTypes   []string `json:"grantTypes" binding:"required"`
+	// Confidential is only read on creation; it defaults to true.
+	Confidential *bool `json:"confidential"`
+}
+
+type clientSecretRequest struct {
+	Secret string `json:"secret" binding:"required"`
+}
+
+type consentRequest struct {
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// registerOAuthRoutes manages partner apps (oauth:clients:manage, proxied by the gateway) and
+// serves the client lookups and consent records the auth-service's authorization server uses.
+// The lookup, authenticate and consent routes are internal: authOnly limits them to the
+// auth-service, and group must already apply it.
+func registerOAuthRoutes(router *gin.Engine, group *gin.RouterGroup, repo *store.Store, authOnly gin.HandlerFunc, trail *audit.Client) {
+	manage := authz.RequirePermission(authz.OAuthClientsManage)
+	clients := router.Group("/v1/oauth/clients")
+
+	clients.GET("", manage, func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		list, err := repo.ListOAuthClients(timeoutCtx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(list))
+		for i := range list {
+			out = append(out, oauthClientResponse(&list[i]))
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	clients.POST("", manage, func(ctx *gin.Context) {
+		var req oauthClientRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		confidential := req.Confidential == nil || *req.Confidential
+		client, err := req.client(confidential)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		created, secret, err := repo.CreateOAuthClient(timeoutCtx, client, confidential)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		trail.RecordChange(ctx, "oauth_client.create", "oauth_client", created.ID, nil, oauthClientResponse(created), nil)
+		resp := oauthClientResponse(created)
+		if secret != "" {
+			resp["clientSecret"] = secret
+		}
+		ctx.JSON(http.StatusCreated, resp)
+	})
+
+	clients.GET("/:id", authOnly, func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		client, err := repo.GetOAuthClient(timeoutCtx, ctx.Param("id"))
+		if err != nil {
+			handleClientError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, oauthClientResponse(client))
+	})
+
+	clients.PUT("/:id", manage, func(ctx *gin.Context) {
+		var req oauthClientRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		existing, err := repo.GetOAuthClient(timeoutCtx, ctx.Param("id"))
+		if err != nil {
+			handleClientError(ctx, err)
+			return
+		}
+		client, err := req.client(existing.Confidential())
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		client.ID = existing.ID
+		updated, err := repo.UpdateOAuthClient(timeoutCtx, client)
+		if err != nil {
+			handleClientError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "oauth_client.update", "oauth_client", updated.ID, oauthClientResponse(existing), oauthClientResponse(updated), nil)
+		ctx.JSON(http.StatusOK, oauthClientResponse(updated))
+	})
+
+	clients.DELETE("/:id", manage, func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		if err := repo.DeleteOAuthClient(timeoutCtx, ctx.Param("id")); err != nil {
+			handleClientError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "oauth_client.delete", "oauth_client", ctx.Param("id"), nil, nil, nil)
+		ctx.Status(http.StatusNoContent)
+	})
+
+	clients.POST("/:id/secret", manage, func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		secret, err := repo.RotateOAuthClientSecret(timeoutCtx, ctx.Param("id"))
+		if err != nil {
+			handleClientError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "oauth_client.secret.rotate", "oauth_client", ctx.Param("id"), nil, nil, nil)
+		ctx.JSON(http.StatusOK, gin.H{"clientId": ctx.Param("id"), "clientSecret": secret})
+	})
+
+	clients.POST("/:id/authenticate", authOnly, func(ctx *gin.Context) {
+		var req clientSecretRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		client, err := repo.AuthenticateOAuthClient(timeoutCtx, ctx.Param("id"), req.Secret)
+		if errors.Is(err, store.ErrInvalidClient) {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, oauthClientResponse(client))
+	})
+
+	group.GET("/:id/consents", func(ctx *gin.Context) {
+		userID, ok := parseUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		consents, err := repo.ListOAuthConsents(timeoutCtx, userID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(consents))
+		for i := range consents {
+			out = append(out, consentResponse(&consents[i]))
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.PUT("/:id/consents/:clientId", func(ctx *gin.Context) {
+		userID, ok := parseUserID(ctx)
+		if !ok {
+			return
+		}
+		var req consentRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		consent, err := repo.GrantOAuthConsent(timeoutCtx, userID, ctx.Param("clientId"), req.Scopes)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, consentResponse(consent))
+	})
+
+	group.DELETE("/:id/consents/:clientId", func(ctx *gin.Context) {
+		userID, ok := parseUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		err := repo.RevokeOAuthConsent(timeoutCtx, userID, ctx.Param("clientId"))
+		if errors.Is(err, pgx.ErrNoRows) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "consent not found"})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+}
+
+// client validates the request into a client row. Client-credentials clients must be
+// confidential, and authorization-code clients need at least one redirect URI.
+func (req *oauthClientRequest) client(confidential bool) (store.OAuthClient, error) {
+	c := store.OAuthClient{Name: strings.TrimSpace(req.Name)}
+	if c.Name == "" {
+		return c, errors.New("name is required")
+	}
+	for _, scope := range req.Scopes {
+		if !authz.ValidScope(scope) {
+			return c, fmt.Errorf("unknown scope %q", scope)
+		}
+		c.Scopes = appendUnique(c.Scopes, scope)
+	}
+	for _, grant := range req.GrantTypes {
+		switch grant {
+		case store.GrantAuthorizationCode:
+		case store.GrantClientCredentials:
+			if !confidential {
+				return c, errors.New("client_credentials requires a confidential client")
+			}
+		default:
+			return c, fmt.Errorf("unsupported grant type %q", grant)
+		}
+		c.GrantTypes = appendUnique(c.GrantTypes, grant)
+	}
+	if len(c.Scopes) == 0 || len(c.GrantTypes) == 0 {
+		return c, errors.New("at least one scope and grant type are required")
+	}
+	for _, raw := range req.RedirectURIs {
+		if err := validRedirectURI(raw); err != nil {
+			return c, err
+		}
+		c.RedirectURIs = appendUnique(c.RedirectURIs, raw)
+	}
+	if containsString(c.GrantTypes, store.GrantAuthorizationCode) && len(c.RedirectURIs) == 0 {
+		return c, errors.New("authorization_code requires at least one redirect URI")
+	}
+	if c.RedirectURIs == nil {
+		c.RedirectURIs = []string{}
+	}
+	return c, nil
+}
+
+// validRedirectURI accepts absolute https URIs without a fragment; plain http only for loopback
+// hosts, for local development.
+func validRedirectURI(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || !u.IsAbs() || u.Host == "" || u.Fragment != "" {
+		return fmt.Errorf("invalid redirect URI %q", raw)
+	}
+	host := u.Hostname()
+	if u.Scheme == "https" || (u.Scheme == "http" && (host == "localhost" || host == "127.0.0.1")) {
+		return nil
+	}
+	return fmt.Errorf("redirect URI %q must use https", raw)
+}
+
+func oauthClientResponse(c *store.OAuthClient) gin.H {
+	return gin.H{
+		"id":           c.ID,
+		"name":         c.Name,
+		"confidential": c.Confidential(),
+		"redirectUris": c.RedirectURIs,
+		"scopes":       c.Scopes,
+		"grantTypes":   c.GrantTypes,
+		"createdAt":    c.CreatedAt.Format(time.RFC3339),
+		"updatedAt":    c.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func consentResponse(c *store.OAuthConsent) gin.H {
+	return gin.H{
+		"clientId":   c.ClientID,
+		"clientName": c.ClientName,
+		"scopes":     c.Scopes,
+		"createdAt":  c.CreatedAt.Format(time.RFC3339),
+		"updatedAt":  c.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func handleClientError(ctx *gin.Context, err error) {
+	if errors.Is(err, pgx.ErrNoRows) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "client not found"})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func parseUserID(ctx *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func appendUnique(list []string, value string) []string {
+	if containsString(list, value) {
+		return list
+	}
+	return append(list, value)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/venue-master/platform/lib/phone"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type lookupRequest struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+type phoneRequest struct {
+	Phone string `json:"phone"`
+}
+
+// registerPhoneRoutes exposes the lookups and phone updates the auth-service drives passwordless
+// sign-in and phone verification with. Like the token routes they are not routed through the
+// gateway.
+func registerPhoneRoutes(group *gin.RouterGroup, repo *store.Store) {
+	// lookup finds a user by email, or by phone number among verified numbers only.
+	group.POST("/lookup", func(ctx *gin.Context) {
+		var req lookupRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		var (
+			user *store.User
+			err  error
+		)
+		switch {
+		case req.Email != "" && req.Phone == "":
+			user, err = repo.GetUserByEmail(timeoutCtx, req.Email)
+		case req.Phone != "" && req.Email == "":
+			number, nerr := normalizePhone(req.Phone)
+			if nerr != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": nerr.Error()})
+				return
+			}
+			user, err = repo.GetUserByVerifiedPhone(timeoutCtx, number)
+		default:
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of email and phone is required"})
+			return
+		}
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	group.PUT("/:id/phone", func(ctx *gin.Context) {
+		userID, ok := parseUserID(ctx)
+		if !ok {
+			return
+		}
+		var req phoneRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		number := ""
+		if req.Phone != "" {
+			var err error
+			if number, err = normalizePhone(req.Phone); err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.SetPhone(timeoutCtx, userID, number)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	// confirm marks the number verified once the auth-service has checked the code sent to it.
+	group.POST("/:id/phone/confirm", func(ctx *gin.Context) {
+		userID, ok := parseUserID(ctx)
+		if !ok {
+			return
+		}
+		var req phoneRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil || req.Phone == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "phone is required"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.ConfirmPhone(timeoutCtx, userID, req.Phone)
+		switch {
+		case errors.Is(err, store.ErrPhoneTaken):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, store.ErrPhoneChanged):
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		case err != nil:
+			handleStoreError(ctx, err)
+		default:
+			ctx.JSON(http.StatusOK, userResponse(user))
+		}
+	})
+}
+
+// normalizePhone returns raw in E.164. National numbers are read in the country of
+// PHONE_DEFAULT_COUNTRY_CODE (a calling code, default 1).
+func normalizePhone(raw string) (string, error) {
+	country := os.Getenv("PHONE_DEFAULT_COUNTRY_CODE")
+	if country == "" {
+		country = "1"
+	}
+	return phone.Normalize(raw, country)
+}
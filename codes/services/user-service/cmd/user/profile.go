@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type profileRequest struct {
+	FirstName   *string        `json:"firstName" binding:"omitempty,max=100"`
+	LastName    *string        `json:"lastName" binding:"omitempty,max=100"`
+	Phone       *string        `json:"phone"`
+	Preferences map[string]any `json:"preferences"`
+}
+
+type avatarUploadRequest struct {
+	ContentType string `json:"contentType" binding:"required"`
+	SizeBytes   int64  `json:"sizeBytes" binding:"required"`
+}
+
+type avatarCompleteRequest struct {
+	Key string `json:"key" binding:"required"`
+}
+
+type changePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword" binding:"required,min=8"`
+}
+
+type changeEmailRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password"`
+}
+
+// registerProfileRoutes exposes self-service profile editing under /me (through the gateway) and
+// the password and email change steps the auth-service drives on internal.
+func registerProfileRoutes(group, internal *gin.RouterGroup, repo *store.Store, pics *avatars) {
+	group.PATCH("/me", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		var req profileRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.FirstName, req.LastName = trimmed(req.FirstName), trimmed(req.LastName)
+		if (req.FirstName != nil && *req.FirstName == "") || (req.LastName != nil && *req.LastName == "") {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "firstName and lastName cannot be empty"})
+			return
+		}
+		if req.Phone != nil && *req.Phone != "" {
+			number, err := normalizePhone(*req.Phone)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			req.Phone = &number
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.UpdateProfile(timeoutCtx, userID, store.ProfileUpdate{
+			FirstName:   req.FirstName,
+			LastName:    req.LastName,
+			Phone:       req.Phone,
+			Preferences: req.Preferences,
+		})
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, profileResponse(timeoutCtx, pics, user))
+	})
+
+	group.POST("/me/avatar", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		var req avatarUploadRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		key, url, expiresAt, err := pics.presignUpload(ctx.Request.Context(), userID, req.ContentType, req.SizeBytes)
+		if errors.Is(err, errAvatarType) || errors.Is(err, errAvatarSize) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{"key": key, "uploadUrl": url, "expiresAt": expiresAt.Format(time.RFC3339)})
+	})
+
+	group.POST("/me/avatar/complete", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		var req avatarCompleteRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 15*time.Second)
+		defer cancel()
+
+		if err := pics.verify(timeoutCtx, userID, req.Key); err != nil {
+			if errors.Is(err, errAvatarKey) {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			// Whatever was uploaded is unusable; do not leave it behind.
+			_ = pics.remove(timeoutCtx, req.Key)
+			ctx.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		setAvatar(ctx, timeoutCtx, repo, pics, userID, req.Key)
+	})
+
+	group.DELETE("/me/avatar", func(ctx *gin.Context) {
+		userID, ok := currentUserID(ctx)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		setAvatar(ctx, timeoutCtx, repo, pics, userID, "")
+	})
+
+	internal.POST("/:id/password", func(ctx *gin.Context) {
+		var req changePasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		user, ok := checkPassword(ctx, repo, req.CurrentPassword)
+		if !ok {
+			return
+		}
+		hash, err := store.HashPassword(req.NewPassword)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "password hashing failed"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err = repo.SetPassword(timeoutCtx, user.ID, hash)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	internal.POST("/:id/email/change", func(ctx *gin.Context) {
+		var req changeEmailRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		user, ok := checkPassword(ctx, repo, req.Password)
+		if !ok {
+			return
+		}
+		if strings.EqualFold(user.Email, req.Email) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "email is unchanged"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		token, user, err := repo.RequestEmailChange(timeoutCtx, user.ID, req.Email)
+		if errors.Is(err, store.ErrEmailTaken) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{"token": token, "user": userResponse(user)})
+	})
+
+	internal.POST("/email/change/confirm", func(ctx *gin.Context) {
+		var req verifyEmailRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.ConfirmEmailChange(timeoutCtx, req.Token)
+		if errors.Is(err, store.ErrEmailTaken) {
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			handleTokenError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+}
+
+// setAvatar stores key (empty to remove) and deletes the picture it replaces.
+func setAvatar(ctx *gin.Context, reqCtx context.Context, repo *store.Store, pics *avatars, userID uuid.UUID, key string) {
+	user, previous, err := repo.SetAvatar(reqCtx, userID, key)
+	if err != nil {
+		handleStoreError(ctx, err)
+		return
+	}
+	if previous != key {
+		// The new avatar is saved either way; a stale object only costs storage.
+		_ = pics.remove(reqCtx, previous)
+	}
+	ctx.JSON(http.StatusOK, profileResponse(reqCtx, pics, user))
+}
+
+// checkPassword loads the :id user and checks password against it, answering 401 on a
+// mismatch. Accounts created through social sign-in have no password and skip the check.
+// Imported members have none yet either, but must choose one first (403): their sign-in only
+// proves access to a token, not to the account.
+func checkPassword(ctx *gin.Context, repo *store.Store, password string) (*store.User, bool) {
+	user, err := fetchUser(ctx, repo, ctx.Param("id"))
+	if err != nil {
+		handleStoreError(ctx, err)
+		return nil, false
+	}
+	if user.InvitationPending {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "password not set"})
+		return nil, false
+	}
+	if user.PasswordHash != "" && store.ComparePassword(user.PasswordHash, password) != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return nil, false
+	}
+	return user, true
+}
+
+// currentUserID reads the caller the gateway forwarded in X-User-ID.
+func currentUserID(ctx *gin.Context) (uuid.UUID, bool) {
+	raw := ctx.GetHeader("X-User-ID")
+	if raw == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "X-User-ID header required"})
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// profileResponse is userResponse plus a short-lived avatar URL, for responses that reach
+// clients.
+func profileResponse(ctx context.Context, pics *avatars, user *store.User) gin.H {
+	resp := userResponse(user)
+	resp["avatarUrl"] = pics.url(ctx, user.AvatarKey)
+	return resp
+}
+
+func trimmed(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	out := strings.TrimSpace(*value)
+	return &out
+}
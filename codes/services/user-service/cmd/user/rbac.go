@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/lib/audit"
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type roleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+// registerRBACRoutes lets holders of rbac:manage edit the role → permission model. Changing a
+// role's grants revokes the tokens of everyone holding it so the next refresh picks them up.
+func registerRBACRoutes(router *gin.Engine, repo *store.Store, revoked *revocation.Store, trail *audit.Client) {
+	manage := authz.RequirePermission(authz.RBACManage)
+
+	router.GET("/v1/permissions", manage, func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		perms, err := repo.ListPermissions(timeoutCtx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(perms))
+		for _, p := range perms {
+			out = append(out, gin.H{"name": p.Name, "description": p.Description})
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	roles := router.Group("/v1/roles", manage)
+
+	roles.GET("", func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		list, err := repo.ListRoles(timeoutCtx)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(list))
+		for i := range list {
+			out = append(out, roleResponse(&list[i]))
+		}
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	roles.PUT("/:name", func(ctx *gin.Context) {
+		name := strings.ToUpper(strings.TrimSpace(ctx.Param("name")))
+		if !validRoleName(name) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "role names use A-Z, 0-9 and _"})
+			return
+		}
+		var req roleRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		perms := make([]string, 0, len(req.Permissions))
+		seen := make(map[string]bool, len(req.Permissions))
+		for _, p := range req.Permissions {
+			p = strings.TrimSpace(p)
+			if p != "" && !seen[p] {
+				seen[p] = true
+				perms = append(perms, p)
+			}
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		before, err := findRole(timeoutCtx, repo, name)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		holders, err := repo.SaveRole(timeoutCtx, store.Role{Name: name, Description: req.Description, Permissions: perms})
+		if errors.Is(err, store.ErrUnknownPermission) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		for _, id := range holders {
+			if err := revoked.RevokeUser(timeoutCtx, id.String()); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "role updated but existing tokens could not be revoked: " + err.Error()})
+				return
+			}
+		}
+		var old any
+		if before != nil {
+			old = gin.H{"description": before.Description, "permissions": before.Permissions}
+		}
+		trail.RecordChange(ctx, "role.save", "role", name, old, gin.H{"description": req.Description, "permissions": perms},
+			map[string]any{"affectedUsers": len(holders)})
+		ctx.JSON(http.StatusOK, gin.H{"name": name, "description": req.Description, "permissions": perms, "affectedUsers": len(holders)})
+	})
+
+	roles.DELETE("/:name", func(ctx *gin.Context) {
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		name := strings.ToUpper(ctx.Param("name"))
+		err := repo.DeleteRole(timeoutCtx, name)
+		switch {
+		case err == nil:
+			trail.RecordChange(ctx, "role.delete", "role", name, nil, nil, nil)
+			ctx.Status(http.StatusNoContent)
+		case errors.Is(err, pgx.ErrNoRows):
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		case errors.Is(err, store.ErrBuiltInRole), errors.Is(err, store.ErrRoleInUse):
+			ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+	})
+}
+
+type venueRolesRequest struct {
+	Assignments []struct {
+		Role    string `json:"role" binding:"required"`
+		VenueID string `json:"venueId" binding:"required"`
+	} `json:"assignments" binding:"required,dive"`
+}
+
+// registerVenueRoleRoutes manages role assignments limited to a single venue.
+func registerVenueRoleRoutes(group *gin.RouterGroup, repo *store.Store, revoked *revocation.Store, trail *audit.Client) {
+	group.PUT("/:id/venue-roles", authz.RequirePermission(authz.UserRolesAssign), func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		var req venueRolesRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		assignments := make([]store.VenueRole, 0, len(req.Assignments))
+		roleSet := make(map[string]bool)
+		roles := []string{}
+		for _, a := range req.Assignments {
+			venueID, err := uuid.Parse(a.VenueID)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid venueId " + a.VenueID})
+				return
+			}
+			role := strings.ToUpper(strings.TrimSpace(a.Role))
+			assignments = append(assignments, store.VenueRole{Role: role, VenueID: venueID})
+			if !roleSet[role] {
+				roleSet[role] = true
+				roles = append(roles, role)
+			}
+		}
+
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		if !checkGrantable(ctx, timeoutCtx, repo, roles) {
+			return
+		}
+		before, err := repo.GetUserByID(timeoutCtx, id)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		user, err := repo.SetVenueRoles(timeoutCtx, id, assignments)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		trail.RecordChange(ctx, "user.venue_roles.update", "user", user.ID.String(), userResponse(before), userResponse(user), nil)
+		if err := revoked.RevokeUser(timeoutCtx, user.ID.String()); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "roles updated but existing tokens could not be revoked: " + err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+}
+
+// checkGrantable writes a 4xx unless every role exists and the caller may grant it. Only RBAC
+// managers may hand out roles that themselves manage RBAC.
+func checkGrantable(ctx *gin.Context, reqCtx context.Context, repo *store.Store, roles []string) bool {
+	missing, err := repo.MissingRoles(reqCtx, roles)
+	if err != nil {
+		handleStoreError(ctx, err)
+		return false
+	}
+	if len(missing) > 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("unknown role %q", missing[0])})
+		return false
+	}
+	granted, err := repo.PermissionsForRoles(reqCtx, roles)
+	if err != nil {
+		handleStoreError(ctx, err)
+		return false
+	}
+	caller, _ := authz.FromHeaders(ctx.Request.Header)
+	for _, p := range granted {
+		if p == authz.RBACManage && !caller.Can(authz.RBACManage) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "granting " + authz.RBACManage + " requires it"})
+			return false
+		}
+	}
+	return true
+}
+
+// findRole returns the role called name, or nil when there is none.
+func findRole(ctx context.Context, repo *store.Store, name string) (*store.Role, error) {
+	roles, err := repo.ListRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if roles[i].Name == name {
+			return &roles[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func roleResponse(role *store.Role) gin.H {
+	perms := role.Permissions
+	if perms == nil {
+		perms = []string{}
+	}
+	return gin.H{
+		"name":        role.Name,
+		"description": role.Description,
+		"builtIn":     role.BuiltIn,
+		"permissions": perms,
+		"createdAt":   role.CreatedAt.Format(time.RFC3339),
+		"updatedAt":   role.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func validRoleName(name string) bool {
+	if name == "" || len(name) > 64 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/venue-master/platform/lib/authz"
+	"github.com/venue-master/platform/lib/revocation"
+	"github.com/venue-master/platform/lib/s3util"
+	"github.com/venue-master/platform/services/user-service/internal/erasure"
+	"github.com/venue-master/platform/services/user-service/internal/notification"
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+const (
+	retentionInterval = time.Hour
+	retentionBatch    = 100
+	retentionTimeout  = 10 * time.Minute
+)
+
+// retention enforces account deletion. Deletions are scheduled by the member or, once an account
+// has been inactive for inactiveAfter, by sweep itself; either way they run after coolingOff.
+// An erasure removes the member's data at every service first and anonymises the account here
+// last, so a failed service leaves the deletion due and it is retried on the next sweep.
+type retention struct {
+	repo     *store.Store
+	revoked  *revocation.Store
+	storage  s3util.StorageProvider
+	services []*erasure.Service
+	notify   *notification.Client
+	// inactiveAfter is zero when inactive accounts are kept indefinitely.
+	inactiveAfter time.Duration
+	coolingOff    time.Duration
+	logger        zerolog.Logger
+}
+
+type deletionRequest struct {
+	Password string `json:"password"`
+}
+
+// registerDeletionRoutes exposes deletion requests and activity tracking to the auth-service,
+// and the erasure receipts to privacy admins.
+func registerDeletionRoutes(group, internal *gin.RouterGroup, repo *store.Store, ret *retention) {
+	internal.POST("/:id/deletion", func(ctx *gin.Context) {
+		var req deletionRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		user, ok := checkPassword(ctx, repo, req.Password)
+		if !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		user, err := repo.ScheduleDeletion(timeoutCtx, user.ID, time.Now().Add(ret.coolingOff))
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	internal.DELETE("/:id/deletion", func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		user, err := repo.CancelDeletion(timeoutCtx, id)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	internal.POST("/:id/activity", func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		if err := repo.TouchActivity(timeoutCtx, id); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.Status(http.StatusNoContent)
+	})
+
+	group.GET("/erasures", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		var query store.ErasureQuery
+		if raw := ctx.Query("userId"); raw != "" {
+			id, err := uuid.Parse(raw)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid userId"})
+				return
+			}
+			query.UserID = id
+		}
+		var ok bool
+		if query.From, ok = timeParam(ctx, "from"); !ok {
+			return
+		}
+		if query.To, ok = timeParam(ctx, "to"); !ok {
+			return
+		}
+		if query.Limit, query.Offset, ok = paginationParams(ctx); !ok {
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		receipts, total, err := repo.ListErasureReceipts(timeoutCtx, query)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		out := make([]gin.H, 0, len(receipts))
+		for _, receipt := range receipts {
+			out = append(out, receiptResponse(receipt))
+		}
+		ctx.Header("X-Total-Count", fmt.Sprint(total))
+		ctx.JSON(http.StatusOK, out)
+	})
+
+	group.GET("/erasures/:id", authz.RequirePermission(authz.PrivacyManage), func(ctx *gin.Context) {
+		id, err := uuid.Parse(ctx.Param("id"))
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid receipt id"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+		receipt, err := repo.GetErasureReceipt(timeoutCtx, id)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				ctx.JSON(http.StatusNotFound, gin.H{"error": "erasure receipt not found"})
+				return
+			}
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusOK, receiptResponse(receipt))
+	})
+}
+
+// receiptResponse renders a receipt; verified reports whether it still matches its digest.
+func receiptResponse(receipt *erasure.Receipt) gin.H {
+	services := make([]gin.H, 0, len(receipt.Services))
+	for _, s := range receipt.Services {
+		services = append(services, gin.H{
+			"service":  s.Service,
+			"records":  s.Records,
+			"erasedAt": s.ErasedAt.Format(time.RFC3339),
+		})
+	}
+	return gin.H{
+		"id":          receipt.ID.String(),
+		"userId":      receipt.UserID.String(),
+		"reason":      receipt.Reason,
+		"requestedAt": receipt.RequestedAt.Format(time.RFC3339),
+		"erasedAt":    receipt.ErasedAt.Format(time.RFC3339),
+		"services":    services,
+		"digest":      receipt.Digest,
+		"verified":    receipt.Verify(),
+	}
+}
+
+// run sweeps for inactive accounts and due deletions until ctx is done.
+func (r *retention) run(ctx context.Context) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweepCtx, cancel := context.WithTimeout(ctx, retentionTimeout)
+			r.sweep(sweepCtx)
+			cancel()
+		}
+	}
+}
+
+func (r *retention) sweep(ctx context.Context) {
+	if r.inactiveAfter > 0 {
+		r.scheduleInactive(ctx)
+	}
+	for {
+		due, err := r.repo.DueDeletions(ctx, retentionBatch)
+		if err != nil {
+			r.logger.Error().Err(err).Msg("load due deletions failed")
+			return
+		}
+		erased := 0
+		for _, user := range due {
+			if r.erase(ctx, user) {
+				erased++
+			}
+		}
+		// Stop when the batch was short, or when nothing in it could be erased this time.
+		if len(due) < retentionBatch || erased == 0 {
+			return
+		}
+	}
+}
+
+// scheduleInactive schedules deletion of accounts nobody has signed in to for inactiveAfter and
+// warns their owners; signing in before the deletion runs cancels it.
+func (r *retention) scheduleInactive(ctx context.Context) {
+	for {
+		now := time.Now()
+		users, err := r.repo.ScheduleInactiveDeletions(ctx, now.Add(-r.inactiveAfter), now.Add(r.coolingOff), retentionBatch)
+		if err != nil {
+			r.logger.Error().Err(err).Msg("schedule inactive account deletions failed")
+			return
+		}
+		for _, user := range users {
+			payload := notification.NotifyPayload{
+				UserID:  user.ID.String(),
+				Title:   "Your account will be deleted",
+				Message: fmt.Sprintf("You have not signed in to Venue Master since %s. Under our retention policy your account and personal information will be deleted on %s unless you sign in before then.", user.LastActiveAt.UTC().Format("January 2, 2006"), user.DeletionScheduledAt.UTC().Format("January 2, 2006")),
+				Channel: "email",
+			}
+			if err := r.notify.Send(ctx, payload); err != nil {
+				r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("failed to send inactivity deletion warning")
+			}
+		}
+		if len(users) < retentionBatch {
+			return
+		}
+	}
+}
+
+// erase erases one account and reports whether it succeeded. The account is marked first, so a
+// member cancelling or signing in meanwhile cannot stop an erasure other services have begun.
+func (r *retention) erase(ctx context.Context, user *store.User) bool {
+	user, err := r.repo.BeginErasure(ctx, user.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// Cancelled or postponed since the sweep listed it.
+		return false
+	}
+	if err != nil {
+		r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("start erasure failed")
+		return false
+	}
+	receipt := &erasure.Receipt{ID: uuid.New(), UserID: user.ID, Reason: user.DeletionReason}
+	if user.DeletionRequestedAt != nil {
+		receipt.RequestedAt = *user.DeletionRequestedAt
+	}
+	for _, service := range r.services {
+		result, err := service.Erase(ctx, user.ID.String())
+		if err != nil {
+			r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("erasure failed; retrying on the next sweep")
+			return false
+		}
+		receipt.Services = append(receipt.Services, result)
+	}
+	receipt.ErasedAt = time.Now()
+	if err := receipt.Seal(); err != nil {
+		r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("seal erasure receipt failed")
+		return false
+	}
+
+	objects, err := r.repo.EraseUser(ctx, receipt)
+	if errors.Is(err, store.ErrAccountErased) {
+		// Another replica got there first.
+		return true
+	}
+	if err != nil {
+		r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("erase account failed")
+		return false
+	}
+	keys := objects.ExportKeys
+	if objects.AvatarKey != "" {
+		keys = append(keys, objects.AvatarKey)
+	}
+	for _, key := range keys {
+		if err := r.storage.Delete(ctx, key); err != nil && !errors.Is(err, s3util.ErrNotFound) {
+			r.logger.Error().Err(err).Str("userId", user.ID.String()).Str("key", key).Msg("delete erased account file failed")
+		}
+	}
+	if err := r.revoked.RevokeUser(ctx, user.ID.String()); err != nil {
+		r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("revoke erased account tokens failed")
+	}
+	r.logger.Info().Str("userId", user.ID.String()).Str("receiptId", receipt.ID.String()).Str("reason", receipt.Reason).Msg("account erased")
+
+	if user.Dependent {
+		// Dependents have no mailbox; the primary account holder removed them or closed the household.
+		return true
+	}
+	// The address is gone from our records, so it is passed explicitly.
+	payload := notification.NotifyPayload{
+		UserID:  user.ID.String(),
+		Title:   "Your account has been deleted",
+		Message: fmt.Sprintf("Your Venue Master account and the personal information we held about you have been deleted. Quote receipt %s if you have any questions about this deletion.", receipt.ID),
+		Channel: "email",
+		To:      user.Email,
+	}
+	if err := r.notify.Send(ctx, payload); err != nil {
+		r.logger.Error().Err(err).Str("userId", user.ID.String()).Msg("failed to send account deletion confirmation")
+	}
+	return true
+}
+
+// envDays reads a whole number of days from key; negative or malformed values use fallback.
+func envDays(key string, fallback int) time.Duration {
+	days, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || days < 0 {
+		days = fallback
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
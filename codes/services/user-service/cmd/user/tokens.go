@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/services/user-service/internal/store"
+)
+
+type tokenRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Purpose string `json:"purpose" binding:"required"`
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// registerTokenRoutes exposes the token endpoints the auth-service drives the password reset
+// and email verification flows with. They are not routed through the gateway.
+func registerTokenRoutes(group *gin.RouterGroup, repo *store.Store) {
+	group.POST("/tokens", func(ctx *gin.Context) {
+		var req tokenRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.GetUserByEmail(timeoutCtx, req.Email)
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		if req.Purpose == store.PurposeEmailVerify && user.EmailVerified {
+			ctx.JSON(http.StatusConflict, gin.H{"error": "email already verified"})
+			return
+		}
+		token, err := repo.CreateToken(timeoutCtx, user.ID, req.Purpose)
+		if errors.Is(err, store.ErrUnknownPurpose) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			handleStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusCreated, gin.H{"token": token, "user": userResponse(user)})
+	})
+
+	group.POST("/password/reset", func(ctx *gin.Context) {
+		var req resetPasswordRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		hash, err := store.HashPassword(req.Password)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "password hashing failed"})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.ResetPassword(timeoutCtx, req.Token, hash)
+		if err != nil {
+			handleTokenError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+
+	group.POST("/email/verify", func(ctx *gin.Context) {
+		var req verifyEmailRequest
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx.Request.Context(), 5*time.Second)
+		defer cancel()
+
+		user, err := repo.VerifyEmail(timeoutCtx, req.Token)
+		if err != nil {
+			handleTokenError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, userResponse(user))
+	})
+}
+
+func handleTokenError(ctx *gin.Context, err error) {
+	if errors.Is(err, store.ErrInvalidToken) || errors.Is(err, pgx.ErrNoRows) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": store.ErrInvalidToken.Error()})
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,157 @@
+// Package dataexport assembles a member's right-of-access archive: the records each service
+// holds about them, packaged as a zip with a JSON and a CSV file per section.
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Record is one exported row, as the owning service rendered it.
+type Record = map[string]any
+
+// Section is one kind of data, e.g. "bookings", and where it came from.
+type Section struct {
+	Name    string
+	Source  string
+	Records []Record
+}
+
+type manifestEntry struct {
+	Name    string   `json:"name"`
+	Source  string   `json:"source"`
+	Records int      `json:"records"`
+	Files   []string `json:"files"`
+}
+
+type manifest struct {
+	UserID      string          `json:"userId"`
+	GeneratedAt string          `json:"generatedAt"`
+	Sections    []manifestEntry `json:"sections"`
+}
+
+const readme = `This archive holds the personal information Venue Master keeps about you.
+
+manifest.json lists each section, the service it came from and how many records it holds.
+Every section is included twice: <section>.json keeps the full structure, <section>.csv
+flattens it for spreadsheets (nested values are written as JSON).
+`
+
+// WriteArchive writes sections for userID to w as a zip archive.
+func WriteArchive(w io.Writer, userID string, generatedAt time.Time, sections []Section) error {
+	zw := zip.NewWriter(w)
+	info := manifest{UserID: userID, GeneratedAt: generatedAt.UTC().Format(time.RFC3339)}
+	if err := writeFile(zw, "README.txt", generatedAt, func(f io.Writer) error {
+		_, err := io.WriteString(f, readme)
+		return err
+	}); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, section := range sections {
+		if seen[section.Name] {
+			return fmt.Errorf("duplicate section %q", section.Name)
+		}
+		seen[section.Name] = true
+		records := section.Records
+		if records == nil {
+			records = []Record{}
+		}
+		jsonName, csvName := section.Name+".json", section.Name+".csv"
+		if err := writeFile(zw, jsonName, generatedAt, func(f io.Writer) error {
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			return enc.Encode(records)
+		}); err != nil {
+			return err
+		}
+		if err := writeFile(zw, csvName, generatedAt, func(f io.Writer) error {
+			return writeCSV(f, records)
+		}); err != nil {
+			return err
+		}
+		info.Sections = append(info.Sections, manifestEntry{
+			Name:    section.Name,
+			Source:  section.Source,
+			Records: len(records),
+			Files:   []string{jsonName, csvName},
+		})
+	}
+	if err := writeFile(zw, "manifest.json", generatedAt, func(f io.Writer) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeFile(zw *zip.Writer, name string, modified time.Time, write func(io.Writer) error) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeCSV writes records with one column per key found in any record, sorted by name. Nested
+// values are encoded as JSON.
+func writeCSV(w io.Writer, records []Record) error {
+	seen := map[string]bool{}
+	var columns []string
+	for _, record := range records {
+		for key := range record {
+			if !seen[key] {
+				seen[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, record := range records {
+		for i, column := range columns {
+			cell, err := csvCell(record[column])
+			if err != nil {
+				return err
+			}
+			row[i] = cell
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func csvCell(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool, int, int64, json.Number:
+		return fmt.Sprint(v), nil
+	default:
+		raw, err := json.Marshal(v)
+		return string(raw), err
+	}
+}
@@ -0,0 +1,107 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func readArchive(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(body)
+	}
+	return files
+}
+
+func TestWriteArchive(t *testing.T) {
+	sections := []Section{
+		{Name: "profile", Source: "user-service", Records: []Record{{"email": "a@example.com", "roles": []string{"MEMBER"}}}},
+		{Name: "bookings", Source: "booking-service", Records: []Record{
+			{"id": "b1", "amountCents": json.Number("2500")},
+			{"id": "b2", "status": "CANCELLED"},
+		}},
+		{Name: "notifications", Source: "notification-service"},
+	}
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, "user-1", time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC), sections); err != nil {
+		t.Fatal(err)
+	}
+	files := readArchive(t, buf.Bytes())
+
+	for _, name := range []string{"README.txt", "manifest.json", "profile.json", "profile.csv", "bookings.json", "bookings.csv", "notifications.json", "notifications.csv"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("archive is missing %s", name)
+		}
+	}
+	if got, want := files["bookings.csv"], "amountCents,id,status\n2500,b1,\n,b2,CANCELLED\n"; got != want {
+		t.Errorf("bookings.csv = %q, want %q", got, want)
+	}
+	if got, want := files["profile.csv"], "email,roles\na@example.com,\"[\"\"MEMBER\"\"]\"\n"; got != want {
+		t.Errorf("profile.csv = %q, want %q", got, want)
+	}
+	if got := files["notifications.json"]; got != "[]\n" {
+		t.Errorf("empty section json = %q, want []", got)
+	}
+
+	var info manifest
+	if err := json.Unmarshal([]byte(files["manifest.json"]), &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.UserID != "user-1" || info.GeneratedAt != "2026-10-01T12:00:00Z" || len(info.Sections) != 3 {
+		t.Fatalf("manifest = %+v", info)
+	}
+	if s := info.Sections[1]; s.Name != "bookings" || s.Source != "booking-service" || s.Records != 2 {
+		t.Errorf("bookings manifest entry = %+v", s)
+	}
+}
+
+func TestSourceFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/privacy/users/user-1/export" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"payments":[{"id":"p1","amountCents":12345678901}],"bookings":[]}`)
+	}))
+	defer server.Close()
+
+	sections, err := NewSource("booking-service", server.URL, nil).Fetch(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 2 || sections[0].Name != "bookings" || sections[1].Name != "payments" {
+		t.Fatalf("sections = %+v", sections)
+	}
+	if got := sections[1].Records[0]["amountCents"]; got != json.Number("12345678901") {
+		t.Errorf("amountCents = %#v, want exact json.Number", got)
+	}
+	if sections[1].Source != "booking-service" {
+		t.Errorf("source = %q", sections[1].Source)
+	}
+
+	if _, err := NewSource("booking-service", server.URL, nil).Fetch(context.Background(), "missing"); err == nil {
+		t.Error("expected an error for a non-200 response")
+	}
+}
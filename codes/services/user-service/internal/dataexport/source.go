@@ -0,0 +1,60 @@
+package dataexport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// Source is a service holding personal data. It serves GET
+// /v1/privacy/users/:id/export, answering an object that maps section names to record lists,
+// e.g. {"bookings": [...]}.
+type Source struct {
+	Name       string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewSource returns a Source for the service at baseURL. transport signs requests for it (see
+// svcauth); nil uses the default transport.
+func NewSource(name, baseURL string, transport http.RoundTripper) *Source {
+	return &Source{
+		Name:       name,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+// Fetch returns the sections the service holds about userID.
+func (s *Source) Fetch(ctx context.Context, userID string) ([]Section, error) {
+	endpoint := fmt.Sprintf("%s/v1/privacy/users/%s/export", s.baseURL, url.PathEscape(userID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s responded with %d", s.Name, resp.StatusCode)
+	}
+	var body map[string][]Record
+	dec := json.NewDecoder(resp.Body)
+	// Keep numbers exactly as the service sent them (ids, cents).
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: decode export: %w", s.Name, err)
+	}
+	sections := make([]Section, 0, len(body))
+	for name, records := range body {
+		sections = append(sections, Section{Name: name, Source: s.Name, Records: records})
+	}
+	sort.Slice(sections, func(i, j int) bool { return sections[i].Name < sections[j].Name })
+	return sections, nil
+}
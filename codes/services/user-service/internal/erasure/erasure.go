@@ -0,0 +1,134 @@
+// Package erasure removes a member's personal data from the services that hold it and seals
+// the receipt recording what was removed.
+package erasure
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ServiceResult is one service's part of an erasure: how many records of each kind it erased
+// or pseudonymised.
+type ServiceResult struct {
+	Service  string         `json:"service"`
+	Records  map[string]int `json:"records"`
+	ErasedAt time.Time      `json:"erasedAt"`
+}
+
+// Receipt is the auditable record of an erasure. Digest is the SHA-256 of every other field, so
+// an edited receipt no longer verifies.
+type Receipt struct {
+	ID          uuid.UUID       `json:"id"`
+	UserID      uuid.UUID       `json:"userId"`
+	Reason      string          `json:"reason"`
+	RequestedAt time.Time       `json:"requestedAt"`
+	ErasedAt    time.Time       `json:"erasedAt"`
+	Services    []ServiceResult `json:"services"`
+	Digest      string          `json:"digest"`
+}
+
+// Seal computes the receipt's digest.
+func (r *Receipt) Seal() error {
+	digest, err := r.digest()
+	if err != nil {
+		return err
+	}
+	r.Digest = digest
+	return nil
+}
+
+// Verify reports whether the receipt still matches its digest.
+func (r *Receipt) Verify() bool {
+	digest, err := r.digest()
+	return err == nil && digest == r.Digest
+}
+
+// digest hashes a canonical form of the receipt: times in UTC at microsecond precision (what
+// PostgreSQL keeps), map keys sorted by encoding/json.
+func (r *Receipt) digest() (string, error) {
+	canonical := func(t time.Time) string {
+		return t.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)
+	}
+	type service struct {
+		Service  string         `json:"service"`
+		Records  map[string]int `json:"records"`
+		ErasedAt string         `json:"erasedAt"`
+	}
+	body := struct {
+		ID          string    `json:"id"`
+		UserID      string    `json:"userId"`
+		Reason      string    `json:"reason"`
+		RequestedAt string    `json:"requestedAt"`
+		ErasedAt    string    `json:"erasedAt"`
+		Services    []service `json:"services"`
+	}{
+		ID:          r.ID.String(),
+		UserID:      r.UserID.String(),
+		Reason:      r.Reason,
+		RequestedAt: canonical(r.RequestedAt),
+		ErasedAt:    canonical(r.ErasedAt),
+		Services:    []service{},
+	}
+	for _, s := range r.Services {
+		body.Services = append(body.Services, service{Service: s.Service, Records: s.Records, ErasedAt: canonical(s.ErasedAt)})
+	}
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Service is a service holding personal data. It serves POST /v1/privacy/users/:id/erase,
+// which must be idempotent and answer {"records": {"<kind>": n}}.
+type Service struct {
+	Name       string
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewService returns a Service for the service at baseURL. transport signs requests for it
+// (see svcauth); nil uses the default transport.
+func NewService(name, baseURL string, transport http.RoundTripper) *Service {
+	return &Service{
+		Name:       name,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second, Transport: transport},
+	}
+}
+
+// Erase asks the service to erase userID's personal data.
+func (s *Service) Erase(ctx context.Context, userID string) (ServiceResult, error) {
+	endpoint := fmt.Sprintf("%s/v1/privacy/users/%s/erase", s.baseURL, url.PathEscape(userID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, nil)
+	if err != nil {
+		return ServiceResult{}, err
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return ServiceResult{}, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ServiceResult{}, fmt.Errorf("%s responded with %d", s.Name, resp.StatusCode)
+	}
+	var body struct {
+		Records map[string]int `json:"records"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return ServiceResult{}, fmt.Errorf("%s: decode erasure: %w", s.Name, err)
+	}
+	if body.Records == nil {
+		body.Records = map[string]int{}
+	}
+	return ServiceResult{Service: s.Name, Records: body.Records, ErasedAt: time.Now()}, nil
+}
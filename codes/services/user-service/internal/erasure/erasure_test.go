@@ -0,0 +1,77 @@
+package erasure
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testReceipt() Receipt {
+	erasedAt := time.Date(2026, 10, 1, 9, 30, 0, 123456789, time.FixedZone("EDT", -4*3600))
+	return Receipt{
+		ID:          uuid.MustParse("11111111-1111-1111-1111-111111111111"),
+		UserID:      uuid.MustParse("22222222-2222-2222-2222-222222222222"),
+		Reason:      "requested",
+		RequestedAt: erasedAt.AddDate(0, 0, -30),
+		ErasedAt:    erasedAt,
+		Services: []ServiceResult{
+			{Service: "booking-service", Records: map[string]int{"bookings": 4, "cancelledBookings": 1}, ErasedAt: erasedAt},
+		},
+	}
+}
+
+func TestReceiptSealAndVerify(t *testing.T) {
+	receipt := testReceipt()
+	if err := receipt.Seal(); err != nil {
+		t.Fatal(err)
+	}
+	if len(receipt.Digest) != 64 {
+		t.Fatalf("digest = %q, want 64 hex characters", receipt.Digest)
+	}
+	if !receipt.Verify() {
+		t.Fatal("freshly sealed receipt does not verify")
+	}
+
+	// A round trip through PostgreSQL drops nanoseconds and the zone; the digest must survive it.
+	stored := receipt
+	stored.RequestedAt = receipt.RequestedAt.UTC().Truncate(time.Microsecond)
+	stored.ErasedAt = receipt.ErasedAt.In(time.Local).Truncate(time.Microsecond)
+	stored.Services = []ServiceResult{receipt.Services[0]}
+	stored.Services[0].ErasedAt = receipt.Services[0].ErasedAt.UTC().Truncate(time.Microsecond)
+	if !stored.Verify() {
+		t.Error("receipt read back from the database does not verify")
+	}
+
+	tampered := receipt
+	tampered.Services = []ServiceResult{{Service: "booking-service", Records: map[string]int{"bookings": 3, "cancelledBookings": 1}, ErasedAt: receipt.ErasedAt}}
+	if tampered.Verify() {
+		t.Error("tampered receipt verifies")
+	}
+}
+
+func TestServiceErase(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/privacy/users/user-1/erase" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = io.WriteString(w, `{"records":{"bookings":2}}`)
+	}))
+	defer server.Close()
+
+	result, err := NewService("booking-service", server.URL, nil).Erase(context.Background(), "user-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Service != "booking-service" || result.Records["bookings"] != 2 || result.ErasedAt.IsZero() {
+		t.Errorf("result = %+v", result)
+	}
+	if _, err := NewService("booking-service", server.URL, nil).Erase(context.Background(), "other"); err == nil {
+		t.Error("expected an error for a non-200 response")
+	}
+}
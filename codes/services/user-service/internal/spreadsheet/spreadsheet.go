@@ -0,0 +1,441 @@
+// Package spreadsheet reads and writes the tables admins exchange with user-service: CSV and
+// the first worksheet of an XLSX workbook. Only cell text is kept; formulas, styles and further
+// sheets are ignored.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Formats.
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+const (
+	// MaxRows caps the rows Read returns, blank ones included.
+	MaxRows = 100_000
+	// maxPartBytes caps each decompressed XLSX part, since a small zip can expand enormously.
+	maxPartBytes = 50 << 20
+)
+
+var (
+	// ErrUnsupportedFormat is returned for formats other than CSV and XLSX.
+	ErrUnsupportedFormat = errors.New("unsupported spreadsheet format; use csv or xlsx")
+	// ErrTooManyRows is returned for tables longer than MaxRows.
+	ErrTooManyRows = fmt.Errorf("spreadsheet has more than %d rows", MaxRows)
+)
+
+// ContentType returns the MIME type of format.
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// FormatOf guesses the format of a file from its name.
+func FormatOf(filename string) (string, error) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv", ".txt":
+		return CSV, nil
+	case ".xlsx":
+		return XLSX, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Read returns the rows of data in format. Trailing empty cells are trimmed and blank rows
+// are kept, so row indexes match the line numbers people see in their spreadsheet app.
+func Read(data []byte, format string) ([][]string, error) {
+	switch format {
+	case CSV:
+		return readCSV(data)
+	case XLSX:
+		return readXLSX(data)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// Write writes rows to w in format. CSV cells that a spreadsheet app would run as a formula are
+// prefixed with a quote; Read removes it again.
+func Write(w io.Writer, format string, rows [][]string) error {
+	switch format {
+	case CSV:
+		cw := csv.NewWriter(w)
+		for _, row := range rows {
+			safe := make([]string, len(row))
+			for i, cell := range row {
+				safe[i] = neutralizeFormula(cell)
+			}
+			if err := cw.Write(safe); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	case XLSX:
+		return writeXLSX(w, rows)
+	}
+	return ErrUnsupportedFormat
+}
+
+// formulaPrefixes are the leading characters that make spreadsheet apps evaluate a CSV cell.
+const formulaPrefixes = "=+-@\t\r"
+
+// neutralizeFormula prefixes cell with a quote when an app would otherwise evaluate it, so
+// exported names such as "=HYPERLINK(...)" stay text.
+func neutralizeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune(formulaPrefixes, rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// restoreFormula undoes neutralizeFormula, so exported files import unchanged.
+func restoreFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.ContainsRune(formulaPrefixes, rune(cell[1])) {
+		return cell[1:]
+	}
+	return cell
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// Excel prefixes UTF-8 CSV exports with a byte order mark.
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	var (
+		rows     [][]string
+		consumed int64 // input offset of the end of the previous record
+		lines    int   // lines up to consumed
+	)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// The reader skips blank lines; put them back so row numbers stay aligned. A quoted
+		// value may span lines, so count from where the previous record ended.
+		start, _ := r.FieldPos(0)
+		if start > MaxRows {
+			return nil, ErrTooManyRows
+		}
+		for ; lines < start-1; lines++ {
+			rows = append(rows, nil)
+		}
+		for i := range record {
+			record[i] = restoreFormula(record[i])
+		}
+		rows = append(rows, trimRow(record))
+		offset := r.InputOffset()
+		lines += bytes.Count(data[consumed:offset], []byte("\n"))
+		consumed = offset
+	}
+}
+
+// XLSX part names. Workbooks written by other tools may place the first sheet elsewhere, so
+// reading follows the workbook relationships instead of assuming sheetPart.
+const (
+	workbookPart = "xl/workbook.xml"
+	sheetPart    = "xl/worksheets/sheet1.xml"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is rich or plain text: a <t> element or runs of them.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			R      string   `xml:"r,attr"`
+			T      string   `xml:"t,attr"`
+			V      string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx workbook: %w", err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared []string
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		var sst struct {
+			Items []xlsxText `xml:"si"`
+		}
+		if err := decodePart(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			shared = append(shared, item.String())
+		}
+	}
+
+	name, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	var sheet xlsxSheet
+	if err := decodePart(files[name], &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		index := row.R - 1
+		if index < len(rows) {
+			index = len(rows)
+		}
+		// The r attribute is trusted for padding, so bound it before allocating.
+		if index >= MaxRows {
+			return nil, ErrTooManyRows
+		}
+		for len(rows) < index {
+			rows = append(rows, nil)
+		}
+		var cells []string
+		for _, cell := range row.Cells {
+			col := len(cells)
+			if cell.R != "" {
+				if col, err = columnIndex(cell.R); err != nil {
+					return nil, err
+				}
+			}
+			value := cell.V
+			switch cell.T {
+			case "s":
+				i, err := strconv.Atoi(cell.V)
+				if err != nil || i < 0 || i >= len(shared) {
+					return nil, fmt.Errorf("cell %s: bad shared string %q", cell.R, cell.V)
+				}
+				value = shared[i]
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = map[string]string{"0": "FALSE", "1": "TRUE"}[cell.V]
+			}
+			for len(cells) < col {
+				cells = append(cells, "")
+			}
+			if col < len(cells) {
+				cells[col] = value
+			} else {
+				cells = append(cells, value)
+			}
+		}
+		rows = append(rows, trimRow(cells))
+	}
+	return rows, nil
+}
+
+// firstSheet returns the part name of the workbook's first worksheet.
+func firstSheet(files map[string]*zip.File) (string, error) {
+	wb, ok := files[workbookPart]
+	if !ok {
+		return "", errors.New("not an xlsx workbook: missing " + workbookPart)
+	}
+	var workbook xlsxWorkbook
+	if err := decodePart(wb, &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	if rels, ok := files["xl/_rels/workbook.xml.rels"]; ok {
+		var relationships xlsxRelationships
+		if err := decodePart(rels, &relationships); err != nil {
+			return "", err
+		}
+		for _, rel := range relationships.Relationships {
+			if rel.ID != workbook.Sheets[0].RelID {
+				continue
+			}
+			name := path.Join("xl", rel.Target)
+			if strings.HasPrefix(rel.Target, "/") {
+				name = strings.TrimPrefix(rel.Target, "/")
+			}
+			if _, ok := files[name]; ok {
+				return name, nil
+			}
+		}
+	}
+	if _, ok := files[sheetPart]; ok {
+		return sheetPart, nil
+	}
+	return "", errors.New("workbook has no readable first sheet")
+}
+
+// decodePart decodes one XML part, reading at most maxPartBytes of it.
+func decodePart(f *zip.File, v any) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	limited := &io.LimitedReader{R: rc, N: maxPartBytes + 1}
+	err = xml.NewDecoder(limited).Decode(v)
+	if limited.N <= 0 {
+		return fmt.Errorf("%s: larger than %d MB uncompressed", f.Name, maxPartBytes>>20)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the zero-based column of a cell reference such as "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	letters := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		letters++
+	}
+	if letters == 0 || letters > 3 {
+		return 0, fmt.Errorf("bad cell reference %q", ref)
+	}
+	return col - 1, nil
+}
+
+// columnName is the inverse of columnIndex without the row.
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+func trimRow(cells []string) []string {
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	for len(cells) > 0 && cells[len(cells)-1] == "" {
+		cells = cells[:len(cells)-1]
+	}
+	return cells
+}
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+)
+
+// writeXLSX writes rows as a single-sheet workbook with every cell stored as inline text, so
+// values such as phone numbers keep their leading + and zeros.
+func writeXLSX(w io.Writer, rows [][]string) error {
+	zw := zip.NewWriter(w)
+	static := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{workbookPart, xlsxWorkbookXML},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range static {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create(sheetPart)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if value == "" {
+				continue
+			}
+			fmt.Fprintf(&b, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, columnName(j), i+1)
+			if err := xml.EscapeText(&b, []byte(value)); err != nil {
+				return err
+			}
+			b.WriteString(`</t></is></c>`)
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	if _, err := b.WriteTo(f); err != nil {
+		return err
+	}
+	return zw.Close()
+}
@@ -0,0 +1,169 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := []byte("\xef\xbb\xbfEmail, First Name,Last Name\n" +
+		"ada@example.com,Ada,Lovelace,,\n" +
+		"\n" +
+		"\"grace@example.com\",\"Grace\nBrewster\",Hopper\n" +
+		"alan@example.com,Alan,Turing\n")
+	rows, err := Read(data, CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"Email", "First Name", "Last Name"},
+		{"ada@example.com", "Ada", "Lovelace"},
+		nil,
+		{"grace@example.com", "Grace\nBrewster", "Hopper"},
+		{"alan@example.com", "Alan", "Turing"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestWriteCSVNeutralizesFormulas(t *testing.T) {
+	rows := [][]string{
+		{"email", "first name", "phone"},
+		{"eve@example.com", "=HYPERLINK(\"http://evil.example\",\"x\")", "+14165550100"},
+		{"mal@example.com", "@SUM(A1)", "-1"},
+		{"tab@example.com", "\t=1", "\r=1"},
+		{"ok@example.com", "a=b", ""},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, CSV, rows); err != nil {
+		t.Fatal(err)
+	}
+	want := "email,first name,phone\n" +
+		"eve@example.com,\"'=HYPERLINK(\"\"http://evil.example\"\",\"\"x\"\")\",'+14165550100\n" +
+		"mal@example.com,'@SUM(A1),'-1\n" +
+		"tab@example.com,'\t=1,\"'\r=1\"\n" +
+		"ok@example.com,a=b,\n"
+	if buf.String() != want {
+		t.Fatalf("csv = %q, want %q", buf.String(), want)
+	}
+	got, err := Read(buf.Bytes(), CSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Read trims cells, so the tab and CR cells come back without their leading whitespace.
+	rows[3] = []string{"tab@example.com", "=1", "=1"}
+	rows[4] = rows[4][:2]
+	if !reflect.DeepEqual(got, rows) {
+		t.Fatalf("read back = %q, want %q", got, rows)
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"email", "phone", "note"},
+		{"ada@example.com", "+14165550100", "<b>&</b>"},
+		{},
+		{"alan@example.com", "", "x"},
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, XLSX, rows); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Read(buf.Bytes(), XLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{rows[0], rows[1], nil, {"alan@example.com", "", "x"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %q, want %q", got, want)
+	}
+}
+
+// TestReadXLSXSharedStrings reads a workbook laid out like Excel's own: shared strings, rich
+// text, numbers and a sheet found through the workbook relationships.
+func TestReadXLSXSharedStrings(t *testing.T) {
+	rows, err := Read(zipParts(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Members" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/members.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>email</t></si><si><t>age</t></si><si><r><t>ada@</t></r><r><t>example.com</t></r></si></sst>`,
+		"xl/worksheets/members.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="C3"><v>36</v></c><c r="D3" t="b"><v>1</v></c></row>
+</sheetData></worksheet>`,
+	}), XLSX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"email", "", "age"}, nil, {"ada@example.com", "", "36", "TRUE"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+}
+
+// TestReadXLSXLimits feeds workbooks that are tiny on disk but would need huge allocations: a
+// row numbered far past MaxRows, and a sheet that inflates beyond maxPartBytes.
+func TestReadXLSXLimits(t *testing.T) {
+	sheet := func(data string) []byte {
+		return zipParts(t, map[string]string{
+			"xl/workbook.xml":          `<workbook><sheets><sheet/></sheets></workbook>`,
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + data + `</sheetData></worksheet>`,
+		})
+	}
+	if _, err := Read(sheet(`<row r="100000000"><c t="inlineStr"><is><t>x</t></is></c></row>`), XLSX); !errors.Is(err, ErrTooManyRows) {
+		t.Fatalf("far row: err = %v, want ErrTooManyRows", err)
+	}
+	bomb := sheet(strings.Repeat(" ", maxPartBytes+1))
+	if len(bomb) > 1<<20 {
+		t.Fatalf("test workbook is %d bytes; it should compress well", len(bomb))
+	}
+	if _, err := Read(bomb, XLSX); err == nil || !strings.Contains(err.Error(), "uncompressed") {
+		t.Fatalf("inflating sheet: err = %v, want size error", err)
+	}
+}
+
+func TestReadRejectsOtherFiles(t *testing.T) {
+	if _, err := Read([]byte("email\n"), XLSX); err == nil {
+		t.Fatal("expected an error for a CSV read as XLSX")
+	}
+	if _, err := FormatOf("members.xls"); err != ErrUnsupportedFormat {
+		t.Fatalf("FormatOf(.xls) = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func zipParts(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestColumnNames(t *testing.T) {
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(col); got != name {
+			t.Errorf("columnName(%d) = %q, want %q", col, got, name)
+		}
+		if got, err := columnIndex(name + "7"); err != nil || got != col {
+			t.Errorf("columnIndex(%q) = %d, %v, want %d", name+"7", got, err, col)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Policy document kinds.
+const (
+	PolicyTerms     = "terms"
+	PolicyPrivacy   = "privacy"
+	PolicyMarketing = "marketing"
+)
+
+// Consent purposes. Terms and privacy record acceptance of that policy document; the marketing
+// purposes are per-channel opt-ins covered by the marketing document.
+const (
+	PurposeTerms          = "terms"
+	PurposePrivacy        = "privacy"
+	PurposeMarketingEmail = "marketing_email"
+	PurposeMarketingSMS   = "marketing_sms"
+	PurposeMarketingPush  = "marketing_push"
+)
+
+// ConsentPurposes maps each purpose to the policy document kind it is given under, in the order
+// the apps present them.
+var ConsentPurposes = []struct {
+	Purpose string
+	Policy  string
+}{
+	{PurposeTerms, PolicyTerms},
+	{PurposePrivacy, PolicyPrivacy},
+	{PurposeMarketingEmail, PolicyMarketing},
+	{PurposeMarketingSMS, PolicyMarketing},
+	{PurposeMarketingPush, PolicyMarketing},
+}
+
+// ErrPolicyVersionConflict is returned when two versions of a policy are published at once.
+var ErrPolicyVersionConflict = errors.New("another version of this policy was published at the same time")
+
+// PolicyDocument is one published version of a policy.
+type PolicyDocument struct {
+	ID          uuid.UUID
+	Kind        string
+	Version     int
+	Title       string
+	URL         string
+	Summary     string
+	Body        string
+	Required    bool
+	PublishedBy *uuid.UUID
+	PublishedAt time.Time
+}
+
+const policyColumns = `id, kind, version, title, url, summary, body, required, published_by, published_at`
+
+func scanPolicy(row pgx.Row) (*PolicyDocument, error) {
+	var p PolicyDocument
+	if err := row.Scan(&p.ID, &p.Kind, &p.Version, &p.Title, &p.URL, &p.Summary, &p.Body, &p.Required, &p.PublishedBy, &p.PublishedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func collectPolicies(rows pgx.Rows, err error) ([]*PolicyDocument, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	policies := []*PolicyDocument{}
+	for rows.Next() {
+		policy, err := scanPolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, rows.Err()
+}
+
+// PublishPolicy stores doc as the next version of its kind.
+func (s *Store) PublishPolicy(ctx context.Context, doc PolicyDocument) (*PolicyDocument, error) {
+	policy, err := scanPolicy(s.pool.QueryRow(ctx, `
+        INSERT INTO policy_documents (id, kind, version, title, url, summary, body, required, published_by)
+        SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5, $6, $7, $8
+        FROM policy_documents WHERE kind = $2
+        RETURNING `+policyColumns,
+		uuid.New(), doc.Kind, doc.Title, doc.URL, doc.Summary, doc.Body, doc.Required, doc.PublishedBy))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrPolicyVersionConflict
+	}
+	return policy, err
+}
+
+// LatestPolicies returns the current version of every published kind.
+func (s *Store) LatestPolicies(ctx context.Context) ([]*PolicyDocument, error) {
+	return collectPolicies(s.pool.Query(ctx, `
+        SELECT DISTINCT ON (kind) `+policyColumns+`
+        FROM policy_documents
+        ORDER BY kind, version DESC
+    `))
+}
+
+// GetPolicy returns one version of a policy, or the latest when version is 0.
+func (s *Store) GetPolicy(ctx context.Context, kind string, version int) (*PolicyDocument, error) {
+	return scanPolicy(s.pool.QueryRow(ctx, `
+        SELECT `+policyColumns+`
+        FROM policy_documents
+        WHERE kind = $1 AND ($2 = 0 OR version = $2)
+        ORDER BY version DESC
+        LIMIT 1
+    `, kind, version))
+}
+
+// ListPolicyVersions returns every version of a policy, newest first.
+func (s *Store) ListPolicyVersions(ctx context.Context, kind string) ([]*PolicyDocument, error) {
+	return collectPolicies(s.pool.Query(ctx, `
+        SELECT `+policyColumns+`
+        FROM policy_documents
+        WHERE kind = $1
+        ORDER BY version DESC
+    `, kind))
+}
+
+// ConsentEvent is one grant or withdrawal. PolicyKind and PolicyVersion describe the document it
+// was given under, if any.
+type ConsentEvent struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Purpose       string
+	Granted       bool
+	PolicyID      *uuid.UUID
+	PolicyKind    string
+	PolicyVersion int
+	Source        string
+	IP            string
+	UserAgent     string
+	RecordedBy    *uuid.UUID
+	RecordedAt    time.Time
+}
+
+const consentColumns = `c.id, c.user_id, c.purpose, c.granted, c.policy_id, COALESCE(p.kind, ''), COALESCE(p.version, 0),
+        c.source, c.ip, c.user_agent, c.recorded_by, c.recorded_at`
+
+func collectConsents(rows pgx.Rows, err error) ([]*ConsentEvent, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	events := []*ConsentEvent{}
+	for rows.Next() {
+		var e ConsentEvent
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Purpose, &e.Granted, &e.PolicyID, &e.PolicyKind, &e.PolicyVersion,
+			&e.Source, &e.IP, &e.UserAgent, &e.RecordedBy, &e.RecordedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// RecordConsents appends events in one transaction.
+func (s *Store) RecordConsents(ctx context.Context, events []ConsentEvent) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	for _, e := range events {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO consent_events (id, user_id, purpose, granted, policy_id, source, ip, user_agent, recorded_by)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        `, uuid.New(), e.UserID, e.Purpose, e.Granted, e.PolicyID, e.Source, e.IP, e.UserAgent, e.RecordedBy); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+// CurrentConsents returns the user's latest event for each purpose they have decided on.
+func (s *Store) CurrentConsents(ctx context.Context, userID uuid.UUID) ([]*ConsentEvent, error) {
+	return collectConsents(s.pool.Query(ctx, `
+        SELECT DISTINCT ON (c.purpose) `+consentColumns+`
+        FROM consent_events c
+        LEFT JOIN policy_documents p ON p.id = c.policy_id
+        WHERE c.user_id = $1
+        ORDER BY c.purpose, c.recorded_at DESC, c.id
+    `, userID))
+}
+
+// ConsentHistory returns one page of the user's consent events, newest first, and the total.
+func (s *Store) ConsentHistory(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*ConsentEvent, int, error) {
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM consent_events WHERE user_id = $1`, userID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	events, err := collectConsents(s.pool.Query(ctx, `
+        SELECT `+consentColumns+`
+        FROM consent_events c
+        LEFT JOIN policy_documents p ON p.id = c.policy_id
+        WHERE c.user_id = $1
+        ORDER BY c.recorded_at DESC, c.id
+        LIMIT $2 OFFSET $3
+    `, userID, limit, offset))
+	return events, total, err
+}
+
+// HasConsent reports whether the user's latest decision for purpose is a grant.
+func (s *Store) HasConsent(ctx context.Context, userID uuid.UUID, purpose string) (bool, error) {
+	var granted bool
+	err := s.pool.QueryRow(ctx, `
+        SELECT granted FROM consent_events
+        WHERE user_id = $1 AND purpose = $2
+        ORDER BY recorded_at DESC, id
+        LIMIT 1
+    `, userID, purpose).Scan(&granted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	return granted, err
+}
@@ -0,0 +1,333 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/services/user-service/internal/erasure"
+)
+
+// Deletion reasons. A requested deletion was filed by the member; an inactivity deletion was
+// scheduled by the retention job and is cancelled by the member's next sign-in.
+const (
+	DeletionRequested  = "requested"
+	DeletionInactivity = "inactivity"
+)
+
+// activityResolution limits how often TouchActivity writes for an active member.
+const activityResolution = time.Hour
+
+var (
+	// ErrAccountErased is returned when erasing an account that has already been erased.
+	ErrAccountErased = errors.New("account already erased")
+	// ErrErasureInProgress is returned when changing the deletion of an account the sweep has
+	// started to erase.
+	ErrErasureInProgress = errors.New("account erasure in progress")
+)
+
+// ScheduleDeletion schedules the member's requested deletion for at. A deletion the member has
+// already requested keeps its original schedule.
+func (s *Store) ScheduleDeletion(ctx context.Context, id uuid.UUID, at time.Time) (*User, error) {
+	return s.unlessErasing(ctx, id)(scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET
+            deletion_requested_at = CASE WHEN deletion_reason = 'requested' THEN deletion_requested_at ELSE NOW() END,
+            deletion_scheduled_at = CASE WHEN deletion_reason = 'requested' THEN deletion_scheduled_at ELSE $2 END,
+            deletion_reason = 'requested'
+        WHERE id = $1 AND erased_at IS NULL AND erasing_at IS NULL
+        RETURNING `+userColumns, id, at)))
+}
+
+// CancelDeletion clears any pending deletion. It returns ErrErasureInProgress once the sweep has
+// started to erase the account.
+func (s *Store) CancelDeletion(ctx context.Context, id uuid.UUID) (*User, error) {
+	return s.unlessErasing(ctx, id)(scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET deletion_requested_at = NULL, deletion_scheduled_at = NULL, deletion_reason = ''
+        WHERE id = $1 AND erased_at IS NULL AND erasing_at IS NULL
+        RETURNING `+userColumns, id)))
+}
+
+// unlessErasing turns the missing row of an update that skipped an account being erased into
+// ErrErasureInProgress.
+func (s *Store) unlessErasing(ctx context.Context, id uuid.UUID) func(*User, error) (*User, error) {
+	return func(user *User, err error) (*User, error) {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return user, err
+		}
+		var erasing bool
+		if s.pool.QueryRow(ctx, `SELECT erasing_at IS NOT NULL FROM users WHERE id = $1`, id).Scan(&erasing) == nil && erasing {
+			return nil, ErrErasureInProgress
+		}
+		return nil, err
+	}
+}
+
+// TouchActivity records that the member signed in or refreshed a session, cancelling a deletion
+// scheduled for inactivity unless its erasure has started. It writes at most once per
+// activityResolution otherwise.
+func (s *Store) TouchActivity(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `
+        UPDATE users SET
+            last_active_at = NOW(),
+            deletion_requested_at = CASE WHEN deletion_reason = 'inactivity' THEN NULL ELSE deletion_requested_at END,
+            deletion_scheduled_at = CASE WHEN deletion_reason = 'inactivity' THEN NULL ELSE deletion_scheduled_at END,
+            deletion_reason = CASE WHEN deletion_reason = 'inactivity' THEN '' ELSE deletion_reason END
+        WHERE id = $1 AND erased_at IS NULL AND erasing_at IS NULL
+          AND (last_active_at < $2 OR deletion_reason = 'inactivity')
+    `, id, time.Now().Add(-activityResolution))
+	return err
+}
+
+// ScheduleInactiveDeletions schedules up to limit accounts inactive since inactiveBefore for
+// deletion at at, and returns them so they can be warned. Dependents never sign in, so they are
+// left alone.
+func (s *Store) ScheduleInactiveDeletions(ctx context.Context, inactiveBefore, at time.Time, limit int) ([]*User, error) {
+	return s.queryUsers(ctx, `
+        UPDATE users SET deletion_requested_at = NOW(), deletion_scheduled_at = $2, deletion_reason = 'inactivity'
+        WHERE id IN (
+            SELECT id FROM users
+            WHERE last_active_at < $1 AND deletion_scheduled_at IS NULL AND erased_at IS NULL AND NOT dependent
+            ORDER BY last_active_at
+            LIMIT $3
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+userColumns, inactiveBefore, at, limit)
+}
+
+// DueDeletions returns up to limit accounts whose deletion is due, oldest first.
+func (s *Store) DueDeletions(ctx context.Context, limit int) ([]*User, error) {
+	return s.queryUsers(ctx, `
+        SELECT `+userColumns+`
+        FROM users
+        WHERE deletion_scheduled_at <= NOW() AND erased_at IS NULL
+        ORDER BY deletion_scheduled_at
+        LIMIT $1
+    `, limit)
+}
+
+// BeginErasure marks an account whose deletion is due as being erased and returns it, so the
+// deletion cannot be cancelled while other services erase their data. It returns pgx.ErrNoRows
+// when the deletion was cancelled or postponed after DueDeletions listed it.
+func (s *Store) BeginErasure(ctx context.Context, id uuid.UUID) (*User, error) {
+	return scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET erasing_at = COALESCE(erasing_at, NOW())
+        WHERE id = $1 AND deletion_scheduled_at <= NOW() AND erased_at IS NULL
+        RETURNING `+userColumns, id))
+}
+
+func (s *Store) queryUsers(ctx context.Context, query string, args ...any) ([]*User, error) {
+	rows, err := s.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []*User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// ErasedObjects are the stored files that belonged to an erased account; the caller deletes
+// them once the erasure has committed.
+type ErasedObjects struct {
+	AvatarKey  string
+	ExportKeys []string
+}
+
+// EraseUser anonymises receipt.UserID and stores the receipt, in one transaction. The row keeps
+// its id so references from other services stay valid, but every credential, linked identity,
+// role and piece of profile data is removed and the account is disabled for good. The erasure
+// must have been started with BeginErasure.
+func (s *Store) EraseUser(ctx context.Context, receipt *erasure.Receipt) (*ErasedObjects, error) {
+	services, err := json.Marshal(receipt.Services)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var objects ErasedObjects
+	var erasedAt, erasingAt *time.Time
+	err = tx.QueryRow(ctx, `
+        SELECT avatar_key, erased_at, erasing_at FROM users WHERE id = $1 FOR UPDATE
+    `, receipt.UserID).Scan(&objects.AvatarKey, &erasedAt, &erasingAt)
+	if err != nil {
+		return nil, err
+	}
+	if erasedAt != nil {
+		return nil, ErrAccountErased
+	}
+	if erasingAt == nil {
+		return nil, errors.New("erasure was not started")
+	}
+
+	// A primary account holder's household closes with the account; its dependents cannot sign in
+	// without it, so they are erased on the next sweep.
+	if _, err := tx.Exec(ctx, `
+        UPDATE users SET deletion_requested_at = NOW(), deletion_scheduled_at = NOW(), deletion_reason = 'requested'
+        WHERE erased_at IS NULL AND id IN (
+            SELECT m.user_id FROM household_members m JOIN households h ON h.id = m.household_id
+            WHERE h.primary_user_id = $1 AND m.role = 'dependent'
+        )
+    `, receipt.UserID); err != nil {
+		return nil, fmt.Errorf("schedule household dependents: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM households WHERE primary_user_id = $1`, receipt.UserID); err != nil {
+		return nil, fmt.Errorf("close household: %w", err)
+	}
+	if _, err := tx.Exec(ctx, `
+        UPDATE user_import_rows SET email = '', first_name = '', last_name = '', phone = '' WHERE user_id = $1
+    `, receipt.UserID); err != nil {
+		return nil, fmt.Errorf("redact import rows: %w", err)
+	}
+	for _, table := range []string{"household_members", "user_tokens", "user_mfa", "user_recovery_codes", "user_venue_roles", "user_identities", "oauth_consents", "consent_events"} {
+		if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE user_id = $1`, receipt.UserID); err != nil {
+			return nil, fmt.Errorf("erase %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `
+        UPDATE users SET
+            email = 'erased-' || id::text || '@erased.invalid',
+            first_name = '', last_name = '', password_hash = '', roles = '{}',
+            email_verified = FALSE, mfa_enabled = FALSE, phone = '', phone_verified = FALSE,
+            avatar_key = '', preferences = '{}', pending_email = '',
+            deletion_scheduled_at = NULL,
+            disabled_at = COALESCE(disabled_at, $2), erased_at = $2
+        WHERE id = $1
+    `, receipt.UserID, receipt.ErasedAt); err != nil {
+		return nil, fmt.Errorf("anonymise user: %w", err)
+	}
+
+	// Ready archives are deleted by the caller; queued exports are abandoned.
+	rows, err := tx.Query(ctx, `
+        UPDATE data_exports SET
+            status = CASE WHEN status = 'ready' THEN 'expired' ELSE 'failed' END,
+            completed_at = COALESCE(completed_at, NOW()),
+            last_error = CASE WHEN status = 'ready' THEN last_error ELSE 'account erased' END
+        WHERE user_id = $1 AND status IN ('pending', 'processing', 'ready')
+        RETURNING object_key
+    `, receipt.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("expire data exports: %w", err)
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if key != "" {
+			objects.ExportKeys = append(objects.ExportKeys, key)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO erasure_receipts (id, user_id, reason, requested_at, erased_at, services, digest)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+    `, receipt.ID, receipt.UserID, receipt.Reason, receipt.RequestedAt, receipt.ErasedAt, services, receipt.Digest); err != nil {
+		return nil, fmt.Errorf("store erasure receipt: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return &objects, nil
+}
+
+const receiptColumns = `id, user_id, reason, requested_at, erased_at, services, digest`
+
+func scanReceipt(row pgx.Row) (*erasure.Receipt, error) {
+	var r erasure.Receipt
+	var services []byte
+	if err := row.Scan(&r.ID, &r.UserID, &r.Reason, &r.RequestedAt, &r.ErasedAt, &services, &r.Digest); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(services, &r.Services); err != nil {
+		return nil, fmt.Errorf("decode erasure receipt services: %w", err)
+	}
+	return &r, nil
+}
+
+// GetErasureReceipt returns a receipt by id.
+func (s *Store) GetErasureReceipt(ctx context.Context, id uuid.UUID) (*erasure.Receipt, error) {
+	return scanReceipt(s.pool.QueryRow(ctx, `SELECT `+receiptColumns+` FROM erasure_receipts WHERE id = $1`, id))
+}
+
+// ErasureQuery filters the erasure log. Zero values match everything.
+type ErasureQuery struct {
+	UserID uuid.UUID
+	// From and To bound erased_at (inclusive, exclusive).
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// ListErasureReceipts returns one page of receipts matching query, newest first, and the total
+// number of matches.
+func (s *Store) ListErasureReceipts(ctx context.Context, query ErasureQuery) ([]*erasure.Receipt, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if query.UserID != uuid.Nil {
+		args = append(args, query.UserID)
+		where = append(where, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		where = append(where, fmt.Sprintf("erased_at >= $%d", len(args)))
+	}
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		where = append(where, fmt.Sprintf("erased_at < $%d", len(args)))
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM erasure_receipts `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+        SELECT `+receiptColumns+`
+        FROM erasure_receipts
+        %s
+        ORDER BY erased_at DESC, id
+        LIMIT $%d OFFSET $%d
+    `, filter, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	receipts := []*erasure.Receipt{}
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		receipts = append(receipts, receipt)
+	}
+	return receipts, total, rows.Err()
+}
@@ -0,0 +1,205 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Data export statuses. An export is pending until the worker claims it, then ready (or failed
+// once retries run out), and expired when its archive has been deleted.
+const (
+	ExportPending    = "pending"
+	ExportProcessing = "processing"
+	ExportReady      = "ready"
+	ExportFailed     = "failed"
+	ExportExpired    = "expired"
+)
+
+// exportStaleAfter is how long an export may stay processing before another worker takes it
+// over, e.g. after a crash mid-run.
+const exportStaleAfter = 15 * time.Minute
+
+// ErrExportInProgress is returned when the user already has an export pending or processing.
+var ErrExportInProgress = errors.New("a data export is already in progress")
+
+// DataExport is one right-of-access request and the archive it produced.
+type DataExport struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	RequestedBy   *uuid.UUID
+	Status        string
+	ObjectKey     string
+	SizeBytes     int64
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	RequestedAt   time.Time
+	StartedAt     *time.Time
+	CompletedAt   *time.Time
+	ExpiresAt     *time.Time
+}
+
+const exportColumns = `id, user_id, requested_by, status, object_key, size_bytes, attempts, last_error,
+    next_attempt_at, requested_at, started_at, completed_at, expires_at`
+
+func scanExport(row pgx.Row) (*DataExport, error) {
+	var e DataExport
+	if err := row.Scan(&e.ID, &e.UserID, &e.RequestedBy, &e.Status, &e.ObjectKey, &e.SizeBytes, &e.Attempts, &e.LastError,
+		&e.NextAttemptAt, &e.RequestedAt, &e.StartedAt, &e.CompletedAt, &e.ExpiresAt); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CreateDataExport queues an export of userID's data. requestedBy is the admin filing it on the
+// member's behalf, or nil.
+func (s *Store) CreateDataExport(ctx context.Context, userID uuid.UUID, requestedBy *uuid.UUID) (*DataExport, error) {
+	export, err := scanExport(s.pool.QueryRow(ctx, `
+        INSERT INTO data_exports (id, user_id, requested_by)
+        VALUES ($1, $2, $3)
+        RETURNING `+exportColumns, uuid.New(), userID, requestedBy))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrExportInProgress
+	}
+	return export, err
+}
+
+// GetDataExport returns an export by id.
+func (s *Store) GetDataExport(ctx context.Context, id uuid.UUID) (*DataExport, error) {
+	return scanExport(s.pool.QueryRow(ctx, `SELECT `+exportColumns+` FROM data_exports WHERE id = $1`, id))
+}
+
+// ClaimDataExport marks the oldest due export processing and returns it, or pgx.ErrNoRows when
+// none is due. Exports stuck processing for too long are claimed again.
+func (s *Store) ClaimDataExport(ctx context.Context) (*DataExport, error) {
+	return scanExport(s.pool.QueryRow(ctx, `
+        UPDATE data_exports SET status = 'processing', started_at = NOW(), attempts = attempts + 1
+        WHERE id = (
+            SELECT id FROM data_exports
+            WHERE (status = 'pending' AND next_attempt_at <= NOW())
+               OR (status = 'processing' AND started_at < $1)
+            ORDER BY requested_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING `+exportColumns, time.Now().Add(-exportStaleAfter)))
+}
+
+// CompleteDataExport records the stored archive of a processed export.
+func (s *Store) CompleteDataExport(ctx context.Context, id uuid.UUID, key string, size int64, expiresAt time.Time) (*DataExport, error) {
+	return scanExport(s.pool.QueryRow(ctx, `
+        UPDATE data_exports SET status = 'ready', object_key = $2, size_bytes = $3, expires_at = $4,
+            completed_at = NOW(), last_error = ''
+        WHERE id = $1
+        RETURNING `+exportColumns, id, key, size, expiresAt))
+}
+
+// FailDataExport records a failed attempt. The export is queued again at retryAt, or marked
+// failed when retryAt is nil.
+func (s *Store) FailDataExport(ctx context.Context, id uuid.UUID, cause string, retryAt *time.Time) (*DataExport, error) {
+	return scanExport(s.pool.QueryRow(ctx, `
+        UPDATE data_exports SET
+            status = CASE WHEN $3::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+            next_attempt_at = COALESCE($3, next_attempt_at),
+            completed_at = CASE WHEN $3::timestamptz IS NULL THEN NOW() END,
+            last_error = $2
+        WHERE id = $1
+        RETURNING `+exportColumns, id, cause, retryAt))
+}
+
+// ExpireDataExports marks ready exports past their expiry expired and returns them so the
+// caller can delete the archives.
+func (s *Store) ExpireDataExports(ctx context.Context) ([]*DataExport, error) {
+	rows, err := s.pool.Query(ctx, `
+        UPDATE data_exports SET status = 'expired'
+        WHERE status = 'ready' AND expires_at <= NOW()
+        RETURNING `+exportColumns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var exports []*DataExport
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, rows.Err()
+}
+
+// DataExportQuery filters the compliance listing. Zero values match everything.
+type DataExportQuery struct {
+	UserID uuid.UUID
+	Status string
+	// From and To bound requested_at (inclusive, exclusive).
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// ListDataExports returns one page of exports matching query, newest first, and the total
+// number of matches.
+func (s *Store) ListDataExports(ctx context.Context, query DataExportQuery) ([]*DataExport, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if query.UserID != uuid.Nil {
+		args = append(args, query.UserID)
+		where = append(where, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if query.Status != "" {
+		args = append(args, query.Status)
+		where = append(where, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if !query.From.IsZero() {
+		args = append(args, query.From)
+		where = append(where, fmt.Sprintf("requested_at >= $%d", len(args)))
+	}
+	if !query.To.IsZero() {
+		args = append(args, query.To)
+		where = append(where, fmt.Sprintf("requested_at < $%d", len(args)))
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM data_exports `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+        SELECT `+exportColumns+`
+        FROM data_exports
+        %s
+        ORDER BY requested_at DESC, id
+        LIMIT $%d OFFSET $%d
+    `, filter, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	exports := []*DataExport{}
+	for rows.Next() {
+		export, err := scanExport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		exports = append(exports, export)
+	}
+	return exports, total, rows.Err()
+}
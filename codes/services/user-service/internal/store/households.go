@@ -0,0 +1,311 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Household member roles.
+const (
+	HouseholdPrimary   = "primary"
+	HouseholdAdult     = "adult"
+	HouseholdDependent = "dependent"
+)
+
+// Household member statuses. Only active members share the household.
+const (
+	MemberInvited = "invited"
+	MemberActive  = "active"
+)
+
+var (
+	// ErrInHousehold is returned when the user is already an active member of a household.
+	ErrInHousehold = errors.New("already a member of a household")
+	// ErrAlreadyInvited is returned when inviting someone who already has an invitation or
+	// membership in the household.
+	ErrAlreadyInvited = errors.New("already invited to this household")
+	// ErrPrimaryMember is returned when removing the primary account holder.
+	ErrPrimaryMember = errors.New("the primary account holder cannot leave the household")
+)
+
+// Household groups accounts that share a payment method and a membership.
+type Household struct {
+	ID            uuid.UUID
+	Name          string
+	PrimaryUserID uuid.UUID
+	// PaymentMethodID is the payment provider's reference for the shared card, or empty.
+	PaymentMethodID string
+	// MembershipType is the membership every member holds through the household, or empty.
+	MembershipType string
+	Members        []HouseholdMember
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// HouseholdMember is one account in a household, with its name for display.
+type HouseholdMember struct {
+	UserID    uuid.UUID
+	FirstName string
+	LastName  string
+	// Email is empty for dependents.
+	Email     string
+	Role      string
+	Status    string
+	InvitedBy *uuid.UUID
+	AddedAt   time.Time
+	JoinedAt  *time.Time
+}
+
+// Member returns the active member userID, or nil.
+func (h *Household) Member(userID uuid.UUID) *HouseholdMember {
+	for i := range h.Members {
+		if h.Members[i].UserID == userID && h.Members[i].Status == MemberActive {
+			return &h.Members[i]
+		}
+	}
+	return nil
+}
+
+// HouseholdUpdate changes a household's settings; nil fields stay unchanged.
+type HouseholdUpdate struct {
+	Name            *string
+	PaymentMethodID *string
+	MembershipType  *string
+}
+
+const householdColumns = `id, name, primary_user_id, payment_method_id, membership_type, created_at, updated_at`
+
+// CreateHousehold creates a household with primary as its primary account holder.
+func (s *Store) CreateHousehold(ctx context.Context, primary uuid.UUID, name string) (*Household, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id := uuid.New()
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO households (id, name, primary_user_id) VALUES ($1, $2, $3)
+    `, id, name, primary); err != nil {
+		return nil, householdError(err)
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO household_members (household_id, user_id, role, status, joined_at)
+        VALUES ($1, $2, 'primary', 'active', NOW())
+    `, id, primary); err != nil {
+		return nil, householdError(err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetHousehold(ctx, id)
+}
+
+// GetHousehold returns a household with its members and pending invitations.
+func (s *Store) GetHousehold(ctx context.Context, id uuid.UUID) (*Household, error) {
+	var h Household
+	if err := s.pool.QueryRow(ctx, `SELECT `+householdColumns+` FROM households WHERE id = $1`, id).
+		Scan(&h.ID, &h.Name, &h.PrimaryUserID, &h.PaymentMethodID, &h.MembershipType, &h.CreatedAt, &h.UpdatedAt); err != nil {
+		return nil, err
+	}
+	rows, err := s.pool.Query(ctx, `
+        SELECT m.user_id, u.first_name, u.last_name, CASE WHEN u.dependent THEN '' ELSE u.email END,
+               m.role, m.status, m.invited_by, m.added_at, m.joined_at
+        FROM household_members m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.household_id = $1
+        ORDER BY CASE m.role WHEN 'primary' THEN 0 WHEN 'adult' THEN 1 ELSE 2 END, m.added_at
+    `, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m HouseholdMember
+		if err := rows.Scan(&m.UserID, &m.FirstName, &m.LastName, &m.Email, &m.Role, &m.Status, &m.InvitedBy, &m.AddedAt, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		h.Members = append(h.Members, m)
+	}
+	return &h, rows.Err()
+}
+
+// GetHouseholdByUser returns the household userID is an active member of, or pgx.ErrNoRows.
+func (s *Store) GetHouseholdByUser(ctx context.Context, userID uuid.UUID) (*Household, error) {
+	var id uuid.UUID
+	if err := s.pool.QueryRow(ctx, `
+        SELECT household_id FROM household_members WHERE user_id = $1 AND status = 'active'
+    `, userID).Scan(&id); err != nil {
+		return nil, err
+	}
+	return s.GetHousehold(ctx, id)
+}
+
+// UpdateHousehold applies update to the household.
+func (s *Store) UpdateHousehold(ctx context.Context, id uuid.UUID, update HouseholdUpdate) (*Household, error) {
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE households SET
+            name = COALESCE($2, name),
+            payment_method_id = COALESCE($3, payment_method_id),
+            membership_type = COALESCE($4, membership_type)
+        WHERE id = $1
+    `, id, update.Name, update.PaymentMethodID, update.MembershipType)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	return s.GetHousehold(ctx, id)
+}
+
+// InviteHouseholdMember invites userID to join the household as an adult member.
+func (s *Store) InviteHouseholdMember(ctx context.Context, householdID, userID, invitedBy uuid.UUID) error {
+	var active bool
+	if err := s.pool.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM household_members WHERE user_id = $1 AND status = 'active')
+    `, userID).Scan(&active); err != nil {
+		return err
+	}
+	if active {
+		return ErrInHousehold
+	}
+	_, err := s.pool.Exec(ctx, `
+        INSERT INTO household_members (household_id, user_id, role, status, invited_by)
+        VALUES ($1, $2, 'adult', 'invited', $3)
+    `, householdID, userID, invitedBy)
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrAlreadyInvited
+	}
+	return err
+}
+
+// AddDependent creates a dependent account in the household. Dependents have no email or
+// password of their own, so they cannot sign in.
+func (s *Store) AddDependent(ctx context.Context, householdID, addedBy uuid.UUID, firstName, lastName string) (*User, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id := uuid.New()
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO users (id, email, first_name, last_name, password_hash, roles, dependent)
+        VALUES ($1, 'dependent-' || $1::text || '@dependents.invalid', $2, $3, '', ARRAY['MEMBER'], TRUE)
+    `, id, firstName, lastName); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO household_members (household_id, user_id, role, status, invited_by, joined_at)
+        VALUES ($1, $2, 'dependent', 'active', $3, NOW())
+    `, householdID, id, addedBy); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(ctx, id)
+}
+
+// AcceptHouseholdInvitation makes userID an active member of the household that invited them.
+// It returns pgx.ErrNoRows when there is no pending invitation.
+func (s *Store) AcceptHouseholdInvitation(ctx context.Context, householdID, userID uuid.UUID) (*Household, error) {
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE household_members SET status = 'active', joined_at = NOW()
+        WHERE household_id = $1 AND user_id = $2 AND status = 'invited'
+    `, householdID, userID)
+	if err != nil {
+		return nil, householdError(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, pgx.ErrNoRows
+	}
+	// Other pending invitations lapse: a member belongs to one household.
+	if _, err := s.pool.Exec(ctx, `
+        DELETE FROM household_members WHERE user_id = $1 AND status = 'invited'
+    `, userID); err != nil {
+		return nil, err
+	}
+	return s.GetHousehold(ctx, householdID)
+}
+
+// ListHouseholdInvitations returns the households with a pending invitation for userID.
+func (s *Store) ListHouseholdInvitations(ctx context.Context, userID uuid.UUID) ([]*Household, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT household_id FROM household_members
+        WHERE user_id = $1 AND status = 'invited'
+        ORDER BY added_at
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	households := make([]*Household, 0, len(ids))
+	for _, id := range ids {
+		household, err := s.GetHousehold(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		households = append(households, household)
+	}
+	return households, nil
+}
+
+// RemoveHouseholdMember removes userID from the household, or withdraws or declines their
+// invitation. A removed dependent has no way to sign in, so their account is scheduled for
+// erasure straight away. It returns the removed member's role.
+func (s *Store) RemoveHouseholdMember(ctx context.Context, householdID, userID uuid.UUID) (string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var role string
+	if err := tx.QueryRow(ctx, `
+        DELETE FROM household_members WHERE household_id = $1 AND user_id = $2
+        RETURNING role
+    `, householdID, userID).Scan(&role); err != nil {
+		return "", err
+	}
+	if role == HouseholdPrimary {
+		return "", ErrPrimaryMember
+	}
+	if role == HouseholdDependent {
+		if _, err := tx.Exec(ctx, `
+            UPDATE users SET deletion_requested_at = NOW(), deletion_scheduled_at = NOW(), deletion_reason = 'requested'
+            WHERE id = $1 AND erased_at IS NULL
+        `, userID); err != nil {
+			return "", err
+		}
+	}
+	return role, tx.Commit(ctx)
+}
+
+// householdError maps a violation of the one-household-per-member index to ErrInHousehold.
+func householdError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return ErrInHousehold
+	}
+	return err
+}
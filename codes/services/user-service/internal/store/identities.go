@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrIdentityConflict is returned when an external identity's email belongs to a local account
+// whose own address was never verified. Linking it would hand the account to whoever controls
+// the external identity, so the owner has to verify the address (or sign in and link) first.
+var ErrIdentityConflict = errors.New("email belongs to an unverified account")
+
+// How ResolveIdentity matched an external identity to a user.
+const (
+	IdentityExisting = "existing"
+	IdentityLinked   = "linked"
+	IdentityCreated  = "created"
+)
+
+// ExternalIdentity is a sign-in asserted by an OpenID provider. Email must have been verified
+// by the provider.
+type ExternalIdentity struct {
+	Provider  string
+	Subject   string
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// ResolveIdentity returns the user an external identity signs in as. A known identity returns
+// its user; otherwise the identity is linked to the user with the same verified email, or a new
+// member is created for it. New users have no password and a verified email.
+func (s *Store) ResolveIdentity(ctx context.Context, ext ExternalIdentity) (*User, string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `
+        SELECT user_id FROM user_identities WHERE provider = $1 AND subject = $2
+    `, ext.Provider, ext.Subject).Scan(&userID)
+	outcome := IdentityExisting
+	switch {
+	case err == nil:
+	case errors.Is(err, pgx.ErrNoRows):
+		outcome, userID, err = linkIdentity(ctx, tx, ext)
+		if err != nil {
+			return nil, "", err
+		}
+	default:
+		return nil, "", err
+	}
+
+	user, err := scanUser(tx.QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, userID))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+	return user, outcome, nil
+}
+
+// linkIdentity attaches a new identity to the user owning its email, creating one if needed.
+func linkIdentity(ctx context.Context, tx pgx.Tx, ext ExternalIdentity) (string, uuid.UUID, error) {
+	var (
+		userID   uuid.UUID
+		verified bool
+	)
+	err := tx.QueryRow(ctx, `
+        SELECT id, email_verified FROM users WHERE LOWER(email) = LOWER($1) FOR UPDATE
+    `, ext.Email).Scan(&userID, &verified)
+	outcome := IdentityLinked
+	switch {
+	case err == nil:
+		if !verified {
+			return "", uuid.Nil, ErrIdentityConflict
+		}
+	case errors.Is(err, pgx.ErrNoRows):
+		outcome, userID = IdentityCreated, uuid.New()
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO users (id, email, first_name, last_name, password_hash, roles, email_verified)
+            VALUES ($1, $2, $3, $4, '', ARRAY['MEMBER'], TRUE)
+        `, userID, strings.ToLower(ext.Email), ext.FirstName, ext.LastName); err != nil {
+			return "", uuid.Nil, err
+		}
+	default:
+		return "", uuid.Nil, err
+	}
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO user_identities (provider, subject, user_id, email) VALUES ($1, $2, $3, $4)
+    `, ext.Provider, ext.Subject, userID, ext.Email); err != nil {
+		return "", uuid.Nil, err
+	}
+	return outcome, userID, nil
+}
+
+// LinkedIdentity is an external identity linked to a user.
+type LinkedIdentity struct {
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// ListIdentities returns the external identities linked to a user, oldest first.
+func (s *Store) ListIdentities(ctx context.Context, userID uuid.UUID) ([]LinkedIdentity, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT provider, subject, email, created_at
+        FROM user_identities
+        WHERE user_id = $1
+        ORDER BY created_at
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var identities []LinkedIdentity
+	for rows.Next() {
+		var i LinkedIdentity
+		if err := rows.Scan(&i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
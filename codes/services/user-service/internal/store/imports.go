@@ -0,0 +1,279 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// User import statuses. An import is pending until the worker claims it, and completed once
+// every row has been handled.
+const (
+	ImportPending    = "pending"
+	ImportProcessing = "processing"
+	ImportCompleted  = "completed"
+)
+
+// Import row statuses.
+const (
+	RowPending = "pending"
+	RowCreated = "created"
+	RowFailed  = "failed"
+)
+
+// importStaleAfter is how long a processing import may go without progress before another
+// worker takes it over, e.g. after a crash mid-run.
+const importStaleAfter = 15 * time.Minute
+
+// UserImport is one bulk upload of members and its progress.
+type UserImport struct {
+	ID          uuid.UUID
+	RequestedBy *uuid.UUID
+	Filename    string
+	Status      string
+	// MembershipType is assigned to rows that do not name their own, or empty.
+	MembershipType  string
+	SendInvitations bool
+	TotalRows       int
+	ProcessedRows   int
+	CreatedRows     int
+	FailedRows      int
+	CreatedAt       time.Time
+	StartedAt       *time.Time
+	CompletedAt     *time.Time
+	UpdatedAt       time.Time
+}
+
+// ImportRow is one spreadsheet line of an import and what became of it.
+type ImportRow struct {
+	RowNumber      int
+	Email          string
+	FirstName      string
+	LastName       string
+	Phone          string
+	MembershipType string
+	Status         string
+	// Error explains a failed row, or notes a problem with a created one (e.g. an invitation
+	// that could not be sent).
+	Error  string
+	UserID *uuid.UUID
+}
+
+const importColumns = `i.id, i.requested_by, i.filename, i.status, i.membership_type, i.send_invitations,
+    (SELECT COUNT(*) FROM user_import_rows r WHERE r.import_id = i.id),
+    (SELECT COUNT(*) FROM user_import_rows r WHERE r.import_id = i.id AND r.status <> 'pending'),
+    (SELECT COUNT(*) FROM user_import_rows r WHERE r.import_id = i.id AND r.status = 'created'),
+    (SELECT COUNT(*) FROM user_import_rows r WHERE r.import_id = i.id AND r.status = 'failed'),
+    i.created_at, i.started_at, i.completed_at, i.updated_at`
+
+func scanImport(row pgx.Row) (*UserImport, error) {
+	var i UserImport
+	if err := row.Scan(&i.ID, &i.RequestedBy, &i.Filename, &i.Status, &i.MembershipType, &i.SendInvitations,
+		&i.TotalRows, &i.ProcessedRows, &i.CreatedRows, &i.FailedRows,
+		&i.CreatedAt, &i.StartedAt, &i.CompletedAt, &i.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
+
+// CreateUserImport queues an import of rows. Rows already marked failed (e.g. duplicates
+// within the file) are stored as they are, so they appear in the error report.
+func (s *Store) CreateUserImport(ctx context.Context, imp *UserImport, rows []ImportRow) (*UserImport, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id := uuid.New()
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO user_imports (id, requested_by, filename, membership_type, send_invitations)
+        VALUES ($1, $2, $3, $4, $5)
+    `, id, imp.RequestedBy, imp.Filename, imp.MembershipType, imp.SendInvitations); err != nil {
+		return nil, err
+	}
+	data := make([][]any, 0, len(rows))
+	for _, r := range rows {
+		status := r.Status
+		if status == "" {
+			status = RowPending
+		}
+		data = append(data, []any{id, r.RowNumber, r.Email, r.FirstName, r.LastName, r.Phone, r.MembershipType, status, r.Error})
+	}
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"user_import_rows"},
+		[]string{"import_id", "row_number", "email", "first_name", "last_name", "phone", "membership_type", "status", "error"},
+		pgx.CopyFromRows(data)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetUserImport(ctx, id)
+}
+
+// GetUserImport returns an import with its progress.
+func (s *Store) GetUserImport(ctx context.Context, id uuid.UUID) (*UserImport, error) {
+	return scanImport(s.pool.QueryRow(ctx, `SELECT `+importColumns+` FROM user_imports i WHERE i.id = $1`, id))
+}
+
+// ListUserImports returns one page of imports, newest first, and the total number of imports.
+func (s *Store) ListUserImports(ctx context.Context, limit, offset int) ([]*UserImport, int, error) {
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM user_imports`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.pool.Query(ctx, `
+        SELECT `+importColumns+`
+        FROM user_imports i
+        ORDER BY i.created_at DESC, i.id
+        LIMIT $1 OFFSET $2
+    `, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	imports := []*UserImport{}
+	for rows.Next() {
+		imp, err := scanImport(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		imports = append(imports, imp)
+	}
+	return imports, total, rows.Err()
+}
+
+// ClaimUserImport marks the oldest pending import processing and returns it, or pgx.ErrNoRows
+// when none is waiting. Imports that stopped making progress, e.g. because the worker crashed or
+// lost the database, are claimed again and resume at their first pending row.
+func (s *Store) ClaimUserImport(ctx context.Context) (*UserImport, error) {
+	var id uuid.UUID
+	err := s.pool.QueryRow(ctx, `
+        UPDATE user_imports SET status = 'processing', started_at = COALESCE(started_at, NOW()), updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM user_imports
+            WHERE status = 'pending' OR (status = 'processing' AND updated_at < $1)
+            ORDER BY created_at
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id
+    `, time.Now().Add(-importStaleAfter)).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetUserImport(ctx, id)
+}
+
+// PendingImportRows returns up to limit unprocessed rows of an import in file order, and marks
+// the import as making progress.
+func (s *Store) PendingImportRows(ctx context.Context, importID uuid.UUID, limit int) ([]ImportRow, error) {
+	if _, err := s.pool.Exec(ctx, `UPDATE user_imports SET updated_at = NOW() WHERE id = $1`, importID); err != nil {
+		return nil, err
+	}
+	return s.importRows(ctx, `WHERE import_id = $1 AND status = 'pending' ORDER BY row_number LIMIT $2`, importID, limit)
+}
+
+// ListImportRows returns the rows of an import with the given status (all when empty) in file
+// order.
+func (s *Store) ListImportRows(ctx context.Context, importID uuid.UUID, status string) ([]ImportRow, error) {
+	return s.importRows(ctx, `WHERE import_id = $1 AND ($2 = '' OR status = $2) ORDER BY row_number`, importID, status)
+}
+
+func (s *Store) importRows(ctx context.Context, filter string, args ...any) ([]ImportRow, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT row_number, email, first_name, last_name, phone, membership_type, status, error, user_id
+        FROM user_import_rows `+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []ImportRow{}
+	for rows.Next() {
+		var r ImportRow
+		if err := rows.Scan(&r.RowNumber, &r.Email, &r.FirstName, &r.LastName, &r.Phone, &r.MembershipType, &r.Status, &r.Error, &r.UserID); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// CreateImportedUser creates the member an import row describes, with no password and
+// InvitationPending set, and marks the row created in the same transaction, so a row is never
+// imported twice. It returns ErrEmailTaken when the email is already registered.
+func (s *Store) CreateImportedUser(ctx context.Context, importID uuid.UUID, row ImportRow) (*User, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	id := uuid.New()
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO users (id, email, first_name, last_name, password_hash, roles, phone, membership_type, invitation_pending)
+        VALUES ($1, $2, $3, $4, '', ARRAY['MEMBER'], $5, $6, TRUE)
+    `, id, row.Email, row.FirstName, row.LastName, row.Phone, row.MembershipType); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrEmailTaken
+		}
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        UPDATE user_import_rows SET status = 'created', user_id = $3, error = ''
+        WHERE import_id = $1 AND row_number = $2
+    `, importID, row.RowNumber, id); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(ctx, id)
+}
+
+// FailImportRow marks a row failed with the reason.
+func (s *Store) FailImportRow(ctx context.Context, importID uuid.UUID, rowNumber int, reason string) error {
+	_, err := s.pool.Exec(ctx, `
+        UPDATE user_import_rows SET status = 'failed', error = $3
+        WHERE import_id = $1 AND row_number = $2
+    `, importID, rowNumber, reason)
+	return err
+}
+
+// NoteImportRow records a problem with a row without changing its status.
+func (s *Store) NoteImportRow(ctx context.Context, importID uuid.UUID, rowNumber int, note string) error {
+	_, err := s.pool.Exec(ctx, `
+        UPDATE user_import_rows SET error = $3
+        WHERE import_id = $1 AND row_number = $2
+    `, importID, rowNumber, note)
+	return err
+}
+
+// CompleteUserImport marks an import completed.
+func (s *Store) CompleteUserImport(ctx context.Context, id uuid.UUID) error {
+	_, err := s.pool.Exec(ctx, `
+        UPDATE user_imports SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+        WHERE id = $1
+    `, id)
+	return err
+}
+
+// RedactImportRows blanks the names, emails and phone numbers in the rows of imports that
+// finished before cutoff, some of them of people who never got an account. Row outcomes are
+// kept for the import summaries.
+func (s *Store) RedactImportRows(ctx context.Context, cutoff time.Time) (int, error) {
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE user_import_rows r SET email = '', first_name = '', last_name = '', phone = ''
+        FROM user_imports i
+        WHERE r.import_id = i.id AND i.completed_at < $1 AND r.email <> ''
+    `, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/venue-master/platform/services/user-service/internal/totp"
+)
+
+// recoveryCodeCount is how many recovery codes a user holds at a time.
+const recoveryCodeCount = 10
+
+// Methods returned by VerifyMFA.
+const (
+	MFAMethodTOTP     = "otp"
+	MFAMethodRecovery = "recovery"
+)
+
+var (
+	// ErrMFAAlreadyEnabled is returned when enrolling a user whose MFA is already confirmed.
+	ErrMFAAlreadyEnabled = errors.New("mfa already enabled")
+	// ErrMFANotEnrolled is returned when confirming or verifying without an enrollment.
+	ErrMFANotEnrolled = errors.New("mfa not enrolled")
+	// ErrInvalidMFACode is returned when a TOTP or recovery code does not match.
+	ErrInvalidMFACode = errors.New("invalid mfa code")
+)
+
+// BeginMFAEnrollment stores a fresh, unconfirmed TOTP secret for the user and returns it.
+// Restarting an unfinished enrollment replaces the previous secret.
+func (s *Store) BeginMFAEnrollment(ctx context.Context, userID uuid.UUID) (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	tag, err := s.pool.Exec(ctx, `
+        INSERT INTO user_mfa (user_id, secret)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_step = 0, created_at = NOW()
+        WHERE user_mfa.confirmed_at IS NULL
+    `, userID, secret)
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", ErrMFAAlreadyEnabled
+	}
+	return secret, nil
+}
+
+// ConfirmMFA activates a pending enrollment once the user submits a valid code, and returns
+// the first set of recovery codes.
+func (s *Store) ConfirmMFA(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var secret string
+	var confirmedAt *time.Time
+	var lastStep int64
+	err = tx.QueryRow(ctx, `
+        SELECT secret, confirmed_at, last_step FROM user_mfa WHERE user_id = $1 FOR UPDATE
+    `, userID).Scan(&secret, &confirmedAt, &lastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if confirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), lastStep)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	if _, err := tx.Exec(ctx, `
+        UPDATE user_mfa SET confirmed_at = NOW(), last_step = $2 WHERE user_id = $1
+    `, userID, step); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE users SET mfa_enabled = TRUE WHERE id = $1`, userID); err != nil {
+		return nil, err
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// VerifyMFA checks a TOTP code, or failing that a recovery code, which is then used up. It
+// returns which kind of code matched.
+func (s *Store) VerifyMFA(ctx context.Context, userID uuid.UUID, code string) (string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var secret string
+	var lastStep int64
+	err = tx.QueryRow(ctx, `
+        SELECT secret, last_step FROM user_mfa
+        WHERE user_id = $1 AND confirmed_at IS NOT NULL
+        FOR UPDATE
+    `, userID).Scan(&secret, &lastStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrMFANotEnrolled
+	}
+	if err != nil {
+		return "", err
+	}
+
+	method := MFAMethodTOTP
+	if step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now(), lastStep); ok {
+		_, err = tx.Exec(ctx, `UPDATE user_mfa SET last_step = $2 WHERE user_id = $1`, userID, step)
+	} else {
+		method = MFAMethodRecovery
+		var id uuid.UUID
+		err = tx.QueryRow(ctx, `
+            UPDATE user_recovery_codes SET used_at = NOW()
+            WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+            RETURNING id
+        `, userID, hashToken(normaliseRecoveryCode(code))).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", ErrInvalidMFACode
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return method, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes.
+func (s *Store) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var enabled bool
+	if err := tx.QueryRow(ctx, `SELECT mfa_enabled FROM users WHERE id = $1`, userID).Scan(&enabled); err != nil {
+		return nil, err
+	}
+	if !enabled {
+		return nil, ErrMFANotEnrolled
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableMFA removes the user's TOTP secret and recovery codes.
+func (s *Store) DisableMFA(ctx context.Context, userID uuid.UUID) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, stmt := range []string{
+		`DELETE FROM user_mfa WHERE user_id = $1`,
+		`DELETE FROM user_recovery_codes WHERE user_id = $1`,
+		`UPDATE users SET mfa_enabled = FALSE WHERE id = $1`,
+	} {
+		if _, err := tx.Exec(ctx, stmt, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID uuid.UUID) ([]string, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO user_recovery_codes (id, user_id, code_hash) VALUES ($1, $2, $3)
+        `, uuid.New(), userID, hashToken(normaliseRecoveryCode(code))); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode returns a 50-bit code formatted as xxxxx-xxxxx.
+func newRecoveryCode() (string, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))[:10]
+	return raw[:5] + "-" + raw[5:], nil
+}
+
+func normaliseRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
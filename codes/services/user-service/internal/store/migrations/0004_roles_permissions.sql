@@ -0,0 +1,86 @@
+-- Role -> permission model. users.roles holds each user's role assignments; a user's effective
+-- permissions are the union over role_permissions for those roles. Built-in roles and their
+-- default grants are seeded here; super admins (rbac:manage) edit them through the API, and
+-- reruns of this migration never re-add a grant they removed.
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    built_in BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE INDEX IF NOT EXISTS users_roles_idx ON users USING GIN (roles);
+
+INSERT INTO permissions (name, description) VALUES
+    ('venue:read', 'View venues'),
+    ('venue:read:archived', 'View archived venues'),
+    ('venue:create', 'Create venues'),
+    ('venue:update', 'Edit venues, their maps and media'),
+    ('venue:delete', 'Archive and restore venues'),
+    ('facility:read', 'View facilities and schedules'),
+    ('facility:read:archived', 'View archived facilities'),
+    ('facility:create', 'Create facilities'),
+    ('facility:update', 'Edit facilities, availability and media'),
+    ('facility:delete', 'Archive and restore facilities'),
+    ('facility:schedule:edit', 'Add and remove schedule overrides'),
+    ('booking:read:own', 'View own bookings'),
+    ('booking:read:any', 'View any booking'),
+    ('booking:create', 'Book for oneself'),
+    ('booking:create:any', 'Book for others or book unavailable facilities'),
+    ('booking:cancel:own', 'Cancel own bookings'),
+    ('booking:cancel:any', 'Cancel any booking'),
+    ('user:roles:assign', 'Assign roles to users'),
+    ('user:unlock', 'Unlock accounts locked after failed logins'),
+    ('rbac:manage', 'Edit roles and their permissions')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO roles (name, description, built_in) VALUES
+    ('MEMBER', 'Club member', TRUE),
+    ('OPERATOR', 'Front-desk operator', TRUE),
+    ('VENUE_ADMIN', 'Venue administrator', TRUE),
+    ('ADMIN', 'Platform administrator', TRUE),
+    ('SUPER_ADMIN', 'Manages roles and permissions', TRUE)
+ON CONFLICT (name) DO NOTHING;
+
+-- Default grants are only seeded for roles that have none yet.
+INSERT INTO role_permissions (role, permission)
+SELECT grants.role, grants.permission
+FROM (VALUES
+    ('MEMBER', 'venue:read'), ('MEMBER', 'facility:read'),
+    ('MEMBER', 'booking:read:own'), ('MEMBER', 'booking:create'), ('MEMBER', 'booking:cancel:own'),
+
+    ('OPERATOR', 'venue:read'), ('OPERATOR', 'facility:read'), ('OPERATOR', 'booking:read:own'),
+
+    ('VENUE_ADMIN', 'venue:read'), ('VENUE_ADMIN', 'venue:read:archived'), ('VENUE_ADMIN', 'venue:create'),
+    ('VENUE_ADMIN', 'venue:update'), ('VENUE_ADMIN', 'venue:delete'),
+    ('VENUE_ADMIN', 'facility:read'), ('VENUE_ADMIN', 'facility:read:archived'), ('VENUE_ADMIN', 'facility:create'),
+    ('VENUE_ADMIN', 'facility:update'), ('VENUE_ADMIN', 'facility:delete'), ('VENUE_ADMIN', 'facility:schedule:edit'),
+    ('VENUE_ADMIN', 'booking:read:own'), ('VENUE_ADMIN', 'booking:read:any'), ('VENUE_ADMIN', 'booking:create'),
+    ('VENUE_ADMIN', 'booking:create:any'), ('VENUE_ADMIN', 'booking:cancel:own'), ('VENUE_ADMIN', 'booking:cancel:any')
+) AS grants(role, permission)
+WHERE NOT EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role = grants.role)
+ON CONFLICT DO NOTHING;
+
+-- ADMIN gets everything except rbac:manage; SUPER_ADMIN gets every permission.
+INSERT INTO role_permissions (role, permission)
+SELECT 'ADMIN', p.name FROM permissions p
+WHERE p.name <> 'rbac:manage'
+  AND NOT EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role = 'ADMIN')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'SUPER_ADMIN', p.name FROM permissions p
+WHERE NOT EXISTS (SELECT 1 FROM role_permissions rp WHERE rp.role = 'SUPER_ADMIN')
+ON CONFLICT DO NOTHING;
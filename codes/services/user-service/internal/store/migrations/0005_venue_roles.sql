@@ -0,0 +1,12 @@
+-- Venue-scoped role assignments. A role granted here applies only at venue_id: its permissions
+-- end up in the token's venuePermissions claim instead of the global permissions. Venues live in
+-- the booking-service database, so venue_id is not a foreign key.
+CREATE TABLE IF NOT EXISTS user_venue_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    venue_id UUID NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role, venue_id)
+);
+
+CREATE INDEX IF NOT EXISTS user_venue_roles_role_idx ON user_venue_roles (role);
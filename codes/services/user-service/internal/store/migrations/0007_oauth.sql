@@ -0,0 +1,35 @@
+-- OAuth 2.0 partner apps. Confidential clients hold a SHA-256 of their secret; public clients have
+-- none and must use PKCE. scopes and grant_types bound what a client may ask for.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    secret_hash TEXT NOT NULL DEFAULT '',
+    redirect_uris TEXT[] NOT NULL DEFAULT '{}',
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    grant_types TEXT[] NOT NULL DEFAULT '{authorization_code}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Scopes a user has granted a client. A later authorization for scopes already granted skips
+-- the consent screen.
+CREATE TABLE IF NOT EXISTS oauth_consents (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id TEXT NOT NULL REFERENCES oauth_clients(id) ON DELETE CASCADE,
+    scopes TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, client_id)
+);
+
+-- Grant the new permission to the admin roles only when it is first created, so a rerun does
+-- not restore a grant that was removed.
+WITH created AS (
+    INSERT INTO permissions (name, description)
+    VALUES ('oauth:clients:manage', 'Register partner apps and rotate their secrets')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, created.name FROM created CROSS JOIN (VALUES ('ADMIN'), ('SUPER_ADMIN')) AS r(name)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- Phone numbers are stored in E.164. A number can sign in (passwordless SMS codes) once it is
+-- verified, and a verified number belongs to exactly one user.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_verified_phone_idx ON users (phone) WHERE phone_verified;
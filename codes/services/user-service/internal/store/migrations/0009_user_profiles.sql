@@ -0,0 +1,25 @@
+-- Profile fields members edit themselves. avatar_key is an object key in the configured storage
+-- provider; preferences is a free-form JSON object owned by the clients.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS preferences JSONB NOT NULL DEFAULT '{}';
+
+-- pending_email holds the address an email change waits to confirm; email only changes once the
+-- link sent to it is followed.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS pending_email TEXT NOT NULL DEFAULT '';
+
+-- Disabled accounts keep their data but cannot sign in or refresh tokens.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS users_created_at_idx ON users (created_at DESC, id);
+
+-- Directory permissions go to ADMIN and SUPER_ADMIN only when first created.
+WITH created AS (
+    INSERT INTO permissions (name, description) VALUES
+        ('user:read', 'Search and view user accounts'),
+        ('user:disable', 'Disable and re-enable user accounts')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, created.name FROM created CROSS JOIN (VALUES ('ADMIN'), ('SUPER_ADMIN')) AS r(name)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,35 @@
+-- Right-of-access (PIPEDA) export requests. Rows are kept after the archive expires as the
+-- compliance record, so user_id deliberately has no foreign key.
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    -- requested_by is the admin who filed the request for the member; NULL when self-service.
+    requested_by UUID,
+    status TEXT NOT NULL DEFAULT 'pending',
+    object_key TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    requested_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS data_exports_user_idx ON data_exports (user_id, requested_at DESC);
+CREATE INDEX IF NOT EXISTS data_exports_queue_idx ON data_exports (status, next_attempt_at);
+
+-- At most one export per member is queued or running at a time.
+CREATE UNIQUE INDEX IF NOT EXISTS data_exports_active_idx ON data_exports (user_id)
+    WHERE status IN ('pending', 'processing');
+
+WITH created AS (
+    INSERT INTO permissions (name, description) VALUES
+        ('privacy:manage', 'File data requests for members and view the compliance log')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, created.name FROM created CROSS JOIN (VALUES ('ADMIN'), ('SUPER_ADMIN')) AS r(name)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,36 @@
+-- Retention: last_active_at is bumped by the auth-service at every sign-in and refresh. Existing
+-- accounts start counting from when this migration runs, so none is due straight away.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+-- A scheduled deletion runs at deletion_scheduled_at unless cancelled first. deletion_reason is
+-- 'requested' (by the member) or 'inactivity' (by the retention job; signing in cancels it).
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_requested_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_scheduled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deletion_reason TEXT NOT NULL DEFAULT '';
+
+-- Erased accounts keep their id (other services reference it) but no personal data.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
+
+-- erasing_at is set, under the row lock, before the sweep erases an account at the other
+-- services. From then on the deletion can no longer be cancelled or postponed, and a sweep that
+-- failed half way finishes it next time.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS erasing_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS users_deletion_due_idx ON users (deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL AND erased_at IS NULL;
+CREATE INDEX IF NOT EXISTS users_last_active_idx ON users (last_active_at) WHERE erased_at IS NULL;
+
+-- One receipt per erasure: what each service removed, sealed with a SHA-256 digest of the
+-- receipt body.
+CREATE TABLE IF NOT EXISTS erasure_receipts (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    requested_at TIMESTAMPTZ NOT NULL,
+    erased_at TIMESTAMPTZ NOT NULL,
+    services JSONB NOT NULL,
+    digest TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS erasure_receipts_erased_idx ON erasure_receipts (erased_at DESC, id);
+CREATE INDEX IF NOT EXISTS erasure_receipts_user_idx ON erasure_receipts (user_id);
@@ -0,0 +1,34 @@
+-- Policy documents are versioned per kind (terms, privacy, marketing). Publishing adds a row with
+-- the next version; rows are never edited, so a consent always points at the text that was shown.
+CREATE TABLE IF NOT EXISTS policy_documents (
+    id UUID PRIMARY KEY,
+    kind TEXT NOT NULL,
+    version INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    url TEXT NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL DEFAULT '',
+    -- Members must accept a required document before the apps let them continue.
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    published_by UUID,
+    published_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, version)
+);
+
+-- Every consent grant and withdrawal, append-only. A member's current consent for a purpose is
+-- their latest event for it.
+CREATE TABLE IF NOT EXISTS consent_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL,
+    granted BOOLEAN NOT NULL,
+    policy_id UUID REFERENCES policy_documents(id),
+    source TEXT NOT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    -- recorded_by is the admin who recorded the consent on the member's behalf, if any.
+    recorded_by UUID,
+    recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS consent_events_user_idx ON consent_events (user_id, purpose, recorded_at DESC);
@@ -0,0 +1,11 @@
+-- The security audit trail lives in the audit-service; reading it is granted here like every
+-- other permission.
+WITH created AS (
+    INSERT INTO permissions (name, description) VALUES
+        ('audit:read', 'Search, export and verify the security audit trail')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, created.name FROM created CROSS JOIN (VALUES ('ADMIN'), ('SUPER_ADMIN')) AS r(name)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,41 @@
+-- Dependents are household members the primary account holder manages, e.g. children. They
+-- cannot sign in: the email is a placeholder, there is no password, and the retention job never
+-- retires them for inactivity.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS dependent BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- A household shares one payment method and one membership between its members. The payment
+-- method is the payment provider's reference; the card itself is never stored here.
+CREATE TABLE IF NOT EXISTS households (
+    id UUID PRIMARY KEY,
+    name TEXT NOT NULL,
+    primary_user_id UUID NOT NULL UNIQUE REFERENCES users(id),
+    payment_method_id TEXT NOT NULL DEFAULT '',
+    membership_type TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+DROP TRIGGER IF EXISTS households_set_updated_at ON households;
+CREATE TRIGGER households_set_updated_at
+BEFORE UPDATE ON households
+FOR EACH ROW
+EXECUTE FUNCTION set_updated_at();
+
+-- role is 'primary', 'adult' or 'dependent'. Adults are existing accounts the primary invited;
+-- they are 'invited' until they accept.
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL,
+    status TEXT NOT NULL,
+    invited_by UUID,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    joined_at TIMESTAMPTZ,
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS household_members_user_idx ON household_members (user_id);
+
+-- A member belongs to one household at a time; invitations from others may still be pending.
+CREATE UNIQUE INDEX IF NOT EXISTS household_members_active_idx ON household_members (user_id)
+    WHERE status = 'active';
@@ -0,0 +1,55 @@
+-- A membership held by the member themselves, e.g. one assigned when their club was imported.
+-- Household memberships take precedence.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS membership_type TEXT NOT NULL DEFAULT '';
+
+-- Imported members have no password until they follow their invitation. Unlike social sign-in
+-- accounts they cannot skip the password check on account changes meanwhile.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS invitation_pending BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Bulk imports of existing members, e.g. when onboarding a venue. The upload is parsed into
+-- user_import_rows straight away and a background worker creates the accounts row by row.
+CREATE TABLE IF NOT EXISTS user_imports (
+    id UUID PRIMARY KEY,
+    requested_by UUID,
+    filename TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    -- membership_type is assigned to rows that do not name their own.
+    membership_type TEXT NOT NULL DEFAULT '',
+    send_invitations BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ,
+    -- updated_at doubles as the worker's heartbeat.
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS user_imports_queue_idx ON user_imports (status, created_at);
+
+-- One row per spreadsheet line; row_number is the line in the uploaded file. Personal details
+-- are blanked some time after the import completes, and when the account a row created is
+-- erased.
+CREATE TABLE IF NOT EXISTS user_import_rows (
+    import_id UUID NOT NULL REFERENCES user_imports(id) ON DELETE CASCADE,
+    row_number INTEGER NOT NULL,
+    email TEXT NOT NULL,
+    first_name TEXT NOT NULL DEFAULT '',
+    last_name TEXT NOT NULL DEFAULT '',
+    phone TEXT NOT NULL DEFAULT '',
+    membership_type TEXT NOT NULL DEFAULT '',
+    status TEXT NOT NULL DEFAULT 'pending',
+    error TEXT NOT NULL DEFAULT '',
+    user_id UUID,
+    PRIMARY KEY (import_id, row_number)
+);
+
+CREATE INDEX IF NOT EXISTS user_import_rows_user_idx ON user_import_rows (user_id) WHERE user_id IS NOT NULL;
+
+WITH created AS (
+    INSERT INTO permissions (name, description) VALUES
+        ('user:import', 'Import members in bulk from a spreadsheet')
+    ON CONFLICT (name) DO NOTHING
+    RETURNING name
+)
+INSERT INTO role_permissions (role, permission)
+SELECT r.name, created.name FROM created CROSS JOIN (VALUES ('ADMIN'), ('SUPER_ADMIN')) AS r(name)
+ON CONFLICT DO NOTHING;
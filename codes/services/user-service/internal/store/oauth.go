@@ -0,0 +1,222 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// OAuth grant types a client can be allowed.
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantClientCredentials = "client_credentials"
+)
+
+// ErrInvalidClient is returned when a client id is unknown or its secret does not match.
+var ErrInvalidClient = errors.New("invalid client credentials")
+
+// OAuthClient is a registered partner app.
+type OAuthClient struct {
+	ID           string
+	Name         string
+	SecretHash   string
+	RedirectURIs []string
+	Scopes       []string
+	GrantTypes   []string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Confidential reports whether the client authenticates with a secret.
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+// OAuthConsent records the scopes a user granted a client.
+type OAuthConsent struct {
+	ClientID   string
+	ClientName string
+	Scopes     []string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+const oauthClientColumns = `id, name, secret_hash, redirect_uris, scopes, grant_types, created_at, updated_at`
+
+// ListOAuthClients returns all registered clients by name.
+func (s *Store) ListOAuthClients(ctx context.Context) ([]OAuthClient, error) {
+	rows, err := s.pool.Query(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients ORDER BY name, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var clients []OAuthClient
+	for rows.Next() {
+		c, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, *c)
+	}
+	return clients, rows.Err()
+}
+
+// GetOAuthClient loads a client by id.
+func (s *Store) GetOAuthClient(ctx context.Context, id string) (*OAuthClient, error) {
+	return scanOAuthClient(s.pool.QueryRow(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients WHERE id = $1`, id))
+}
+
+// CreateOAuthClient registers a client with a generated id. Confidential clients also get a
+// secret, which is returned once; only its hash is stored.
+func (s *Store) CreateOAuthClient(ctx context.Context, c OAuthClient, confidential bool) (*OAuthClient, string, error) {
+	var secret, hash string
+	if confidential {
+		var err error
+		if secret, err = newClientSecret(); err != nil {
+			return nil, "", err
+		}
+		hash = hashToken(secret)
+	}
+	created, err := scanOAuthClient(s.pool.QueryRow(ctx, `
+        INSERT INTO oauth_clients (id, name, secret_hash, redirect_uris, scopes, grant_types)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING `+oauthClientColumns,
+		uuid.NewString(), c.Name, hash, c.RedirectURIs, c.Scopes, c.GrantTypes))
+	if err != nil {
+		return nil, "", err
+	}
+	return created, secret, nil
+}
+
+// UpdateOAuthClient replaces a client's name, redirect URIs, scopes and grant types.
+func (s *Store) UpdateOAuthClient(ctx context.Context, c OAuthClient) (*OAuthClient, error) {
+	return scanOAuthClient(s.pool.QueryRow(ctx, `
+        UPDATE oauth_clients
+        SET name = $2, redirect_uris = $3, scopes = $4, grant_types = $5, updated_at = NOW()
+        WHERE id = $1
+        RETURNING `+oauthClientColumns,
+		c.ID, c.Name, c.RedirectURIs, c.Scopes, c.GrantTypes))
+}
+
+// RotateOAuthClientSecret replaces a client's secret and returns the new one. A public client
+// becomes confidential.
+func (s *Store) RotateOAuthClientSecret(ctx context.Context, id string) (string, error) {
+	secret, err := newClientSecret()
+	if err != nil {
+		return "", err
+	}
+	tag, err := s.pool.Exec(ctx, `
+        UPDATE oauth_clients SET secret_hash = $2, updated_at = NOW() WHERE id = $1
+    `, id, hashToken(secret))
+	if err != nil {
+		return "", err
+	}
+	if tag.RowsAffected() == 0 {
+		return "", pgx.ErrNoRows
+	}
+	return secret, nil
+}
+
+// DeleteOAuthClient removes a client and the consents granted to it.
+func (s *Store) DeleteOAuthClient(ctx context.Context, id string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM oauth_clients WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// AuthenticateOAuthClient checks a confidential client's secret.
+func (s *Store) AuthenticateOAuthClient(ctx context.Context, id, secret string) (*OAuthClient, error) {
+	client, err := s.GetOAuthClient(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidClient
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential() || subtle.ConstantTimeCompare([]byte(client.SecretHash), []byte(hashToken(secret))) != 1 {
+		return nil, ErrInvalidClient
+	}
+	return client, nil
+}
+
+// ListOAuthConsents returns the clients a user has authorized.
+func (s *Store) ListOAuthConsents(ctx context.Context, userID uuid.UUID) ([]OAuthConsent, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT c.client_id, oc.name, c.scopes, c.created_at, c.updated_at
+        FROM oauth_consents c JOIN oauth_clients oc ON oc.id = c.client_id
+        WHERE c.user_id = $1
+        ORDER BY oc.name
+    `, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var consents []OAuthConsent
+	for rows.Next() {
+		var c OAuthConsent
+		if err := rows.Scan(&c.ClientID, &c.ClientName, &c.Scopes, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		consents = append(consents, c)
+	}
+	return consents, rows.Err()
+}
+
+// GrantOAuthConsent adds scopes to the user's consent for a client and returns the result.
+func (s *Store) GrantOAuthConsent(ctx context.Context, userID uuid.UUID, clientID string, scopes []string) (*OAuthConsent, error) {
+	c := OAuthConsent{ClientID: clientID}
+	err := s.pool.QueryRow(ctx, `
+        WITH saved AS (
+            INSERT INTO oauth_consents (user_id, client_id, scopes) VALUES ($1, $2, $3)
+            ON CONFLICT (user_id, client_id) DO UPDATE SET
+                scopes = ARRAY(SELECT DISTINCT s FROM unnest(oauth_consents.scopes || EXCLUDED.scopes) AS s ORDER BY s),
+                updated_at = NOW()
+            RETURNING client_id, scopes, created_at, updated_at
+        )
+        SELECT oc.name, saved.scopes, saved.created_at, saved.updated_at
+        FROM saved JOIN oauth_clients oc ON oc.id = saved.client_id
+    `, userID, clientID, scopes).Scan(&c.ClientName, &c.Scopes, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RevokeOAuthConsent removes the user's consent for a client.
+func (s *Store) RevokeOAuthConsent(ctx context.Context, userID uuid.UUID, clientID string) error {
+	tag, err := s.pool.Exec(ctx, `DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2`, userID, clientID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+func scanOAuthClient(row pgx.Row) (*OAuthClient, error) {
+	var c OAuthClient
+	if err := row.Scan(&c.ID, &c.Name, &c.SecretHash, &c.RedirectURIs, &c.Scopes, &c.GrantTypes, &c.CreatedAt, &c.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func newClientSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
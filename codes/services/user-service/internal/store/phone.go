@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrPhoneTaken is returned when verifying a number another user has already verified.
+	ErrPhoneTaken = errors.New("phone number already in use")
+	// ErrPhoneChanged is returned when confirming a number that is no longer the user's.
+	ErrPhoneChanged = errors.New("phone number changed since the code was sent")
+)
+
+// GetUserByVerifiedPhone fetches the user who verified phone (E.164).
+func (s *Store) GetUserByVerifiedPhone(ctx context.Context, phone string) (*User, error) {
+	return scanUser(s.pool.QueryRow(ctx, `
+        SELECT `+userColumns+`
+        FROM users
+        WHERE phone = $1 AND phone_verified
+    `, phone))
+}
+
+// SetPhone replaces a user's phone number (E.164, or empty to remove it). A new number starts
+// unverified; setting the current one again keeps its verification.
+func (s *Store) SetPhone(ctx context.Context, id uuid.UUID, phone string) (*User, error) {
+	return scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET phone = $2, phone_verified = phone_verified AND phone = $2
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, phone))
+}
+
+// ConfirmPhone marks the user's number verified. phone is the number the code was sent to, so a
+// code cannot verify a number changed in the meantime.
+func (s *Store) ConfirmPhone(ctx context.Context, id uuid.UUID, phone string) (*User, error) {
+	user, err := scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET phone_verified = TRUE
+        WHERE id = $1 AND phone = $2 AND phone <> ''
+        RETURNING `+userColumns+`
+    `, id, phone))
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return nil, ErrPhoneTaken
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrPhoneChanged
+	}
+	return user, err
+}
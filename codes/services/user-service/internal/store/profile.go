@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Account statuses accepted by UserQuery.Status.
+const (
+	StatusActive   = "active"
+	StatusDisabled = "disabled"
+)
+
+// ErrEmailTaken is returned when changing to, or importing, an address another account
+// already uses.
+var ErrEmailTaken = errors.New("email already registered")
+
+// ProfileUpdate holds the profile fields a member edits; nil fields are left unchanged.
+// Preferences are merged into the stored object, and keys set to null are removed.
+type ProfileUpdate struct {
+	FirstName   *string
+	LastName    *string
+	Phone       *string
+	Preferences map[string]any
+}
+
+// UpdateProfile applies update to the user's profile. Changing the phone number resets its
+// verification like SetPhone.
+func (s *Store) UpdateProfile(ctx context.Context, id uuid.UUID, update ProfileUpdate) (*User, error) {
+	var prefs any
+	if update.Preferences != nil {
+		prefs = update.Preferences
+	}
+	return scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET
+            first_name = COALESCE($2, first_name),
+            last_name = COALESCE($3, last_name),
+            phone = COALESCE($4, phone),
+            phone_verified = phone_verified AND phone = COALESCE($4, phone),
+            preferences = jsonb_strip_nulls(preferences || COALESCE($5::jsonb, '{}'))
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, update.FirstName, update.LastName, update.Phone, prefs))
+}
+
+// SetAvatar stores key as the user's avatar and returns the key it replaced, so the caller can
+// delete the old object. An empty key removes the avatar.
+func (s *Store) SetAvatar(ctx context.Context, id uuid.UUID, key string) (*User, string, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var previous string
+	if err := tx.QueryRow(ctx, `SELECT avatar_key FROM users WHERE id = $1 FOR UPDATE`, id).Scan(&previous); err != nil {
+		return nil, "", err
+	}
+	user, err := scanUser(tx.QueryRow(ctx, `
+        UPDATE users SET avatar_key = $2
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, key))
+	if err != nil {
+		return nil, "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, "", err
+	}
+	return user, previous, nil
+}
+
+// SetPassword replaces the user's password hash.
+func (s *Store) SetPassword(ctx context.Context, id uuid.UUID, passwordHash string) (*User, error) {
+	return scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET password_hash = $2
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, passwordHash))
+}
+
+// RequestEmailChange records email as the user's pending address and returns a token that
+// confirms it. Requesting again replaces both the pending address and the token.
+func (s *Store) RequestEmailChange(ctx context.Context, id uuid.UUID, email string) (string, *User, error) {
+	var taken bool
+	if err := s.pool.QueryRow(ctx, `
+        SELECT EXISTS (SELECT 1 FROM users WHERE LOWER(email) = LOWER($2) AND id <> $1)
+    `, id, email).Scan(&taken); err != nil {
+		return "", nil, err
+	}
+	if taken {
+		return "", nil, ErrEmailTaken
+	}
+	user, err := scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET pending_email = $2
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, strings.ToLower(email)))
+	if err != nil {
+		return "", nil, err
+	}
+	token, err := s.CreateToken(ctx, id, PurposeEmailChange)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, user, nil
+}
+
+// ConfirmEmailChange redeems an email change token, making the pending address the user's
+// (verified) email.
+func (s *Store) ConfirmEmailChange(ctx context.Context, token string) (*User, error) {
+	user, err := s.redeem(ctx, token, PurposeEmailChange, `
+        UPDATE users SET email = pending_email, pending_email = '', email_verified = TRUE
+        WHERE id = $1 AND pending_email <> ''
+        RETURNING `+userColumns+`
+    `)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return nil, ErrEmailTaken
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrInvalidToken
+	}
+	return user, err
+}
+
+// UserQuery filters the admin user directory.
+type UserQuery struct {
+	// Search matches a substring of the email or full name, case-insensitively.
+	Search string
+	Role   string
+	// Status is StatusActive, StatusDisabled or empty for both.
+	Status string
+	Limit  int
+	Offset int
+}
+
+// ListUsers returns one page of users matching query, newest first, and the total number of
+// matches.
+func (s *Store) ListUsers(ctx context.Context, query UserQuery) ([]*User, int, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if search := strings.TrimSpace(query.Search); search != "" {
+		args = append(args, "%"+escapeLike(strings.ToLower(search))+"%")
+		where = append(where, fmt.Sprintf(
+			"(LOWER(email) LIKE $%[1]d OR LOWER(first_name || ' ' || last_name) LIKE $%[1]d)", len(args)))
+	}
+	if query.Role != "" {
+		args = append(args, strings.ToUpper(query.Role))
+		where = append(where, fmt.Sprintf(
+			"($%[1]d = ANY(roles) OR EXISTS (SELECT 1 FROM user_venue_roles vr WHERE vr.user_id = users.id AND vr.role = $%[1]d))", len(args)))
+	}
+	switch query.Status {
+	case StatusActive:
+		where = append(where, "disabled_at IS NULL")
+	case StatusDisabled:
+		where = append(where, "disabled_at IS NOT NULL")
+	}
+	filter := ""
+	if len(where) > 0 {
+		filter = "WHERE " + strings.Join(where, " AND ")
+	}
+
+	var total int
+	if err := s.pool.QueryRow(ctx, `SELECT COUNT(*) FROM users `+filter, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, query.Limit, query.Offset)
+	rows, err := s.pool.Query(ctx, fmt.Sprintf(`
+        SELECT `+userColumns+`
+        FROM users
+        %s
+        ORDER BY created_at DESC, id
+        LIMIT $%d OFFSET $%d
+    `, filter, len(args)-1, len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	users := []*User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+	return users, total, rows.Err()
+}
+
+// SetDisabled disables or re-enables an account. Disabling an already disabled account keeps
+// the original timestamp.
+func (s *Store) SetDisabled(ctx context.Context, id uuid.UUID, disabled bool) (*User, error) {
+	return scanUser(s.pool.QueryRow(ctx, `
+        UPDATE users SET disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, disabled))
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+var (
+	// ErrBuiltInRole is returned when deleting one of the seeded roles.
+	ErrBuiltInRole = errors.New("built-in roles cannot be deleted")
+	// ErrRoleInUse is returned when deleting a role that is still assigned to users.
+	ErrRoleInUse = errors.New("role is still assigned to users")
+	// ErrUnknownPermission is returned when granting a permission that does not exist.
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+// Permission is one entry of the permission catalogue.
+type Permission struct {
+	Name        string
+	Description string
+}
+
+// Role is a named set of permissions.
+type Role struct {
+	Name        string
+	Description string
+	BuiltIn     bool
+	Permissions []string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// VenueRole assigns a role at a single venue.
+type VenueRole struct {
+	Role    string    `json:"role"`
+	VenueID uuid.UUID `json:"venueId"`
+}
+
+// ListPermissions returns the permission catalogue.
+func (s *Store) ListPermissions(ctx context.Context) ([]Permission, error) {
+	rows, err := s.pool.Query(ctx, `SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var perms []Permission
+	for rows.Next() {
+		var p Permission
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// ListRoles returns every role with its permissions.
+func (s *Store) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := s.pool.Query(ctx, `
+        SELECT r.name, r.description, r.built_in, r.created_at, r.updated_at,
+               ARRAY(SELECT rp.permission FROM role_permissions rp WHERE rp.role = r.name ORDER BY rp.permission)
+        FROM roles r
+        ORDER BY r.name
+    `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []Role
+	for rows.Next() {
+		var r Role
+		if err := rows.Scan(&r.Name, &r.Description, &r.BuiltIn, &r.CreatedAt, &r.UpdatedAt, &r.Permissions); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// MissingRoles returns the names that are not defined roles.
+func (s *Store) MissingRoles(ctx context.Context, names []string) ([]string, error) {
+	var missing []string
+	err := s.pool.QueryRow(ctx, `
+        SELECT COALESCE(array_agg(n), '{}') FROM unnest($1::text[]) AS n
+        WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.name = n)
+    `, names).Scan(&missing)
+	return missing, err
+}
+
+// PermissionsForRoles returns the union of the roles' permissions.
+func (s *Store) PermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	var perms []string
+	err := s.pool.QueryRow(ctx, `
+        SELECT ARRAY(SELECT DISTINCT permission FROM role_permissions WHERE role = ANY($1) ORDER BY permission)
+    `, roles).Scan(&perms)
+	return perms, err
+}
+
+// SaveRole creates or updates a role and replaces its permissions. It returns the ids of users
+// holding the role, whose tokens now carry stale permissions.
+func (s *Store) SaveRole(ctx context.Context, role Role) ([]uuid.UUID, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var unknown []string
+	if err := tx.QueryRow(ctx, `
+        SELECT COALESCE(array_agg(n), '{}') FROM unnest($1::text[]) AS n
+        WHERE NOT EXISTS (SELECT 1 FROM permissions p WHERE p.name = n)
+    `, role.Permissions).Scan(&unknown); err != nil {
+		return nil, err
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w %q", ErrUnknownPermission, unknown[0])
+	}
+
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO roles (name, description) VALUES ($1, $2)
+        ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, updated_at = NOW()
+    `, role.Name, role.Description); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM role_permissions WHERE role = $1`, role.Name); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO role_permissions (role, permission) SELECT $1, unnest($2::text[])
+    `, role.Name, role.Permissions); err != nil {
+		return nil, err
+	}
+	holders, err := usersWithRole(ctx, tx, role.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return holders, nil
+}
+
+// DeleteRole removes a custom role that nobody holds.
+func (s *Store) DeleteRole(ctx context.Context, name string) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var builtIn bool
+	err = tx.QueryRow(ctx, `SELECT built_in FROM roles WHERE name = $1 FOR UPDATE`, name).Scan(&builtIn)
+	if err != nil {
+		return err
+	}
+	if builtIn {
+		return ErrBuiltInRole
+	}
+	holders, err := usersWithRole(ctx, tx, name)
+	if err != nil {
+		return err
+	}
+	if len(holders) > 0 {
+		return ErrRoleInUse
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM roles WHERE name = $1`, name); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetVenueRoles replaces a user's venue-scoped role assignments and returns the updated user.
+func (s *Store) SetVenueRoles(ctx context.Context, userID uuid.UUID, assignments []VenueRole) (*User, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var exists bool
+	if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_venue_roles WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	for _, a := range assignments {
+		if _, err := tx.Exec(ctx, `
+            INSERT INTO user_venue_roles (user_id, role, venue_id) VALUES ($1, $2, $3)
+            ON CONFLICT DO NOTHING
+        `, userID, a.Role, a.VenueID); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetUserByID(ctx, userID)
+}
+
+// usersWithRole returns the users holding role globally or at any venue.
+func usersWithRole(ctx context.Context, tx pgx.Tx, role string) ([]uuid.UUID, error) {
+	rows, err := tx.Query(ctx, `
+        SELECT id FROM users WHERE $1 = ANY(roles)
+        UNION
+        SELECT user_id FROM user_venue_roles WHERE role = $1
+    `, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
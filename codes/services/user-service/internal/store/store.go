@@ -72,22 +72,65 @@ func (s *Store) Ping(ctx context.Context) error {
 	return s.pool.Ping(ctx)
 }
 
+// userColumns is the column list scanUser expects. Permissions are resolved from the user's roles,
+// venue permissions from their venue-scoped roles.
+const userColumns = `id, email, first_name, last_name, password_hash, roles,
+        ARRAY(SELECT DISTINCT rp.permission FROM role_permissions rp WHERE rp.role = ANY(users.roles) ORDER BY rp.permission),
+        COALESCE((SELECT jsonb_agg(jsonb_build_object('role', vr.role, 'venueId', vr.venue_id) ORDER BY vr.venue_id, vr.role)
+                  FROM user_venue_roles vr WHERE vr.user_id = users.id), '[]'),
+        COALESCE((SELECT jsonb_object_agg(g.venue_id::text, g.permissions) FROM (
+                      SELECT vr.venue_id, array_agg(DISTINCT rp.permission ORDER BY rp.permission) AS permissions
+                      FROM user_venue_roles vr JOIN role_permissions rp ON rp.role = vr.role
+                      WHERE vr.user_id = users.id GROUP BY vr.venue_id) g), '{}'),
+        email_verified, mfa_enabled, phone, phone_verified, avatar_key, preferences, pending_email, disabled_at,
+        last_active_at, deletion_requested_at, deletion_scheduled_at, deletion_reason, erased_at,
+        dependent, membership_type, invitation_pending, created_at, updated_at`
+
 // User represents a stored user row.
 type User struct {
-	ID           uuid.UUID
-	Email        string
-	FirstName    string
-	LastName     string
-	Roles        []string
-	PasswordHash string
-	CreatedAt    time.Time
-	UpdatedAt    time.Time
+	ID          uuid.UUID
+	Email       string
+	FirstName   string
+	LastName    string
+	Roles       []string
+	Permissions []string
+	// VenueRoles are role assignments limited to one venue; VenuePermissions resolves them.
+	VenueRoles       []VenueRole
+	VenuePermissions map[string][]string
+	PasswordHash     string
+	EmailVerified    bool
+	MFAEnabled       bool
+	// Phone is in E.164 form, or empty.
+	Phone         string
+	PhoneVerified bool
+	// AvatarKey is the storage key of the profile picture, or empty.
+	AvatarKey   string
+	Preferences map[string]any
+	// PendingEmail is the address an unconfirmed email change is waiting on.
+	PendingEmail string
+	// DisabledAt is set while an admin has disabled the account.
+	DisabledAt   *time.Time
+	LastActiveAt time.Time
+	// DeletionScheduledAt is when a pending deletion (DeletionReason) will erase the account.
+	DeletionRequestedAt *time.Time
+	DeletionScheduledAt *time.Time
+	DeletionReason      string
+	// ErasedAt is set once the account has been anonymised.
+	ErasedAt *time.Time
+	// Dependent is set for household dependents, who have no sign-in of their own.
+	Dependent bool
+	// MembershipType is the member's own membership, or empty; a household's takes precedence.
+	MembershipType string
+	// InvitationPending is set for imported members until they choose their first password.
+	InvitationPending bool
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 }
 
 // GetUserByID fetches a user by UUID.
 func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	row := s.pool.QueryRow(ctx, `
-        SELECT id, email, first_name, last_name, password_hash, roles, created_at, updated_at
+        SELECT `+userColumns+`
         FROM users
         WHERE id = $1
     `, id)
@@ -97,7 +140,7 @@ func (s *Store) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 // GetUserByEmail fetches a user by email address.
 func (s *Store) GetUserByEmail(ctx context.Context, email string) (*User, error) {
 	row := s.pool.QueryRow(ctx, `
-        SELECT id, email, first_name, last_name, password_hash, roles, created_at, updated_at
+        SELECT `+userColumns+`
         FROM users
         WHERE LOWER(email) = LOWER($1)
     `, email)
@@ -110,19 +153,32 @@ func (s *Store) UpsertUser(ctx context.Context, user *User) error {
 		user.ID = uuid.New()
 	}
 	_, err := s.pool.Exec(ctx, `
-        INSERT INTO users (id, email, first_name, last_name, password_hash, roles, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+        INSERT INTO users (id, email, first_name, last_name, password_hash, roles, email_verified, phone, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
         ON CONFLICT (id) DO UPDATE SET
             email = EXCLUDED.email,
             first_name = EXCLUDED.first_name,
             last_name = EXCLUDED.last_name,
             password_hash = EXCLUDED.password_hash,
             roles = EXCLUDED.roles,
+            email_verified = EXCLUDED.email_verified,
+            phone = EXCLUDED.phone,
+            phone_verified = users.phone_verified AND users.phone = EXCLUDED.phone,
             updated_at = NOW()
-    `, user.ID, user.Email, user.FirstName, user.LastName, user.PasswordHash, user.Roles)
+    `, user.ID, user.Email, user.FirstName, user.LastName, user.PasswordHash, user.Roles, user.EmailVerified, user.Phone)
 	return err
 }
 
+// UpdateRoles replaces a user's roles and returns the updated row.
+func (s *Store) UpdateRoles(ctx context.Context, id uuid.UUID, roles []string) (*User, error) {
+	row := s.pool.QueryRow(ctx, `
+        UPDATE users SET roles = $2
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `, id, roles)
+	return scanUser(row)
+}
+
 // SeedDefaultUser ensures an initial member exists for local development.
 func (s *Store) SeedDefaultUser(ctx context.Context, email, password string) (*User, error) {
 	if email == "" || password == "" {
@@ -142,12 +198,13 @@ func (s *Store) SeedDefaultUser(ctx context.Context, email, password string) (*U
 	}
 
 	user = &User{
-		ID:           uuid.New(),
-		Email:        strings.ToLower(email),
-		FirstName:    "Venue",
-		LastName:     "Member",
-		Roles:        []string{"MEMBER"},
-		PasswordHash: hash,
+		ID:            uuid.New(),
+		Email:         strings.ToLower(email),
+		FirstName:     "Venue",
+		LastName:      "Member",
+		Roles:         []string{"MEMBER"},
+		PasswordHash:  hash,
+		EmailVerified: true,
 	}
 	if err := s.UpsertUser(ctx, user); err != nil {
 		return nil, err
@@ -171,7 +228,8 @@ func ComparePassword(hash, password string) error {
 
 func scanUser(row pgx.Row) (*User, error) {
 	var u User
-	if err := row.Scan(&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.PasswordHash, &u.Roles, &u.CreatedAt, &u.UpdatedAt); err != nil {
+	if err := row.Scan(&u.ID, &u.Email, &u.FirstName, &u.LastName, &u.PasswordHash, &u.Roles, &u.Permissions, &u.VenueRoles, &u.VenuePermissions, &u.EmailVerified, &u.MFAEnabled, &u.Phone, &u.PhoneVerified, &u.AvatarKey, &u.Preferences, &u.PendingEmail, &u.DisabledAt,
+		&u.LastActiveAt, &u.DeletionRequestedAt, &u.DeletionScheduledAt, &u.DeletionReason, &u.ErasedAt, &u.Dependent, &u.MembershipType, &u.InvitationPending, &u.CreatedAt, &u.UpdatedAt); err != nil {
 		return nil, err
 	}
 	return &u, nil
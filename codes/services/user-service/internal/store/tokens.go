@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Token purposes.
+const (
+	PurposePasswordReset = "password_reset"
+	PurposeEmailVerify   = "email_verify"
+	// PurposeEmailChange confirms the pending address of an email change.
+	PurposeEmailChange = "email_change"
+	// PurposeInvitation lets an imported member choose their first password.
+	PurposeInvitation = "invitation"
+)
+
+// tokenTTLs bound how long an emailed link stays usable.
+var tokenTTLs = map[string]time.Duration{
+	PurposePasswordReset: time.Hour,
+	PurposeEmailVerify:   48 * time.Hour,
+	PurposeEmailChange:   24 * time.Hour,
+	PurposeInvitation:    14 * 24 * time.Hour,
+}
+
+var (
+	// ErrUnknownPurpose is returned for token purposes other than the ones above.
+	ErrUnknownPurpose = errors.New("unknown token purpose")
+	// ErrInvalidToken is returned when a token does not exist, has expired or was already used.
+	ErrInvalidToken = errors.New("invalid or expired token")
+)
+
+// CreateToken issues a single-use token for the user and returns it. Only its hash is stored,
+// and any unused token of the same purpose is discarded so only the newest link works.
+func (s *Store) CreateToken(ctx context.Context, userID uuid.UUID, purpose string) (string, error) {
+	ttl, ok := tokenTTLs[purpose]
+	if !ok {
+		return "", ErrUnknownPurpose
+	}
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+        DELETE FROM user_tokens WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+    `, userID, purpose); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `
+        INSERT INTO user_tokens (id, user_id, purpose, token_hash, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+    `, uuid.New(), userID, purpose, hashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResetPassword redeems a password reset or invitation token and stores the new hash.
+// Receiving the link proves ownership of the address, so the email is marked verified as well,
+// and an imported member's invitation is settled.
+func (s *Store) ResetPassword(ctx context.Context, token, passwordHash string) (*User, error) {
+	const update = `
+        UPDATE users SET password_hash = $2, email_verified = TRUE, invitation_pending = FALSE
+        WHERE id = $1
+        RETURNING ` + userColumns
+	user, err := s.redeem(ctx, token, PurposePasswordReset, update, passwordHash)
+	if errors.Is(err, ErrInvalidToken) {
+		return s.redeem(ctx, token, PurposeInvitation, update, passwordHash)
+	}
+	return user, err
+}
+
+// VerifyEmail redeems an email verification token.
+func (s *Store) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	return s.redeem(ctx, token, PurposeEmailVerify, `
+        UPDATE users SET email_verified = TRUE
+        WHERE id = $1
+        RETURNING `+userColumns+`
+    `)
+}
+
+// redeem marks the token used and runs update ($1 is the user id) in the same transaction, so
+// a token can never be redeemed twice.
+func (s *Store) redeem(ctx context.Context, token, purpose, update string, args ...any) (*User, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID uuid.UUID
+	err = tx.QueryRow(ctx, `
+        UPDATE user_tokens SET used_at = NOW()
+        WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+        RETURNING user_id
+    `, hashToken(token), purpose).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := scanUser(tx.QueryRow(ctx, update, append([]any{userID}, args...)...))
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
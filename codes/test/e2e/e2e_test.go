@@ -5,15 +5,21 @@ package e2e
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/venue-master/platform/lib/authz"
 	"github.com/venue-master/platform/lib/config"
 	"github.com/venue-master/platform/lib/jwtutil"
 )
@@ -147,6 +153,53 @@ type graphQLResponse struct {
 	} `json:"errors"`
 }
 
+// TestMFAGuessingLocksAccount signs in with the right password and wrong codes until the account
+// locks: starting a new challenge must not reset the failures the previous one collected.
+func TestMFAGuessingLocksAccount(t *testing.T) {
+	cfg := loadConfig()
+	waitFor(t, cfg.GatewayURL+"/healthz")
+
+	email := fmt.Sprintf("mfa-lockout-%d@example.com", time.Now().UnixNano())
+	var registered struct {
+		AccessToken string `json:"accessToken"`
+	}
+	postJSON(t, cfg.AuthURL+"/v1/auth/register", "", map[string]string{
+		"email": email, "password": "Secret123!", "firstName": "Mfa", "lastName": "Lockout",
+	}, http.StatusCreated, &registered)
+	var enrollment struct {
+		Secret string `json:"secret"`
+	}
+	postJSON(t, cfg.AuthURL+"/v1/auth/mfa/enroll", registered.AccessToken, nil, http.StatusCreated, &enrollment)
+	postJSON(t, cfg.AuthURL+"/v1/auth/mfa/confirm", registered.AccessToken,
+		map[string]string{"code": totpCode(t, enrollment.Secret)}, http.StatusOK, nil)
+
+	for round := 0; round < 4; round++ {
+		var challenge struct {
+			MFAToken string `json:"mfaToken"`
+		}
+		status := postRetrying(t, cfg.AuthURL+"/v1/auth/login", map[string]string{"email": email, "password": "Secret123!"}, &challenge)
+		if status == http.StatusLocked {
+			return
+		}
+		if status != http.StatusOK || challenge.MFAToken == "" {
+			t.Fatalf("round %d: login status %d without an mfa challenge", round, status)
+		}
+		for {
+			status := postRetrying(t, cfg.AuthURL+"/v1/auth/login/mfa", map[string]string{"mfaToken": challenge.MFAToken, "code": "000000"}, nil)
+			if status == http.StatusLocked {
+				return
+			}
+			if status != http.StatusUnauthorized {
+				t.Fatalf("round %d: wrong code answered %d", round, status)
+			}
+			if !challengeLive(t, cfg.AuthURL, challenge.MFAToken) {
+				break
+			}
+		}
+	}
+	t.Fatalf("account never locked after repeated wrong codes")
+}
+
 func loadConfig() struct {
 	GatewayURL string
 	AuthURL    string
@@ -252,12 +305,24 @@ func decodeData[T any](t *testing.T, data map[string]json.RawMessage, key string
 func generateAdminToken(t *testing.T) string {
 	t.Helper()
 	cfg := jwtConfigFromEnv()
-	manager := jwtutil.NewManager(cfg)
-	access, _, err := manager.Generate("11111111-2222-3333-4444-555555555555", []string{"ADMIN", "VENUE_ADMIN"}, nil)
+	manager, err := jwtutil.NewManager(cfg)
+	if err != nil {
+		t.Fatalf("init jwt manager: %v", err)
+	}
+	// Admin mutations require a token issued after a second factor.
+	amr := []string{jwtutil.AMRPassword, jwtutil.AMROTP, jwtutil.AMRMFA}
+	permissions := []string{
+		authz.VenueRead, authz.VenueReadArchived, authz.VenueCreate, authz.VenueUpdate, authz.VenueDelete,
+		authz.FacilityRead, authz.FacilityReadArchived, authz.FacilityCreate, authz.FacilityUpdate,
+		authz.FacilityDelete, authz.FacilitySchedule,
+		authz.BookingReadOwn, authz.BookingReadAny, authz.BookingCreate, authz.BookingCreateAny,
+		authz.BookingCancelOwn, authz.BookingCancelAny,
+	}
+	access, err := manager.Issue("11111111-2222-3333-4444-555555555555", "", []string{"ADMIN", "VENUE_ADMIN"}, permissions, nil, amr)
 	if err != nil {
 		t.Fatalf("generate admin token: %v", err)
 	}
-	return access
+	return access.Token
 }
 
 func jwtConfigFromEnv() config.JWTConfig {
@@ -273,11 +338,100 @@ func jwtConfigFromEnv() config.JWTConfig {
 	if audience == "" {
 		audience = "venue-master-clients"
 	}
+	// With RS256/EdDSA the suite signs with the same private key the auth-service uses.
 	return config.JWTConfig{
-		Secret:        secret,
-		Issuer:        issuer,
-		Audience:      audience,
-		AccessExpiry:  time.Hour,
-		RefreshExpiry: 24 * time.Hour,
+		Algorithm:      os.Getenv("JWT_ALGORITHM"),
+		Secret:         secret,
+		PrivateKeyFile: os.Getenv("JWT_PRIVATE_KEY_FILE"),
+		Issuer:         issuer,
+		Audience:       audience,
+		AccessExpiry:   time.Hour,
+		RefreshExpiry:  24 * time.Hour,
+	}
+}
+
+// postJSON posts body, optionally with a bearer token, and decodes the response into dest when
+// it has the wanted status.
+func postJSON(t *testing.T, url, token string, body any, want int, dest any) {
+	t.Helper()
+	status, data := post(t, url, token, body)
+	if status != want {
+		t.Fatalf("POST %s status %d: %s", url, status, string(data))
+	}
+	if dest != nil {
+		if err := json.Unmarshal(data, dest); err != nil {
+			t.Fatalf("decode %s: %v", url, err)
+		}
+	}
+}
+
+// postRetrying posts body, waiting out 429 backoffs from the lockout guard, and returns the
+// final status. A 200 response is decoded into dest.
+func postRetrying(t *testing.T, url string, body, dest any) int {
+	t.Helper()
+	for {
+		status, data := post(t, url, "", body)
+		if status == http.StatusTooManyRequests {
+			var parsed struct {
+				Details struct {
+					RetryAfter int `json:"retryAfter"`
+				} `json:"details"`
+			}
+			_ = json.Unmarshal(data, &parsed)
+			time.Sleep(time.Duration(parsed.Details.RetryAfter+1) * time.Second)
+			continue
+		}
+		if status == http.StatusOK && dest != nil {
+			if err := json.Unmarshal(data, dest); err != nil {
+				t.Fatalf("decode %s: %v", url, err)
+			}
+		}
+		return status
+	}
+}
+
+// challengeLive reports whether an MFA challenge still accepts codes; enrolled users get 409
+// from the enrollment endpoint while it does.
+func challengeLive(t *testing.T, authURL, mfaToken string) bool {
+	t.Helper()
+	status, _ := post(t, authURL+"/v1/auth/login/mfa/enroll", "", map[string]string{"mfaToken": mfaToken})
+	return status == http.StatusConflict
+}
+
+func post(t *testing.T, url, token string, body any) (int, []byte) {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		t.Fatalf("build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
 	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, data
+}
+
+// totpCode computes the current 6-digit TOTP (HMAC-SHA1, 30 second steps) for a base32 secret.
+func totpCode(t *testing.T, secret string) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		t.Fatalf("decode totp secret: %v", err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	code := strconv.Itoa(int(value % 1_000_000))
+	return strings.Repeat("0", 6-len(code)) + code
 }